@@ -0,0 +1,645 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	eostest "github.com/digital-scarcity/eos-go-test"
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/dao-contracts/dao-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+	"github.com/spf13/viper"
+)
+
+// runSettings reads the settings document, changes single settings or applies a settings file,
+// signed by the contract or, with -proposer, as an msig proposal:
+//
+//	settings get [-out settings.yaml] [key]
+//	settings set <key> <value>
+//	settings rm <key>
+//	settings apply [-dry-run] [-proposer johnnyhypha1 -name setsettings -approvers dao.hypha@active] <settings.yaml>
+func runSettings(ctx context.Context, args []string) {
+
+	sub, args := subcommand("settings", args, "get", "set", "rm", "apply")
+	flags := newFlagSet("settings " + sub)
+	out := flags.String("out", "", "settings file to write the settings on chain to")
+	dryRun := flags.Bool("dry-run", false, "print the changes without sending them")
+	proposer := flags.String("proposer", "", "account proposing the changes as an msig instead of applying them")
+	proposalName := flags.String("name", "setsettings", "name of the msig proposal")
+	approvers := flags.String("approvers", "", "comma separated actor@permission approvals requested, the permission of the profile if an actor has none, the contract if not set")
+	s := connect(ctx, flags, args)
+
+	current, err := dao.LoadSettings(ctx, s.api, s.contract)
+	if err != nil {
+		fail(err)
+	}
+
+	desired := current
+	switch sub {
+	case "get":
+		if *out != "" {
+			if err := dao.WriteSettingsFile(*out, current); err != nil {
+				fail(err)
+			}
+		}
+		if flags.NArg() == 1 {
+			value, set, err := current.Get(flags.Arg(0))
+			if err != nil {
+				fail(err)
+			}
+			if !set {
+				fail(fmt.Errorf("setting %v is not set", flags.Arg(0)))
+			}
+			fmt.Println(value)
+			return
+		}
+		current.Print(os.Stdout)
+		return
+	case "set":
+		args := arguments(flags, 2, "<key> <value>")
+		err = desired.Set(args[0], args[1])
+	case "rm":
+		args := arguments(flags, 1, "<key>")
+		err = desired.Remove(args[0])
+	case "apply":
+		args := arguments(flags, 1, "<settings.yaml>")
+		desired, err = dao.ReadSettingsFile(args[0])
+	}
+	if err != nil {
+		fail(err)
+	}
+
+	if *dryRun {
+		dao.DiffSettings(current, desired).Print(os.Stdout)
+		if err := desired.Validate(); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	var diff dao.SettingsDiff
+	var trxID string
+	if *proposer != "" {
+		requested := []eos.PermissionLevel{{Actor: s.contract, Permission: s.profile.Permission}}
+		if *approvers != "" {
+			requested = nil
+			for _, approver := range parseList(*approvers) {
+				if !strings.Contains(approver, "@") {
+					approver += "@" + string(s.profile.Permission)
+				}
+				level, err := eos.NewPermissionLevel(approver)
+				if err != nil {
+					fail(err)
+				}
+				requested = append(requested, level)
+			}
+		}
+		diff, trxID, err = dao.ProposeSettings(ctx, s.api, s.contract, eos.AN(*proposer), eos.Name(*proposalName), requested, desired)
+	} else {
+		diff, trxID, err = dao.ApplySettings(ctx, s.api, s.contract, desired)
+	}
+
+	diff.Print(os.Stdout)
+	if err != nil {
+		fail(err)
+	}
+	if trxID != "" {
+		fmt.Println("Transaction: " + trxID)
+	}
+}
+
+// runPeriods prints the calendar or the current period, or adds periods after the last one
+//
+//	periods list
+//	periods current
+//	periods add [-count 10] [-duration 168h]
+func runPeriods(ctx context.Context, args []string) {
+
+	sub, args := subcommand("periods", args, "list", "current", "add")
+	flags := newFlagSet("periods " + sub)
+	count := flags.Int("count", 10, "number of periods to add")
+	duration := flags.Duration("duration", 0, "duration of the periods to add, the periodDuration setting if not set")
+	s := connect(ctx, flags, args)
+
+	calendar, err := dao.Calendar(ctx, s.graph)
+	if err != nil {
+		fail(err)
+	}
+
+	switch sub {
+	case "list":
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "LABEL\tSTART\tEND\tHASH")
+		for _, period := range calendar {
+			end := "-"
+			if !period.EndTime.IsZero() {
+				end = period.EndTime.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", dao.NodeLabel(period.Document), period.StartTime.Format(time.RFC3339), end, period.Document.Hash.String())
+		}
+		w.Flush()
+	case "current":
+		period, err := dao.CurrentPeriod(calendar, time.Now())
+		if err != nil {
+			fail(err)
+		}
+		fmt.Printf("%v %v\n", dao.NodeLabel(period.Document), period.Document.Hash.String())
+	case "add":
+		if *duration == 0 {
+			*duration = viper.GetDuration("periodDuration")
+		}
+
+		predecessor := dao.RootHash(s.contract)
+		if len(calendar) > 0 {
+			predecessor = calendar[len(calendar)-1].Document.Hash
+		}
+
+		periods, err := dao.AddPeriods(ctx, s.api, s.contract, predecessor, *count, *duration)
+		if err != nil {
+			fail(err)
+		}
+		fmt.Printf("\nAdded %d periods of %v\n", len(periods), *duration)
+	}
+}
+
+// runPropose proposes the document read from a JSON file, as the given type of proposal
+//
+//	propose role|badge|attestation -proposer <account> <document.json>
+//	propose assignment -proposer <account> -assignee <account> -role <hash> -start-period <hash> <document.json>
+//	propose assignbadge -proposer <account> -assignee <account> -badge <hash> -start-period <hash> <document.json>
+//	propose payout -proposer <account> -recipient <account> -usd "100.00 USD" -deferred 50 <document.json>
+//	propose edit -proposer <account> -original <hash> <document.json>
+func runPropose(ctx context.Context, args []string) {
+
+	sub, args := subcommand("propose", args, "role", "assignment", "payout", "badge", "assignbadge", "attestation", "edit")
+	flags := newFlagSet("propose " + sub)
+	proposer := flags.String("proposer", "", "member proposing")
+	assignee := flags.String("assignee", "", "member assigned to the role or badge")
+	role := flags.String("role", "", "hash of the role of an assignment")
+	badge := flags.String("badge", "", "hash of the badge of a badge assignment")
+	startPeriod := flags.String("start-period", "", "hash of the first period of an assignment")
+	recipient := flags.String("recipient", "", "recipient of a payout")
+	usd := flags.String("usd", "", "USD amount of a payout, such as \"100.00 USD\"")
+	deferred := flags.Int64("deferred", 0, "percentage of a payout deferred to escrow")
+	original := flags.String("original", "", "hash of the document an edit changes")
+	s := connect(ctx, flags, args)
+
+	document := readFile(arguments(flags, 1, "<document.json>")[0])
+	if *proposer == "" {
+		fail(fmt.Errorf("-proposer is required"))
+	}
+	from := eos.AN(*proposer)
+
+	var trxID string
+	var proposal docgraph.Document
+	var err error
+	switch sub {
+	case "role":
+		trxID, proposal, err = dao.ProposeRole(ctx, s.api, s.contract, from, document)
+	case "badge":
+		trxID, proposal, err = dao.ProposeBadge(ctx, s.api, s.contract, from, document)
+	case "assignment":
+		trxID, proposal, err = dao.ProposeAssignment(ctx, s.api, s.contract, from, eos.AN(*assignee), parseHash(*role), parseHash(*startPeriod), document)
+	case "assignbadge":
+		trxID, proposal, err = dao.ProposeBadgeAssignment(ctx, s.api, s.contract, from, eos.AN(*assignee), parseHash(*badge), parseHash(*startPeriod), document)
+	case "payout":
+		amount, parseErr := eos.NewAssetFromString(*usd)
+		if parseErr != nil {
+			fail(fmt.Errorf("invalid -usd amount %v: %v", *usd, parseErr))
+		}
+		trxID, proposal, err = dao.ProposePayout(ctx, s.api, s.contract, from, eos.AN(*recipient), amount, *deferred, document)
+	case "edit":
+		var target docgraph.Document
+		target, err = docgraph.LoadDocument(ctx, s.api, s.contract, parseHash(*original).String())
+		if err == nil {
+			trxID, proposal, err = dao.ProposeEdit(ctx, s.api, s.contract, from, target, document)
+		}
+	case "attestation":
+		var attestation docgraph.Document
+		err = json.Unmarshal([]byte(document), &attestation)
+		if err == nil {
+			trxID, proposal, err = dao.Propose(ctx, s.api, s.contract, from, dao.Proposal{
+				Proposer:      from,
+				ProposalType:  eos.Name("attestation"),
+				ContentGroups: attestation.ContentGroups,
+			})
+		}
+	}
+	if trxID != "" {
+		fmt.Printf("Transaction: %v\n", trxID)
+	}
+	if err != nil {
+		fail(err)
+	}
+	fmt.Printf("Proposed %v %v %v\n", dao.DocumentType(proposal), proposal.Hash, dao.NodeLabel(proposal))
+}
+
+// runVote votes on a proposal: vote -voter <account> [-vote pass|fail] <proposal hash>
+func runVote(ctx context.Context, args []string) {
+
+	flags := newFlagSet("vote")
+	voter := flags.String("voter", "", "member voting")
+	vote := flags.String("vote", "pass", "pass or fail")
+	s := connect(ctx, flags, args)
+
+	hash := parseHash(arguments(flags, 1, "<proposal hash>")[0])
+	if *voter == "" {
+		fail(fmt.Errorf("-voter is required"))
+	}
+	if *vote != "pass" && *vote != "fail" {
+		fail(fmt.Errorf("-vote must be pass or fail, not %v", *vote))
+	}
+	printTransaction(dao.ProposalVote(ctx, s.api, s.contract, eos.AN(*voter), *vote, hash))
+}
+
+// runClose closes a proposal: close -closer <account> <proposal hash>
+func runClose(ctx context.Context, args []string) {
+
+	flags := newFlagSet("close")
+	closer := flags.String("closer", "", "account closing the proposal")
+	s := connect(ctx, flags, args)
+
+	hash := parseHash(arguments(flags, 1, "<proposal hash>")[0])
+	if *closer == "" {
+		fail(fmt.Errorf("-closer is required"))
+	}
+	printTransaction(dao.CloseProposal(ctx, s.api, s.contract, eos.AN(*closer), hash))
+}
+
+// runClaim claims the next period of an assignment, or with -simulate prints the payout of each of its
+// unclaimed periods, priced with the SEEDS exchange of the profile
+//
+//	claim [-claimer <account>] <assignment hash>
+//	claim -simulate <assignment hash>
+func runClaim(ctx context.Context, args []string) {
+
+	flags := newFlagSet("claim")
+	claimer := flags.String("claimer", "", "assignee claiming, the assignee of the assignment if not set")
+	simulate := flags.Bool("simulate", false, "print the simulated payout of every unclaimed period instead of claiming")
+	s := connect(ctx, flags, args)
+
+	hash := parseHash(arguments(flags, 1, "<assignment hash>")[0])
+	assignment, err := dao.LoadAssignment(ctx, s.api, s.contract, hash)
+	if err != nil {
+		fail(err)
+	}
+
+	if *simulate {
+		payouts, err := dao.SimulatePayouts(ctx, s.api, s.contract, s.profile.SeedsExchange, assignment)
+		if err != nil {
+			fail(err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PERIOD\tSTART\tHUSD\tHYPHA\tHVOICE\tSEEDS")
+		for _, payout := range payouts {
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", payout.Period.Document.Hash, payout.Period.StartTime.UTC().Format(time.RFC3339),
+				payout.Total.Husd, payout.Total.Hypha, payout.Total.Hvoice, payout.Total.Seeds)
+		}
+		w.Flush()
+		return
+	}
+
+	if *claimer == "" {
+		assignee, err := assignment.GetContent("assignee")
+		if err != nil {
+			fail(err)
+		}
+		*claimer = assignee.String()
+	}
+	_, result, err := dao.ClaimNextPayments(ctx, s.api, s.contract, eos.AN(*claimer), assignment.Hash)
+	printSubmitted(result, err)
+}
+
+// printSubmitted prints the result of a transaction that was pushed, and fails on err unless only the
+// changes to the graph are not available, which the result notes
+func printSubmitted(result dao.TransactionResult, err error) {
+	if result.ID != "" {
+		result.Print(os.Stdout)
+	}
+	if err != nil && !errors.Is(err, dao.ErrNoDeltas) {
+		fail(err)
+	}
+}
+
+// runMembers lists the members and applicants, shows the profile of one, or applies for or enrolls in membership
+//
+//	members list [-applicants]
+//	members show <account>
+//	members apply -notes "..." <applicant>
+//	members enroll -enroller <account> <applicant>
+//	members onboard -enroller <account> -out <outcome.csv> [-dry-run] <accounts.csv>
+func runMembers(ctx context.Context, args []string) {
+
+	sub, args := subcommand("members", args, "list", "show", "apply", "enroll", "onboard")
+	flags := newFlagSet("members " + sub)
+	notes := flags.String("notes", "", "notes of the application")
+	enroller := flags.String("enroller", "", "member enrolling the applicant")
+	applicants := flags.Bool("applicants", false, "list the pending applicants instead of the members")
+	out := flags.String("out", "", "file to write the onboarding outcomes to, standard output if not set")
+	dryRun := flags.Bool("dry-run", false, "write the planned steps without running them")
+	s := connect(ctx, flags, args)
+
+	switch sub {
+	case "list":
+		list := dao.Members
+		if *applicants {
+			list = dao.Applicants
+		}
+		members, err := list(ctx, s.graph)
+		if err != nil {
+			fail(err)
+		}
+		dao.PrintMembers(os.Stdout, members)
+	case "show":
+		account := arguments(flags, 1, "<account>")[0]
+		profile, err := dao.MemberProfile(ctx, s.api, s.contract, eos.AN(account))
+		if err != nil {
+			fail(err)
+		}
+		profile.Print(os.Stdout)
+	case "apply":
+		applicant := arguments(flags, 1, "<applicant>")[0]
+		printTransaction(dao.Apply(ctx, s.api, s.contract, eos.AN(applicant), *notes))
+	case "enroll":
+		applicant := arguments(flags, 1, "<applicant>")[0]
+		_, result, err := dao.EnrollApplicant(ctx, s.api, s.contract, eos.AN(*enroller), eos.AN(applicant))
+		printSubmitted(result, err)
+	case "onboard":
+		runOnboard(ctx, s, arguments(flags, 1, "<accounts.csv>")[0], dao.OnboardOptions{
+			Enroller:    eos.AN(*enroller),
+			TelosDecide: s.profile.TelosDecide,
+			DryRun:      *dryRun,
+		}, *out)
+	}
+}
+
+// runOnboard onboards the accounts of a CSV with the columns account, notes and hvoice, and writes
+// the outcome of each; rows that are already onboarded are left unchanged, so it can run again
+func runOnboard(ctx context.Context, s *session, file string, options dao.OnboardOptions, out string) {
+
+	in, err := os.Open(file)
+	if err != nil {
+		fail(err)
+	}
+	defer in.Close()
+
+	rows, err := dao.ReadOnboardingCSV(in)
+	if err != nil {
+		fail(fmt.Errorf("%v: %v", file, err))
+	}
+
+	outcomes, err := dao.Onboard(ctx, s.api, s.contract, options, rows)
+	if err != nil {
+		fail(err)
+	}
+
+	w := os.Stdout
+	if out != "" {
+		if w, err = os.Create(out); err != nil {
+			fail(err)
+		}
+		defer w.Close()
+	}
+	if err = dao.WriteOnboardingCSV(w, outcomes); err != nil {
+		fail(err)
+	}
+
+	counts := make(map[string]int)
+	for _, outcome := range outcomes {
+		counts[outcome.Outcome]++
+	}
+	fmt.Fprintf(os.Stderr, "%v accounts: %v onboarded, %v planned, %v unchanged, %v failed\n", len(outcomes),
+		counts[dao.OutcomeOnboarded], counts[dao.OutcomePlanned], counts[dao.OutcomeUnchanged], counts[dao.OutcomeFailed])
+	if counts[dao.OutcomeFailed] > 0 {
+		os.Exit(1)
+	}
+}
+
+// runTrace prints the actions of a transaction and the documents and edges it created: trace <transaction id>
+func runTrace(ctx context.Context, args []string) {
+
+	flags := newFlagSet("trace")
+	s := connect(ctx, flags, args)
+
+	trxID := arguments(flags, 1, "<transaction id>")[0]
+	result, err := dao.LoadTransaction(ctx, s.api, s.contract, trxID)
+	if err != nil {
+		fail(err)
+	}
+	result.Print(os.Stdout)
+}
+
+// reviewPolicy returns the review policy of the configuration: the review.reviewers, review.threshold
+// of approvals, and review.enroller, the contract if not set
+func reviewPolicy(s *session) dao.ReviewPolicy {
+
+	policy := dao.ReviewPolicy{
+		Threshold: viper.GetInt("review.threshold"),
+		Enroller:  eos.AN(viper.GetString("review.enroller")),
+	}
+	for _, value := range viper.GetStringSlice("review.reviewers") {
+		for _, reviewer := range parseList(value) {
+			policy.Reviewers = append(policy.Reviewers, eos.AN(reviewer))
+		}
+	}
+	if policy.Enroller == "" {
+		policy.Enroller = s.contract
+	}
+	if err := policy.Validate(); err != nil {
+		fail(fmt.Errorf("invalid review configuration %v", err))
+	}
+	return policy
+}
+
+// runReview lists the applications under review, records the decisions of reviewers in the review store,
+// and enrolls the applicants the review policy approves, or proposes their enrollment as an msig:
+//
+//	review list
+//	review approve -reviewer <account> [-reason "..."] <applicant>
+//	review reject -reviewer <account> [-reason "..."] <applicant>
+//	review enroll [-proposer <account>]
+//	review trail [applicant]
+func runReview(ctx context.Context, args []string) {
+
+	sub, args := subcommand("review", args, "list", "approve", "reject", "enroll", "trail")
+	flags := newFlagSet("review " + sub)
+	reviewer := flags.String("reviewer", "", "reviewer deciding on the applicant")
+	reason := flags.String("reason", "", "reason of the decision")
+	proposer := flags.String("proposer", "", "account proposing the enrollments as an msig when no key of the enroller is available")
+	s := connect(ctx, flags, args)
+
+	policy := reviewPolicy(s)
+	store, err := dao.OpenReviewStore(viper.GetString("review.store"))
+	if err != nil {
+		fail(err)
+	}
+	defer store.Close()
+
+	switch sub {
+	case "list":
+		applications, err := dao.ListApplications(ctx, s.api, s.contract)
+		if err != nil {
+			fail(err)
+		}
+		fmt.Printf("%-13v %-20v %-10v %-9v %v\n", "APPLICANT", "APPLIED", "STATUS", "APPROVALS", "NOTES")
+		for _, application := range applications {
+			tally := policy.Tally(application.Account, store.Trail(application.Account))
+			status := tally.Status
+			if tally.Submitted != nil {
+				status = tally.Submitted.Decision
+			}
+			fmt.Printf("%-13v %-20v %-10v %-9v %v\n", application.Account, application.Since.Format("2006-01-02 15:04:05"),
+				status, fmt.Sprintf("%v/%v", len(tally.Approvals), policy.Threshold), application.Notes)
+		}
+	case "approve", "reject":
+		applicant := arguments(flags, 1, "<applicant>")[0]
+		// the subcommands are named after the decisions they record
+		tally, err := store.Decide(policy, eos.AN(applicant), eos.AN(*reviewer), sub, *reason)
+		if err != nil {
+			fail(err)
+		}
+		fmt.Printf("%v is %v with %v of %v approvals, approved by %v, rejected by %v\n", applicant, tally.Status,
+			len(tally.Approvals), policy.Threshold, tally.Approvals, tally.Rejections)
+	case "enroll":
+		submitted, err := dao.EnrollApproved(ctx, s.api, s.contract, store, policy, eos.AN(*proposer))
+		dao.PrintTrail(os.Stdout, submitted)
+		if err != nil {
+			fail(err)
+		}
+	case "trail":
+		var applicant string
+		if flags.NArg() > 0 {
+			applicant = flags.Arg(0)
+		}
+		dao.PrintTrail(os.Stdout, store.Trail(eos.AN(applicant)))
+	}
+}
+
+type notes struct {
+	Notes string `json:"notes"`
+}
+
+// runDev resets and populates a test environment; reset and erase destroy data and refuse to run on a
+// profile other than local unless -yes-i-mean-it is given
+//
+//	dev reset [-yes-i-mean-it]
+//	dev erase [-yes-i-mean-it]
+//	dev seed
+//	dev pretend [-telos-decide trailservice] [-member mem2.hypha] <dir>
+//
+// pretend proposes and passes a role, an assignment, a payout, a badge and a badge assignment from the
+// role.json, assignment.json, payout.json, badge.json and badge-assignment.json files of dir
+func runDev(ctx context.Context, args []string) {
+
+	sub, args := subcommand("dev", args, "reset", "erase", "seed", "pretend")
+	flags := newFlagSet("dev " + sub)
+	confirmed := flags.Bool("yes-i-mean-it", false, "allow reset and erase on a profile other than local")
+	telosDecide := flags.String("telos-decide", "", "Telos Decide contract, the one of the profile if not set")
+	member := flags.String("member", "mem2.hypha", "member proposing and assigned")
+	s := connect(ctx, flags, args)
+
+	// reset and erase destroy the DAO of the profile, which is only expected of a local node
+	if (sub == "reset" || sub == "erase") && s.profile.Name != "local" && !*confirmed {
+		fail(fmt.Errorf("dev %v erases the DAO %v on profile %v; pass -yes-i-mean-it to run it on a profile other than local",
+			sub, s.contract, s.profile.Name))
+	}
+
+	switch sub {
+	case "reset":
+		fmt.Println("\nRunning contract reset action on: " + string(s.contract))
+		printTransaction(eostest.ExecTrx(ctx, s.api, []*eos.Action{{
+			Account: s.contract,
+			Name:    eos.ActN("reset4test"),
+			Authorization: []eos.PermissionLevel{
+				{Actor: s.contract, Permission: eos.PN("active")},
+			},
+			ActionData: eos.NewActionData(notes{
+				Notes: "resetting for testing",
+			}),
+		}}))
+	case "erase":
+		// erase environment except for settings
+		dao.EraseAllDocuments(ctx, s.api, s.contract)
+	case "seed":
+		dao.EnrollMembers(ctx, s.api, s.contract)
+	case "pretend":
+		if *telosDecide == "" {
+			*telosDecide = string(s.profile.TelosDecide)
+		}
+		dir := arguments(flags, 1, "<dir>")[0]
+		roleAssignment, err := dao.CreatePretend(ctx, s.api, s.contract, eos.AN(*telosDecide), eos.AN(*member), dir)
+		if err != nil {
+			fail(err)
+		}
+		fmt.Println(roleAssignment.Hash.String())
+	}
+}
+
+// runProfiles lists the network profiles, built in and from the configuration file, or checks the
+// health of the endpoints of a profile, the configured one if not given
+//
+//	profiles list
+//	profiles check [profile]
+func runProfiles(ctx context.Context, args []string) {
+
+	sub, args := subcommand("profiles", args, "list", "check")
+	flags := newFlagSet("profiles " + sub)
+	flags.Parse(args)
+	if err := loadConfig(flags); err != nil {
+		fail(err)
+	}
+
+	profiles, err := dao.LoadProfiles(viper.ConfigFileUsed())
+	if err != nil {
+		fail(err)
+	}
+
+	switch sub {
+	case "list":
+		var names []string
+		for name := range profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PROFILE\tCONTRACT\tTELOS DECIDE\tENDPOINTS")
+		for _, name := range names {
+			profile := profiles[name]
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", name, profile.Contract, profile.TelosDecide, strings.Join(profile.Endpoints, ", "))
+		}
+		w.Flush()
+	case "check":
+		name := viper.GetString("profile")
+		if flags.NArg() > 0 {
+			name = arguments(flags, 1, "[profile]")[0]
+		}
+		profile, err := dao.LoadProfile(viper.ConfigFileUsed(), name)
+		if err != nil {
+			fail(err)
+		}
+
+		healthy := false
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ENDPOINT\tHEAD BLOCK\tLATENCY\tSTATUS")
+		for _, health := range profile.CheckEndpoints(ctx) {
+			status := "ok"
+			if !health.Healthy() {
+				status = health.Err.Error()
+			}
+			healthy = healthy || health.Healthy()
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", health.Endpoint, health.HeadBlockNum, health.Latency.Round(time.Millisecond), status)
+		}
+		w.Flush()
+		if !healthy {
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/dao-contracts/dao-go"
+)
+
+// parseList splits a comma separated flag value, dropping empty items
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func parseNames(value string) []eos.Name {
+	var names []eos.Name
+	for _, name := range parseList(value) {
+		names = append(names, eos.Name(name))
+	}
+	return names
+}
+
+// openGraph opens the graph of contract read from from, the host of the session, or its graph cache,
+// if empty
+func (s *session) openGraph(ctx context.Context, contract eos.AccountName, from string) *dao.Graph {
+	var graph *dao.Graph
+	var err error
+	switch {
+	case from == "" && s.cache != nil && contract == s.contract:
+		graph, err = s.cache.Graph(ctx)
+	case from == "":
+		graph, err = dao.OpenGraph(ctx, s.api.BaseURL, contract)
+	default:
+		graph, err = dao.OpenGraph(ctx, from, contract)
+	}
+	if err != nil {
+		fail(err)
+	}
+	return graph
+}
+
+// runGraph exports, checks, compares, saves and restores the document graph, read live or, with -from,
+// from a graph archive or a folder of documents.json and edges.json
+//
+//	graph export [-from graph.json] [-format dot|graphml|cypher|neo4j] [-types member,assignment] [-edges member,assigned] [-start <hash>] [-depth 3] <path>
+//	graph check [-from graph.json] [-out integrity.json]
+//	graph diff [-out graph-diff.json] <old contract>@<old from> <new contract>@<new from>
+//	graph snapshot <graph.json>
+//	graph restore [-journal restore.journal] <graph.json>
+func runGraph(ctx context.Context, args []string) {
+
+	sub, args := subcommand("graph", args, "export", "check", "diff", "snapshot", "restore")
+	flags := newFlagSet("graph " + sub)
+	from := flags.String("from", "", "graph archive, folder of documents.json and edges.json, or endpoint to read the graph from, the host if not set")
+	format := flags.String("format", "dot", "export format: dot, graphml, cypher, or neo4j for a folder of Neo4j CSV files")
+	types := flags.String("types", "", "comma separated document types to export")
+	edges := flags.String("edges", "", "comma separated edge names to export")
+	start := flags.String("start", "", "hash of the document to export the graph reachable from")
+	depth := flags.Int("depth", 0, "number of edges to follow from -start, all if 0")
+	out := flags.String("out", "", "JSON file to save the integrity report or the diff to")
+	journalPath := flags.String("journal", "restore.journal", "journal recording the progress of the restore")
+	s := connect(ctx, flags, args)
+
+	switch sub {
+	case "export":
+		path := arguments(flags, 1, "<path>")[0]
+		graph := s.openGraph(ctx, s.contract, *from).Filter(dao.GraphFilter{
+			Types:     parseList(*types),
+			EdgeNames: parseNames(*edges),
+			Start:     *start,
+			Depth:     *depth,
+		})
+
+		if *format == "neo4j" {
+			if err := dao.ExportNeo4jCSV(path, graph); err != nil {
+				fail(err)
+			}
+			return
+		}
+
+		file, err := os.Create(path)
+		if err != nil {
+			fail(err)
+		}
+		defer file.Close()
+
+		if err = dao.ExportGraph(file, graph, *format); err != nil {
+			fail(err)
+		}
+	case "check":
+		report, err := dao.Check(s.openGraph(ctx, s.contract, *from))
+		if err != nil {
+			fail(err)
+		}
+
+		report.Print(os.Stdout)
+		if *out != "" {
+			writeJSON(*out, report)
+		}
+		if report.Errors() > 0 {
+			os.Exit(1)
+		}
+	case "diff":
+		args := arguments(flags, 2, "<old contract>@<old from> <new contract>@<new from>")
+		var graphs [2]*dao.Graph
+		for i, arg := range args {
+			parts := strings.SplitN(arg, "@", 2)
+			if len(parts) != 2 {
+				fail(fmt.Errorf("expected <contract>@<from>, such as dao.hypha@https://api.telos.kitchen: %v", arg))
+			}
+			graphs[i] = s.openGraph(ctx, eos.AN(parts[0]), parts[1])
+		}
+
+		diff := dao.DiffGraphs(graphs[0], graphs[1])
+		diff.Print(os.Stdout)
+		if *out != "" {
+			writeJSON(*out, diff)
+		}
+	case "snapshot":
+		path := arguments(flags, 1, "<graph.json>")[0]
+		if err := dao.SnapshotGraph(ctx, s.api, s.contract, path); err != nil {
+			fail(err)
+		}
+	case "restore":
+		path := arguments(flags, 1, "<graph.json>")[0]
+		archive, err := dao.LoadGraphArchive(path)
+		if err != nil {
+			fail(err)
+		}
+
+		journal, err := dao.OpenJournal(*journalPath)
+		if err != nil {
+			fail(err)
+		}
+
+		executor := dao.NewExecutor(dao.DefaultExecutorOptions(), journal)
+		restore, err := dao.RestoreGraph(ctx, s.api, s.contract, archive, executor)
+		journal.PrintSummary(os.Stdout)
+		journal.Close()
+		if err != nil {
+			fail(err)
+		}
+		restore.Print(os.Stdout)
+	}
+}
+
+// runQuery evaluates the query given as arguments against the live graph, its cache with -cache, or the graph
+// archive or table dumps given with -from, printing a table or, with -json, the JSON result
+//
+//	query 'root -member-> * -assigned-> assignment -claimed-> period return node_label limit 10'
+func runQuery(ctx context.Context, args []string) {
+
+	flags := newFlagSet("query")
+	from := flags.String("from", "", "graph archive, or folder of documents.json and edges.json, to query instead of the live graph")
+	asJSON := flags.Bool("json", false, "print the result as JSON")
+	s := connect(ctx, flags, args)
+
+	source := s.graph
+	if *from != "" {
+		source = s.openGraph(ctx, s.contract, *from).Source()
+	}
+
+	result, err := dao.RunQuery(ctx, source, strings.Join(flags.Args(), " "))
+	if err != nil {
+		fail(err)
+	}
+
+	if *asJSON {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			fail(err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	result.Print(os.Stdout)
+}
+
+// runMigrate copies the legacy tables of a source endpoint and migrates them into the document graph.
+// A snapshot of the source tables lets the copy and migration be rehearsed from a file; rerunning
+// with the same journal resumes the migration and retries the failed records. Phases completed by an
+// earlier run with the same journal satisfy the ones depending on them, so the scopes can also be
+// migrated one run at a time, roles before assignments.
+//
+//	migrate snapshot [-from https://api.telos.kitchen] [-scopes role,assignment,payout] <legacy-snapshot.json>
+//	migrate copy [-from https://api.telos.kitchen] [-scopes assignment,payout]
+//	migrate preview [-from https://api.telos.kitchen] [-scopes role,assignment,payout] [-out preview.json]
+//	migrate run [-snapshot legacy-snapshot.json] [-scopes role,assignment,payout] [-journal migration.journal]
+//	migrate reconcile [-from https://api.telos.kitchen] [-scopes role,assignment,payout] [-out reconciliation.json]
+func runMigrate(ctx context.Context, args []string) {
+
+	sub, args := subcommand("migrate", args, "snapshot", "copy", "preview", "run", "reconcile")
+	flags := newFlagSet("migrate " + sub)
+	from := flags.String("from", "https://api.telos.kitchen", "endpoint of the contract holding the legacy tables")
+	scopes := flags.String("scopes", "role,assignment,payout", "comma separated object scopes")
+	snapshot := flags.String("snapshot", "", "legacy snapshot to migrate from instead of the tables on the host")
+	journalPath := flags.String("journal", "migration.journal", "journal recording the progress of the migration")
+	out := flags.String("out", "", "JSON file to save the preview or the reconciliation report to")
+	s := connect(ctx, flags, args)
+
+	switch sub {
+	case "snapshot":
+		path := arguments(flags, 1, "<legacy-snapshot.json>")[0]
+		err := dao.SnapshotLegacyTables(ctx, eos.New(*from), s.contract, parseNames(*scopes), path)
+		if err != nil {
+			fail(err)
+		}
+	case "copy":
+		copier := dao.NewExecutor(dao.DefaultExecutorOptions(), nil)
+		err := dao.CopyMembers(ctx, s.api, s.contract, *from, copier)
+		if err == nil {
+			err = dao.CopyPeriods(ctx, s.api, s.contract, *from, copier)
+		}
+		for _, scope := range parseNames(*scopes) {
+			if err == nil {
+				err = dao.CopyObjects(ctx, s.api, s.contract, scope, *from, copier)
+			}
+		}
+		if err == nil {
+			err = dao.CopyAssPayouts(ctx, s.api, s.contract, *from, copier)
+		}
+		if err != nil {
+			fail(err)
+		}
+	case "preview":
+		documents, err := dao.PreviewMigration(ctx, s.api, s.contract, parseNames(*scopes), *from)
+		if err != nil {
+			fail(err)
+		}
+
+		dao.PrintPreview(os.Stdout, documents)
+		if *out != "" {
+			writeJSON(*out, documents)
+		}
+	case "run":
+		source := dao.NewEndpointTables(s.api, s.contract)
+		if *snapshot != "" {
+			legacy, err := dao.LoadLegacySnapshot(*snapshot)
+			if err != nil {
+				fail(err)
+			}
+			source = legacy
+		}
+		migrate(ctx, s.api, s.contract, source, parseNames(*scopes), *journalPath)
+	case "reconcile":
+		report, err := dao.Reconcile(ctx, s.api, s.contract, parseNames(*scopes), *from)
+		if err != nil {
+			fail(err)
+		}
+
+		report.Print(os.Stdout)
+		if *out != "" {
+			writeJSON(*out, report)
+		}
+		if len(report.Discrepancies) > 0 {
+			os.Exit(1)
+		}
+	}
+}
+
+// migrate runs the migration steps of scopes in order for the records listed by source, recording progress
+// in the journal at journalPath, and exits with a non-zero status if any record failed
+func migrate(ctx context.Context, api *eos.API, contract eos.AccountName, source dao.LegacyTables, scopes []eos.Name, journalPath string) {
+
+	journal, err := dao.OpenJournal(journalPath)
+	if err != nil {
+		fail(err)
+	}
+	defer journal.Close()
+
+	executor := dao.NewExecutor(dao.DefaultExecutorOptions(), journal)
+
+	err = dao.MigrateMembers(ctx, api, contract, source, executor)
+	if err == nil {
+		err = dao.MigratePeriods(ctx, api, contract, source, executor)
+	}
+
+	for _, scope := range scopes {
+		if err == nil {
+			err = dao.MigrateObjects(ctx, api, contract, scope, source, executor)
+		}
+	}
+
+	// the payouts of assignments are migrated with the assignments
+	for _, scope := range scopes {
+		if err == nil && scope == "assignment" {
+			err = dao.MigrateAssPayouts(ctx, api, contract, source, executor)
+		}
+	}
+
+	journal.PrintSummary(os.Stdout)
+
+	if err != nil {
+		fmt.Println("\nMigration stopped: ", err)
+	}
+
+	if err != nil || len(journal.Failures()) > 0 {
+		journal.Close()
+		os.Exit(1)
+	}
+}
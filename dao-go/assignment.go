@@ -0,0 +1,84 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+)
+
+// PeriodSpan is a period document along with its start and end times; a period
+// ends when the period linked by its 'next' edge starts
+type PeriodSpan struct {
+	Document  docgraph.Document
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// LoadAssignment loads a document and verifies that it is an assignment
+func LoadAssignment(ctx context.Context, api *eos.API, contract eos.AccountName, assignmentHash eos.Checksum256) (docgraph.Document, error) {
+	assignment, err := docgraph.LoadDocument(ctx, api, contract, assignmentHash.String())
+	if err != nil {
+		return docgraph.Document{}, fmt.Errorf("cannot load assignment %v: %v", assignmentHash.String(), err)
+	}
+
+	docType, err := getName(assignment, "type")
+	if err != nil {
+		return docgraph.Document{}, err
+	}
+
+	if docType != eos.Name("assignment") {
+		return docgraph.Document{}, fmt.Errorf("invalid document type. Expected: assignment; actual: %v", docType)
+	}
+	return assignment, nil
+}
+
+// AssignmentPeriods returns the periods an assignment covers, starting at its start_period
+// and following 'next' edges for period_count periods
+func AssignmentPeriods(ctx context.Context, api *eos.API, contract eos.AccountName, assignment docgraph.Document) ([]PeriodSpan, error) {
+
+	startPeriodHash, err := getChecksum(assignment, "start_period")
+	if err != nil {
+		return []PeriodSpan{}, err
+	}
+
+	periodCount, err := getInt64(assignment, "period_count")
+	if err != nil {
+		return []PeriodSpan{}, err
+	}
+
+	period, err := docgraph.LoadDocument(ctx, api, contract, startPeriodHash.String())
+	if err != nil {
+		return []PeriodSpan{}, fmt.Errorf("cannot load start period %v: %v", startPeriodHash.String(), err)
+	}
+
+	startTime, err := getTimePoint(period, "start_time")
+	if err != nil {
+		return []PeriodSpan{}, err
+	}
+
+	spans := make([]PeriodSpan, 0, periodCount)
+	for int64(len(spans)) < periodCount {
+		next, err := getEdgeTarget(ctx, api, contract, period, eos.Name("next"))
+		if err != nil {
+			return spans, fmt.Errorf("end of calendar has been reached after %v of %v periods: %v", len(spans), periodCount, err)
+		}
+
+		endTime, err := getTimePoint(next, "start_time")
+		if err != nil {
+			return spans, err
+		}
+
+		spans = append(spans, PeriodSpan{
+			Document:  period,
+			StartTime: ToTime(startTime),
+			EndTime:   ToTime(endTime),
+		})
+
+		period = next
+		startTime = endTime
+	}
+	return spans, nil
+}
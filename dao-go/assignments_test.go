@@ -2,69 +2,27 @@ package dao_test
 
 import (
   "strconv"
-  "strings"
   "testing"
   "time"
 
   "github.com/eoscanada/eos-go"
   "github.com/hypha-dao/dao-contracts/dao-go"
+  "github.com/hypha-dao/dao-contracts/dao-go/daotest"
   "github.com/hypha-dao/document-graph/docgraph"
   "gotest.tools/assert"
 )
 
-func GetAdjustInfo(assignment eos.Checksum256, timeShare int64, startDate eos.TimePoint) ([]docgraph.ContentGroup) {
-
-  return []docgraph.ContentGroup{
-  {
-      {
-        Label: "assignemnt_id",
-        Value: 	&docgraph.FlexValue{ 
-          BaseVariant: eos.BaseVariant{
-          TypeID: docgraph.GetVariants().TypeID("checksum256"),
-          Impl:   assignment,
-        }},
-      },
-      {
-        Label: "new_time_share_x100",
-        Value: &docgraph.FlexValue{
-          BaseVariant: eos.BaseVariant{
-            TypeID: docgraph.GetVariants().TypeID("int64"),
-            Impl:  timeShare,
-          }},
-      },
-      {
-        Label: "start_date",
-        Value: &docgraph.FlexValue{
-          BaseVariant: eos.BaseVariant{
-            TypeID: docgraph.GetVariants().TypeID("time_point"),
-            Impl:  startDate,
-          }},
-      },
-  }}
-}
-
-//Used to calculate token compensation with 3 adjustments over the same
-//period
-func CalculateTotalCompensation(alfa, beta, gamma, totalByPeriod float32) float32 {
-  //Or factorized totalByPeriod*1.0/3.0*(alfa+beta+gamma)
-  return	totalByPeriod * 1.0 / 3.0 * alfa + 
-          totalByPeriod * 1.0 / 3.0 * beta +
-          totalByPeriod * 1.0 / 3.0 * gamma
-}
-
-func CreateAdjustmentAfter(commitment, startSecs int64, offsetSecs time.Duration, assignment *docgraph.Document, assignee *Member, env *Environment, t *testing.T) error {
+func CreateAdjustmentAfter(commitment, startSecs int64, offsetSecs time.Duration, assignment *docgraph.Document, assignee *daotest.Member, env *daotest.Environment, t *testing.T) error {
 
   time := time.Unix(startSecs, 0)
 
   adjustStartDate := eos.TimePoint(time.Add(offsetSecs).UnixNano()/1000)
 
-  var adjustInfo = GetAdjustInfo(assignment.Hash, commitment, adjustStartDate)
-
-  _, err := AdjustCommitment(env, assignee.Member, adjustInfo);
+  _, err := dao.AdjustCommitment(env.Ctx, env.API, env.DAO, assignment.Hash, commitment, adjustStartDate)
 
   assert.NilError(t, err);
 
-  timeShare, err := docgraph.GetLastDocument(env.ctx, &env.api, env.DAO)
+  timeShare, err := docgraph.GetLastDocument(env.Ctx, env.API, env.DAO)
 
   ts, err := timeShare.GetContent("time_share_x100")
   assert.NilError(t, err);
@@ -77,53 +35,40 @@ func CreateAdjustmentAfter(commitment, startSecs int64, offsetSecs time.Duration
   return err
 }
 
-func AssetToInt(asset *docgraph.FlexValue) (int64, string, error) {
-  assetStr := asset.Impl.(*eos.Asset).String()
-  stringItms := strings.Split(assetStr, " ")
-  parsed, err := strconv.ParseFloat(stringItms[0], 32)
-
-  return int64(parsed), stringItms[1], err
-}
-
-func ValidateLastReceipt(targetHUSD, targetHYPHA, targetHVOICE, targetSEEDS int64, env *Environment, t *testing.T) {
+//Compares the payments of the last claimed period with the
+//simulated payout of that period
+func ValidateLastReceipt(simulated []dao.PeriodPayout, env *daotest.Environment, t *testing.T) {
 
-  period, err := docgraph.GetLastDocumentOfEdge(env.ctx, &env.api, env.DAO, eos.Name("claimed"))
+  period, err := docgraph.GetLastDocumentOfEdge(env.Ctx, env.API, env.DAO, eos.Name("claimed"))
   assert.NilError(t, err);
 
-  paymentEdges, err := docgraph.GetEdgesFromDocumentWithEdge(env.ctx, &env.api, env.DAO, period, eos.Name("payment"))
-  assert.NilError(t, err);
+  var expected *dao.PeriodPayout
+  for i := range simulated {
+    if simulated[i].Period.Document.Hash.String() == period.Hash.String() {
+      expected = &simulated[i]
+    }
+  }
+  assert.Assert(t, expected != nil, "claimed period is not covered by the assignment: " + period.Hash.String())
 
-  var paymentMap map[string]int64
+  paymentEdges, err := docgraph.GetEdgesFromDocumentWithEdge(env.Ctx, env.API, env.DAO, period, eos.Name("payment"))
+  assert.NilError(t, err);
 
-  paymentMap = make(map[string]int64)
+  paymentMap := make(map[string]eos.Int64)
 
   for _, edge := range paymentEdges {
-    payment, err := docgraph.LoadDocument(env.ctx, &env.api, env.DAO, edge.ToNode.String())
+    payment, err := docgraph.LoadDocument(env.Ctx, env.API, env.DAO, edge.ToNode.String())
     assert.NilError(t, err);
-    asset, err := payment.GetContent("amount");
+    amount, err := payment.GetContent("amount");
     assert.NilError(t, err);
-    amount, symbol, err := AssetToInt(asset)
-    assert.NilError(t, err);
-    paymentMap[symbol] = amount
-  }
-
-  if (targetHUSD != 0) {
-    assert.Equal(t, targetHUSD, paymentMap["HUSD"])
+    asset := amount.Impl.(*eos.Asset)
+    paymentMap[asset.Symbol.Symbol] = asset.Amount
   }
 
-  if (targetHYPHA != 0) {
-    assert.Equal(t, targetHYPHA, paymentMap["HYPHA"])
-  }
-
-  if (targetHVOICE != 0) {
-    assert.Equal(t, targetHVOICE, paymentMap["HVOICE"])
-  }
-
-  if (targetSEEDS != 0) {
-    assert.Equal(t, targetSEEDS, paymentMap["SEEDS"])
+  for _, target := range []eos.Asset{expected.Total.Husd, expected.Total.Hypha, expected.Total.Hvoice, expected.Total.Seeds} {
+    if (target.Amount != 0) {
+      assert.Equal(t, target.Amount, paymentMap[target.Symbol.Symbol])
+    }
   }
-
-  assert.NilError(t, err);
 }
 
 func TestAdjustCommitment(t *testing.T) {
@@ -170,46 +115,25 @@ func TestAdjustCommitment(t *testing.T) {
 
       t.Log("\n\nStarting test: ", test.name)
 
-      _, err := dao.ProposeAssignment(env.ctx, &env.api, env.DAO, proposer.Member, assignee.Member, test.role.Hash, env.Periods[0].Hash, test.assignment)
-      assert.NilError(t, err)
-
-      // retrieve the document we just created
-      proposal, err := docgraph.GetLastDocumentOfEdge(env.ctx, &env.api, env.DAO, eos.Name("proposal"))
+      _, proposal, err := dao.ProposeAssignment(env.Ctx, env.API, env.DAO, proposer.Member, assignee.Member, test.role.Hash, env.Periods[0].Hash, test.assignment)
       assert.NilError(t, err)
     
-      ballot, err := proposal.GetContent("ballot_id")
-      assert.NilError(t, err)
-      voteToPassTD(t, env, ballot.Impl.(eos.Name))
+      voteToPassTD(t, env, proposal)
 
       //Wait 1 Period to close the proposal and test the special 
       //case when approved time overlaps in the first period
       t.Log("Waiting for a period to lapse...")
       pause(t, env.PeriodPause, "", "Waiting...")
 
-      _, err = dao.CloseProposal(env.ctx, &env.api, env.DAO, closer.Member, proposal.Hash)
+      _, err = dao.CloseProposal(env.Ctx, env.API, env.DAO, closer.Member, proposal.Hash)
       assert.NilError(t, err)
 
-      assignment, err := docgraph.GetLastDocumentOfEdge(env.ctx, &env.api, env.DAO, eos.Name("assignment"))
+      assignment, err := docgraph.GetLastDocumentOfEdge(env.Ctx, env.API, env.DAO, eos.Name("assignment"))
 
-      var firstPeriodStartSecs int64
-      var firstPeriodEndSecs int64
-      //Get starting period to calculate half period duration
-      {
-        periodHash, err := assignment.GetContent("start_period")
-        assert.NilError(t, err)
-        period, err := docgraph.LoadDocument(env.ctx, &env.api, env.DAO, periodHash.String());
-        assert.NilError(t, err)
-        startTime, err := period.GetContent("start_time")
-        assert.NilError(t, err)
-        firstPeriodStartSecs = int64(startTime.Impl.(eos.TimePoint))/1000000
-        nextPeriods, err := docgraph.GetEdgesFromDocumentWithEdge(env.ctx, &env.api, env.DAO, period, eos.Name("next"))
-        assert.NilError(t, err)
-        nextPeriod, err := docgraph.LoadDocument(env.ctx, &env.api, env.DAO, nextPeriods[0].ToNode.String())
-        assert.NilError(t, err)
-        startTime, err = nextPeriod.GetContent("start_time")
-        assert.NilError(t, err)
-        firstPeriodEndSecs = int64(startTime.Impl.(eos.TimePoint))/1000000
-      }
+      //Get starting period to calculate the adjustment dates
+      periods, err := dao.AssignmentPeriods(env.Ctx, env.API, env.DAO, assignment)
+      assert.NilError(t, err)
+      firstPeriodStartSecs := periods[0].StartTime.Unix()
 
       //Create Adjustment 2.5 Periods after start period
       CreateAdjustmentAfter(int64(50), firstPeriodStartSecs, 
@@ -231,32 +155,22 @@ func TestAdjustCommitment(t *testing.T) {
                             &assignment,
                             &assignee, env, t)
 
-      //TODO: Calculate seeds_per_usd using tlosto.seeds table
-      //Set to 0 to temporaly disable  calculating SEEDS
-      hardcodedSeedsPerUsd := float32(0) /*float32(39.0840)*/
-      var seedsDeferralFactor float32
-      {
-        settings, err := docgraph.GetLastDocumentOfEdge(env.ctx, &env.api, env.DAO, eos.Name("settings"))
-        
-        seedsDeferral, err := settings.GetContent("seeds_deferral_factor_x100")
-        assert.NilError(t, err)
-
-        seedsDeferralFactor = float32(seedsDeferral.Impl.(int64)) / 100.0
+      //Initial time share followed by the 3 adjustments, each one
+      //ending where the next one starts
+      history, err := dao.TimeShareHistory(env.Ctx, env.API, env.DAO, assignment)
+      assert.NilError(t, err)
+      assert.Equal(t, len(history), 4)
+      assert.Equal(t, history[1].TimeShare, int64(50))
+      assert.Equal(t, history[2].TimeShare, int64(100))
+      assert.Equal(t, history[3].TimeShare, int64(75))
+      for i := 1; i < len(history); i++ {
+        assert.Equal(t, history[i-1].EndDate, history[i].StartDate)
       }
 
-      usdSalaryPerPhase := float32(3039.01)
-
-      //Number of HYPHA tokens received in 1 full period
-      totalHYPHA := float32(759.75)
-
-      //Number of HUSD tokens received in 1 full period
-      totalHUSD := float32(0)
-
-      //Number of HVOICE tokens received in 1 full period
-      totalHVOICE := float32(usdSalaryPerPhase*2.0)
-
-      //Number of seeds received in 1 full period
-      totalSEEDS := usdSalaryPerPhase * hardcodedSeedsPerUsd * seedsDeferralFactor
+      //Expected payment of every period, pro-rated over the time
+      //share history the same way the contract does
+      simulated, err := dao.SimulatePayouts(env.Ctx, env.API, env.DAO, env.SeedsExchange, assignment)
+      assert.NilError(t, err)
 
       //Claim first period
       t.Log("Waiting for a period to lapse...")
@@ -266,19 +180,7 @@ func TestAdjustCommitment(t *testing.T) {
       //the start time of the period
       _, err = ClaimNextPeriod(t, env, assignee.Member, assignment)
       assert.NilError(t, err)
-
-      {
-        initTimeShare, err := docgraph.GetLastDocumentOfEdge(env.ctx, &env.api, env.DAO, eos.Name("initimeshare"))
-        assert.NilError(t, err)
-        approvedTime, err := initTimeShare.GetContent("start_date")
-        approvedSecs := int64(approvedTime.Impl.(eos.TimePoint))/1000000
-        periodDuration := float32(firstPeriodEndSecs-firstPeriodStartSecs)
-        timeFactor := float32(firstPeriodEndSecs-approvedSecs) / periodDuration
-        ValidateLastReceipt(int64(totalHUSD*timeFactor),
-                            int64(totalHYPHA*timeFactor),
-                            int64(totalHVOICE*timeFactor),
-                            int64(totalSEEDS*timeFactor), env, t)
-      }
+      ValidateLastReceipt(simulated, env, t)
 
       //Claim second period
       t.Log("Waiting for a period to lapse...")
@@ -288,9 +190,7 @@ func TestAdjustCommitment(t *testing.T) {
       //place on the next period
       _, err = ClaimNextPeriod(t, env, assignee.Member, assignment)
       assert.NilError(t, err)
-
-      //Ignore decimal precision.
-      ValidateLastReceipt(int64(totalHUSD), int64(totalHYPHA), int64(totalHVOICE), int64(totalSEEDS), env, t)
+      ValidateLastReceipt(simulated, env, t)
 
       //Claim third period
       t.Log("Waiting for another period to lapse...")
@@ -300,17 +200,7 @@ func TestAdjustCommitment(t *testing.T) {
       //and then half payment for the last half of the period
       _, err = ClaimNextPeriod(t, env, assignee.Member, assignment)
       assert.NilError(t, err)
-      
-      {
-        //15 : Period Duration
-        firstHalf := float32(7.0 / 15.0)
-        secondHalf := float32(8.0 / 15.0)
-        newTotalSEEDS := totalSEEDS * firstHalf + totalSEEDS * float32(0.5) * secondHalf
-        newTotalHYPHA := totalHYPHA * firstHalf + totalHYPHA * float32(0.5) * secondHalf
-        newTotalHVOICE := totalHVOICE * firstHalf + totalHVOICE * float32(0.5) * secondHalf
-        newTotalHUSD := totalHUSD * firstHalf + totalHUSD * float32(0.5) * secondHalf
-        ValidateLastReceipt(int64(newTotalHUSD), int64(newTotalHYPHA), int64(newTotalHVOICE), int64(newTotalSEEDS), env, t)
-      }
+      ValidateLastReceipt(simulated, env, t)
 
       //Claim last period
       t.Log("Waiting for another period to lapse...")
@@ -321,14 +211,7 @@ func TestAdjustCommitment(t *testing.T) {
       //75% of payment on the last third
       _, err = ClaimNextPeriod(t, env, assignee.Member, assignment)
       assert.NilError(t, err)
-
-      {
-        newTotalSEEDS := CalculateTotalCompensation(0.5, 1.0, 0.75, totalSEEDS)
-        newTotalHYPHA := CalculateTotalCompensation(0.5, 1.0, 0.75, totalHYPHA)
-        newTotalHVOICE := CalculateTotalCompensation(0.5, 1.0, 0.75, totalHVOICE)
-        newTotalHUSD := CalculateTotalCompensation(0.5, 1.0, 0.75, totalHUSD)
-        ValidateLastReceipt(int64(newTotalHUSD), int64(newTotalHYPHA), int64(newTotalHVOICE), int64(newTotalSEEDS), env, t)
-      }
+      ValidateLastReceipt(simulated, env, t)
     }
   })
 }
@@ -445,11 +328,7 @@ func TestAssignmentProposalDocument(t *testing.T) {
 		for _, test := range tests {
 
 			t.Log("\n\nStarting test: ", test.name)
-			_, err := dao.ProposeAssignment(env.ctx, &env.api, env.DAO, proposer.Member, assignee.Member, test.role.Hash, env.Periods[0].Hash, test.assignment)
-			assert.NilError(t, err)
-
-			// retrieve the document we just created
-			assignment, err := docgraph.GetLastDocumentOfEdge(env.ctx, &env.api, env.DAO, eos.Name("proposal"))
+			_, assignment, err := dao.ProposeAssignment(env.Ctx, env.API, env.DAO, proposer.Member, assignee.Member, test.role.Hash, env.Periods[0].Hash, test.assignment)
 			assert.NilError(t, err)
 			assert.Equal(t, assignment.Creator, proposer.Member)
 
@@ -470,11 +349,11 @@ func TestAssignmentProposalDocument(t *testing.T) {
 			// roleHash, err := assignment.GetContent("role")
 			// assert.NilError(t, err)
 
-			// roleDocument, err := docgraph.LoadDocument(env.ctx, &env.api, env.DAO, roleHash.String())
+			// roleDocument, err := docgraph.LoadDocument(env.Ctx, env.API, env.DAO, roleHash.String())
 			// assert.NilError(t, err)
 
 			// // check edge from assignment to role
-			// exists, err := docgraph.EdgeExists(env.ctx, &env.api, env.DAO, assignment, roleDocument, eos.Name("role"))
+			// exists, err := docgraph.EdgeExists(env.Ctx, env.API, env.DAO, assignment, roleDocument, eos.Name("role"))
 			// assert.NilError(t, err)
 			// if !exists {
 			// 	t.Log("Edge does not exist	: ", assignment.Hash.String(), "	-- role	--> 	", roleDocument.Hash.String())
@@ -484,7 +363,7 @@ func TestAssignmentProposalDocument(t *testing.T) {
 			voteToPassTD(t, env, assignment)
 
 			t.Log("Member: ", closer.Member, " is closing assignment proposal	: ", assignment.Hash.String())
-			_, err = dao.CloseProposal(env.ctx, &env.api, env.DAO, closer.Member, assignment.Hash)
+			_, err = dao.CloseProposal(env.Ctx, env.API, env.DAO, closer.Member, assignment.Hash)
 			assert.NilError(t, err)
 
 			// verify that the edges are created correctly
@@ -502,7 +381,7 @@ func TestAssignmentProposalDocument(t *testing.T) {
 			//  root ---- passedprops        ---->   role_assignment
 			checkEdge(t, env, env.Root, assignment, eos.Name("passedprops"))
 
-			fetchedAssignment, err := docgraph.GetLastDocumentOfEdge(env.ctx, &env.api, env.DAO, eos.Name("assignment"))
+			fetchedAssignment, err := docgraph.GetLastDocumentOfEdge(env.Ctx, env.API, env.DAO, eos.Name("assignment"))
 
 			husd, err := fetchedAssignment.GetContent("husd_salary_per_phase")
 			assert.NilError(t, err)
@@ -568,11 +447,7 @@ func TestAssignmentDefaults(t *testing.T) {
     for _, test := range tests {
 
       t.Log("\n\nStarting test: ", test.name)
-      _, err := dao.ProposeAssignment(env.ctx, &env.api, env.DAO, proposer.Member, assignee.Member, test.role.Hash, env.Periods[0].Hash, test.assignment)
-      assert.NilError(t, err)
-
-      // retrieve the document we just created
-      assignment, err := docgraph.GetLastDocumentOfEdge(env.ctx, &env.api, env.DAO, eos.Name("proposal"))
+      _, assignment, err := dao.ProposeAssignment(env.Ctx, env.API, env.DAO, proposer.Member, assignee.Member, test.role.Hash, env.Periods[0].Hash, test.assignment)
       assert.NilError(t, err)
       assert.Equal(t, assignment.Creator, proposer.Member)
 
@@ -591,7 +466,7 @@ func TestAssignmentPayClaim(t *testing.T) {
 	t.Log(env.String())
 	t.Log("\nDAO Environment Setup complete\n")
 
-	balances = append(balances, NewBalance())
+	balances = append(balances, daotest.NewBalance())
 
 	// roles
 	proposer := env.Members[0]
@@ -629,12 +504,7 @@ func TestAssignmentPayClaim(t *testing.T) {
 			t.Log("\n\nStarting test: ", test.name)
 			role := CreateRole(t, env, proposer, closer, test.role)
 
-			trxID, err := dao.ProposeAssignment(env.ctx, &env.api, env.DAO, proposer.Member, assignee.Member, role.Hash, env.Periods[0].Hash, test.assignment)
-			t.Log("Assignment proposed: ", trxID)
-			assert.NilError(t, err)
-
-			// retrieve the document we just created
-			assignment, err := docgraph.GetLastDocumentOfEdge(env.ctx, &env.api, env.DAO, eos.Name("proposal"))
+			_, assignment, err := dao.ProposeAssignment(env.Ctx, env.API, env.DAO, proposer.Member, assignee.Member, role.Hash, env.Periods[0].Hash, test.assignment)
 			assert.NilError(t, err)
 			assert.Equal(t, assignment.Creator, proposer.Member)
 
@@ -654,7 +524,7 @@ func TestAssignmentPayClaim(t *testing.T) {
 			voteToPassTD(t, env, assignment)
 
 			t.Log("Member: ", closer.Member, " is closing assignment proposal	: ", assignment.Hash.String())
-			_, err = dao.CloseProposal(env.ctx, &env.api, env.DAO, closer.Member, assignment.Hash)
+			_, err = dao.CloseProposal(env.Ctx, env.API, env.DAO, closer.Member, assignment.Hash)
 			assert.NilError(t, err)
 
 			// verify that the edges are created correctly
@@ -678,7 +548,7 @@ func TestAssignmentPayClaim(t *testing.T) {
 			_, err = ClaimNextPeriod(t, env, assignee.Member, assignment)
 			assert.NilError(t, err)
 
-			fetchedAssignment, err := docgraph.GetLastDocumentOfEdge(env.ctx, &env.api, env.DAO, eos.Name("assignment"))
+			fetchedAssignment, err := docgraph.GetLastDocumentOfEdge(env.Ctx, env.API, env.DAO, eos.Name("assignment"))
 
 			husd, err := fetchedAssignment.GetContent("husd_salary_per_phase")
 			assert.NilError(t, err)
@@ -691,8 +561,8 @@ func TestAssignmentPayClaim(t *testing.T) {
 
 			// first payment is a partial payment, so should be less than the amount on the assignment record
 			// SEEDS escrow payment should be greater than zero
-			payments = append(payments, CalcLastPayment(t, env, balances[len(balances)-1], assignee.Member))
-			balances = append(balances, GetBalance(t, env, assignee.Member))
+			payments = append(payments, env.LastPayment(balances[len(balances)-1], assignee.Member))
+			balances = append(balances, env.Balance(assignee.Member))
 			assert.Assert(t, hypha.Impl.(*eos.Asset).Amount >= payments[len(payments)-1].Hypha.Amount)
 			assert.Assert(t, husd.Impl.(*eos.Asset).Amount >= payments[len(payments)-1].Husd.Amount)
 			t.Log("Hvoice from payment      : ", strconv.Itoa(int(payments[len(payments)-1].Hvoice.Amount)))
@@ -708,8 +578,8 @@ func TestAssignmentPayClaim(t *testing.T) {
 
 			// 2nd payment should be equal to the payment on the assignment record
 			// 2nd SEEDS escrow payment should be greater than the first one
-			payments = append(payments, CalcLastPayment(t, env, balances[len(balances)-1], assignee.Member))
-			balances = append(balances, GetBalance(t, env, assignee.Member))
+			payments = append(payments, env.LastPayment(balances[len(balances)-1], assignee.Member))
+			balances = append(balances, env.Balance(assignee.Member))
 			assert.Equal(t, hypha.Impl.(*eos.Asset).Amount, payments[len(payments)-1].Hypha.Amount)
 			assert.Equal(t, husd.Impl.(*eos.Asset).Amount, payments[len(payments)-1].Husd.Amount)
 			assert.Equal(t, hvoice.Impl.(*eos.Asset).Amount, payments[len(payments)-1].Hvoice.Amount)
@@ -5,7 +5,7 @@ import (
 
 	"github.com/eoscanada/eos-go"
 	"github.com/hypha-dao/dao-contracts/dao-go"
-	"github.com/hypha-dao/document-graph/docgraph"
+	"github.com/hypha-dao/dao-contracts/dao-go/daotest"
 	testassert "github.com/stretchr/testify/assert"
 	"gotest.tools/assert"
 )
@@ -38,7 +38,7 @@ func TestBadgeProposals(t *testing.T) {
 	assert.NilError(t, err)
 
 	// balances at the start are always zero
-	balances = append(balances, NewBalance())
+	balances = append(balances, daotest.NewBalance())
 
 	// Claiming the first period
 	t.Log("Waiting for a period to lapse and claiming the first period pay...")
@@ -46,8 +46,8 @@ func TestBadgeProposals(t *testing.T) {
 	_, err = ClaimNextPeriod(t, env, assignee.Member, assignment)
 	assert.NilError(t, err)
 
-	payments = append(payments, CalcLastPayment(t, env, balances[len(balances)-1], assignee.Member))
-	balances = append(balances, GetBalance(t, env, assignee.Member))
+	payments = append(payments, env.LastPayment(balances[len(balances)-1], assignee.Member))
+	balances = append(balances, env.Balance(assignee.Member))
 
 	// Claiming the second period
 	t.Log("Waiting for a period to lapse and claiming the second period pay...")
@@ -55,8 +55,8 @@ func TestBadgeProposals(t *testing.T) {
 	_, err = ClaimNextPeriod(t, env, assignee.Member, assignment)
 	assert.NilError(t, err)
 
-	payments = append(payments, CalcLastPayment(t, env, balances[len(balances)-1], assignee.Member))
-	balances = append(balances, GetBalance(t, env, assignee.Member))
+	payments = append(payments, env.LastPayment(balances[len(balances)-1], assignee.Member))
+	balances = append(balances, env.Balance(assignee.Member))
 
 	t.Run("Badge proposals", func(t *testing.T) {
 
@@ -87,11 +87,7 @@ func TestBadgeProposals(t *testing.T) {
 			t.Run(test.name, func(t *testing.T) {
 
 				t.Log("\nStarting test: ", test.name)
-				_, err := dao.ProposeBadge(env.ctx, &env.api, env.DAO, proposer.Member, test.badge)
-				assert.NilError(t, err)
-
-				// retrieve the document we just created
-				badgeDoc, err := docgraph.GetLastDocumentOfEdge(env.ctx, &env.api, env.DAO, eos.Name("proposal"))
+				_, badgeDoc, err := dao.ProposeBadge(env.Ctx, env.API, env.DAO, proposer.Member, test.badge)
 				assert.NilError(t, err)
 				assert.Equal(t, badgeDoc.Creator, proposer.Member)
 
@@ -111,7 +107,7 @@ func TestBadgeProposals(t *testing.T) {
 				voteToPassTD(t, env, badgeDoc)
 
 				t.Log("Member: ", closer.Member, " is closing badge proposal	: ", badgeDoc.Hash.String())
-				_, err = dao.CloseProposal(env.ctx, &env.api, env.DAO, closer.Member, badgeDoc.Hash)
+				_, err = dao.CloseProposal(env.Ctx, env.API, env.DAO, closer.Member, badgeDoc.Hash)
 				assert.NilError(t, err)
 
 				// verify that the edges are created correctly
@@ -122,16 +118,13 @@ func TestBadgeProposals(t *testing.T) {
 				t.Log("Member: ", proposer.Member, " is submitting badge assignment proposal for	: "+string(assignee.Member)+"; badge: "+badgeDoc.Hash.String())
 				pause(t, env.ChainResponsePause, "", "")
 
-				_, err = dao.ProposeBadgeAssignment(env.ctx, &env.api, env.DAO, proposer.Member, assignee.Member, badgeDoc.Hash, env.Periods[0].Hash, test.badge_assignment)
-				assert.NilError(t, err)
-
-				badgeAssignmentDoc, err := docgraph.GetLastDocumentOfEdge(env.ctx, &env.api, env.DAO, eos.Name("proposal"))
+				_, badgeAssignmentDoc, err := dao.ProposeBadgeAssignment(env.Ctx, env.API, env.DAO, proposer.Member, assignee.Member, badgeDoc.Hash, env.Periods[0].Hash, test.badge_assignment)
 				assert.NilError(t, err)
 
 				voteToPassTD(t, env, badgeAssignmentDoc)
 
 				t.Log("Member: ", closer.Member, " is closing badge assignment proposal	: ", badgeAssignmentDoc.Hash.String())
-				_, err = dao.CloseProposal(env.ctx, &env.api, env.DAO, closer.Member, badgeAssignmentDoc.Hash)
+				_, err = dao.CloseProposal(env.Ctx, env.API, env.DAO, closer.Member, badgeAssignmentDoc.Hash)
 				assert.NilError(t, err)
 
 				// verify that the edges are created correctly
@@ -156,8 +149,8 @@ func TestBadgeProposals(t *testing.T) {
 				assert.NilError(t, err)
 
 				// last balances are greater than the prior balances
-				payments = append(payments, CalcLastPayment(t, env, balances[len(balances)-1], assignee.Member))
-				balances = append(balances, GetBalance(t, env, assignee.Member))
+				payments = append(payments, env.LastPayment(balances[len(balances)-1], assignee.Member))
+				balances = append(balances, env.Balance(assignee.Member))
 
 				// balances are greater than before
 				assert.Assert(t, balances[len(balances)-1].Hypha.Amount > balances[len(balances)-2].Hypha.Amount)
@@ -202,7 +195,7 @@ func TestBadgeProposals(t *testing.T) {
 // 	var assignment, role dao.V1Object
 
 // 	// balances at the start are always zero
-// 	balances = append(balances, NewBalance())
+// 	balances = append(balances, daotest.NewBalance())
 
 // 	t.Log(env.String())
 // 	t.Log("\nDAO Environment Setup complete\n")
@@ -220,8 +213,8 @@ func TestBadgeProposals(t *testing.T) {
 // 	assert.NilError(t, err)
 // 	// first payment is a partial payment, so should be less than the amount on the assignment record
 // 	// SEEDS escrow payment should be greater than zero
-// 	payments = append(payments, CalcLastPayment(t, env, balances[len(balances)-1], assignee.Member))
-// 	balances = append(balances, GetBalance(t, env, assignee.Member))
+// 	payments = append(payments, env.LastPayment(balances[len(balances)-1], assignee.Member))
+// 	balances = append(balances, env.Balance(assignee.Member))
 // 	assert.Assert(t, assignment.Assets["hypha_salary_per_phase"].Amount >= payments[len(payments)-1].Hypha.Amount)
 // 	assert.Assert(t, assignment.Assets["husd_salary_per_phase"].Amount >= payments[len(payments)-1].Husd.Amount)
 // 	// assert.Assert(t, assignment.Assets["hvoice_salary_per_phase"].Amount >= payments[len(payments)-1].Hvoice.Amount)
@@ -234,8 +227,8 @@ func TestBadgeProposals(t *testing.T) {
 // 	assert.NilError(t, err)
 // 	// 2nd payment should be equal to the payment on the assignment record
 // 	// 2nd SEEDS escrow payment should be greater than the first one
-// 	payments = append(payments, CalcLastPayment(t, env, balances[len(balances)-1], assignee.Member))
-// 	balances = append(balances, GetBalance(t, env, assignee.Member))
+// 	payments = append(payments, env.LastPayment(balances[len(balances)-1], assignee.Member))
+// 	balances = append(balances, env.Balance(assignee.Member))
 // 	assert.Equal(t, assignment.Assets["hypha_salary_per_phase"].Amount, payments[len(payments)-1].Hypha.Amount)
 // 	assert.Equal(t, assignment.Assets["husd_salary_per_phase"].Amount, payments[len(payments)-1].Husd.Amount)
 // 	// assert.Equal(t, assignment.Assets["hvoice_salary_per_phase"].Amount, payments[len(payments)-1].Hvoice.Amount)
@@ -263,11 +256,11 @@ func TestBadgeProposals(t *testing.T) {
 // 			t.Run(test.name, func(t *testing.T) {
 
 // 				t.Log("\n\nStarting test: ", test.name)
-// 				_, err := dao.ProposeBadge(env.ctx, &env.api, env.DAO, proposer.Member, test.badge)
+// 				_, err := dao.ProposeBadge(env.Ctx, env.API, env.DAO, proposer.Member, test.badge)
 // 				assert.NilError(t, err)
 
 // 				// retrieve the document we just created
-// 				badgeDoc, err := docgraph.GetLastDocumentOfEdge(env.ctx, &env.api, env.DAO, eos.Name("propopsal"))
+// 				badgeDoc, err := docgraph.GetLastDocumentOfEdge(env.Ctx, env.API, env.DAO, eos.Name("propopsal"))
 // 				assert.NilError(t, err)
 // 				assert.Equal(t, badgeDoc.Creator, proposer.Member)
 
@@ -287,7 +280,7 @@ func TestBadgeProposals(t *testing.T) {
 // 				voteToPassTD(t, env, badgeDoc)
 
 // 				t.Log("Member: ", closer.Member, " is closing badge proposal	: ", badgeDoc.Hash.String())
-// 				_, err = dao.CloseProposal(env.ctx, &env.api, env.DAO, closer.Member, badgeDoc.Hash)
+// 				_, err = dao.CloseProposal(env.Ctx, env.API, env.DAO, closer.Member, badgeDoc.Hash)
 // 				assert.NilError(t, err)
 
 // 				// verify that the edges are created correctly
@@ -298,16 +291,16 @@ func TestBadgeProposals(t *testing.T) {
 // 				t.Log("Member: ", proposer.Member, " is submitting badge assignment proposal for	: "+string(assignee.Member)+"; badge: "+badgeDoc.Hash.String())
 // 				pause(t, env.ChainResponsePause, "", "")
 
-// 				_, err = dao.ProposeBadgeAssignment(env.ctx, &env.api, env.DAO, proposer.Member, assignee.Member, badgeDoc.Hash, test.badge_assignment)
+// 				_, err = dao.ProposeBadgeAssignment(env.Ctx, env.API, env.DAO, proposer.Member, assignee.Member, badgeDoc.Hash, test.badge_assignment)
 // 				assert.NilError(t, err)
 
-// 				badgeAssignmentDoc, err := docgraph.GetLastDocumentOfEdge(env.ctx, &env.api, env.DAO, eos.Name("propopsal"))
+// 				badgeAssignmentDoc, err := docgraph.GetLastDocumentOfEdge(env.Ctx, env.API, env.DAO, eos.Name("propopsal"))
 // 				assert.NilError(t, err)
 
 // 				voteToPassTD(t, env, badgeAssignmentDoc)
 
 // 				t.Log("Member: ", closer.Member, " is closing badge assignment proposal	: ", badgeAssignmentDoc.Hash.String())
-// 				_, err = dao.CloseProposal(env.ctx, &env.api, env.DAO, closer.Member, badgeAssignmentDoc.Hash)
+// 				_, err = dao.CloseProposal(env.Ctx, env.API, env.DAO, closer.Member, badgeAssignmentDoc.Hash)
 // 				assert.NilError(t, err)
 
 // 				// verify that the edges are created correctly
@@ -329,8 +322,8 @@ func TestBadgeProposals(t *testing.T) {
 // 				_, err = ClaimNextPeriod(t, env, assignee.Member, assignment)
 // 				assert.NilError(t, err)
 // 				// last balances are greater than the prior balances
-// 				payments = append(payments, CalcLastPayment(t, env, balances[len(balances)-1], assignee.Member))
-// 				balances = append(balances, GetBalance(t, env, assignee.Member))
+// 				payments = append(payments, env.LastPayment(balances[len(balances)-1], assignee.Member))
+// 				balances = append(balances, env.Balance(assignee.Member))
 
 // 				// balances are greater than before
 // 				assert.Assert(t, balances[len(balances)-1].Hypha.Amount > balances[len(balances)-2].Hypha.Amount)
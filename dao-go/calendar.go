@@ -0,0 +1,76 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/eoscanada/eos-go"
+)
+
+// Calendar returns the periods of source in order, from the root's start edge along the next edges;
+// the last period has no end time
+func Calendar(ctx context.Context, source QuerySource) ([]PeriodSpan, error) {
+
+	root, err := source.Root(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load root %v", err)
+	}
+
+	edges, err := source.EdgesFrom(ctx, root.Hash.String(), eos.Name("start"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read start edge %v", err)
+	}
+
+	var calendar []PeriodSpan
+	seen := make(map[string]bool)
+	for len(edges) > 0 {
+		hash := edges[0].ToNode.String()
+		if seen[hash] {
+			return calendar, fmt.Errorf("calendar loops back to period %v", hash)
+		}
+		seen[hash] = true
+
+		period, err := source.Document(ctx, hash)
+		if err != nil {
+			return calendar, fmt.Errorf("cannot load period %v: %v", hash, err)
+		}
+
+		startTime, err := getTimePoint(period, "start_time")
+		if err != nil {
+			return calendar, err
+		}
+
+		if len(calendar) > 0 {
+			calendar[len(calendar)-1].EndTime = ToTime(startTime)
+		}
+		calendar = append(calendar, PeriodSpan{Document: period, StartTime: ToTime(startTime)})
+
+		edges, err = source.EdgesFrom(ctx, hash, eos.Name("next"))
+		if err != nil {
+			return calendar, fmt.Errorf("cannot read next edge of period %v: %v", hash, err)
+		}
+	}
+	return calendar, nil
+}
+
+// LoadCalendar returns the periods of contract in order
+func LoadCalendar(ctx context.Context, api *eos.API, contract eos.AccountName) ([]PeriodSpan, error) {
+	return Calendar(ctx, NewEndpointSource(api, contract))
+}
+
+// CurrentPeriod returns the period of calendar that contains at; the last period only ends when
+// another is added, so at must be before its start to be in the calendar
+func CurrentPeriod(calendar []PeriodSpan, at time.Time) (PeriodSpan, error) {
+
+	for _, period := range calendar {
+		if !at.Before(period.StartTime) && at.Before(period.EndTime) {
+			return period, nil
+		}
+	}
+
+	if len(calendar) == 0 {
+		return PeriodSpan{}, fmt.Errorf("the calendar has no periods")
+	}
+	return PeriodSpan{}, fmt.Errorf("%v is outside of the calendar, from %v to %v", at.UTC(), calendar[0].StartTime, calendar[len(calendar)-1].StartTime)
+}
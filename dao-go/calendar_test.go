@@ -0,0 +1,48 @@
+package dao_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hypha-dao/dao-contracts/dao-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+	"gotest.tools/assert"
+)
+
+func TestCalendar(t *testing.T) {
+
+	start := time.Date(2021, 1, 4, 0, 0, 0, 0, time.UTC)
+	period := func(hexChar string, label string, week int) docgraph.Document {
+		return withDetails(testDocument(t, hexChar, "period", label), "start_time", 4, dao.ToTimePoint(start.AddDate(0, 0, 7*week)))
+	}
+
+	root := testDocument(t, "1", "dho", "Hypha DHO Root")
+	q1, q2, q3 := period("2", "Q1", 0), period("3", "Q2", 1), period("4", "Q3", 2)
+	graph := &dao.Graph{
+		Documents: []docgraph.Document{root, q3, q1, q2},
+		Edges: []docgraph.Edge{
+			testEdge(root, q1, "start"),
+			testEdge(q1, q2, "next"),
+			testEdge(q2, q3, "next"),
+		},
+	}
+
+	calendar, err := dao.Calendar(context.Background(), graph.Source())
+	assert.NilError(t, err)
+	assert.Equal(t, len(calendar), 3)
+	assert.Equal(t, calendar[0].Document.Hash.String(), q1.Hash.String())
+	assert.Equal(t, calendar[1].EndTime, start.AddDate(0, 0, 14))
+	assert.Assert(t, calendar[2].EndTime.IsZero())
+
+	current, err := dao.CurrentPeriod(calendar, start.AddDate(0, 0, 8))
+	assert.NilError(t, err)
+	assert.Equal(t, current.Document.Hash.String(), q2.Hash.String())
+
+	_, err = dao.CurrentPeriod(calendar, start.AddDate(0, 0, 15))
+	assert.ErrorContains(t, err, "outside of the calendar")
+
+	graph.Edges = append(graph.Edges, testEdge(q3, q1, "next"))
+	_, err = dao.Calendar(context.Background(), graph.Source())
+	assert.ErrorContains(t, err, "loops back")
+}
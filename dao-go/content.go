@@ -0,0 +1,109 @@
+package dao
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+)
+
+// ToTime converts an EOSIO time_point (microseconds since epoch) to a time.Time
+func ToTime(tp eos.TimePoint) time.Time {
+	return time.Unix(0, int64(tp)*1000).UTC()
+}
+
+// ToTimePoint converts a time.Time to an EOSIO time_point
+func ToTimePoint(t time.Time) eos.TimePoint {
+	return eos.TimePoint(t.UnixNano() / 1000)
+}
+
+func getInt64(doc docgraph.Document, label string) (int64, error) {
+	fv, err := doc.GetContent(label)
+	if err != nil {
+		return 0, fmt.Errorf("cannot find %v on document %v: %v", label, doc.Hash.String(), err)
+	}
+
+	switch v := fv.Impl.(type) {
+	case int64:
+		return v, nil
+	case eos.Int64:
+		return int64(v), nil
+	}
+	return 0, fmt.Errorf("%v on document %v is not an int64: %v", label, doc.Hash.String(), fv.String())
+}
+
+func getName(doc docgraph.Document, label string) (eos.Name, error) {
+	fv, err := doc.GetContent(label)
+	if err != nil {
+		return eos.Name(""), fmt.Errorf("cannot find %v on document %v: %v", label, doc.Hash.String(), err)
+	}
+
+	switch v := fv.Impl.(type) {
+	case eos.Name:
+		return v, nil
+	case eos.AccountName:
+		return eos.Name(v), nil
+	}
+	return eos.Name(""), fmt.Errorf("%v on document %v is not a name: %v", label, doc.Hash.String(), fv.String())
+}
+
+func getTimePoint(doc docgraph.Document, label string) (eos.TimePoint, error) {
+	fv, err := doc.GetContent(label)
+	if err != nil {
+		return 0, fmt.Errorf("cannot find %v on document %v: %v", label, doc.Hash.String(), err)
+	}
+
+	if v, ok := fv.Impl.(eos.TimePoint); ok {
+		return v, nil
+	}
+	return 0, fmt.Errorf("%v on document %v is not a time_point: %v", label, doc.Hash.String(), fv.String())
+}
+
+func getAsset(doc docgraph.Document, label string) (eos.Asset, error) {
+	fv, err := doc.GetContent(label)
+	if err != nil {
+		return eos.Asset{}, fmt.Errorf("cannot find %v on document %v: %v", label, doc.Hash.String(), err)
+	}
+
+	switch v := fv.Impl.(type) {
+	case *eos.Asset:
+		return *v, nil
+	case eos.Asset:
+		return v, nil
+	}
+	return eos.Asset{}, fmt.Errorf("%v on document %v is not an asset: %v", label, doc.Hash.String(), fv.String())
+}
+
+func getChecksum(doc docgraph.Document, label string) (eos.Checksum256, error) {
+	fv, err := doc.GetContent(label)
+	if err != nil {
+		return eos.Checksum256{}, fmt.Errorf("cannot find %v on document %v: %v", label, doc.Hash.String(), err)
+	}
+
+	if v, ok := fv.Impl.(eos.Checksum256); ok {
+		return v, nil
+	}
+
+	hash, err := hex.DecodeString(fv.String())
+	if err != nil {
+		return eos.Checksum256{}, fmt.Errorf("%v on document %v is not a checksum256: %v", label, doc.Hash.String(), fv.String())
+	}
+	return eos.Checksum256(hash), nil
+}
+
+// getEdgeTarget loads the document at the end of the only edge with this name leaving doc
+func getEdgeTarget(ctx context.Context, api *eos.API, contract eos.AccountName, doc docgraph.Document, edgeName eos.Name) (docgraph.Document, error) {
+	edges, err := docgraph.GetEdgesFromDocumentWithEdge(ctx, api, contract, doc, edgeName)
+	if err != nil {
+		return docgraph.Document{}, fmt.Errorf("cannot retrieve %v edge from %v: %v", edgeName, doc.Hash.String(), err)
+	}
+
+	if len(edges) == 0 {
+		return docgraph.Document{}, fmt.Errorf("document %v has no %v edge", doc.Hash.String(), edgeName)
+	}
+
+	return docgraph.LoadDocument(ctx, api, contract, edges[0].ToNode.String())
+}
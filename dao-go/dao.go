@@ -1,6 +1,7 @@
 package dao
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"strconv"
@@ -14,6 +15,16 @@ import (
 // SetSetting sets a single attribute on the configuration
 func SetSetting(ctx context.Context, api *eos.API, contract eos.AccountName, configAtt string, flexValue *docgraph.FlexValue) (string, error) {
 
+	action, err := setSettingAction(ctx, api, contract, configAtt, flexValue)
+	if err != nil {
+		return "error", err
+	}
+	return eostest.ExecTrx(ctx, api, []*eos.Action{action})
+}
+
+// setSettingAction returns the setsetting action of a single attribute, packed with the ABI of contract
+func setSettingAction(ctx context.Context, api *eos.API, contract eos.AccountName, configAtt string, flexValue *docgraph.FlexValue) (*eos.Action, error) {
+
 	action := eos.ActN("setsetting")
 	actionData := make(map[string]interface{})
 	actionData["key"] = configAtt
@@ -21,19 +32,17 @@ func SetSetting(ctx context.Context, api *eos.API, contract eos.AccountName, con
 
 	actionBinary, err := api.ABIJSONToBin(ctx, contract, eos.Name(action), actionData)
 	if err != nil {
-		return "error", fmt.Errorf("cannot pack action data %v: %v", configAtt, err)
+		return nil, fmt.Errorf("cannot pack action data %v: %v", configAtt, err)
 	}
 
-	actions := []*eos.Action{{
+	return &eos.Action{
 		Account: contract,
 		Name:    action,
 		Authorization: []eos.PermissionLevel{
 			{Actor: contract, Permission: eos.PN("active")},
 		},
 		ActionData: eos.NewActionDataFromHexData([]byte(actionBinary)),
-	}}
-
-	return eostest.ExecTrx(ctx, api, actions)
+	}, nil
 }
 
 // RemSetting ...
@@ -99,6 +108,8 @@ func AddPeriods(ctx context.Context, api *eos.API, daoContract eos.AccountName,
 
 	for i := 0; i < numPeriods; i++ {
 		startTime = eos.TimePoint((marker.UnixNano() / 1000) + 1)
+		label := "period #" + strconv.Itoa(i+1) + " of " + strconv.Itoa(numPeriods)
+		periodHash := PeriodHash(startTime, label)
 		addPeriodAction := eos.Action{
 			Account: daoContract,
 			Name:    eos.ActN("addperiod"),
@@ -108,19 +119,30 @@ func AddPeriods(ctx context.Context, api *eos.API, daoContract eos.AccountName,
 			ActionData: eos.NewActionData(addPeriod{
 				Predecessor: predecessor,
 				StartTime:   startTime,
-				Label:       "period #" + strconv.Itoa(i+1) + " of " + strconv.Itoa(numPeriods),
+				Label:       label,
 			}),
 		}
 
 		startTime = eos.TimePoint(marker.Add(periodDuration).UnixNano() / 1000)
 		marker = marker.Add(periodDuration).Add(time.Millisecond)
 
-		_, err := eostest.ExecTrx(ctx, api, []*eos.Action{&addPeriodAction})
+		result, err := Submit(ctx, api, daoContract, []*eos.Action{&addPeriodAction})
 		if err != nil {
 			return periods, fmt.Errorf("cannot add period: %v", err)
 		}
 
-		periods[i], _ = docgraph.GetLastDocument(ctx, api, daoContract)
+		if result.Deltas {
+			periods[i], err = createdDocument(result, "period")
+		} else if periods[i], err = LoadHashed(ctx, api, daoContract, periodHash); err != nil {
+			// without table deltas the period is read by its hash, set by its start time and label
+			err = &ResultError{TrxID: result.ID, Err: err}
+		}
+		if err != nil {
+			return periods, fmt.Errorf("cannot read added period: %v", err)
+		}
+		if !bytes.Equal(periods[i].Hash, periodHash) {
+			return periods, fmt.Errorf("added period %v instead of the expected %v", periods[i].Hash, periodHash)
+		}
 		predecessor = periods[i].Hash
 		time.Sleep(defaultPause())
 		bar.Add(1)
@@ -188,19 +210,7 @@ type enrollParm struct {
 
 // Enroll an applicant in the DAO
 func Enroll(ctx context.Context, api *eos.API, contract eos.AccountName, enroller, applicant eos.AccountName) (string, error) {
-	actions := []*eos.Action{{
-		Account: contract,
-		Name:    eos.ActN("enroll"),
-		Authorization: []eos.PermissionLevel{
-			{Actor: enroller, Permission: eos.PN("active")},
-		},
-		ActionData: eos.NewActionData(enrollParm{
-			Enroller:  enroller,
-			Applicant: applicant,
-			Content:   string("enroll in dao"),
-		}),
-	}}
-	return eostest.ExecTrx(ctx, api, actions)
+	return eostest.ExecTrx(ctx, api, []*eos.Action{enrollAction(contract, enroller, applicant)})
 }
 
 // CreateRoot creates the root node
@@ -246,6 +256,11 @@ func ClaimPay(ctx context.Context, api *eos.API, contract, claimer eos.AccountNa
 	return eostest.ExecTrx(ctx, api, actions)
 }
 
+// ClaimNextPeriod claims the next unclaimed, completed period of pay for an assignment
+func ClaimNextPeriod(ctx context.Context, api *eos.API, contract, claimer eos.AccountName, assignmentHash eos.Checksum256) (string, error) {
+	return eostest.ExecTrx(ctx, api, []*eos.Action{claimNextAction(contract, claimer, assignmentHash)})
+}
+
 // type AssignmentPay struct {
 // 	ID           uint64             `json:"ass_payment_id"`
 // 	AssignmentID uint64             `json:"assignment_id"`
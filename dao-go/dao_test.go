@@ -6,13 +6,15 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hypha-dao/dao-contracts/dao-go"
+	"github.com/hypha-dao/dao-contracts/dao-go/daotest"
 	"gotest.tools/assert"
 )
 
-var env *Environment
+var env *daotest.Environment
 
-var payments []Balance
-var balances []Balance
+var payments []daotest.Balance
+var balances []daotest.Balance
 
 // func TestMain(m *testing.M) {
 // 	log.SetOutput(ansi.NewAnsiStdout())
@@ -50,7 +52,7 @@ func setupTestCase(t *testing.T) func(t *testing.T) {
 
 		folderName := "test_results"
 		t.Log("Saving graph to : ", folderName)
-		err := SaveGraph(env.ctx, &env.api, env.DAO, folderName)
+		err := dao.SnapshotGraph(env.Ctx, env.API, env.DAO, folderName+"/graph.json")
 		assert.NilError(t, err)
 	}
 }
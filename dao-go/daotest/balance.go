@@ -0,0 +1,61 @@
+package daotest
+
+import (
+	"time"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/dao-contracts/dao-go"
+)
+
+// Balance is what an account holds of the tokens the DAO pays in at a point in time
+type Balance struct {
+	SnapshotTime time.Time
+	Hypha        eos.Asset
+	Hvoice       eos.Asset
+	SeedsEscrow  eos.Asset
+	Husd         eos.Asset
+}
+
+func (b *Balance) String() string {
+	s := "\n"
+	s += "Time: 		" + b.SnapshotTime.String() + "\n"
+	s += "Hypha: 		" + b.Hypha.String() + "\n"
+	s += "Husd: 		" + b.Husd.String() + "\n"
+	s += "SeedsEscrow:	" + b.SeedsEscrow.String() + "\n"
+	s += "Hvoice:		" + b.Hvoice.String() + "\n"
+	return s
+}
+
+// NewBalance returns an empty balance taken now
+func NewBalance() Balance {
+	return Balance{
+		SnapshotTime: time.Now(),
+		Hypha:        asset("0.00 HYPHA"),
+		Hvoice:       asset("0.00 HVOICE"),
+		Husd:         asset("0.00 HUSD"),
+		SeedsEscrow:  asset("0.0000 SEEDS"),
+	}
+}
+
+// Balance reads the balance of account
+func (e *Environment) Balance(account eos.AccountName) Balance {
+	return Balance{
+		SnapshotTime: time.Now(),
+		Hypha:        dao.GetBalance(e.Ctx, e.API, string(e.HyphaToken), string(account)),
+		Husd:         dao.GetBalance(e.Ctx, e.API, string(e.HusdToken), string(account)),
+		Hvoice:       dao.GetVotingPower(e.Ctx, e.API, e.TelosDecide, account),
+		SeedsEscrow:  dao.GetEscrowBalance(e.Ctx, e.API, string(e.SeedsEscrow), string(account)),
+	}
+}
+
+// LastPayment returns what account received since its previous balance
+func (e *Environment) LastPayment(previous Balance, account eos.AccountName) Balance {
+	current := e.Balance(account)
+	return Balance{
+		SnapshotTime: time.Now(),
+		Hypha:        current.Hypha.Sub(previous.Hypha),
+		SeedsEscrow:  current.SeedsEscrow.Sub(previous.SeedsEscrow),
+		Husd:         current.Husd.Sub(previous.Husd),
+		Hvoice:       current.Hvoice.Sub(previous.Hvoice),
+	}
+}
@@ -0,0 +1,659 @@
+// Package daotest sets up a DAO with its tokens, treasury, Telos Decide and members on a local
+// nodeos, for the integration tests of this module and of the projects that build on it
+package daotest
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/alexeyco/simpletable"
+	eostest "github.com/digital-scarcity/eos-go-test"
+	"github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/ecc"
+	"github.com/eoscanada/eos-go/system"
+	"github.com/hypha-dao/dao-contracts/dao-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+)
+
+// Accounts are the accounts the contracts of the environment are deployed to
+type Accounts struct {
+	DAO           eos.AccountName
+	Bank          eos.AccountName
+	HusdToken     eos.AccountName
+	HyphaToken    eos.AccountName
+	HvoiceToken   eos.AccountName
+	SeedsToken    eos.AccountName
+	SeedsEscrow   eos.AccountName
+	SeedsExchange eos.AccountName
+	Events        eos.AccountName
+	TelosDecide   eos.AccountName
+	TlosToken     eos.AccountName
+}
+
+// MemberOptions is an account to enroll as a member, with the HVOICE minted to it
+type MemberOptions struct {
+	Account string
+	Hvoice  eos.Asset
+}
+
+// Options configures the environment Setup creates
+type Options struct {
+	Endpoint string
+	Key      string
+
+	// DAOArtifacts is the path of dao.wasm and dao.abi without the extension, e.g. build/dao/dao.
+	DAOArtifacts string
+	// Artifacts holds the decide, escrow, monitor, token and treasury contracts, each in its own folder
+	Artifacts string
+	// ExchangeArtifacts is the path of the SEEDS exchange mock without the extension
+	ExchangeArtifacts string
+
+	Accounts Accounts
+
+	HusdSupply   eos.Asset
+	HyphaSupply  eos.Asset
+	HvoiceSupply eos.Asset
+	SeedsSupply  eos.Asset
+	TlosSupply   eos.Asset
+
+	VotingDurationSeconds int64
+	HyphaDeferralFactor   int64
+	SeedsDeferralFactor   int64
+
+	// NumPeriods periods of PeriodDuration are added; none when zero
+	NumPeriods     int
+	PeriodDuration time.Duration
+
+	ChainResponsePause time.Duration
+
+	// Whale holds most of the voting power; no whale is enrolled when its account is empty
+	Whale   MemberOptions
+	Members []MemberOptions
+
+	// SeedsConfig and SeedsPrices are loaded into the SEEDS exchange mock
+	SeedsConfig dao.SeedsExchConfigTable
+	SeedsPrices []dao.SeedsPriceHistory
+}
+
+func asset(amount string) eos.Asset {
+	a, _ := eos.NewAssetFromString(amount)
+	return a
+}
+
+// DefaultOptions returns the options of a local nodeos listening on port 8888, with a whale and four
+// members; the artifact locations have no default and must be set
+func DefaultOptions() Options {
+
+	options := Options{
+		Endpoint: "http://localhost:8888",
+		Key:      eostest.DefaultKey(),
+		Accounts: Accounts{
+			DAO:           "dao.hypha",
+			Bank:          "bank.hypha",
+			HusdToken:     "husd.hypha",
+			HyphaToken:    "token.hypha",
+			HvoiceToken:   "hvoice.hypha",
+			SeedsToken:    "token.seeds",
+			SeedsEscrow:   "escrow.seeds",
+			SeedsExchange: "tlosto.seeds",
+			Events:        "publsh.hypha",
+			TelosDecide:   "telos.decide",
+			TlosToken:     "eosio.token",
+		},
+		HusdSupply:            asset("1000000000.00 HUSD"),
+		HyphaSupply:           asset("1000000000.00 HYPHA"),
+		HvoiceSupply:          asset("1000000000.00 HVOICE"),
+		SeedsSupply:           asset("1000000000.0000 SEEDS"),
+		TlosSupply:            asset("1000000000.0000 TLOS"),
+		VotingDurationSeconds: 2,
+		HyphaDeferralFactor:   25,
+		SeedsDeferralFactor:   100,
+		NumPeriods:            20,
+		PeriodDuration:        15 * time.Second,
+		ChainResponsePause:    time.Second,
+		Whale:                 MemberOptions{Account: "alice", Hvoice: asset("100.00 HVOICE")},
+		SeedsConfig: dao.SeedsExchConfigTable{
+			SeedsPerUsd:   asset("100.0000 SEEDS"),
+			TlosPerUsd:    asset("3.0000 TLOS"),
+			CitizenLimit:  asset("25000.0000 USD"),
+			ResidentLimit: asset("10000.0000 USD"),
+			VisitorLimit:  asset("1000.0000 USD"),
+		},
+		SeedsPrices: []dao.SeedsPriceHistory{{SeedsUSD: asset("0.0100 USD"), Date: dao.ToTimePoint(time.Now())}},
+	}
+	for i := 1; i < 5; i++ {
+		options.Members = append(options.Members, MemberOptions{Account: "mem" + strconv.Itoa(i) + ".hypha", Hvoice: asset("1.00 HVOICE")})
+	}
+	return options
+}
+
+// Member is an enrolled member and its member document
+type Member struct {
+	Member eos.AccountName
+	Doc    docgraph.Document
+}
+
+// Environment is a DAO set up on a chain, with the contracts it uses, its periods and its members
+type Environment struct {
+	Ctx context.Context
+	API *eos.API
+	key string
+
+	Accounts
+	Root docgraph.Document
+
+	VotingDurationSeconds int64
+	HyphaDeferralFactor   int64
+	SeedsDeferralFactor   int64
+
+	NumPeriods     int
+	PeriodDuration time.Duration
+
+	PeriodPause        time.Duration
+	VotingPause        time.Duration
+	ChainResponsePause time.Duration
+
+	Whale   Member
+	Members []Member
+	Periods []docgraph.Document
+}
+
+// String lists the accounts and settings of the environment as a table
+func (e *Environment) String() string {
+	table := simpletable.New()
+	table.Header = &simpletable.Header{
+		Cells: []*simpletable.Cell{
+			{Align: simpletable.AlignCenter, Text: "Variable"},
+			{Align: simpletable.AlignCenter, Text: "Value"},
+		},
+	}
+
+	kvs := [][]string{
+		{"DAO", string(e.DAO)},
+		{"HUSD Token", string(e.HusdToken)},
+		{"HVOICE Token", string(e.HvoiceToken)},
+		{"HYPHA Token", string(e.HyphaToken)},
+		{"SEEDS Token", string(e.SeedsToken)},
+		{"Bank", string(e.Bank)},
+		{"Escrow", string(e.SeedsEscrow)},
+		{"Exchange", string(e.SeedsExchange)},
+		{"Telos Decide", string(e.TelosDecide)},
+		{"Whale", string(e.Whale.Member)},
+		{"Voting Duration (s)", strconv.Itoa(int(e.VotingDurationSeconds))},
+		{"HYPHA deferral X", strconv.Itoa(int(e.HyphaDeferralFactor))},
+		{"SEEDS deferral X", strconv.Itoa(int(e.SeedsDeferralFactor))},
+	}
+	for _, kv := range kvs {
+		table.Body.Cells = append(table.Body.Cells, []*simpletable.Cell{
+			{Align: simpletable.AlignLeft, Text: kv[0]},
+			{Align: simpletable.AlignRight, Text: kv[1]},
+		})
+	}
+	return table.String()
+}
+
+// Voters returns the whale, if any, followed by the other members
+func (e *Environment) Voters() []Member {
+	if e.Whale.Member == "" {
+		return e.Members
+	}
+	return append([]Member{e.Whale}, e.Members...)
+}
+
+// Logger receives the progress of Setup; any testing.TB satisfies it
+type Logger interface {
+	Log(args ...interface{})
+}
+
+// LogFunc adapts a function such as log.Println to a Logger, for callers outside of tests
+type LogFunc func(args ...interface{})
+
+// Log calls f with args
+func (f LogFunc) Log(args ...interface{}) {
+	f(args...)
+}
+
+// Setup creates the accounts of options on a fresh chain, deploys and configures the contracts, and
+// adds the periods and members of options, reporting its progress to logger
+func Setup(logger Logger, options Options) (*Environment, error) {
+
+	if options.DAOArtifacts == "" || options.Artifacts == "" || options.ExchangeArtifacts == "" {
+		return nil, fmt.Errorf("the locations of the DAO, contract and exchange artifacts are required")
+	}
+
+	env := Environment{
+		Ctx:                   context.Background(),
+		API:                   eos.New(options.Endpoint),
+		key:                   options.Key,
+		Accounts:              options.Accounts,
+		VotingDurationSeconds: options.VotingDurationSeconds,
+		HyphaDeferralFactor:   options.HyphaDeferralFactor,
+		SeedsDeferralFactor:   options.SeedsDeferralFactor,
+		NumPeriods:            options.NumPeriods,
+		PeriodDuration:        options.PeriodDuration,
+		ChainResponsePause:    options.ChainResponsePause,
+	}
+	env.VotingPause = time.Duration(env.VotingDurationSeconds)*time.Second + time.Second + env.ChainResponsePause
+	env.PeriodPause = env.PeriodDuration + time.Second
+
+	keyBag := &eos.KeyBag{}
+	if err := keyBag.ImportPrivateKey(env.Ctx, options.Key); err != nil {
+		return nil, fmt.Errorf("cannot import key %v", err)
+	}
+	env.API.SetSigner(keyBag)
+
+	if err := env.createAccounts(options); err != nil {
+		return nil, err
+	}
+
+	contracts := []struct {
+		account  eos.AccountName
+		artifact string
+	}{
+		{env.DAO, options.DAOArtifacts},
+		{env.Bank, options.Artifacts + "/treasury/treasury."},
+		{env.SeedsEscrow, options.Artifacts + "/escrow/escrow."},
+		{env.SeedsExchange, options.ExchangeArtifacts},
+		{env.Events, options.Artifacts + "/monitor/monitor."},
+		{env.TelosDecide, options.Artifacts + "/decide/decide."},
+	}
+	for _, contract := range contracts {
+		logger.Log("Deploying ", contract.artifact, " to 		: ", contract.account)
+		if _, err := eostest.SetContract(env.Ctx, env.API, contract.account, contract.artifact+"wasm", contract.artifact+"abi"); err != nil {
+			return nil, fmt.Errorf("cannot deploy %v to %v: %v", contract.artifact, contract.account, err)
+		}
+	}
+
+	if err := env.loadSeedsExchange(options.SeedsConfig, options.SeedsPrices); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if _, err = dao.CreateRoot(env.Ctx, env.API, env.DAO); err != nil {
+		return nil, fmt.Errorf("cannot create root %v", err)
+	}
+	if env.Root, err = dao.LoadRoot(env.Ctx, env.API, env.DAO); err != nil {
+		return nil, fmt.Errorf("cannot load root %v", err)
+	}
+
+	tokens := []struct {
+		account   eos.AccountName
+		issuer    eos.AccountName
+		maxSupply eos.Asset
+		issue     bool
+	}{
+		{env.HusdToken, env.Bank, options.HusdSupply, false},
+		{env.HyphaToken, env.DAO, options.HyphaSupply, false},
+		{env.HvoiceToken, env.DAO, options.HvoiceSupply, false},
+		{env.SeedsToken, env.DAO, options.SeedsSupply, true},
+		{env.TlosToken, env.DAO, options.TlosSupply, true},
+	}
+	for _, token := range tokens {
+		logger.Log("Deploying token ", token.maxSupply.Symbol.Symbol, " to 		: ", token.account)
+		if _, err = eostest.SetContract(env.Ctx, env.API, token.account, options.Artifacts+"/token/token.wasm", options.Artifacts+"/token/token.abi"); err != nil {
+			return nil, fmt.Errorf("cannot deploy token to %v: %v", token.account, err)
+		}
+		if _, err = dao.CreateToken(env.Ctx, env.API, token.account, token.issuer, token.maxSupply); err != nil {
+			return nil, fmt.Errorf("cannot create %v on %v: %v", token.maxSupply.Symbol.Symbol, token.account, err)
+		}
+		if !token.issue {
+			continue
+		}
+		if _, err = dao.Issue(env.Ctx, env.API, token.account, token.issuer, token.maxSupply); err != nil {
+			return nil, fmt.Errorf("cannot issue %v %v", token.maxSupply, err)
+		}
+	}
+
+	logger.Log("Setting configuration options on DAO 		: ", env.DAO)
+	if err = env.configure(); err != nil {
+		return nil, err
+	}
+
+	if env.NumPeriods > 0 {
+		logger.Log("Adding "+strconv.Itoa(env.NumPeriods)+" periods with duration 		: ", env.PeriodDuration)
+		if env.Periods, err = dao.AddPeriods(env.Ctx, env.API, env.DAO, env.Root.Hash, env.NumPeriods, env.PeriodDuration); err != nil {
+			return nil, err
+		}
+	}
+
+	logger.Log("Configuring Telos Decide contract 		: ", env.TelosDecide)
+	if err = env.setupTelosDecide(options.TlosSupply); err != nil {
+		return nil, err
+	}
+
+	if options.Whale.Account == "" && len(options.Members) == 0 {
+		return &env, nil
+	}
+
+	if _, err = dao.Mint(env.Ctx, env.API, env.TelosDecide, env.DAO, env.DAO, asset("1.00 HVOICE")); err != nil {
+		return nil, fmt.Errorf("cannot mint HVOICE to %v: %v", env.DAO, err)
+	}
+	if options.Whale.Account != "" {
+		logger.Log("Creating and enrolling whale  		: ", options.Whale.Account, " 	with voting power	: ", options.Whale.Hvoice.String())
+		if env.Whale, err = env.SetupMember(options.Whale.Account, options.Whale.Hvoice); err != nil {
+			return nil, err
+		}
+	}
+	for _, member := range options.Members {
+		logger.Log("Creating and enrolling new member  		: ", member.Account, " 	with voting power	: ", member.Hvoice.String())
+		newMember, err := env.SetupMember(member.Account, member.Hvoice)
+		if err != nil {
+			return nil, err
+		}
+		env.Members = append(env.Members, newMember)
+	}
+	return &env, nil
+}
+
+// SetupFake sets up the DAO of options on a FakeChain instead of nodeos: no contract is deployed and
+// nothing waits, so VotingPause and PeriodPause are zero and tests call Advance on the fake chain to
+// let ballots and periods end. The fake chain must be closed when done.
+func SetupFake(options Options) (*Environment, *FakeChain, error) {
+
+	fake, err := NewFakeChain(options.Accounts, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	fake.SetSeedsPrices(options.SeedsPrices)
+
+	env := Environment{
+		Ctx:                   context.Background(),
+		API:                   fake.API(),
+		key:                   eostest.DefaultKey(),
+		Accounts:              options.Accounts,
+		VotingDurationSeconds: options.VotingDurationSeconds,
+		HyphaDeferralFactor:   options.HyphaDeferralFactor,
+		SeedsDeferralFactor:   options.SeedsDeferralFactor,
+		NumPeriods:            options.NumPeriods,
+		PeriodDuration:        options.PeriodDuration,
+	}
+
+	if err = env.setupFake(fake, options); err != nil {
+		fake.Close()
+		return nil, nil, err
+	}
+	return &env, fake, nil
+}
+
+func (e *Environment) setupFake(fake *FakeChain, options Options) error {
+
+	var err error
+	if _, err = dao.CreateRoot(e.Ctx, e.API, e.DAO); err != nil {
+		return fmt.Errorf("cannot create root %v", err)
+	}
+	if e.Root, err = dao.LoadRoot(e.Ctx, e.API, e.DAO); err != nil {
+		return fmt.Errorf("cannot load root %v", err)
+	}
+	if err = e.configure(); err != nil {
+		return err
+	}
+
+	// the periods start with the clock of the fake chain, like AddPeriods starts them with the wall clock
+	predecessor := e.Root.Hash
+	start := fake.Now()
+	for i := 0; i < e.NumPeriods; i++ {
+		startTime := dao.ToTimePoint(start.Add(time.Duration(i) * e.PeriodDuration))
+		label := "period #" + strconv.Itoa(i+1) + " of " + strconv.Itoa(e.NumPeriods)
+		_, err = dao.Submit(e.Ctx, e.API, e.DAO, []*eos.Action{{
+			Account: e.DAO,
+			Name:    eos.ActN("addperiod"),
+			Authorization: []eos.PermissionLevel{
+				{Actor: e.DAO, Permission: eos.PN("active")},
+			},
+			ActionData: eos.NewActionData(addPeriodData{
+				Predecessor: predecessor,
+				StartTime:   startTime,
+				Label:       label,
+			}),
+		}})
+		if err != nil {
+			return fmt.Errorf("cannot add period: %v", err)
+		}
+
+		period, err := dao.LoadHashed(e.Ctx, e.API, e.DAO, dao.PeriodHash(startTime, label))
+		if err != nil {
+			return fmt.Errorf("cannot read added period: %v", err)
+		}
+		e.Periods = append(e.Periods, period)
+		predecessor = period.Hash
+	}
+
+	if _, err = dao.RegVoter(e.Ctx, e.API, e.TelosDecide, e.DAO); err != nil {
+		return fmt.Errorf("cannot register %v as a voter %v", e.DAO, err)
+	}
+	if _, err = dao.Mint(e.Ctx, e.API, e.TelosDecide, e.DAO, e.DAO, asset("1.00 HVOICE")); err != nil {
+		return fmt.Errorf("cannot mint HVOICE to %v: %v", e.DAO, err)
+	}
+
+	if options.Whale.Account != "" {
+		if e.Whale, err = e.SetupMember(options.Whale.Account, options.Whale.Hvoice); err != nil {
+			return err
+		}
+	}
+	for _, member := range options.Members {
+		newMember, err := e.SetupMember(member.Account, member.Hvoice)
+		if err != nil {
+			return err
+		}
+		e.Members = append(e.Members, newMember)
+	}
+	return nil
+}
+
+// createAccounts creates the contract accounts and lets the DAO act on behalf of the bank
+func (e *Environment) createAccounts(options Options) error {
+
+	var err error
+	if e.DAO, err = eostest.CreateAccountFromString(e.Ctx, e.API, string(options.Accounts.DAO), options.Key); err != nil {
+		return fmt.Errorf("cannot create account %v: %v", options.Accounts.DAO, err)
+	}
+
+	var bankKey ecc.PublicKey
+	if bankKey, e.Bank, err = eostest.CreateAccountWithRandomKey(e.Ctx, e.API, string(options.Accounts.Bank)); err != nil {
+		return fmt.Errorf("cannot create account %v: %v", options.Accounts.Bank, err)
+	}
+
+	bankPermissionActions := []*eos.Action{system.NewUpdateAuth(e.Bank,
+		"active",
+		"owner",
+		eos.Authority{
+			Threshold: 1,
+			Keys: []eos.KeyWeight{{
+				PublicKey: bankKey,
+				Weight:    1,
+			}},
+			Accounts: []eos.PermissionLevelWeight{
+				{
+					Permission: eos.PermissionLevel{
+						Actor:      e.Bank,
+						Permission: "eosio.code",
+					},
+					Weight: 1,
+				},
+				{
+					Permission: eos.PermissionLevel{
+						Actor:      e.DAO,
+						Permission: "eosio.code",
+					},
+					Weight: 1,
+				}},
+			Waits: []eos.WaitWeight{},
+		}, "owner")}
+
+	if _, err = eostest.ExecTrx(e.Ctx, e.API, bankPermissionActions); err != nil {
+		return fmt.Errorf("cannot update the permissions of %v: %v", e.Bank, err)
+	}
+
+	accounts := []*eos.AccountName{&e.HusdToken, &e.HvoiceToken, &e.HyphaToken, &e.Events,
+		&e.SeedsToken, &e.SeedsEscrow, &e.SeedsExchange, &e.TelosDecide, &e.TlosToken}
+	for _, account := range accounts {
+		name := string(*account)
+		if _, *account, err = eostest.CreateAccountWithRandomKey(e.Ctx, e.API, name); err != nil {
+			return fmt.Errorf("cannot create account %v: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// loadSeedsExchange loads the configuration and price history of the SEEDS exchange mock
+func (e *Environment) loadSeedsExchange(config dao.SeedsExchConfigTable, prices []dao.SeedsPriceHistory) error {
+
+	exchangeAction := func(name string, data interface{}) []*eos.Action {
+		return []*eos.Action{{
+			Account: e.SeedsExchange,
+			Name:    eos.ActN(name),
+			Authorization: []eos.PermissionLevel{
+				{Actor: e.SeedsExchange, Permission: eos.PN("active")},
+			},
+			ActionData: eos.NewActionData(data)}}
+	}
+
+	if _, err := eostest.ExecTrx(e.Ctx, e.API, exchangeAction("updateconfig", config)); err != nil {
+		return fmt.Errorf("cannot configure the SEEDS exchange %v", err)
+	}
+	for _, price := range prices {
+		if _, err := eostest.ExecTrx(e.Ctx, e.API, exchangeAction("inshistory", price)); err != nil {
+			return fmt.Errorf("cannot insert SEEDS price %v", err)
+		}
+	}
+	return nil
+}
+
+// ReadSeedsExchange reads the configuration and price history of the SEEDS exchange of another chain,
+// to load them in the SEEDS exchange mock through Options
+func ReadSeedsExchange(ctx context.Context, api *eos.API, exchange eos.AccountName) (dao.SeedsExchConfigTable, []dao.SeedsPriceHistory, error) {
+
+	var config []dao.SeedsExchConfigTable
+	var prices []dao.SeedsPriceHistory
+	tables := []struct {
+		table string
+		limit uint32
+		rows  interface{}
+	}{
+		{"config", 1, &config},
+		{"pricehistory", 1000, &prices},
+	}
+	for _, table := range tables {
+		var request eos.GetTableRowsRequest
+		request.Code = string(exchange)
+		request.Scope = string(exchange)
+		request.Table = table.table
+		request.Limit = table.limit
+		request.JSON = true
+		response, err := api.GetTableRows(ctx, request)
+		if err != nil {
+			return dao.SeedsExchConfigTable{}, nil, fmt.Errorf("cannot read %v of %v: %v", table.table, exchange, err)
+		}
+		if err = response.JSONToStructs(table.rows); err != nil {
+			return dao.SeedsExchConfigTable{}, nil, fmt.Errorf("cannot read %v of %v: %v", table.table, exchange, err)
+		}
+	}
+	if len(config) == 0 {
+		return dao.SeedsExchConfigTable{}, nil, fmt.Errorf("%v has no config", exchange)
+	}
+	return config[0], prices, nil
+}
+
+// configure sets the durations, deferral factors and contracts of the DAO
+func (e *Environment) configure() error {
+
+	ints := []struct {
+		label string
+		value int64
+	}{
+		{"voting_duration_sec", e.VotingDurationSeconds},
+		{"seeds_deferral_factor_x100", e.SeedsDeferralFactor},
+		{"hypha_deferral_factor_x100", e.HyphaDeferralFactor},
+		{"paused", 0},
+	}
+	for _, setting := range ints {
+		if _, err := dao.SetIntSetting(e.Ctx, e.API, e.DAO, setting.label, setting.value); err != nil {
+			return fmt.Errorf("cannot set %v %v", setting.label, err)
+		}
+	}
+
+	names := []struct {
+		label string
+		value eos.AccountName
+	}{
+		{"hypha_token_contract", e.HyphaToken},
+		{"hvoice_token_contract", e.HvoiceToken},
+		{"husd_token_contract", e.HusdToken},
+		{"seeds_token_contract", e.SeedsToken},
+		{"seeds_escrow_contract", e.SeedsEscrow},
+		{"publisher_contract", e.Events},
+		{"treasury_contract", e.Bank},
+		{"telos_decide_contract", e.TelosDecide},
+		{"last_ballot_id", "hypha......1"},
+	}
+	for _, setting := range names {
+		if _, err := dao.SetNameSetting(e.Ctx, e.API, e.DAO, setting.label, setting.value); err != nil {
+			return fmt.Errorf("cannot set %v %v", setting.label, err)
+		}
+	}
+	return nil
+}
+
+// setupTelosDecide funds Telos Decide with the TLOS supply and creates the HVOICE treasury of the DAO
+func (e *Environment) setupTelosDecide(tlosSupply eos.Asset) error {
+
+	if _, err := dao.InitTD(e.Ctx, e.API, e.TelosDecide); err != nil {
+		return fmt.Errorf("cannot initialize Telos Decide %v", err)
+	}
+	if _, err := dao.Transfer(e.Ctx, e.API, e.TlosToken, e.DAO, e.TelosDecide, tlosSupply, "deposit"); err != nil {
+		return fmt.Errorf("cannot deposit to Telos Decide %v", err)
+	}
+	if _, err := dao.NewTreasury(e.Ctx, e.API, e.TelosDecide, e.DAO); err != nil {
+		return fmt.Errorf("cannot create the HVOICE treasury %v", err)
+	}
+	if _, err := dao.RegVoter(e.Ctx, e.API, e.TelosDecide, e.DAO); err != nil {
+		return fmt.Errorf("cannot register %v as a voter %v", e.DAO, err)
+	}
+	return nil
+}
+
+// SetupMember creates account with the key of the environment, registers it with Telos Decide, mints
+// hvoice to it, and enrolls it in the DAO
+func (e *Environment) SetupMember(account string, hvoice eos.Asset) (Member, error) {
+
+	memberAccount, err := eostest.CreateAccountFromString(e.Ctx, e.API, account, e.key)
+	if err != nil {
+		return Member{}, fmt.Errorf("cannot create account %v: %v", account, err)
+	}
+
+	steps := []struct {
+		name string
+		run  func() (string, error)
+	}{
+		{"register", func() (string, error) { return dao.RegVoter(e.Ctx, e.API, e.TelosDecide, memberAccount) }},
+		{"mint to", func() (string, error) { return dao.Mint(e.Ctx, e.API, e.TelosDecide, e.DAO, memberAccount, hvoice) }},
+		{"apply", func() (string, error) { return dao.Apply(e.Ctx, e.API, e.DAO, memberAccount, "apply to DAO") }},
+		{"enroll", func() (string, error) { return dao.Enroll(e.Ctx, e.API, e.DAO, e.DAO, memberAccount) }},
+	}
+	for _, step := range steps {
+		if _, err = step.run(); err != nil {
+			return Member{}, fmt.Errorf("cannot %v %v: %v", step.name, account, err)
+		}
+	}
+	time.Sleep(e.ChainResponsePause)
+
+	memberDoc, err := dao.LoadHashed(e.Ctx, e.API, e.DAO, dao.MemberHash(memberAccount))
+	if err != nil {
+		return Member{}, err
+	}
+	return Member{Member: memberAccount, Doc: memberDoc}, nil
+}
+
+// VoteToPass has every voter vote pass on proposal and waits for the ballot to close
+func (e *Environment) VoteToPass(proposal eos.Checksum256) error {
+
+	for _, voter := range e.Voters() {
+		if _, err := dao.ProposalVote(e.Ctx, e.API, e.DAO, voter.Member, "pass", proposal); err != nil {
+			return fmt.Errorf("cannot vote %v on %v: %v", voter.Member, proposal, err)
+		}
+	}
+	time.Sleep(e.VotingPause)
+	return nil
+}
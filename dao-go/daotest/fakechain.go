@@ -0,0 +1,626 @@
+package daotest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	eostest "github.com/digital-scarcity/eos-go-test"
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/dao-contracts/dao-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+)
+
+// blockInterval is the time between blocks; every transaction pushed to the fake chain gets its own block
+const blockInterval = 500 * time.Millisecond
+
+// blockTimeFormat is the format of block times in the responses of the chain API
+const blockTimeFormat = "2006-01-02T15:04:05.000"
+
+// FakeChain serves the part of the chain API that this module uses from memory, running the actions of
+// the DAO contract, and the Telos Decide, token and escrow actions it depends on, the way the contracts
+// do. Transactions are not verified against keys or resources, and the clock only moves when a block is
+// produced or Advance is called, so tests can cross voting and period boundaries without waiting.
+type FakeChain struct {
+	Accounts
+
+	server *httptest.Server
+	api    *eos.API
+
+	mutex    sync.Mutex
+	state    *fakeState
+	now      time.Time
+	blockNum uint32
+}
+
+type balanceKey struct {
+	token   eos.AccountName
+	account eos.AccountName
+}
+
+// fakeState is what the contracts store; a transaction runs on a copy, so it is kept only if every action succeeds
+type fakeState struct {
+	documents      []docgraph.Document
+	edges          []docgraph.Edge
+	nextDocumentID uint64
+	nextEdgeID     uint64
+	voters         map[eos.AccountName]eos.Asset
+	balances       map[balanceKey]eos.Asset
+	locks          []dao.Lock
+	seedsPrices    []dao.SeedsPriceHistory
+	legacyMembers  map[eos.AccountName]bool
+}
+
+func (s *fakeState) clone() *fakeState {
+	clone := *s
+	clone.documents = append([]docgraph.Document{}, s.documents...)
+	clone.edges = append([]docgraph.Edge{}, s.edges...)
+	clone.locks = append([]dao.Lock{}, s.locks...)
+	clone.voters = make(map[eos.AccountName]eos.Asset, len(s.voters))
+	for voter, liquid := range s.voters {
+		clone.voters[voter] = liquid
+	}
+	clone.balances = make(map[balanceKey]eos.Asset, len(s.balances))
+	for key, balance := range s.balances {
+		clone.balances[key] = balance
+	}
+	clone.legacyMembers = make(map[eos.AccountName]bool, len(s.legacyMembers))
+	for member := range s.legacyMembers {
+		clone.legacyMembers[member] = true
+	}
+	return &clone
+}
+
+// NewFakeChain starts a fake chain for the contracts of accounts, with its clock at start, and returns it
+// with an API signing with the default key; it must be closed when done
+func NewFakeChain(accounts Accounts, start time.Time) (*FakeChain, error) {
+
+	chain := &FakeChain{
+		Accounts: accounts,
+		state: &fakeState{
+			nextDocumentID: 1,
+			nextEdgeID:     1,
+			voters:         make(map[eos.AccountName]eos.Asset),
+			balances:       make(map[balanceKey]eos.Asset),
+			legacyMembers:  make(map[eos.AccountName]bool),
+		},
+		now:      start.UTC().Truncate(blockInterval),
+		blockNum: 1,
+	}
+	chain.server = httptest.NewServer(chain)
+
+	keyBag := &eos.KeyBag{}
+	if err := keyBag.ImportPrivateKey(context.Background(), eostest.DefaultKey()); err != nil {
+		chain.server.Close()
+		return nil, fmt.Errorf("cannot import key %v", err)
+	}
+	chain.api = eos.New(chain.server.URL)
+	chain.api.SetSigner(keyBag)
+	return chain, nil
+}
+
+// URL is the endpoint of the fake chain
+func (c *FakeChain) URL() string {
+	return c.server.URL
+}
+
+// API returns the API of the fake chain
+func (c *FakeChain) API() *eos.API {
+	return c.api
+}
+
+// Close stops serving the fake chain
+func (c *FakeChain) Close() {
+	c.server.Close()
+}
+
+// Now returns the time of the head block
+func (c *FakeChain) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+// Advance moves the clock of the chain forward by d, as if empty blocks were produced
+func (c *FakeChain) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d).Truncate(blockInterval)
+}
+
+// SetSeedsPrices replaces the price history of the SEEDS exchange
+func (c *FakeChain) SetSeedsPrices(prices []dao.SeedsPriceHistory) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.state.seedsPrices = append([]dao.SeedsPriceHistory{}, prices...)
+}
+
+// Graph returns a copy of the documents and edges of the DAO
+func (c *FakeChain) Graph() *dao.Graph {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return &dao.Graph{
+		Documents: append([]docgraph.Document{}, c.state.documents...),
+		Edges:     append([]docgraph.Edge{}, c.state.edges...),
+	}
+}
+
+// chainError is an error reported by the chain API, such as a failed eosio_assert
+type chainError struct {
+	code    int
+	name    string
+	what    string
+	message string
+}
+
+func (e *chainError) Error() string {
+	return e.what + ": " + e.message
+}
+
+// assertion fails the transaction like eosio::check with the message
+func assertion(format string, args ...interface{}) error {
+	return &chainError{
+		code:    3050003,
+		name:    "eosio_assert_message_exception",
+		what:    "eosio_assert_message assertion failure",
+		message: "assertion failure with message: " + fmt.Sprintf(format, args...),
+	}
+}
+
+func missingAuthority(account eos.AccountName) error {
+	return &chainError{
+		code:    3090004,
+		name:    "missing_auth_exception",
+		what:    "Missing required authority",
+		message: "missing authority of " + string(account),
+	}
+}
+
+// ServeHTTP answers the chain API requests
+func (c *FakeChain) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	handlers := map[string]func(data json.RawMessage) (interface{}, error){
+		"/v1/chain/get_info":          c.getInfo,
+		"/v1/chain/get_required_keys": c.getRequiredKeys,
+		"/v1/chain/get_table_rows":    c.getTableRows,
+		"/v1/chain/abi_json_to_bin":   c.abiJSONToBin,
+		"/v1/chain/push_transaction":  c.pushTransaction,
+	}
+
+	handler, ok := handlers[r.URL.Path]
+	if !ok {
+		writeChainError(w, http.StatusNotFound, &chainError{code: 404, name: "not_found", what: "Not Found", message: r.URL.Path + " is not served by the fake chain"})
+		return
+	}
+
+	// get_info is posted without a body
+	request, err := ioutil.ReadAll(r.Body)
+	if err != nil || (len(request) > 0 && !json.Valid(request)) {
+		writeChainError(w, http.StatusBadRequest, &chainError{code: 400, name: "bad_request", what: "Invalid request", message: "the body is not JSON"})
+		return
+	}
+
+	response, err := handler(request)
+	if err != nil {
+		failure, ok := err.(*chainError)
+		if !ok {
+			failure = &chainError{code: 3000000, name: "chain_exception", what: "blockchain exception", message: err.Error()}
+		}
+		writeChainError(w, http.StatusInternalServerError, failure)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func writeChainError(w http.ResponseWriter, status int, failure *chainError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code":    status,
+		"message": http.StatusText(status),
+		"error": map[string]interface{}{
+			"code": failure.code,
+			"name": failure.name,
+			"what": failure.what,
+			"details": []map[string]interface{}{
+				{"message": failure.message, "file": "", "line_number": 0, "method": ""},
+			},
+		},
+	})
+}
+
+// blockID starts with the block number, like the ids of blocks on chain
+func blockID(blockNum uint32) string {
+	id := sha256.Sum256([]byte("fake block " + strconv.FormatUint(uint64(blockNum), 10)))
+	binary.BigEndian.PutUint32(id[:4], blockNum)
+	return hex.EncodeToString(id[:])
+}
+
+func (c *FakeChain) getInfo(request json.RawMessage) (interface{}, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	chainID := sha256.Sum256([]byte("fake chain"))
+	return map[string]interface{}{
+		"server_version":              "fake",
+		"chain_id":                    hex.EncodeToString(chainID[:]),
+		"head_block_num":              c.blockNum,
+		"last_irreversible_block_num": c.blockNum,
+		"last_irreversible_block_id":  blockID(c.blockNum),
+		"head_block_id":               blockID(c.blockNum),
+		"head_block_time":             c.now.Format(blockTimeFormat),
+		"head_block_producer":         "eosio",
+	}, nil
+}
+
+// getRequiredKeys requires every available key, since signatures are not verified
+func (c *FakeChain) getRequiredKeys(request json.RawMessage) (interface{}, error) {
+	var keys struct {
+		AvailableKeys json.RawMessage `json:"available_keys"`
+	}
+	if err := json.Unmarshal(request, &keys); err != nil {
+		return nil, fmt.Errorf("cannot read get_required_keys request %v", err)
+	}
+	return map[string]json.RawMessage{"required_keys": keys.AvailableKeys}, nil
+}
+
+// tableKey is a key of a table index, a number for i64 and name keys, a hex string for checksums
+type tableKey struct {
+	number uint64
+	hash   string
+}
+
+func (k tableKey) less(other tableKey) bool {
+	if k.number != other.number {
+		return k.number < other.number
+	}
+	return k.hash < other.hash
+}
+
+func (k tableKey) String() string {
+	if k.hash != "" {
+		return k.hash
+	}
+	return strconv.FormatUint(k.number, 10)
+}
+
+// parseKey reads a bound of get_table_rows, an i64 bound being either a number or a name
+func parseKey(bound, keyType string) (tableKey, error) {
+	switch keyType {
+	case "sha256", "i256", "ripemd160":
+		return tableKey{hash: strings.ToLower(bound)}, nil
+	}
+
+	if number, err := strconv.ParseUint(bound, 10, 64); err == nil {
+		return tableKey{number: number}, nil
+	}
+	number, err := eos.StringToName(bound)
+	if err != nil {
+		return tableKey{}, fmt.Errorf("invalid %v bound %v: %v", keyType, bound, err)
+	}
+	return tableKey{number: number}, nil
+}
+
+func nameKey(name eos.Name) tableKey {
+	number, _ := eos.StringToName(string(name))
+	return tableKey{number: number}
+}
+
+func timeKey(created eos.TimePoint) tableKey {
+	return tableKey{number: uint64(dao.ToTime(created).Unix())}
+}
+
+// tableRow is a row with its keys by index position, 1 being the primary key
+type tableRow struct {
+	keys  map[int]tableKey
+	value interface{}
+}
+
+var indexPositions = map[string]int{
+	"": 1, "primary": 1, "secondary": 2, "tertiary": 3, "fourth": 4, "fifth": 5,
+	"sixth": 6, "seventh": 7, "eighth": 8, "ninth": 9, "tenth": 10,
+}
+
+// rows returns the rows of a table with the keys of the indexes the module reads; the other tables are empty
+func (c *FakeChain) rows(code, scope eos.AccountName, table string) []tableRow {
+
+	var rows []tableRow
+	switch {
+	case code == c.DAO && table == "documents":
+		for _, document := range c.state.documents {
+			rows = append(rows, tableRow{keys: map[int]tableKey{
+				1: {number: document.ID},
+				2: {hash: document.Hash.String()},
+				3: nameKey(eos.Name(document.Creator)),
+				4: timeKey(document.CreatedDate),
+			}, value: document})
+		}
+
+	case code == c.DAO && table == "edges":
+		for _, edge := range c.state.edges {
+			rows = append(rows, tableRow{keys: map[int]tableKey{
+				1: {number: edge.ID},
+				2: {hash: edge.FromNode.String()},
+				3: {hash: edge.ToNode.String()},
+				4: nameKey(edge.EdgeName),
+				5: timeKey(edge.CreatedDate),
+				6: nameKey(edge.Creator),
+			}, value: edge})
+		}
+
+	case code == c.TelosDecide && table == "voters":
+		if liquid, ok := c.state.voters[scope]; ok {
+			staked := liquid
+			staked.Amount = 0
+			rows = append(rows, tableRow{keys: map[int]tableKey{1: {number: 0}}, value: map[string]interface{}{
+				"liquid": liquid,
+				"staked": staked,
+			}})
+		}
+
+	case table == "accounts":
+		if balance, ok := c.state.balances[balanceKey{token: code, account: scope}]; ok {
+			rows = append(rows, tableRow{keys: map[int]tableKey{1: {number: 0}}, value: map[string]interface{}{
+				"balance": balance,
+			}})
+		}
+
+	case code == c.SeedsEscrow && table == "locks":
+		for _, lock := range c.state.locks {
+			rows = append(rows, tableRow{keys: map[int]tableKey{
+				1: {number: lock.ID},
+				3: nameKey(lock.Beneficiary),
+			}, value: lock})
+		}
+
+	case code == c.SeedsExchange && table == "pricehistory":
+		for _, price := range c.state.seedsPrices {
+			rows = append(rows, tableRow{keys: map[int]tableKey{1: {number: price.ID}}, value: price})
+		}
+	}
+	return rows
+}
+
+func (c *FakeChain) getTableRows(request json.RawMessage) (interface{}, error) {
+
+	var query struct {
+		Code          eos.AccountName `json:"code"`
+		Scope         eos.AccountName `json:"scope"`
+		Table         string          `json:"table"`
+		IndexPosition string          `json:"index_position"`
+		KeyType       string          `json:"key_type"`
+		LowerBound    string          `json:"lower_bound"`
+		UpperBound    string          `json:"upper_bound"`
+		Limit         uint32          `json:"limit"`
+		Reverse       bool            `json:"reverse"`
+	}
+	if err := json.Unmarshal(request, &query); err != nil {
+		return nil, fmt.Errorf("cannot read get_table_rows request %v", err)
+	}
+
+	position, ok := indexPositions[query.IndexPosition]
+	if !ok {
+		number, err := strconv.Atoi(query.IndexPosition)
+		if err != nil {
+			return nil, fmt.Errorf("invalid index position %v", query.IndexPosition)
+		}
+		position = number
+	}
+
+	c.mutex.Lock()
+	rows := c.rows(query.Code, query.Scope, query.Table)
+	c.mutex.Unlock()
+
+	// an index the fake does not keep reads in primary key order
+	key := func(row tableRow) tableKey {
+		if k, ok := row.keys[position]; ok {
+			return k
+		}
+		return row.keys[1]
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if key(rows[i]) == key(rows[j]) {
+			return rows[i].keys[1].less(rows[j].keys[1])
+		}
+		return key(rows[i]).less(key(rows[j]))
+	})
+
+	var selected []tableRow
+	for _, row := range rows {
+		if query.LowerBound != "" {
+			lower, err := parseKey(query.LowerBound, query.KeyType)
+			if err != nil {
+				return nil, err
+			}
+			if key(row).less(lower) {
+				continue
+			}
+		}
+		if query.UpperBound != "" {
+			upper, err := parseKey(query.UpperBound, query.KeyType)
+			if err != nil {
+				return nil, err
+			}
+			if upper.less(key(row)) {
+				continue
+			}
+		}
+		selected = append(selected, row)
+	}
+	if query.Reverse {
+		for i, j := 0, len(selected)-1; i < j; i, j = i+1, j-1 {
+			selected[i], selected[j] = selected[j], selected[i]
+		}
+	}
+
+	limit := int(query.Limit)
+	if limit == 0 {
+		limit = 10
+	}
+	response := struct {
+		Rows    []interface{} `json:"rows"`
+		More    bool          `json:"more"`
+		NextKey string        `json:"next_key"`
+	}{Rows: []interface{}{}}
+	for i, row := range selected {
+		if i == limit {
+			response.More = true
+			response.NextKey = key(row).String()
+			break
+		}
+		response.Rows = append(response.Rows, row.value)
+	}
+	return response, nil
+}
+
+// packers read the JSON arguments of the actions that the module packs with abi_json_to_bin
+var packers = map[eos.ActionName]func(args json.RawMessage) (interface{}, error){
+	eos.ActN("setsetting"): func(args json.RawMessage) (interface{}, error) {
+		var data setSettingData
+		return data, json.Unmarshal(args, &data)
+	},
+	eos.ActN("remsetting"): func(args json.RawMessage) (interface{}, error) {
+		var data remSettingData
+		return data, json.Unmarshal(args, &data)
+	},
+	eos.ActN("createroot"): func(args json.RawMessage) (interface{}, error) {
+		var data createRootData
+		return data, json.Unmarshal(args, &data)
+	},
+	eos.ActN("propose"): func(args json.RawMessage) (interface{}, error) {
+		var data dao.Proposal
+		return data, json.Unmarshal(args, &data)
+	},
+	eos.ActN("regvoter"): func(args json.RawMessage) (interface{}, error) {
+		var data struct {
+			Voter          eos.Name  `json:"voter"`
+			TreasurySymbol string    `json:"treasury_symbol"`
+			Referrer       *eos.Name `json:"referrer"`
+		}
+		if err := json.Unmarshal(args, &data); err != nil {
+			return nil, err
+		}
+		symbol, err := eos.StringToSymbol(data.TreasurySymbol)
+		return regVoterData{Voter: data.Voter, TreasurySymbol: symbol, Referrer: data.Referrer}, err
+	},
+}
+
+func (c *FakeChain) abiJSONToBin(request json.RawMessage) (interface{}, error) {
+
+	var pack struct {
+		Code   eos.AccountName `json:"code"`
+		Action eos.ActionName  `json:"action"`
+		Args   json.RawMessage `json:"args"`
+	}
+	if err := json.Unmarshal(request, &pack); err != nil {
+		return nil, fmt.Errorf("cannot read abi_json_to_bin request %v", err)
+	}
+
+	packer, ok := packers[pack.Action]
+	if !ok || (pack.Code != c.DAO && pack.Action != eos.ActN("regvoter")) || (pack.Code != c.TelosDecide && pack.Action == eos.ActN("regvoter")) {
+		return nil, fmt.Errorf("the fake chain has no ABI for %v::%v", pack.Code, pack.Action)
+	}
+
+	data, err := packer(pack.Args)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %v arguments %v", pack.Action, err)
+	}
+	binargs, err := eos.MarshalBinary(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot pack %v arguments %v", pack.Action, err)
+	}
+	return map[string]string{"binargs": hex.EncodeToString(binargs)}, nil
+}
+
+// traceJSON is an action trace of push_transaction, with inline actions listed flat after their creator,
+// and the changes to the documents and edges tables of the DAO in the db_ops of the action making them
+type traceJSON struct {
+	ActionOrdinal        uint32          `json:"action_ordinal"`
+	CreatorActionOrdinal uint32          `json:"creator_action_ordinal"`
+	Receiver             eos.AccountName `json:"receiver"`
+	Action               traceActionJSON `json:"act"`
+	Console              string          `json:"console"`
+	TrxID                string          `json:"trx_id"`
+	BlockNum             uint32          `json:"block_num"`
+	DBOps                []dbOpJSON      `json:"db_ops"`
+}
+
+// dbOpJSON is a table delta of an action, like nodes running a table delta plugin report in db_ops
+type dbOpJSON struct {
+	Operation string          `json:"operation"`
+	Code      eos.AccountName `json:"code"`
+	TableName string          `json:"table_name"`
+	OldJSON   interface{}     `json:"old_json,omitempty"`
+	NewJSON   interface{}     `json:"new_json,omitempty"`
+}
+
+type traceActionJSON struct {
+	Account       eos.AccountName       `json:"account"`
+	Name          eos.ActionName        `json:"name"`
+	Authorization []eos.PermissionLevel `json:"authorization"`
+	Data          interface{}           `json:"data"`
+}
+
+// pushTransaction runs the actions of a transaction in a new block, keeping what they stored only if all succeed
+func (c *FakeChain) pushTransaction(request json.RawMessage) (interface{}, error) {
+
+	var packed struct {
+		PackedTrx eos.HexBytes `json:"packed_trx"`
+	}
+	if err := json.Unmarshal(request, &packed); err != nil {
+		return nil, fmt.Errorf("cannot read push_transaction request %v", err)
+	}
+
+	var transaction eos.Transaction
+	if err := eos.UnmarshalBinary(packed.PackedTrx, &transaction); err != nil {
+		return nil, fmt.Errorf("cannot unpack transaction %v", err)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = c.now.Add(blockInterval).Truncate(blockInterval)
+	c.blockNum++
+	id := sha256.Sum256(append([]byte(blockID(c.blockNum)), packed.PackedTrx...))
+
+	run := &fakeTransaction{
+		chain:     c,
+		state:     c.state.clone(),
+		blockTime: c.now,
+	}
+	for _, action := range transaction.Actions {
+		if err := run.dispatch(action); err != nil {
+			return nil, err
+		}
+	}
+	c.state = run.state
+
+	trxID := hex.EncodeToString(id[:])
+	for i := range run.traces {
+		run.traces[i].TrxID = trxID
+		run.traces[i].BlockNum = c.blockNum
+	}
+	return map[string]interface{}{
+		"transaction_id": trxID,
+		"processed": map[string]interface{}{
+			"id":            trxID,
+			"block_num":     c.blockNum,
+			"block_time":    c.now.Format(blockTimeFormat),
+			"status":        "executed",
+			"action_traces": run.traces,
+		},
+	}, nil
+}
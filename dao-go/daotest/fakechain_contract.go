@@ -0,0 +1,1619 @@
+package daotest
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/dao-contracts/dao-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+)
+
+// action data, in the order of the fields of the contracts' actions
+
+type createRootData struct {
+	Notes string `json:"notes"`
+}
+
+type setSettingData struct {
+	Key   string              `json:"key"`
+	Value *docgraph.FlexValue `json:"value"`
+}
+
+type remSettingData struct {
+	Key string `json:"key"`
+}
+
+type addPeriodData struct {
+	Predecessor eos.Checksum256 `json:"predecessor"`
+	StartTime   eos.TimePoint   `json:"start_time"`
+	Label       string          `json:"label"`
+}
+
+type applyData struct {
+	Applicant eos.AccountName `json:"applicant"`
+	Content   string          `json:"content"`
+}
+
+type enrollData struct {
+	Enroller  eos.AccountName `json:"enroller"`
+	Applicant eos.AccountName `json:"applicant"`
+	Content   string          `json:"content"`
+}
+
+type claimData struct {
+	AssignmentHash eos.Checksum256 `json:"assignment_hash"`
+}
+
+type memberData struct {
+	Member eos.AccountName `json:"member"`
+}
+
+type regVoterData struct {
+	Voter          eos.Name   `json:"voter"`
+	TreasurySymbol eos.Symbol `json:"treasury_symbol"`
+	Referrer       *eos.Name  `json:"referrer" eos:"optional"`
+}
+
+type issueData struct {
+	To       eos.AccountName `json:"to"`
+	Quantity eos.Asset       `json:"quantity"`
+	Memo     string          `json:"memo"`
+}
+
+type transferData struct {
+	From     eos.AccountName `json:"from"`
+	To       eos.AccountName `json:"to"`
+	Quantity eos.Asset       `json:"quantity"`
+	Memo     string          `json:"memo"`
+}
+
+// fakeTransaction runs the actions of a transaction on a copy of the state of the chain
+type fakeTransaction struct {
+	chain     *FakeChain
+	state     *fakeState
+	blockTime time.Time
+	traces    []traceJSON
+
+	// authorization and ordinal of the action running
+	authorization []eos.PermissionLevel
+	ordinal       uint32
+}
+
+func (tx *fakeTransaction) now() eos.TimePoint {
+	return dao.ToTimePoint(tx.blockTime)
+}
+
+func (tx *fakeTransaction) requireAuth(account eos.AccountName) error {
+	for _, level := range tx.authorization {
+		if level.Actor == account {
+			return nil
+		}
+	}
+	return missingAuthority(account)
+}
+
+func (tx *fakeTransaction) trace(account eos.AccountName, name eos.ActionName, authorization []eos.PermissionLevel, data interface{}, creator uint32) uint32 {
+	ordinal := uint32(len(tx.traces) + 1)
+	tx.traces = append(tx.traces, traceJSON{
+		ActionOrdinal:        ordinal,
+		CreatorActionOrdinal: creator,
+		Receiver:             account,
+		Action:               traceActionJSON{Account: account, Name: name, Authorization: authorization, Data: data},
+		DBOps:                []dbOpJSON{},
+	})
+	return ordinal
+}
+
+// dbOp records a change to the table of the DAO in the trace of the action running
+func (tx *fakeTransaction) dbOp(operation, table string, oldRow, newRow interface{}) {
+	if tx.ordinal == 0 {
+		return
+	}
+	trace := &tx.traces[tx.ordinal-1]
+	trace.DBOps = append(trace.DBOps, dbOpJSON{Operation: operation, Code: tx.chain.DAO, TableName: table, OldJSON: oldRow, NewJSON: newRow})
+}
+
+// inline records an action sent by the action running, authorized by actor
+func (tx *fakeTransaction) inline(account eos.AccountName, name string, actor eos.AccountName, data interface{}) {
+	tx.trace(account, eos.ActN(name), []eos.PermissionLevel{{Actor: actor, Permission: eos.PN("active")}}, data, tx.ordinal)
+}
+
+// dispatch decodes and runs an action of the transaction
+func (tx *fakeTransaction) dispatch(action *eos.Action) error {
+
+	var data interface{}
+	var run func() error
+	name := string(action.Name)
+
+	switch {
+	case action.Account == "eosio":
+		// accounts, resources and permissions are not emulated
+		tx.trace(action.Account, action.Name, action.Authorization, action.HexData, 0)
+		return nil
+
+	case action.Account == tx.chain.DAO:
+		switch name {
+		case "createroot":
+			d := &createRootData{}
+			data, run = d, func() error { return tx.createRoot() }
+		case "setsetting":
+			d := &setSettingData{}
+			data, run = d, func() error { return tx.setSetting(d.Key, d.Value) }
+		case "remsetting":
+			d := &remSettingData{}
+			data, run = d, func() error { return tx.remSetting(d.Key) }
+		case "addperiod":
+			d := &addPeriodData{}
+			data, run = d, func() error { return tx.addPeriod(*d) }
+		case "apply":
+			d := &applyData{}
+			data, run = d, func() error { return tx.apply(d.Applicant) }
+		case "enroll":
+			d := &enrollData{}
+			data, run = d, func() error { return tx.enroll(d.Enroller, d.Applicant) }
+		case "propose":
+			d := &dao.Proposal{}
+			data, run = d, func() error { return tx.propose(d.Proposer, d.ProposalType, d.ContentGroups) }
+		case "vote":
+			d := &dao.VoteProposal{}
+			data, run = d, func() error { return tx.vote(d.Voter, d.ProposalHash, d.Vote) }
+		case "closedocprop":
+			d := &dao.CloseDocProp{}
+			data, run = d, func() error { return tx.closeProposal(d.ProposalHash) }
+		case "claimnextper":
+			d := &claimData{}
+			data, run = d, func() error { return tx.claimNextPeriod(d.AssignmentHash) }
+		case "addmember":
+			d := &memberData{}
+			data, run = d, func() error { return tx.addMember(d.Member) }
+		case "migratemem":
+			d := &memberData{}
+			data, run = d, func() error { return tx.migrateMember(d.Member) }
+		}
+
+	case action.Account == tx.chain.TelosDecide:
+		switch name {
+		case "regvoter":
+			d := &regVoterData{}
+			data, run = d, func() error { return tx.regVoter(eos.AccountName(d.Voter), d.TreasurySymbol) }
+		case "mint":
+			d := &issueData{}
+			data, run = d, func() error {
+				if err := tx.requireAuth(tx.chain.DAO); err != nil {
+					return err
+				}
+				return tx.mint(d.To, d.Quantity)
+			}
+		}
+	}
+
+	if run == nil {
+		return fmt.Errorf("the fake chain does not emulate %v::%v", action.Account, action.Name)
+	}
+	if err := eos.UnmarshalBinary(action.HexData, data); err != nil {
+		return fmt.Errorf("cannot decode %v::%v data %v", action.Account, action.Name, err)
+	}
+
+	tx.authorization = action.Authorization
+	tx.ordinal = tx.trace(action.Account, action.Name, action.Authorization, data, 0)
+	return run()
+}
+
+// content
+
+var flexTypes = map[reflect.Type]string{
+	reflect.TypeOf(eos.Name("")):         "name",
+	reflect.TypeOf(""):                   "string",
+	reflect.TypeOf(eos.Asset{}):          "asset",
+	reflect.TypeOf(eos.TimePoint(0)):     "time_point",
+	reflect.TypeOf(int64(0)):             "int64",
+	reflect.TypeOf(eos.Checksum256(nil)): "checksum256",
+}
+
+// item returns a content item holding value, typed by its Go type
+func item(label string, value interface{}) docgraph.ContentItem {
+	return docgraph.ContentItem{
+		Label: label,
+		Value: &docgraph.FlexValue{
+			BaseVariant: eos.BaseVariant{
+				TypeID: docgraph.GetVariants().TypeID(flexTypes[reflect.TypeOf(value)]),
+				Impl:   value,
+			},
+		},
+	}
+}
+
+// normalizeContent copies groups with the values decoded from action data, which are pointers, dereferenced
+func normalizeContent(groups []docgraph.ContentGroup) []docgraph.ContentGroup {
+	normalized := make([]docgraph.ContentGroup, len(groups))
+	for i, group := range groups {
+		normalized[i] = make(docgraph.ContentGroup, len(group))
+		for j, contentItem := range group {
+			normalized[i][j] = contentItem
+			if contentItem.Value == nil || contentItem.Value.Impl == nil {
+				continue
+			}
+			value := reflect.ValueOf(contentItem.Value.Impl)
+			for value.Kind() == reflect.Ptr && !value.IsNil() {
+				value = value.Elem()
+			}
+			normalized[i][j].Value = &docgraph.FlexValue{
+				BaseVariant: eos.BaseVariant{TypeID: contentItem.Value.TypeID, Impl: value.Interface()},
+			}
+		}
+	}
+	return normalized
+}
+
+func labelOf(group docgraph.ContentGroup) string {
+	for _, contentItem := range group {
+		if contentItem.Label == "content_group_label" && contentItem.Value != nil {
+			if label, ok := contentItem.Value.Impl.(string); ok {
+				return label
+			}
+		}
+	}
+	return ""
+}
+
+// getValue reads label from the content group labeled groupLabel, like ContentWrapper::getOrFail
+func getValue(groups []docgraph.ContentGroup, groupLabel, label string) (interface{}, error) {
+	for _, group := range groups {
+		if labelOf(group) != groupLabel {
+			continue
+		}
+		for _, contentItem := range group {
+			if contentItem.Label == label && contentItem.Value != nil {
+				return contentItem.Value.Impl, nil
+			}
+		}
+	}
+	return nil, assertion("group: %v; label: %v does not exist", groupLabel, label)
+}
+
+func hasValue(groups []docgraph.ContentGroup, groupLabel, label string) bool {
+	_, err := getValue(groups, groupLabel, label)
+	return err == nil
+}
+
+func getInt64(groups []docgraph.ContentGroup, groupLabel, label string) (int64, error) {
+	value, err := getValue(groups, groupLabel, label)
+	if err != nil {
+		return 0, err
+	}
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case eos.Int64:
+		return int64(v), nil
+	}
+	return 0, assertion("%v must be an int64, it is a %T", label, value)
+}
+
+func getAsset(groups []docgraph.ContentGroup, groupLabel, label string) (eos.Asset, error) {
+	value, err := getValue(groups, groupLabel, label)
+	if err != nil {
+		return eos.Asset{}, err
+	}
+	asset, ok := value.(eos.Asset)
+	if !ok {
+		return eos.Asset{}, assertion("%v must be an asset, it is a %T", label, value)
+	}
+	return asset, nil
+}
+
+func getName(groups []docgraph.ContentGroup, groupLabel, label string) (eos.Name, error) {
+	value, err := getValue(groups, groupLabel, label)
+	if err != nil {
+		return "", err
+	}
+	switch v := value.(type) {
+	case eos.Name:
+		return v, nil
+	case eos.AccountName:
+		return eos.Name(v), nil
+	}
+	return "", assertion("%v must be a name, it is a %T", label, value)
+}
+
+func getTimePoint(groups []docgraph.ContentGroup, groupLabel, label string) (eos.TimePoint, error) {
+	value, err := getValue(groups, groupLabel, label)
+	if err != nil {
+		return 0, err
+	}
+	timePoint, ok := value.(eos.TimePoint)
+	if !ok {
+		return 0, assertion("%v must be a time_point, it is a %T", label, value)
+	}
+	return timePoint, nil
+}
+
+func getChecksum(groups []docgraph.ContentGroup, groupLabel, label string) (eos.Checksum256, error) {
+	value, err := getValue(groups, groupLabel, label)
+	if err != nil {
+		return nil, err
+	}
+	checksum, ok := value.(eos.Checksum256)
+	if !ok {
+		return nil, assertion("%v must be a checksum256, it is a %T", label, value)
+	}
+	return checksum, nil
+}
+
+func documentType(document docgraph.Document) eos.Name {
+	documentType, _ := getName(document.ContentGroups, "system", "type")
+	return documentType
+}
+
+// insertOrReplace sets label in the content group labeled groupLabel, appending it if it is not there
+func insertOrReplace(groups []docgraph.ContentGroup, groupLabel string, newItem docgraph.ContentItem) []docgraph.ContentGroup {
+	updated := make([]docgraph.ContentGroup, len(groups))
+	for i, group := range groups {
+		updated[i] = append(docgraph.ContentGroup{}, group...)
+		if labelOf(group) != groupLabel {
+			continue
+		}
+
+		replaced := false
+		for j, contentItem := range updated[i] {
+			if contentItem.Label == newItem.Label {
+				updated[i][j] = newItem
+				replaced = true
+			}
+		}
+		if !replaced {
+			updated[i] = append(updated[i], newItem)
+		}
+	}
+	return updated
+}
+
+// documents and edges
+
+func (tx *fakeTransaction) findDocument(hash eos.Checksum256) (int, bool) {
+	for i, document := range tx.state.documents {
+		if bytes.Equal(document.Hash, hash) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (tx *fakeTransaction) getDocument(hash eos.Checksum256) (docgraph.Document, error) {
+	i, ok := tx.findDocument(hash)
+	if !ok {
+		return docgraph.Document{}, assertion("document not found %v", hash.String())
+	}
+	return tx.state.documents[i], nil
+}
+
+// newDocument stores a document with groups, failing if it exists
+func (tx *fakeTransaction) newDocument(creator eos.AccountName, groups []docgraph.ContentGroup) (docgraph.Document, error) {
+	hash, err := dao.HashContent(groups)
+	if err != nil {
+		return docgraph.Document{}, err
+	}
+	if _, exists := tx.findDocument(hash); exists {
+		return docgraph.Document{}, assertion("document already exists %v", hash.String())
+	}
+
+	document := docgraph.Document{
+		ID:            tx.state.nextDocumentID,
+		Hash:          hash,
+		Creator:       creator,
+		ContentGroups: groups,
+		Certificates:  []docgraph.Certificate{},
+		CreatedDate:   tx.now(),
+	}
+	tx.state.nextDocumentID++
+	tx.state.documents = append(tx.state.documents, document)
+	tx.dbOp("INS", "documents", nil, document)
+	return document, nil
+}
+
+func (tx *fakeTransaction) getOrNewDocument(creator eos.AccountName, groups []docgraph.ContentGroup) (docgraph.Document, error) {
+	hash, err := dao.HashContent(groups)
+	if err != nil {
+		return docgraph.Document{}, err
+	}
+	if i, exists := tx.findDocument(hash); exists {
+		return tx.state.documents[i], nil
+	}
+	return tx.newDocument(creator, groups)
+}
+
+func (tx *fakeTransaction) eraseDocument(hash eos.Checksum256) {
+	if i, ok := tx.findDocument(hash); ok {
+		tx.dbOp("REM", "documents", tx.state.documents[i], nil)
+		tx.state.documents = append(tx.state.documents[:i], tx.state.documents[i+1:]...)
+	}
+}
+
+func (tx *fakeTransaction) findEdge(from, to eos.Checksum256, name eos.Name) (int, bool) {
+	for i, edge := range tx.state.edges {
+		if edge.EdgeName == name && bytes.Equal(edge.FromNode, from) && bytes.Equal(edge.ToNode, to) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (tx *fakeTransaction) edgeExists(from, to eos.Checksum256, name eos.Name) bool {
+	_, exists := tx.findEdge(from, to, name)
+	return exists
+}
+
+// newEdge stores an edge, failing if it exists
+func (tx *fakeTransaction) newEdge(creator eos.AccountName, from, to eos.Checksum256, name eos.Name) error {
+	if tx.edgeExists(from, to, name) {
+		return assertion("edge from: %v to: %v with name: %v already exists", from.String(), to.String(), name)
+	}
+
+	edge := docgraph.Edge{
+		ID:          tx.state.nextEdgeID,
+		Creator:     eos.Name(creator),
+		FromNode:    from,
+		ToNode:      to,
+		EdgeName:    name,
+		CreatedDate: tx.now(),
+	}
+	tx.state.edges = append(tx.state.edges, edge)
+	tx.state.nextEdgeID++
+	tx.dbOp("INS", "edges", nil, edge)
+	return nil
+}
+
+func (tx *fakeTransaction) getOrNewEdge(creator eos.AccountName, from, to eos.Checksum256, name eos.Name) error {
+	if tx.edgeExists(from, to, name) {
+		return nil
+	}
+	return tx.newEdge(creator, from, to, name)
+}
+
+func (tx *fakeTransaction) eraseEdge(from, to eos.Checksum256, name eos.Name) error {
+	i, ok := tx.findEdge(from, to, name)
+	if !ok {
+		return assertion("edge does not exist: from %v to %v with edge name of %v", from.String(), to.String(), name)
+	}
+	tx.dbOp("REM", "edges", tx.state.edges[i], nil)
+	tx.state.edges = append(tx.state.edges[:i], tx.state.edges[i+1:]...)
+	return nil
+}
+
+func (tx *fakeTransaction) edgesFrom(from eos.Checksum256, name eos.Name) []docgraph.Edge {
+	var edges []docgraph.Edge
+	for _, edge := range tx.state.edges {
+		if edge.EdgeName == name && bytes.Equal(edge.FromNode, from) {
+			edges = append(edges, edge)
+		}
+	}
+	return edges
+}
+
+// edgeFrom returns the first edge named name from a document, like Edge::get(from, name)
+func (tx *fakeTransaction) edgeFrom(from eos.Checksum256, name eos.Name) (docgraph.Edge, error) {
+	edges := tx.edgesFrom(from, name)
+	if len(edges) == 0 {
+		return docgraph.Edge{}, assertion("no edges exist: from %v with edge name of %v", from.String(), name)
+	}
+	return edges[0], nil
+}
+
+// updateDocument replaces document with one of groups, moving its edges to the new document
+func (tx *fakeTransaction) updateDocument(document docgraph.Document, groups []docgraph.ContentGroup) (docgraph.Document, error) {
+	updated, err := tx.newDocument(tx.chain.DAO, groups)
+	if err != nil {
+		return docgraph.Document{}, err
+	}
+
+	var moved []docgraph.Edge
+	kept := tx.state.edges[:0]
+	for _, edge := range tx.state.edges {
+		if bytes.Equal(edge.FromNode, document.Hash) || bytes.Equal(edge.ToNode, document.Hash) {
+			tx.dbOp("REM", "edges", edge, nil)
+			moved = append(moved, edge)
+		} else {
+			kept = append(kept, edge)
+		}
+	}
+	tx.state.edges = kept
+
+	for _, edge := range moved {
+		from, to := edge.FromNode, edge.ToNode
+		if bytes.Equal(from, document.Hash) {
+			from = updated.Hash
+		}
+		if bytes.Equal(to, document.Hash) {
+			to = updated.Hash
+		}
+		if err = tx.newEdge(tx.chain.DAO, from, to, edge.EdgeName); err != nil {
+			return docgraph.Document{}, err
+		}
+	}
+
+	tx.eraseDocument(document.Hash)
+	return updated, nil
+}
+
+// DAO state
+
+func (tx *fakeTransaction) root() eos.Checksum256 {
+	return dao.RootHash(tx.chain.DAO)
+}
+
+func (tx *fakeTransaction) isMember(account eos.AccountName) bool {
+	return tx.edgeExists(tx.root(), dao.MemberHash(account), eos.Name("member"))
+}
+
+func (tx *fakeTransaction) settings() (docgraph.Document, error) {
+	edges := tx.edgesFrom(tx.root(), eos.Name("settings"))
+	if len(edges) != 1 {
+		return docgraph.Document{}, assertion("There should only exists only 1 settings edge from root node")
+	}
+	return tx.getDocument(edges[0].ToNode)
+}
+
+func (tx *fakeTransaction) setting(key string) (interface{}, error) {
+	settings, err := tx.settings()
+	if err != nil {
+		return nil, err
+	}
+	value, err := getValue(settings.ContentGroups, "settings", key)
+	if err != nil {
+		return nil, assertion("setting %v does not exist", key)
+	}
+	return value, nil
+}
+
+func (tx *fakeTransaction) int64Setting(key string) (int64, error) {
+	value, err := tx.setting(key)
+	if err != nil {
+		return 0, err
+	}
+	number, ok := value.(int64)
+	if !ok {
+		return 0, assertion("setting %v must be an int64", key)
+	}
+	return number, nil
+}
+
+func (tx *fakeTransaction) nameSetting(key string) (eos.AccountName, error) {
+	value, err := tx.setting(key)
+	if err != nil {
+		return "", err
+	}
+	name, ok := value.(eos.Name)
+	if !ok {
+		return "", assertion("setting %v must be a name", key)
+	}
+	return eos.AccountName(name), nil
+}
+
+// periodStart reads the start time of a period, in seconds like the contract compares them
+func periodStart(period docgraph.Document) (time.Time, error) {
+	start, err := getTimePoint(period.ContentGroups, "details", "start_time")
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(dao.ToTime(start).Unix(), 0).UTC(), nil
+}
+
+func (tx *fakeTransaction) nextPeriod(period docgraph.Document) (docgraph.Document, error) {
+	edges := tx.edgesFrom(period.Hash, eos.Name("next"))
+	if len(edges) == 0 {
+		return docgraph.Document{}, assertion("End of calendar has been reached. Contact administrator to add more time periods.")
+	}
+	return tx.getDocument(edges[0].ToNode)
+}
+
+// periodSpan returns a period with its end, the start of the next period
+func (tx *fakeTransaction) periodSpan(period docgraph.Document) (dao.PeriodSpan, error) {
+	start, err := periodStart(period)
+	if err != nil {
+		return dao.PeriodSpan{}, err
+	}
+	next, err := tx.nextPeriod(period)
+	if err != nil {
+		return dao.PeriodSpan{}, err
+	}
+	end, err := periodStart(next)
+	if err != nil {
+		return dao.PeriodSpan{}, err
+	}
+	return dao.PeriodSpan{Document: period, StartTime: start, EndTime: end}, nil
+}
+
+func (tx *fakeTransaction) currentPeriod() (docgraph.Document, error) {
+	edges := tx.edgesFrom(tx.root(), eos.Name("start"))
+	if len(edges) == 0 {
+		return docgraph.Document{}, assertion("Root node does not have a 'start' edge.")
+	}
+	period, err := tx.getDocument(edges[0].ToNode)
+	if err != nil {
+		return docgraph.Document{}, err
+	}
+
+	start, err := periodStart(period)
+	if err != nil {
+		return docgraph.Document{}, err
+	}
+	if !start.Before(tx.blockTime) {
+		return docgraph.Document{}, assertion("start_period is in the future. No period found.")
+	}
+
+	for {
+		span, err := tx.periodSpan(period)
+		if err != nil {
+			return docgraph.Document{}, err
+		}
+		if !span.EndTime.Before(tx.blockTime) {
+			return period, nil
+		}
+		period, err = tx.nextPeriod(period)
+		if err != nil {
+			return docgraph.Document{}, err
+		}
+	}
+}
+
+// adjustAsset scales an asset with the float32 arithmetic of the contract
+func adjustAsset(original eos.Asset, adjustment float32) eos.Asset {
+	return eos.Asset{Amount: eos.Int64(float32(original.Amount) * adjustment), Symbol: original.Symbol}
+}
+
+var (
+	hvoiceSymbol = eos.Symbol{Precision: 2, Symbol: "HVOICE"}
+	hyphaSymbol  = eos.Symbol{Precision: 2, Symbol: "HYPHA"}
+	husdSymbol   = eos.Symbol{Precision: 2, Symbol: "HUSD"}
+	seedsSymbol  = eos.Symbol{Precision: 4, Symbol: "SEEDS"}
+)
+
+// actions of the DAO contract
+
+func (tx *fakeTransaction) createRoot() error {
+	if err := tx.requireAuth(tx.chain.DAO); err != nil {
+		return err
+	}
+
+	root, err := tx.newDocument(tx.chain.DAO, dao.RootContent(tx.chain.DAO))
+	if err != nil {
+		return err
+	}
+
+	settings, err := tx.newDocument(tx.chain.DAO, []docgraph.ContentGroup{
+		{
+			item("content_group_label", "settings"),
+			item("root_node", root.Hash.String()),
+		},
+		{
+			item("content_group_label", "system"),
+			item("type", eos.Name("settings")),
+			item("node_label", "Settings"),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return tx.newEdge(tx.chain.DAO, root.Hash, settings.Hash, eos.Name("settings"))
+}
+
+func (tx *fakeTransaction) setSetting(key string, value *docgraph.FlexValue) error {
+	if err := tx.requireAuth(tx.chain.DAO); err != nil {
+		return err
+	}
+
+	settings, err := tx.settings()
+	if err != nil {
+		return err
+	}
+
+	setting := normalizeContent([]docgraph.ContentGroup{{{Label: key, Value: value}}})[0][0]
+	groups := insertOrReplace(settings.ContentGroups, "settings", setting)
+	groups = insertOrReplace(groups, "settings", item("updated_date", tx.now()))
+	_, err = tx.updateDocument(settings, groups)
+	return err
+}
+
+// remSetting fails whatever the key, as the contract's remsetting checks false after finding the setting
+func (tx *fakeTransaction) remSetting(key string) error {
+	if err := tx.requireAuth(tx.chain.DAO); err != nil {
+		return err
+	}
+	return assertion("The specified setting does not exist: %v", key)
+}
+
+func (tx *fakeTransaction) addPeriod(data addPeriodData) error {
+	if err := tx.requireAuth(tx.chain.DAO); err != nil {
+		return err
+	}
+
+	period, err := tx.newDocument(tx.chain.DAO, dao.PeriodContent(data.StartTime, data.Label))
+	if err != nil {
+		return err
+	}
+	predecessor, err := tx.getDocument(data.Predecessor)
+	if err != nil {
+		return err
+	}
+
+	edgeName := eos.Name("start")
+	if documentType(predecessor) == eos.Name("period") {
+		predecessorStart, err := periodStart(predecessor)
+		if err != nil {
+			return err
+		}
+		if predecessorStart.Unix() >= dao.ToTime(data.StartTime).Unix() {
+			return assertion("start_time of period predecessor must be before the new period's start_time")
+		}
+		edgeName = eos.Name("next")
+	}
+	return tx.newEdge(tx.chain.DAO, predecessor.Hash, period.Hash, edgeName)
+}
+
+func (tx *fakeTransaction) apply(applicant eos.AccountName) error {
+	if err := tx.requireAuth(applicant); err != nil {
+		return err
+	}
+
+	member, err := tx.newDocument(tx.chain.DAO, dao.MemberContent(applicant))
+	if err != nil {
+		return err
+	}
+	if err = tx.newEdge(applicant, tx.root(), member.Hash, eos.Name("applicant")); err != nil {
+		return err
+	}
+	return tx.newEdge(applicant, member.Hash, tx.root(), eos.Name("applicantof"))
+}
+
+func (tx *fakeTransaction) enroll(enroller, applicant eos.AccountName) error {
+	if err := tx.requireAuth(enroller); err != nil {
+		return err
+	}
+
+	member, err := tx.getDocument(dao.MemberHash(applicant))
+	if err != nil {
+		return err
+	}
+
+	root := tx.root()
+	steps := []func() error{
+		func() error { return tx.newEdge(enroller, root, member.Hash, eos.Name("member")) },
+		func() error { return tx.newEdge(enroller, member.Hash, root, eos.Name("memberof")) },
+		func() error { return tx.eraseEdge(root, member.Hash, eos.Name("applicant")) },
+		func() error { return tx.eraseEdge(member.Hash, root, eos.Name("applicantof")) },
+	}
+	for _, step := range steps {
+		if err = step(); err != nil {
+			return err
+		}
+	}
+
+	genesisVoice := eos.Asset{Amount: 100, Symbol: hvoiceSymbol}
+	memo := "genesis voice issuance during enrollment"
+	if err = tx.mintVoice(applicant, genesisVoice, memo); err != nil {
+		return err
+	}
+
+	receipt, err := tx.newDocument(tx.chain.DAO, receiptContent(applicant, genesisVoice, memo))
+	if err != nil {
+		return err
+	}
+	return tx.newEdge(applicant, member.Hash, receipt.Hash, eos.Name("payment"))
+}
+
+func receiptContent(recipient eos.AccountName, amount eos.Asset, memo string) []docgraph.ContentGroup {
+	return []docgraph.ContentGroup{
+		{
+			item("content_group_label", "details"),
+			item("recipient", eos.Name(recipient)),
+			item("amount", amount),
+			item("memo", memo),
+		},
+		{
+			item("content_group_label", "system"),
+			item("type", eos.Name("payment")),
+			item("node_label", amount.String()+" to "+string(recipient)),
+		},
+	}
+}
+
+// proposalTypes are the proposal types whose propose, vote and close are emulated
+var proposalTypes = map[eos.Name]bool{"role": true, "badge": true, "assignment": true}
+
+func checkProposalType(proposalType eos.Name) error {
+	switch {
+	case proposalTypes[proposalType]:
+		return nil
+	case proposalType == "assignbadge" || proposalType == "payout" || proposalType == "attestation" || proposalType == "edit":
+		return fmt.Errorf("the fake chain does not emulate %v proposals", proposalType)
+	}
+	return assertion("Unknown proposal_type: %v", proposalType)
+}
+
+func (tx *fakeTransaction) propose(proposer eos.AccountName, proposalType eos.Name, content []docgraph.ContentGroup) error {
+	if err := tx.requireAuth(proposer); err != nil {
+		return err
+	}
+	if !tx.isMember(proposer) {
+		return assertion("only members can make proposals: %v", proposer)
+	}
+	if err := checkProposalType(proposalType); err != nil {
+		return err
+	}
+
+	groups := normalizeContent(content)
+	var err error
+	switch proposalType {
+	case "role":
+		err = checkRole(groups)
+	case "badge":
+		err = checkBadge(groups)
+	case "assignment":
+		groups, err = tx.checkAssignment(groups)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err = getValue(groups, "details", "title"); err != nil {
+		return err
+	}
+
+	settingsDocument, err := tx.settings()
+	if err != nil {
+		return err
+	}
+	settings, err := dao.SettingsFromDocument(settingsDocument)
+	if err != nil {
+		return err
+	}
+	votingDuration, err := tx.int64Setting("voting_duration_sec")
+	if err != nil {
+		return err
+	}
+
+	groups, err = dao.ProposalContent(proposalType, groups, settings, tx.blockTime.Add(time.Duration(votingDuration)*time.Second))
+	if err != nil {
+		return err
+	}
+	proposal, err := tx.newDocument(proposer, groups)
+	if err != nil {
+		return err
+	}
+
+	member := dao.MemberHash(proposer)
+	if err = tx.newEdge(proposer, member, proposal.Hash, eos.Name("owns")); err != nil {
+		return err
+	}
+	if err = tx.newEdge(proposer, proposal.Hash, member, eos.Name("ownedby")); err != nil {
+		return err
+	}
+	if err = tx.newEdge(proposer, tx.root(), proposal.Hash, eos.Name("proposal")); err != nil {
+		return err
+	}
+	if err = tx.updateVoteTally(proposal); err != nil {
+		return err
+	}
+
+	if proposalType == "assignment" {
+		role, _ := getChecksum(groups, "details", "role")
+		return tx.newEdge(tx.chain.DAO, proposal.Hash, role, eos.Name("role"))
+	}
+	return nil
+}
+
+func checkRole(groups []docgraph.ContentGroup) error {
+	salary, err := getAsset(groups, "details", "annual_usd_salary")
+	if err != nil {
+		return err
+	}
+	if salary.Amount <= 0 {
+		return assertion("annual_usd_salary must be greater than zero. You submitted: %v", salary.String())
+	}
+	return nil
+}
+
+func checkBadge(groups []docgraph.ContentGroup) error {
+	for _, label := range []string{"husd_coefficient_x10000", "hypha_coefficient_x10000", "hvoice_coefficient_x10000", "seeds_coefficient_x10000"} {
+		if !hasValue(groups, "details", label) {
+			continue
+		}
+		coefficient, err := getInt64(groups, "details", label)
+		if err != nil {
+			return err
+		}
+		if coefficient < 7000 || coefficient > 13000 {
+			return assertion("%v must be between 7000 and 13000, inclusive. You submitted: %v", label, coefficient)
+		}
+	}
+	return nil
+}
+
+// checkAssignment validates an assignment against its role, and adds the start period, the period count
+// and the salary per period the contract computes
+func (tx *fakeTransaction) checkAssignment(groups []docgraph.ContentGroup) ([]docgraph.ContentGroup, error) {
+
+	assignee, err := getName(groups, "details", "assignee")
+	if err != nil {
+		return nil, err
+	}
+	if !tx.isMember(eos.AccountName(assignee)) {
+		return nil, assertion("only members can be assigned to assignments %v", assignee)
+	}
+
+	roleHash, err := getChecksum(groups, "details", "role")
+	if err != nil {
+		return nil, err
+	}
+	role, err := tx.getDocument(roleHash)
+	if err != nil {
+		return nil, err
+	}
+	if documentType(role) != eos.Name("role") {
+		return nil, assertion("role document hash provided in assignment proposal is not of type role")
+	}
+
+	timeShare, err := getInt64(groups, "details", "time_share_x100")
+	if err != nil {
+		return nil, err
+	}
+	if timeShare < 1 || timeShare > 10000 {
+		return nil, assertion("time_share_x100 must be between 1 and 10000, inclusive. You submitted: %v", timeShare)
+	}
+	if hasValue(role.ContentGroups, "details", "min_time_share_x100") {
+		minTimeShare, err := getInt64(role.ContentGroups, "details", "min_time_share_x100")
+		if err != nil {
+			return nil, err
+		}
+		if timeShare < minTimeShare {
+			return nil, assertion("time_share_x100 must be greater than or equal to the role configuration. Role value for min_time_share_x100 is %v, and you submitted: %v", minTimeShare, timeShare)
+		}
+	}
+
+	deferred, err := getInt64(groups, "details", "deferred_perc_x100")
+	if err != nil {
+		return nil, err
+	}
+	if deferred < 0 || deferred > 10000 {
+		return nil, assertion("deferred_perc_x100 must be between 0 and 10000, inclusive. You submitted: %v", deferred)
+	}
+	if hasValue(role.ContentGroups, "details", "min_deferred_x100") {
+		minDeferred, err := getInt64(role.ContentGroups, "details", "min_deferred_x100")
+		if err != nil {
+			return nil, err
+		}
+		if deferred < minDeferred {
+			return nil, assertion("deferred_perc_x100 must be greater than or equal to the role configuration. Role value for min_deferred_x100 is %v, and you submitted: %v", minDeferred, deferred)
+		}
+	}
+
+	var nextPeriod eos.Checksum256
+	if hasValue(groups, "details", "start_period") {
+		startPeriod, err := getChecksum(groups, "details", "start_period")
+		if err != nil {
+			return nil, err
+		}
+		if _, err = tx.getDocument(startPeriod); err != nil {
+			return nil, err
+		}
+	} else {
+		current, err := tx.currentPeriod()
+		if err != nil {
+			return nil, err
+		}
+		next, err := tx.nextPeriod(current)
+		if err != nil {
+			return nil, err
+		}
+		nextPeriod = next.Hash
+	}
+
+	if hasValue(groups, "details", "period_count") {
+		periodCount, err := getInt64(groups, "details", "period_count")
+		if err != nil {
+			return nil, err
+		}
+		if periodCount >= 26 {
+			return nil, assertion("period_count must be less than 26. You submitted: %v", periodCount)
+		}
+	}
+
+	// the defaults and the salary per period are what the client predicts for the proposal
+	hyphaDeferralFactor, err := tx.int64Setting("hypha_deferral_factor_x100")
+	if err != nil {
+		return nil, err
+	}
+	return dao.AssignmentContent(groups, role, nextPeriod, hyphaDeferralFactor)
+}
+
+func (tx *fakeTransaction) vote(voter eos.AccountName, proposalHash eos.Checksum256, vote string) error {
+	if err := tx.requireAuth(voter); err != nil {
+		return err
+	}
+
+	proposal, err := tx.getDocument(proposalHash)
+	if err != nil {
+		return err
+	}
+	if err = checkProposalType(documentType(proposal)); err != nil {
+		return err
+	}
+	if !hasValue(proposal.ContentGroups, "ballot_options", vote) || vote == "content_group_label" {
+		return assertion("Invalid vote")
+	}
+	if !tx.edgeExists(tx.root(), proposal.Hash, eos.Name("proposal")) {
+		return assertion("Only allowed to vote active proposals")
+	}
+
+	// a new vote replaces the previous vote of voter
+	member := dao.MemberHash(voter)
+	for _, edge := range tx.edgesFrom(proposal.Hash, eos.Name("vote")) {
+		if edge.Creator != eos.Name(voter) {
+			continue
+		}
+		for _, link := range []struct {
+			from, to eos.Checksum256
+			name     eos.Name
+		}{
+			{member, edge.ToNode, "vote"},
+			{proposal.Hash, edge.ToNode, "vote"},
+			{edge.ToNode, member, "ownedby"},
+			{edge.ToNode, proposal.Hash, "voteon"},
+		} {
+			// the member's vote and ownedby edges are shared by the votes with the same content
+			if tx.edgeExists(link.from, link.to, link.name) {
+				if err := tx.eraseEdge(link.from, link.to, link.name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	votePower, registered := tx.state.voters[voter]
+	if !registered {
+		return assertion("No HVOICE found")
+	}
+
+	voteDocument, err := tx.getOrNewDocument(tx.chain.DAO, []docgraph.ContentGroup{{
+		item("voter", eos.Name(voter)),
+		item("vote_power", votePower),
+		item("vote", vote),
+	}})
+	if err != nil {
+		return err
+	}
+
+	steps := []func() error{
+		func() error { return tx.getOrNewEdge(voter, member, voteDocument.Hash, eos.Name("vote")) },
+		func() error { return tx.newEdge(voter, proposal.Hash, voteDocument.Hash, eos.Name("vote")) },
+		func() error { return tx.getOrNewEdge(voter, voteDocument.Hash, member, eos.Name("ownedby")) },
+		func() error { return tx.newEdge(voter, voteDocument.Hash, proposal.Hash, eos.Name("voteon")) },
+	}
+	for _, step := range steps {
+		if err = step(); err != nil {
+			return err
+		}
+	}
+	return tx.updateVoteTally(proposal)
+}
+
+// updateVoteTally replaces the vote tally of proposal with the sum of the vote power of its votes
+func (tx *fakeTransaction) updateVoteTally(proposal docgraph.Document) error {
+
+	for _, edge := range tx.edgesFrom(proposal.Hash, eos.Name("votetally")) {
+		if err := tx.eraseEdge(edge.FromNode, edge.ToNode, edge.EdgeName); err != nil {
+			return err
+		}
+	}
+
+	var options []string
+	tally := make(map[string]eos.Asset)
+	for _, group := range proposal.ContentGroups {
+		if labelOf(group) != "ballot_options" {
+			continue
+		}
+		for _, option := range group {
+			if option.Label != "content_group_label" {
+				options = append(options, option.Label)
+				tally[option.Label] = eos.Asset{Amount: 0, Symbol: hvoiceSymbol}
+			}
+		}
+	}
+
+	for _, edge := range tx.edgesFrom(proposal.Hash, eos.Name("vote")) {
+		vote, err := tx.getDocument(edge.ToNode)
+		if err != nil {
+			return err
+		}
+		group := vote.ContentGroups[0]
+		var option string
+		var power eos.Asset
+		for _, contentItem := range group {
+			switch contentItem.Label {
+			case "vote":
+				option, _ = contentItem.Value.Impl.(string)
+			case "vote_power":
+				power, _ = contentItem.Value.Impl.(eos.Asset)
+			}
+		}
+		if total, ok := tally[option]; ok {
+			total.Amount += power.Amount
+			tally[option] = total
+		}
+	}
+
+	var groups []docgraph.ContentGroup
+	for _, option := range options {
+		groups = append(groups, docgraph.ContentGroup{
+			item("content_group_label", option),
+			item("vote_power", tally[option]),
+		})
+	}
+	tallyDocument, err := tx.getOrNewDocument(tx.chain.DAO, groups)
+	if err != nil {
+		return err
+	}
+	return tx.newEdge(tx.chain.DAO, proposal.Hash, tallyDocument.Hash, eos.Name("votetally"))
+}
+
+// closeProposal closes a proposal whatever its expiration, as the contract does
+func (tx *fakeTransaction) closeProposal(proposalHash eos.Checksum256) error {
+
+	proposal, err := tx.getDocument(proposalHash)
+	if err != nil {
+		return err
+	}
+	if err = checkProposalType(documentType(proposal)); err != nil {
+		return err
+	}
+	if err = tx.eraseEdge(tx.root(), proposal.Hash, eos.Name("proposal")); err != nil {
+		return err
+	}
+
+	tallyEdge, err := tx.edgeFrom(proposal.Hash, eos.Name("votetally"))
+	if err != nil {
+		return err
+	}
+	tally, err := tx.getDocument(tallyEdge.ToNode)
+	if err != nil {
+		return err
+	}
+
+	passed, err := tx.didPass(tally)
+	if err != nil {
+		return err
+	}
+	if !passed {
+		return tx.newEdge(tx.chain.DAO, tx.root(), proposal.Hash, eos.Name("failedprops"))
+	}
+
+	if err = tx.passProposal(proposal); err != nil {
+		return err
+	}
+	return tx.newEdge(tx.chain.DAO, tx.root(), proposal.Hash, eos.Name("passedprops"))
+}
+
+// didPass requires a fifth of the HVOICE supply to vote and four fifths of the votes to pass
+func (tx *fakeTransaction) didPass(tally docgraph.Document) (bool, error) {
+
+	if len(tx.state.voters) == 0 {
+		return false, assertion("Treasury: HVOICE not found.")
+	}
+	supply := eos.Asset{Amount: 0, Symbol: hvoiceSymbol}
+	for _, liquid := range tx.state.voters {
+		supply.Amount += liquid.Amount
+	}
+	quorum := adjustAsset(supply, float32(0.2))
+
+	pass, err := getAsset(tally.ContentGroups, "pass", "vote_power")
+	if err != nil {
+		return false, err
+	}
+	fail, err := getAsset(tally.ContentGroups, "fail", "vote_power")
+	if err != nil {
+		return false, err
+	}
+	return pass.Amount+fail.Amount >= quorum.Amount && adjustAsset(pass, float32(0.25)).Amount > fail.Amount, nil
+}
+
+func (tx *fakeTransaction) passProposal(proposal docgraph.Document) error {
+
+	proposalType := documentType(proposal)
+	if proposalType != "assignment" {
+		return tx.newEdge(tx.chain.DAO, tx.root(), proposal.Hash, proposalType)
+	}
+
+	assignee, err := getName(proposal.ContentGroups, "details", "assignee")
+	if err != nil {
+		return err
+	}
+	member := dao.MemberHash(eos.AccountName(assignee))
+	role, err := getChecksum(proposal.ContentGroups, "details", "role")
+	if err != nil {
+		return err
+	}
+	timeShare, err := getInt64(proposal.ContentGroups, "details", "time_share_x100")
+	if err != nil {
+		return err
+	}
+
+	timeShareDocument, err := tx.newDocument(tx.chain.DAO, []docgraph.ContentGroup{
+		{
+			item("content_group_label", "details"),
+			item("time_share_x100", timeShare),
+			item("start_date", tx.now()),
+		},
+		{
+			item("content_group_label", "system"),
+			item("type", eos.Name("timeshare")),
+			item("node_label", "timeshare"),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	edges := []struct {
+		from, to eos.Checksum256
+		name     eos.Name
+	}{
+		{member, proposal.Hash, "assigned"},
+		{proposal.Hash, member, "assignee"},
+		{role, proposal.Hash, "assignment"},
+		{proposal.Hash, timeShareDocument.Hash, "initimeshare"},
+		{proposal.Hash, timeShareDocument.Hash, "curtimeshare"},
+		{proposal.Hash, timeShareDocument.Hash, "lastimeshare"},
+	}
+	for _, edge := range edges {
+		if err = tx.newEdge(tx.chain.DAO, edge.from, edge.to, edge.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tx *fakeTransaction) claimNextPeriod(assignmentHash eos.Checksum256) error {
+
+	assignment, err := tx.getDocument(assignmentHash)
+	if err != nil {
+		return err
+	}
+	if documentType(assignment) != eos.Name("assignment") {
+		return assertion("invalid document type. Expected: assignment; actual: %v", documentType(assignment))
+	}
+
+	assigneeEdge, err := tx.edgeFrom(assignment.Hash, eos.Name("assignee"))
+	if err != nil {
+		return err
+	}
+	memberDocument, err := tx.getDocument(assigneeEdge.ToNode)
+	if err != nil {
+		return err
+	}
+	memberName, err := getName(memberDocument.ContentGroups, "details", "member")
+	if err != nil {
+		return err
+	}
+	assignee := eos.AccountName(memberName)
+	if !tx.isMember(assignee) {
+		return assertion("assignee must be a current member to claim pay: %v", assignee)
+	}
+
+	history, err := tx.timeShareHistory(assignment)
+	if err != nil {
+		return err
+	}
+
+	period, err := tx.nextClaimablePeriod(assignment, history[0].StartDate)
+	if err != nil {
+		return err
+	}
+
+	if err = tx.requireAuth(assignee); err != nil {
+		return err
+	}
+	if err = tx.newEdge(tx.chain.DAO, assignment.Hash, period.Document.Hash, eos.Name("claimed")); err != nil {
+		return err
+	}
+
+	seedsDeferralFactor, err := tx.int64Setting("seeds_deferral_factor_x100")
+	if err != nil {
+		return err
+	}
+	simulator, err := dao.NewPayoutSimulator(assignment, seedsDeferralFactor, tx.state.seedsPrices)
+	if err != nil {
+		return err
+	}
+	if simulator.SeedsEscrow == nil && simulator.UsdSalary != nil && len(tx.state.seedsPrices) == 0 {
+		return fmt.Errorf("the fake chain has no SEEDS prices to pay %v, set them with SetSeedsPrices", assignment.Hash.String())
+	}
+
+	current, err := tx.edgeFrom(assignment.Hash, eos.Name("curtimeshare"))
+	if err != nil {
+		return err
+	}
+	start := 0
+	for i, segment := range history {
+		if bytes.Equal(segment.Hash, current.ToNode) {
+			start = i
+		}
+	}
+
+	payout, lastUsed := simulator.SimulatePeriod(period, history, start)
+	if len(payout.Segments) == 0 {
+		return assertion("fatal error: SEEDS has to be a valid asset")
+	}
+
+	last := history[lastUsed].Hash
+	if !bytes.Equal(current.ToNode, last) {
+		if err = tx.eraseEdge(current.FromNode, current.ToNode, current.EdgeName); err != nil {
+			return err
+		}
+		if err = tx.newEdge(tx.chain.DAO, assignment.Hash, last, eos.Name("curtimeshare")); err != nil {
+			return err
+		}
+	}
+
+	memo := "Payment for assignment " + assignment.Hash.String() + "; Period: " + period.Document.Hash.String()
+	for _, amount := range []eos.Asset{payout.Total.Hypha, payout.Total.Seeds, payout.Total.Hvoice, payout.Total.Husd} {
+		if amount.Amount == 0 || amount.Symbol.Symbol == "USD" {
+			continue
+		}
+		if err = tx.makePayment(period.Document, assignee, amount, memo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// timeShareHistory follows the time shares of an assignment from the initial one
+func (tx *fakeTransaction) timeShareHistory(assignment docgraph.Document) ([]dao.TimeShareSegment, error) {
+
+	edge, err := tx.edgeFrom(assignment.Hash, eos.Name("initimeshare"))
+	if err != nil {
+		return nil, err
+	}
+
+	var history []dao.TimeShareSegment
+	for {
+		timeShare, err := tx.getDocument(edge.ToNode)
+		if err != nil {
+			return nil, err
+		}
+		share, err := getInt64(timeShare.ContentGroups, "details", "time_share_x100")
+		if err != nil {
+			return nil, err
+		}
+		startDate, err := getTimePoint(timeShare.ContentGroups, "details", "start_date")
+		if err != nil {
+			return nil, err
+		}
+
+		segment := dao.TimeShareSegment{Hash: timeShare.Hash, TimeShare: share, StartDate: dao.ToTime(startDate)}
+		if len(history) > 0 {
+			history[len(history)-1].EndDate = segment.StartDate
+		}
+		history = append(history, segment)
+
+		next := tx.edgesFrom(timeShare.Hash, eos.Name("nextimeshare"))
+		if len(next) == 0 {
+			return history, nil
+		}
+		edge = next[0]
+	}
+}
+
+// nextClaimablePeriod returns the first period of the assignment that ended, was not claimed, and ended
+// after the assignment was approved
+func (tx *fakeTransaction) nextClaimablePeriod(assignment docgraph.Document, approved time.Time) (dao.PeriodSpan, error) {
+
+	startPeriod, err := getChecksum(assignment.ContentGroups, "details", "start_period")
+	if err != nil {
+		return dao.PeriodSpan{}, err
+	}
+	periodCount, err := getInt64(assignment.ContentGroups, "details", "period_count")
+	if err != nil {
+		return dao.PeriodSpan{}, err
+	}
+	period, err := tx.getDocument(startPeriod)
+	if err != nil {
+		return dao.PeriodSpan{}, err
+	}
+
+	approvedSec := approved.Unix()
+	nowSec := tx.blockTime.Unix()
+	for counter := int64(0); counter < periodCount; counter++ {
+		span, err := tx.periodSpan(period)
+		if err != nil {
+			return dao.PeriodSpan{}, err
+		}
+
+		startSec, endSec := span.StartTime.Unix(), span.EndTime.Unix()
+		if (startSec >= approvedSec || approvedSec < endSec) && endSec <= nowSec &&
+			!tx.edgeExists(assignment.Hash, period.Hash, eos.Name("claimed")) {
+			return span, nil
+		}
+
+		if period, err = tx.nextPeriod(period); err != nil {
+			return dao.PeriodSpan{}, err
+		}
+	}
+	return dao.PeriodSpan{}, assertion("All available periods for this assignment have been claimed: %v", assignment.Hash.String())
+}
+
+// makePayment pays recipient with the token of amount and records the receipt of the payment for period
+func (tx *fakeTransaction) makePayment(period docgraph.Document, recipient eos.AccountName, amount eos.Asset, memo string) error {
+
+	groups := receiptContent(recipient, amount, memo)
+	var err error
+	switch amount.Symbol.Symbol {
+	case hyphaSymbol.Symbol:
+		err = tx.payToken("hypha_token_contract", tx.chain.DAO, recipient, amount, memo)
+	case husdSymbol.Symbol:
+		var treasury eos.AccountName
+		if treasury, err = tx.nameSetting("treasury_contract"); err == nil {
+			err = tx.payToken("husd_token_contract", treasury, recipient, amount, memo)
+		}
+	case hvoiceSymbol.Symbol:
+		err = tx.mintVoice(recipient, amount, memo)
+	case seedsSymbol.Symbol:
+		groups, err = tx.escrowSeeds(recipient, amount, memo)
+	default:
+		err = fmt.Errorf("the fake chain does not pay %v", amount.Symbol.Symbol)
+	}
+	if err != nil {
+		return err
+	}
+
+	receipt, err := tx.newDocument(tx.chain.DAO, groups)
+	if err != nil {
+		return err
+	}
+	if err = tx.newEdge(tx.chain.DAO, period.Hash, receipt.Hash, eos.Name("payment")); err != nil {
+		return err
+	}
+	return tx.newEdge(tx.chain.DAO, dao.MemberHash(recipient), receipt.Hash, eos.Name("paid"))
+}
+
+// payToken issues amount with the token contract of the setting and transfers it from issuer to recipient
+func (tx *fakeTransaction) payToken(tokenSetting string, issuer, recipient eos.AccountName, amount eos.Asset, memo string) error {
+	token, err := tx.nameSetting(tokenSetting)
+	if err != nil {
+		return err
+	}
+
+	tx.inline(token, "issue", issuer, issueData{To: issuer, Quantity: amount, Memo: memo})
+	tx.inline(token, "transfer", issuer, transferData{From: issuer, To: recipient, Quantity: amount, Memo: memo})
+	tx.credit(token, recipient, amount)
+	return nil
+}
+
+func (tx *fakeTransaction) credit(token, account eos.AccountName, amount eos.Asset) {
+	key := balanceKey{token: token, account: account}
+	balance, ok := tx.state.balances[key]
+	if !ok {
+		balance = eos.Asset{Amount: 0, Symbol: amount.Symbol}
+	}
+	balance.Amount += amount.Amount
+	tx.state.balances[key] = balance
+}
+
+// escrowSeeds locks amount for recipient in the escrow until go live, and returns the content of its receipt
+func (tx *fakeTransaction) escrowSeeds(recipient eos.AccountName, amount eos.Asset, memo string) ([]docgraph.ContentGroup, error) {
+	token, err := tx.nameSetting("seeds_token_contract")
+	if err != nil {
+		return nil, err
+	}
+	escrow, err := tx.nameSetting("seeds_escrow_contract")
+	if err != nil {
+		return nil, err
+	}
+
+	tx.inline(token, "transfer", tx.chain.DAO, transferData{From: tx.chain.DAO, To: escrow, Quantity: amount, Memo: memo})
+	tx.credit(token, escrow, amount)
+
+	lock := dao.Lock{
+		ID:            uint64(len(tx.state.locks)),
+		LockType:      eos.Name("event"),
+		Sponsor:       eos.Name(tx.chain.DAO),
+		Beneficiary:   eos.Name(recipient),
+		Quantity:      amount,
+		TriggerEvent:  eos.Name("golive"),
+		TriggerSource: eos.Name(tx.chain.DAO),
+		VestingDate:   eos.BlockTimestamp{Time: tx.blockTime},
+		Notes:         memo,
+		CreatedDate:   eos.BlockTimestamp{Time: tx.blockTime},
+		UpdatedDate:   eos.BlockTimestamp{Time: tx.blockTime},
+	}
+	tx.inline(escrow, "lock", tx.chain.DAO, lock)
+	tx.state.locks = append(tx.state.locks, lock)
+
+	return []docgraph.ContentGroup{
+		{
+			item("content_group_label", "details"),
+			item("recipient", eos.Name(recipient)),
+			item("amount", amount),
+			item("memo", memo),
+			item("payment_type", "escrow_seeds_amount"),
+			item("event", eos.Name("golive")),
+		},
+		{
+			item("content_group_label", "system"),
+			item("type", eos.Name("payment")),
+			item("node_label", "Escrow "+amount.String()+" to "+string(recipient)),
+		},
+	}, nil
+}
+
+// mintVoice mints HVOICE to a registered voter with the Telos Decide contract of the settings
+func (tx *fakeTransaction) mintVoice(recipient eos.AccountName, amount eos.Asset, memo string) error {
+	telosDecide, err := tx.nameSetting("telos_decide_contract")
+	if err != nil {
+		return err
+	}
+	tx.inline(telosDecide, "mint", tx.chain.DAO, issueData{To: recipient, Quantity: amount, Memo: memo})
+	return tx.mint(recipient, amount)
+}
+
+// addMember adds member to the legacy members table, like Migration::addLegacyMember
+func (tx *fakeTransaction) addMember(member eos.AccountName) error {
+	if err := tx.requireAuth(tx.chain.DAO); err != nil {
+		return err
+	}
+	tx.state.legacyMembers[member] = true
+	return nil
+}
+
+// migrateMember moves a legacy member into the graph, like Migration::migrateMember
+func (tx *fakeTransaction) migrateMember(member eos.AccountName) error {
+	if err := tx.requireAuth(tx.chain.DAO); err != nil {
+		return err
+	}
+
+	document, err := tx.newDocument(tx.chain.DAO, dao.MemberContent(member))
+	if err != nil {
+		return err
+	}
+	root := tx.root()
+	if err = tx.newEdge(tx.chain.DAO, root, document.Hash, eos.Name("member")); err != nil {
+		return err
+	}
+	if err = tx.newEdge(tx.chain.DAO, document.Hash, root, eos.Name("memberof")); err != nil {
+		return err
+	}
+
+	if !tx.state.legacyMembers[member] {
+		return assertion("member not in members table: %v", member)
+	}
+	delete(tx.state.legacyMembers, member)
+	return nil
+}
+
+// actions of Telos Decide
+
+func (tx *fakeTransaction) regVoter(voter eos.AccountName, symbol eos.Symbol) error {
+	if err := tx.requireAuth(voter); err != nil {
+		return err
+	}
+	if _, registered := tx.state.voters[voter]; registered {
+		return assertion("voter already exists")
+	}
+	tx.state.voters[voter] = eos.Asset{Amount: 0, Symbol: symbol}
+	return nil
+}
+
+func (tx *fakeTransaction) mint(to eos.AccountName, quantity eos.Asset) error {
+	liquid, registered := tx.state.voters[to]
+	if !registered {
+		return assertion("voter not found")
+	}
+	if liquid.Symbol.Symbol != quantity.Symbol.Symbol {
+		return assertion("quantity symbol must be %v, not %v", liquid.Symbol.Symbol, quantity.Symbol.Symbol)
+	}
+	liquid.Amount += quantity.Amount
+	tx.state.voters[to] = liquid
+	return nil
+}
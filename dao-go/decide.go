@@ -130,6 +130,28 @@ func Transfer(ctx context.Context, api *eos.API, token, from, to eos.AccountName
 	return eostest.ExecTrx(ctx, api, actions)
 }
 
+type creation struct {
+	Issuer        eos.AccountName
+	MaximumSupply eos.Asset
+}
+
+// CreateToken creates the symbol of maxSupply on a token contract
+func CreateToken(ctx context.Context, api *eos.API, token, issuer eos.AccountName, maxSupply eos.Asset) (string, error) {
+
+	actions := []*eos.Action{{
+		Account: token,
+		Name:    eos.ActN("create"),
+		Authorization: []eos.PermissionLevel{
+			{Actor: token, Permission: eos.PN("active")},
+		},
+		ActionData: eos.NewActionData(creation{
+			Issuer:        issuer,
+			MaximumSupply: maxSupply,
+		}),
+	}}
+	return eostest.ExecTrx(ctx, api, actions)
+}
+
 type issuance struct {
 	To       eos.AccountName
 	Quantity eos.Asset
@@ -198,3 +220,27 @@ func RegVoter(ctx context.Context, api *eos.API, telosDecide, registrant eos.Acc
 
 	return eostest.ExecTrx(ctx, api, actions)
 }
+
+// LoadVotingPower returns the liquid voting power of voter in telosDecide, and whether voter is registered
+func LoadVotingPower(ctx context.Context, api *eos.API, telosDecide, voter eos.AccountName) (eos.Asset, bool, error) {
+
+	var rows []voters
+	var request eos.GetTableRowsRequest
+	request.Code = string(telosDecide)
+	request.Scope = string(voter)
+	request.Table = "voters"
+	request.Limit = 1
+	request.JSON = true
+	response, err := api.GetTableRows(ctx, request)
+	if err != nil {
+		return eos.Asset{}, false, fmt.Errorf("cannot read voters of %v: %v", telosDecide, err)
+	}
+
+	if err = response.JSONToStructs(&rows); err != nil {
+		return eos.Asset{}, false, fmt.Errorf("cannot read voter %v: %v", voter, err)
+	}
+	if len(rows) == 0 {
+		return eos.Asset{}, false, nil
+	}
+	return rows[0].Liquid, true, nil
+}
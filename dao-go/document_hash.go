@@ -0,0 +1,369 @@
+package dao
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+)
+
+// contentString renders a content item like the document graph's Content::toString, {label=[type,value]}
+func contentString(item docgraph.ContentItem) (string, error) {
+
+	if item.Value == nil {
+		return "", fmt.Errorf("content item %v has no value", item.Label)
+	}
+
+	// values decoded from action data or table rows can be pointers
+	impl := item.Value.Impl
+	if pointer := reflect.ValueOf(impl); pointer.Kind() == reflect.Ptr && !pointer.IsNil() {
+		impl = pointer.Elem().Interface()
+	}
+
+	var value string
+	switch v := impl.(type) {
+	case int64:
+		value = "int64," + strconv.FormatInt(v, 10)
+	case eos.Int64:
+		value = "int64," + strconv.FormatInt(int64(v), 10)
+	case eos.Asset:
+		value = "asset," + v.String()
+	case eos.TimePoint:
+		value = "time_point," + strconv.FormatUint(uint64(v)/1000000, 10)
+	case string:
+		value = "string," + v
+	case eos.Checksum256:
+		value = "checksum256," + hex.EncodeToString(v)
+	case eos.Name:
+		value = "name," + string(v)
+	case eos.AccountName:
+		value = "name," + string(v)
+	default:
+		return "", fmt.Errorf("content item %v has a value of unsupported type %T", item.Label, v)
+	}
+	return "{" + item.Label + "=[" + value + "]}", nil
+}
+
+// contentGroupsString renders content groups like the document graph's Document::toString,
+// [[{label=[type,value]},...],...] without spaces
+func contentGroupsString(groups []docgraph.ContentGroup) (string, error) {
+
+	var rendered []string
+	for _, group := range groups {
+		var items []string
+		for _, item := range group {
+			content, err := contentString(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, content)
+		}
+		rendered = append(rendered, "["+strings.Join(items, ",")+"]")
+	}
+	return "[" + strings.Join(rendered, ",") + "]", nil
+}
+
+// HashContent returns the hash the contract gives a document with content groups, the sha256 of their rendering
+func HashContent(groups []docgraph.ContentGroup) (eos.Checksum256, error) {
+
+	rendered, err := contentGroupsString(groups)
+	if err != nil {
+		return nil, fmt.Errorf("cannot hash content %v", err)
+	}
+
+	hash := sha256.Sum256([]byte(rendered))
+	return eos.Checksum256(hash[:]), nil
+}
+
+// VerifyDocument checks that the hash of document is the hash of its content
+func VerifyDocument(document docgraph.Document) error {
+
+	hash, err := HashContent(document.ContentGroups)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(hash, document.Hash) {
+		return fmt.Errorf("document %v does not match the hash of its content %v", document.Hash, hash)
+	}
+	return nil
+}
+
+// LoadHashed loads the document with the hash computed for content created by a transaction, and verifies
+// that the document read has that hash
+func LoadHashed(ctx context.Context, api *eos.API, contract eos.AccountName, hash eos.Checksum256) (docgraph.Document, error) {
+
+	document, err := docgraph.LoadDocument(ctx, api, contract, hash.String())
+	if err != nil {
+		return docgraph.Document{}, fmt.Errorf("cannot load document %v: %v", hash, err)
+	}
+
+	if !bytes.Equal(document.Hash, hash) {
+		return docgraph.Document{}, fmt.Errorf("loaded document %v instead of %v", document.Hash, hash)
+	}
+	return document, VerifyDocument(document)
+}
+
+// MemberContent returns the content of the member document of account, as the contract's Member::defaultContent
+func MemberContent(account eos.AccountName) []docgraph.ContentGroup {
+	return []docgraph.ContentGroup{
+		{
+			newContentItem("content_group_label", "string", "details"),
+			newContentItem("member", "name", eos.Name(account)),
+		},
+		{
+			newContentItem("content_group_label", "string", "system"),
+			newContentItem("type", "name", eos.Name("member")),
+			newContentItem("node_label", "string", string(account)),
+		},
+	}
+}
+
+// MemberHash returns the hash of the member document of account, like the contract's Member::calcHash
+func MemberHash(account eos.AccountName) eos.Checksum256 {
+	hash, err := HashContent(MemberContent(account))
+	if err != nil {
+		panic("cannot hash member content: " + err.Error())
+	}
+	return hash
+}
+
+// PeriodContent returns the content of the period document created by addperiod
+func PeriodContent(startTime eos.TimePoint, label string) []docgraph.ContentGroup {
+	return []docgraph.ContentGroup{
+		{
+			newContentItem("content_group_label", "string", "details"),
+			newContentItem("start_time", "time_point", startTime),
+			newContentItem("label", "string", label),
+		},
+		{
+			newContentItem("content_group_label", "string", "system"),
+			newContentItem("type", "name", eos.Name("period")),
+			newContentItem("node_label", "string", label),
+		},
+	}
+}
+
+// PeriodHash returns the hash of the period document created by addperiod
+func PeriodHash(startTime eos.TimePoint, label string) eos.Checksum256 {
+	hash, err := HashContent(PeriodContent(startTime, label))
+	if err != nil {
+		panic("cannot hash period content: " + err.Error())
+	}
+	return hash
+}
+
+// defaultVersion is the version of proposals when the client_version or contract_version settings are not set
+const defaultVersion = "un-versioned"
+
+// ProposalContent returns the content of the document created by proposing content, with the system, ballot
+// and ballot options groups that Proposal::propose appends; expiration is the block time of the proposal
+// plus the voting duration. Content must already have the fields the proposal type adds to the details,
+// see AssignmentContent and BadgeAssignmentContent. Payouts with a usd_amount are rejected, because the
+// contract converts it with the SEEDS price of the block.
+func ProposalContent(proposalType eos.Name, content []docgraph.ContentGroup, settings Settings,
+	expiration time.Time) ([]docgraph.ContentGroup, error) {
+
+	document := docgraph.Document{ContentGroups: content}
+	title, err := document.GetContent("title")
+	if err != nil {
+		return nil, fmt.Errorf("proposal has no title %v", err)
+	}
+	if _, err = document.GetContent("usd_amount"); proposalType == eos.Name("payout") && err == nil {
+		return nil, fmt.Errorf("cannot predict the content of a payout with a usd_amount")
+	}
+
+	clientVersion, contractVersion := defaultVersion, defaultVersion
+	if settings.ClientVersion != nil {
+		clientVersion = *settings.ClientVersion
+	}
+	if settings.ContractVersion != nil {
+		contractVersion = *settings.ContractVersion
+	}
+
+	groups := append([]docgraph.ContentGroup{}, content...)
+	return append(groups,
+		docgraph.ContentGroup{
+			newContentItem("content_group_label", "string", "system"),
+			newContentItem("client_version", "string", clientVersion),
+			newContentItem("contract_version", "string", contractVersion),
+			newContentItem("node_label", "string", title.Impl),
+			newContentItem("type", "name", proposalType),
+		},
+		docgraph.ContentGroup{
+			newContentItem("content_group_label", "string", "ballot"),
+			newContentItem("expiration", "time_point", ToTimePoint(expiration.Truncate(time.Second))),
+		},
+		docgraph.ContentGroup{
+			newContentItem("content_group_label", "string", "ballot_options"),
+			newContentItem("pass", "name", eos.Name("pass")),
+			newContentItem("fail", "name", eos.Name("fail")),
+		}), nil
+}
+
+// phaseToYearRatio is the contract's common::PHASE_TO_YEAR_RATIO, the share of a year paid per period
+const phaseToYearRatio = float32(0.02026009582)
+
+// copyDetails copies content with its own details group, which is returned for changes
+func copyDetails(content []docgraph.ContentGroup) ([]docgraph.ContentGroup, *docgraph.ContentGroup, error) {
+
+	groups := append([]docgraph.ContentGroup{}, content...)
+	for i := range groups {
+		if groupLabel(groups[i]) == "details" {
+			groups[i] = append(docgraph.ContentGroup{}, groups[i]...)
+			return groups, &groups[i], nil
+		}
+	}
+	return nil, nil, fmt.Errorf("proposal has no details group")
+}
+
+// defaultPeriods sets the start_period to nextPeriod and the period_count to 13 when they are missing
+func defaultPeriods(content []docgraph.ContentGroup, details *docgraph.ContentGroup, nextPeriod eos.Checksum256) {
+
+	document := docgraph.Document{ContentGroups: content}
+	if _, err := document.GetContent("start_period"); err != nil {
+		insertOrReplace(details, newContentItem("start_period", "checksum256", nextPeriod))
+	}
+	if _, err := document.GetContent("period_count"); err != nil {
+		insertOrReplace(details, newContentItem("period_count", "int64", int64(13)))
+	}
+}
+
+// BadgeAssignmentContent applies the changes of BadgeAssignmentProposal::proposeImpl to content: the
+// start_period defaults to nextPeriod, the period after the current one, and the period_count to 13
+func BadgeAssignmentContent(content []docgraph.ContentGroup, nextPeriod eos.Checksum256) ([]docgraph.ContentGroup, error) {
+
+	groups, details, err := copyDetails(content)
+	if err != nil {
+		return nil, err
+	}
+	defaultPeriods(content, details, nextPeriod)
+	return groups, nil
+}
+
+// AssignmentContent applies the changes of AssignmentProposal::proposeImpl to content: the periods default
+// like BadgeAssignmentContent, and the salary per period is computed from the annual_usd_salary of role
+// with the float arithmetic of the contract
+func AssignmentContent(content []docgraph.ContentGroup, role docgraph.Document, nextPeriod eos.Checksum256,
+	hyphaDeferralFactor int64) ([]docgraph.ContentGroup, error) {
+
+	document := docgraph.Document{ContentGroups: content}
+	timeShare, err := getInt64(document, "time_share_x100")
+	if err != nil {
+		return nil, err
+	}
+	deferred, err := getInt64(document, "deferred_perc_x100")
+	if err != nil {
+		return nil, err
+	}
+	annualSalary, err := getAsset(role, "annual_usd_salary")
+	if err != nil {
+		return nil, err
+	}
+
+	groups, details, err := copyDetails(content)
+	if err != nil {
+		return nil, err
+	}
+	defaultPeriods(content, details, nextPeriod)
+	insertOrReplace(details, newContentItem("usd_salary_value_per_phase", "asset", adjustAsset(annualSalary, phaseToYearRatio)))
+
+	timeShareUsd := adjustAsset(adjustAsset(annualSalary, float32(timeShare)/float32(100)), phaseToYearRatio)
+
+	husd := adjustAsset(timeShareUsd, float32(1)-float32(deferred)/float32(100))
+	husd.Symbol = eos.Symbol{Precision: 2, Symbol: "HUSD"}
+	if husd.Amount > 0 {
+		insertOrReplace(details, newContentItem("husd_salary_per_phase", "asset", husd))
+	}
+
+	deferredUsd := adjustAsset(timeShareUsd, float32(deferred)/float32(100))
+	hypha := adjustAsset(eos.Asset{Amount: deferredUsd.Amount, Symbol: eos.Symbol{Precision: 2, Symbol: "HYPHA"}},
+		float32(hyphaDeferralFactor)/float32(100))
+	if hypha.Amount > 0 {
+		insertOrReplace(details, newContentItem("hypha_salary_per_phase", "asset", hypha))
+	}
+
+	hvoice := eos.Asset{Amount: timeShareUsd.Amount * 2, Symbol: eos.Symbol{Precision: 2, Symbol: "HVOICE"}}
+	if hvoice.Amount > 0 {
+		insertOrReplace(details, newContentItem("hvoice_salary_per_phase", "asset", hvoice))
+	}
+	return groups, nil
+}
+
+// PredictProposalContent returns the content of the document created by proposing content in a block at
+// blockTime, reading the settings, the calendar and the role of assignments from the chain
+func PredictProposalContent(ctx context.Context, api *eos.API, contract eos.AccountName, proposalType eos.Name,
+	content []docgraph.ContentGroup, blockTime time.Time) ([]docgraph.ContentGroup, error) {
+
+	settings, err := LoadSettings(ctx, api, contract)
+	if err != nil {
+		return nil, err
+	}
+	if settings.VotingDurationSec == nil {
+		return nil, fmt.Errorf("voting_duration_sec is not set")
+	}
+
+	if proposalType == eos.Name("assignment") || proposalType == eos.Name("assignbadge") {
+		nextPeriod, err := nextPeriodHash(ctx, api, contract, blockTime)
+		if err != nil {
+			return nil, err
+		}
+
+		if proposalType == eos.Name("assignbadge") {
+			content, err = BadgeAssignmentContent(content, nextPeriod)
+		} else {
+			content, err = predictAssignment(ctx, api, contract, content, nextPeriod, settings)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	duration := time.Duration(*settings.VotingDurationSec) * time.Second
+	return ProposalContent(proposalType, content, settings, blockTime.Add(duration))
+}
+
+func predictAssignment(ctx context.Context, api *eos.API, contract eos.AccountName, content []docgraph.ContentGroup,
+	nextPeriod eos.Checksum256, settings Settings) ([]docgraph.ContentGroup, error) {
+
+	if settings.HyphaDeferralFactorX100 == nil {
+		return nil, fmt.Errorf("hypha_deferral_factor_x100 is not set")
+	}
+
+	roleHash, err := getChecksum(docgraph.Document{ContentGroups: content}, "role")
+	if err != nil {
+		return nil, err
+	}
+	role, err := docgraph.LoadDocument(ctx, api, contract, roleHash.String())
+	if err != nil {
+		return nil, fmt.Errorf("cannot load role %v: %v", roleHash, err)
+	}
+	return AssignmentContent(content, role, nextPeriod, *settings.HyphaDeferralFactorX100)
+}
+
+// nextPeriodHash returns the hash of the period after the one containing at
+func nextPeriodHash(ctx context.Context, api *eos.API, contract eos.AccountName, at time.Time) (eos.Checksum256, error) {
+
+	calendar, err := LoadCalendar(ctx, api, contract)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := CurrentPeriod(calendar, at)
+	if err != nil {
+		return nil, err
+	}
+	for i := range calendar[:len(calendar)-1] {
+		if bytes.Equal(calendar[i].Document.Hash, current.Document.Hash) {
+			return calendar[i+1].Document.Hash, nil
+		}
+	}
+	return nil, fmt.Errorf("the calendar has no period after %v", current.Document.Hash)
+}
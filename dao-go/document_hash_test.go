@@ -0,0 +1,150 @@
+package dao_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/dao-contracts/dao-go"
+	"github.com/hypha-dao/dao-contracts/dao-go/daotest"
+	"github.com/hypha-dao/document-graph/docgraph"
+	"gotest.tools/assert"
+)
+
+func TestDocumentHash(t *testing.T) {
+
+	value := func(impl interface{}) *docgraph.FlexValue {
+		return &docgraph.FlexValue{BaseVariant: eos.BaseVariant{Impl: impl}}
+	}
+	sha := func(rendered string) string {
+		hash := sha256.Sum256([]byte(rendered))
+		return hex.EncodeToString(hash[:])
+	}
+
+	startTime := dao.ToTimePoint(time.Date(2020, 10, 1, 12, 0, 0, 500000000, time.UTC))
+	usd := eos.Asset{Amount: 15000000, Symbol: eos.Symbol{Precision: 2, Symbol: "USD"}}
+
+	t.Run("Rendering", func(t *testing.T) {
+		hash, err := dao.HashContent([]docgraph.ContentGroup{
+			{
+				{Label: "title", Value: value("Healer")},
+				{Label: "annual_usd_salary", Value: value(usd)},
+				{Label: "min_time_share_x100", Value: value(int64(50))},
+			},
+			{
+				{Label: "start_time", Value: value(startTime)},
+				{Label: "role", Value: value(dao.RootHash("dao.hypha"))},
+				{Label: "assignee", Value: value(eos.AN("johnnyhypha1"))},
+			},
+		})
+		assert.NilError(t, err)
+
+		// time points render in seconds, checksums in lower case hex, with no spaces anywhere
+		assert.Equal(t, hash.String(), sha("[[{title=[string,Healer]},{annual_usd_salary=[asset,150000.00 USD]},{min_time_share_x100=[int64,50]}],"+
+			"[{start_time=[time_point,1601553600]},{role=[checksum256,52a7ff82bd6f53b31285e97d6806d886eefb650e79754784e9d923d3df347c91]},{assignee=[name,johnnyhypha1]}]]"))
+
+		_, err = dao.HashContent([]docgraph.ContentGroup{{{Label: "ratio", Value: value(0.5)}}})
+		assert.ErrorContains(t, err, "unsupported type float64")
+	})
+
+	t.Run("Documents", func(t *testing.T) {
+		assert.Equal(t, dao.MemberHash("johnnyhypha1").String(), sha("[[{content_group_label=[string,details]},{member=[name,johnnyhypha1]}],"+
+			"[{content_group_label=[string,system]},{type=[name,member]},{node_label=[string,johnnyhypha1]}]]"))
+		assert.Equal(t, dao.PeriodHash(startTime, "period #1 of 4").String(), sha("[[{content_group_label=[string,details]},{start_time=[time_point,1601553600]},{label=[string,period #1 of 4]}],"+
+			"[{content_group_label=[string,system]},{type=[name,period]},{node_label=[string,period #1 of 4]}]]"))
+
+		// the root hash is computed from its fixed rendering, the general hashing must agree with it
+		rootHash, err := dao.HashContent(dao.RootContent("dao1.hypha"))
+		assert.NilError(t, err)
+		assert.Equal(t, rootHash.String(), dao.RootHash("dao1.hypha").String())
+
+		member := docgraph.Document{Hash: dao.MemberHash("johnnyhypha1"), ContentGroups: dao.MemberContent("johnnyhypha1")}
+		assert.NilError(t, dao.VerifyDocument(member))
+		member.ContentGroups = dao.MemberContent("alice")
+		assert.ErrorContains(t, dao.VerifyDocument(member), "does not match the hash of its content")
+	})
+
+	t.Run("Proposal", func(t *testing.T) {
+		content := []docgraph.ContentGroup{{
+			{Label: "content_group_label", Value: value("details")},
+			{Label: "title", Value: value("Healer")},
+		}}
+		version := "0.2.0 pre-release"
+		expiration := time.Date(2020, 10, 8, 12, 0, 0, 700000000, time.UTC)
+
+		groups, err := dao.ProposalContent("role", content, dao.Settings{ContractVersion: &version}, expiration)
+		assert.NilError(t, err)
+		assert.Equal(t, len(content), 1)
+
+		hash, err := dao.HashContent(groups)
+		assert.NilError(t, err)
+		assert.Equal(t, hash.String(), sha("[[{content_group_label=[string,details]},{title=[string,Healer]}],"+
+			"[{content_group_label=[string,system]},{client_version=[string,un-versioned]},{contract_version=[string,0.2.0 pre-release]},{node_label=[string,Healer]},{type=[name,role]}],"+
+			"[{content_group_label=[string,ballot]},{expiration=[time_point,1602158400]}],"+
+			"[{content_group_label=[string,ballot_options]},{pass=[name,pass]},{fail=[name,fail]}]]"))
+
+		_, err = dao.ProposalContent("role", nil, dao.Settings{}, expiration)
+		assert.ErrorContains(t, err, "proposal has no title")
+	})
+}
+
+func TestPredictProposalContent(t *testing.T) {
+
+	options := daotest.DefaultOptions()
+	options.NumPeriods = 3
+	options.PeriodDuration = time.Hour
+	env, fake, err := daotest.SetupFake(options)
+	assert.NilError(t, err)
+	defer fake.Close()
+
+	item := func(label, typeName string, impl interface{}) docgraph.ContentItem {
+		return docgraph.ContentItem{Label: label, Value: &docgraph.FlexValue{
+			BaseVariant: eos.BaseVariant{TypeID: docgraph.GetVariants().TypeID(typeName), Impl: impl},
+		}}
+	}
+
+	predict := func(proposalType eos.Name, content []docgraph.ContentGroup, created docgraph.Document) {
+		groups, err := dao.PredictProposalContent(env.Ctx, env.API, env.DAO, proposalType, content, dao.ToTime(created.CreatedDate))
+		assert.NilError(t, err)
+		hash, err := dao.HashContent(groups)
+		assert.NilError(t, err)
+		assert.Equal(t, hash.String(), created.Hash.String())
+	}
+
+	// the fake chain builds proposals with its own copy of propose; the prediction must match what it created
+	var roleContent docgraph.Document
+	assert.NilError(t, json.Unmarshal([]byte(role1), &roleContent))
+	_, proposed, err := dao.ProposeRole(env.Ctx, env.API, env.DAO, env.Whale.Member, role1)
+	assert.NilError(t, err)
+	predict(eos.Name("role"), roleContent.ContentGroups, proposed)
+
+	role := CreateRole(t, env, env.Whale, env.Members[0], role1)
+
+	// the contract adds the start period, the period count and the salary per period
+	content := []docgraph.ContentGroup{{
+		item("content_group_label", "string", "details"),
+		item("title", "string", "Underwater Basket Weaver"),
+		item("assignee", "name", eos.Name(env.Members[1].Member)),
+		item("role", "checksum256", role.Hash),
+		item("time_share_x100", "int64", int64(5000)),
+		item("deferred_perc_x100", "int64", int64(50)),
+	}}
+	_, assignment, err := dao.Propose(env.Ctx, env.API, env.DAO, env.Members[0].Member, dao.Proposal{
+		Proposer:      env.Members[0].Member,
+		ProposalType:  eos.Name("assignment"),
+		ContentGroups: content,
+	})
+	assert.NilError(t, err)
+
+	predict(eos.Name("assignment"), content, assignment)
+
+	_, err = dao.PredictProposalContent(env.Ctx, env.API, env.DAO, eos.Name("payout"), []docgraph.ContentGroup{{
+		item("content_group_label", "string", "details"),
+		item("title", "string", "Bonus"),
+		item("usd_amount", "asset", eos.Asset{Amount: 10000, Symbol: eos.Symbol{Precision: 2, Symbol: "USD"}}),
+	}}, time.Now())
+	assert.ErrorContains(t, err, "cannot predict the content of a payout")
+}
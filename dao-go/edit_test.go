@@ -49,18 +49,12 @@ func TestEditProposal(t *testing.T) {
 			err := json.Unmarshal([]byte(test.original), &originalDoc)
 			assert.NilError(t, err)
 
-			_, err = dao.Propose(env.ctx, &env.api, env.DAO, proposer.Member, dao.Proposal{
+			_, attestation, err := dao.Propose(env.Ctx, env.API, env.DAO, proposer.Member, dao.Proposal{
 				Proposer:      proposer.Member,
 				ProposalType:  eos.Name("attestation"),
 				ContentGroups: originalDoc.ContentGroups,
 			})
 			assert.NilError(t, err)
-
-			t.Log("Policy document proposed")
-
-			// retrieve the document we just created
-			attestation, err := docgraph.GetLastDocumentOfEdge(env.ctx, &env.api, env.DAO, eos.Name("proposal"))
-			assert.NilError(t, err)
 			assert.Equal(t, attestation.Creator, proposer.Member)
 
 			beforeThreshold, err := attestation.GetContent(test.editedField)
@@ -81,19 +75,12 @@ func TestEditProposal(t *testing.T) {
 			voteToPassTD(t, env, attestation)
 
 			t.Log("Member: ", closer.Member, " is closing attestation proposal	: ", attestation.Hash.String())
-			_, err = dao.CloseProposal(env.ctx, &env.api, env.DAO, closer.Member, attestation.Hash)
+			_, err = dao.CloseProposal(env.Ctx, env.API, env.DAO, closer.Member, attestation.Hash)
 			assert.NilError(t, err)
 
-			_, err = dao.ProposeEdit(env.ctx, &env.api, env.DAO, proposer.Member, attestation, test.edit)
+			_, edit, err := dao.ProposeEdit(env.Ctx, env.API, env.DAO, proposer.Member, attestation, test.edit)
 			assert.NilError(t, err)
 
-			t.Log("EDIT to Policy document proposed")
-
-			// pause(t, env.ChainResponsePause, "Building block...", "Waiting")
-
-			// retrieve the document we just created
-			edit, err := docgraph.GetLastDocumentOfEdge(env.ctx, &env.api, env.DAO, eos.Name("proposal"))
-			assert.NilError(t, err)
 			assert.Assert(t, edit.ID > attestation.ID)
 			assert.Equal(t, edit.Creator, proposer.Member)
 
@@ -109,10 +96,10 @@ func TestEditProposal(t *testing.T) {
 			voteToPassTD(t, env, edit)
 
 			t.Log("Member: ", closer.Member, " is closing edit proposal	: ", edit.Hash.String())
-			_, err = dao.CloseProposal(env.ctx, &env.api, env.DAO, closer.Member, edit.Hash)
+			_, err = dao.CloseProposal(env.Ctx, env.API, env.DAO, closer.Member, edit.Hash)
 			assert.NilError(t, err)
 
-			merged, err := docgraph.GetLastDocumentOfEdge(env.ctx, &env.api, env.DAO, eos.Name("attestation"))
+			merged, err := docgraph.GetLastDocumentOfEdge(env.Ctx, env.API, env.DAO, eos.Name("attestation"))
 			assert.NilError(t, err)
 			assert.Equal(t, edit.Creator, proposer.Member)
 
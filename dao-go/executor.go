@@ -0,0 +1,360 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	eostest "github.com/digital-scarcity/eos-go-test"
+	"github.com/eoscanada/eos-go"
+	"github.com/spf13/viper"
+)
+
+// ExecutorOptions configures the worker pool and rate limits of an Executor
+type ExecutorOptions struct {
+	// Concurrency is the number of transactions in flight
+	Concurrency int
+
+	// TPS is the maximum number of transactions per second, 0 for no limit
+	TPS float64
+
+	// CPUBudget is the maximum CPU, in microseconds, the transactions may be billed per second, 0 for no limit
+	CPUBudget int64
+
+	// TrxCPU is the CPU, in microseconds, each transaction is charged against the budget
+	TrxCPU int64
+}
+
+// DefaultExecutorOptions reads the concurrency, tps, cpuBudget and trxCpu settings, defaulting
+// to a single worker sending one transaction per pause
+func DefaultExecutorOptions() ExecutorOptions {
+	options := ExecutorOptions{
+		Concurrency: 1,
+		TPS:         float64(time.Second) / float64(defaultPause()),
+		TrxCPU:      2000,
+	}
+
+	if viper.IsSet("concurrency") {
+		options.Concurrency = viper.GetInt("concurrency")
+	}
+	if viper.IsSet("tps") {
+		options.TPS = viper.GetFloat64("tps")
+	}
+	if viper.IsSet("cpuBudget") {
+		options.CPUBudget = viper.GetInt64("cpuBudget")
+	}
+	if viper.IsSet("trxCpu") {
+		options.TrxCPU = viper.GetInt64("trxCpu")
+	}
+	return options
+}
+
+// phaseDependencies lists the phases that must have run before a phase can start
+var phaseDependencies = map[string][]string{
+	"migrate role":       {"migratemem"},
+	"migrate assignment": {"migratemem", "migrateper", "migrate role"},
+	"migrate payout":     {"migratemem", "migrateper"},
+	"migasspay":          {"migratemem", "migrateper", "migrate assignment"},
+	"restore settings":   {"restore root"},
+	"restore members":    {"restore root"},
+	"restore periods":    {"restore root"},
+}
+
+// Task is a transaction sent by an Executor for a single legacy record
+type Task struct {
+	// Step and Key identify the record in the journal; tasks without a step are not journaled
+	Step string
+	Key  string
+
+	// Label identifies the record in failure messages
+	Label   string
+	Actions []*eos.Action
+
+	// Pending, when set, is checked before sending; returning false skips the task
+	Pending func(ctx context.Context) bool
+}
+
+// Executor sends the transactions of a phase from a pool of workers, within the rate limits,
+// recording each outcome in the journal
+type Executor struct {
+	options   ExecutorOptions
+	journal   *Journal
+	trxBucket *tokenBucket
+	cpuBucket *tokenBucket
+
+	mutex     sync.Mutex
+	completed map[string]bool
+}
+
+// NewExecutor returns an executor recording outcomes in journal, which may be nil. The phases
+// the journal records as completed by a previous run count as completed.
+func NewExecutor(options ExecutorOptions, journal *Journal) *Executor {
+	if options.Concurrency < 1 {
+		options.Concurrency = 1
+	}
+
+	executor := Executor{
+		options:   options,
+		journal:   journal,
+		completed: make(map[string]bool),
+	}
+	for _, phase := range journal.Completed() {
+		executor.completed[phase] = true
+	}
+
+	if options.TPS > 0 {
+		executor.trxBucket = newTokenBucket(options.TPS, float64(options.Concurrency))
+	}
+	if options.CPUBudget > 0 && options.TrxCPU > 0 {
+		executor.cpuBucket = newTokenBucket(float64(options.CPUBudget), float64(options.CPUBudget))
+	}
+	return &executor
+}
+
+// MarkCompleted records phases that completed in a previous run, so the phases depending on them can start
+func (e *Executor) MarkCompleted(phases ...string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for _, phase := range phases {
+		e.completed[phase] = true
+	}
+}
+
+func (e *Executor) checkDependencies(phase string) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	var missing []string
+	for _, dependency := range phaseDependencies[phase] {
+		if !e.completed[dependency] {
+			missing = append(missing, dependency)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%v must run after %v", phase, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Run sends the tasks of a phase and returns once all of them are done. Tasks already
+// succeeded in the journal are skipped. Sequential phases use a single worker, for records
+// that depend on the previous one, such as periods.
+func (e *Executor) Run(ctx context.Context, api *eos.API, phase string, sequential bool, tasks []Task) error {
+
+	err := e.checkDependencies(phase)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%v: %d records\n", phase, len(tasks))
+	progress := newProgress(phase, len(tasks))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := e.options.Concurrency
+	if sequential {
+		workers = 1
+	}
+
+	queue := make(chan Task)
+	var wg sync.WaitGroup
+	var errMutex sync.Mutex
+	var runErr error
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range queue {
+				err := e.execute(ctx, api, phase, task, progress)
+				if err != nil {
+					errMutex.Lock()
+					if runErr == nil {
+						runErr = err
+					}
+					errMutex.Unlock()
+					cancel()
+				}
+			}
+		}()
+	}
+
+	for _, task := range tasks {
+		if task.Step != "" && e.journal.Succeeded(task.Step, task.Key) {
+			progress.add(false)
+			continue
+		}
+
+		select {
+		case queue <- task:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	progress.finish()
+
+	if runErr != nil {
+		return runErr
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	// a phase with failed records is not completed, and the phases depending on it stay blocked,
+	// until the records succeed on a later run
+	if progress.failed > 0 {
+		return nil
+	}
+	err = e.journal.Complete(phase)
+	if err != nil {
+		return err
+	}
+
+	e.MarkCompleted(phase)
+	return nil
+}
+
+func (e *Executor) execute(ctx context.Context, api *eos.API, phase string, task Task, progress *progress) error {
+
+	if task.Pending != nil && !task.Pending(ctx) {
+		progress.add(false)
+		return nil
+	}
+
+	err := e.trxBucket.take(ctx, 1)
+	if err != nil {
+		return err
+	}
+
+	err = e.cpuBucket.take(ctx, float64(e.options.TrxCPU))
+	if err != nil {
+		return err
+	}
+
+	trxID, err := eostest.ExecTrx(ctx, api, task.Actions)
+	if err != nil {
+		fmt.Println("\n\nFAILED to " + phase + ": " + task.Label)
+		fmt.Println(err)
+		fmt.Println()
+	}
+	progress.add(err != nil)
+
+	if task.Step == "" {
+		return nil
+	}
+	return e.journal.Record(task.Step, task.Key, trxID, err)
+}
+
+// tokenBucket refills at rate tokens per second up to burst tokens
+type tokenBucket struct {
+	mutex  sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate, burst float64) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// take waits until n tokens are available and removes them; a nil bucket does not limit
+func (b *tokenBucket) take(ctx context.Context, n float64) error {
+	if b == nil {
+		return nil
+	}
+
+	// a request larger than the bucket can only wait for a full bucket
+	if n > b.burst {
+		n = b.burst
+	}
+
+	for {
+		b.mutex.Lock()
+		now := time.Now()
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+
+		if b.tokens >= n {
+			b.tokens -= n
+			b.mutex.Unlock()
+			return nil
+		}
+
+		wait := time.Duration((n - b.tokens) / b.rate * float64(time.Second))
+		b.mutex.Unlock()
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// progress prints the throughput and estimated time remaining of a phase
+type progress struct {
+	mutex   sync.Mutex
+	phase   string
+	total   int
+	done    int
+	failed  int
+	start   time.Time
+	printed time.Time
+}
+
+func newProgress(phase string, total int) *progress {
+	return &progress{phase: phase, total: total, start: time.Now()}
+}
+
+func (p *progress) add(failed bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.done++
+	if failed {
+		p.failed++
+	}
+
+	if time.Since(p.printed) >= time.Second/2 || p.done == p.total {
+		p.print()
+	}
+}
+
+func (p *progress) print() {
+	p.printed = time.Now()
+	elapsed := time.Since(p.start)
+	rate := float64(p.done) / elapsed.Seconds()
+
+	eta := "-"
+	if rate > 0 {
+		eta = (time.Duration(float64(p.total-p.done)/rate) * time.Second).Round(time.Second).String()
+	}
+
+	fmt.Printf("\r%v %d/%d  failed: %d  %.2f records/s  elapsed: %v  ETA: %v   ",
+		p.phase, p.done, p.total, p.failed, rate, elapsed.Round(time.Second), eta)
+}
+
+func (p *progress) finish() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.print()
+	fmt.Println()
+}
@@ -0,0 +1,93 @@
+package dao_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/dao-contracts/dao-go"
+	"gotest.tools/assert"
+)
+
+func TestExecutorOrdering(t *testing.T) {
+
+	ctx := context.Background()
+	executor := dao.NewExecutor(dao.ExecutorOptions{Concurrency: 4}, nil)
+
+	err := executor.Run(ctx, nil, "migrate assignment", false, nil)
+	assert.Assert(t, err != nil)
+	assert.Assert(t, strings.Contains(err.Error(), "migratemem, migrateper, migrate role"))
+
+	assert.NilError(t, executor.Run(ctx, nil, "migratemem", false, nil))
+	assert.NilError(t, executor.Run(ctx, nil, "migrateper", true, nil))
+
+	// roles were migrated by a previous run
+	executor.MarkCompleted("migrate role")
+
+	checked := 0
+	tasks := make([]dao.Task, 10)
+	for index := range tasks {
+		tasks[index].Pending = func(ctx context.Context) bool {
+			checked++
+			return false
+		}
+	}
+
+	assert.NilError(t, executor.Run(ctx, nil, "migrate assignment", true, tasks))
+	assert.Equal(t, checked, 10)
+}
+
+func TestExecutorResume(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "journal")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "migration.journal")
+	ctx := context.Background()
+
+	journal, err := dao.OpenJournal(path)
+	assert.NilError(t, err)
+	executor := dao.NewExecutor(dao.ExecutorOptions{}, journal)
+	for _, phase := range []string{"migratemem", "migrateper", "migrate role"} {
+		assert.NilError(t, executor.Run(ctx, nil, phase, false, nil))
+	}
+	assert.NilError(t, journal.Close())
+
+	// a later run migrates the assignments, after the phases of the first run
+	journal, err = dao.OpenJournal(path)
+	assert.NilError(t, err)
+	defer journal.Close()
+	assert.DeepEqual(t, journal.Completed(), []string{"migrate role", "migratemem", "migrateper"})
+
+	executor = dao.NewExecutor(dao.ExecutorOptions{}, journal)
+	assert.NilError(t, executor.Run(ctx, nil, "migrate assignment", false, nil))
+	assert.NilError(t, executor.Run(ctx, nil, "migasspay", false, nil))
+	assert.Equal(t, len(journal.Failures()), 0)
+}
+
+func TestExecutorFailedPhase(t *testing.T) {
+
+	// every transaction fails on a node that rejects all requests
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	executor := dao.NewExecutor(dao.ExecutorOptions{Concurrency: 1}, nil)
+	executor.MarkCompleted("migrateper", "migrate role")
+
+	tasks := []dao.Task{{Label: "member", Actions: []*eos.Action{{Account: "dao.hypha", Name: eos.ActN("migratemem")}}}}
+	assert.NilError(t, executor.Run(ctx, eos.New(server.URL), "migratemem", false, tasks))
+
+	// the assignments stay blocked until the members that failed are migrated
+	err := executor.Run(ctx, nil, "migrate assignment", false, nil)
+	assert.ErrorContains(t, err, "migrate assignment must run after migratemem")
+}
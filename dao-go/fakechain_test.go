@@ -0,0 +1,87 @@
+package dao_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/dao-contracts/dao-go"
+	"github.com/hypha-dao/dao-contracts/dao-go/daotest"
+	"github.com/hypha-dao/document-graph/docgraph"
+	"gotest.tools/assert"
+)
+
+func TestFakeChain(t *testing.T) {
+
+	options := daotest.DefaultOptions()
+	options.NumPeriods = 5
+	options.PeriodDuration = time.Hour
+	env, fake, err := daotest.SetupFake(options)
+	assert.NilError(t, err)
+	defer fake.Close()
+
+	t.Run("Members", func(t *testing.T) {
+		members, err := dao.ListMembers(env.Ctx, env.API, env.DAO)
+		assert.NilError(t, err)
+		assert.Equal(t, len(members), len(env.Voters()))
+		assert.Equal(t, members[0].Account, env.Whale.Member)
+		assert.Equal(t, members[0].By, env.DAO)
+	})
+
+	var role, assignment docgraph.Document
+	t.Run("Role", func(t *testing.T) {
+		role = CreateRole(t, env, env.Whale, env.Members[0], role1)
+	})
+
+	t.Run("Assignment", func(t *testing.T) {
+		assignment = CreateAssignment(t, env, &role, env.Whale, env.Members[0], env.Members[1], assignment1)
+
+		_, err := dao.ClaimNextPeriod(env.Ctx, env.API, env.DAO, env.Members[1].Member, assignment.Hash)
+		assert.ErrorContains(t, err, "All available periods for this assignment have been claimed")
+	})
+
+	t.Run("Claim", func(t *testing.T) {
+		voice := dao.GetVotingPower(env.Ctx, env.API, env.TelosDecide, env.Members[1].Member)
+
+		// the assignment starts with the period after the one it was approved in
+		fake.Advance(2 * options.PeriodDuration)
+		payments, _, err := dao.ClaimNextPayments(env.Ctx, env.API, env.DAO, env.Members[1].Member, assignment.Hash)
+		assert.NilError(t, err)
+		assert.Equal(t, len(payments), 4)
+
+		husd := dao.GetBalance(env.Ctx, env.API, string(env.HusdToken), string(env.Members[1].Member))
+		assert.Equal(t, husd.String(), "30.08 HUSD")
+		hypha := dao.GetBalance(env.Ctx, env.API, string(env.HyphaToken), string(env.Members[1].Member))
+		assert.Equal(t, hypha.String(), "0.07 HYPHA")
+		assert.Equal(t, dao.GetVotingPower(env.Ctx, env.API, env.TelosDecide, env.Members[1].Member).Amount, voice.Amount+6078)
+		assert.Equal(t, dao.GetEscrowBalance(env.Ctx, env.API, string(env.SeedsEscrow), string(env.Members[1].Member)).Amount > 0, true)
+	})
+
+	t.Run("Integrity", func(t *testing.T) {
+		graph, err := dao.LoadGraph(env.Ctx, env.API, env.DAO)
+		assert.NilError(t, err)
+		report, err := dao.Check(graph)
+		assert.NilError(t, err)
+		assert.Equal(t, report.Errors(), 0)
+	})
+
+	t.Run("Cache", func(t *testing.T) {
+		cache := dao.NewGraphCache(env.API, env.DAO, dao.CacheOptions{Concurrency: 2})
+		proposals := func() int {
+			edges, err := cache.EdgesFrom(env.Ctx, env.Root.Hash.String(), eos.Name("proposal"))
+			assert.NilError(t, err)
+			return len(edges)
+		}
+		open := proposals()
+
+		_, role, err := dao.ProposeRole(env.Ctx, env.API, env.DAO, env.Whale.Member, role1)
+		assert.NilError(t, err)
+		assert.Equal(t, proposals(), open+1)
+
+		// closing erases the proposal edge, which the cache drops on its next load
+		voteToPassTD(t, env, role)
+		_, err = dao.CloseProposal(env.Ctx, env.API, env.DAO, env.Members[0].Member, role.Hash)
+		assert.NilError(t, err)
+		assert.Equal(t, proposals(), open)
+	})
+}
@@ -0,0 +1,304 @@
+package dao
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+)
+
+// Graph is an in memory copy of the documents and edges of a contract
+type Graph struct {
+	Documents []docgraph.Document
+	Edges     []docgraph.Edge
+}
+
+// LoadGraph reads every document and edge of contract
+func LoadGraph(ctx context.Context, api *eos.API, contract eos.AccountName) (*Graph, error) {
+
+	var graph Graph
+	err := loadAllRows(ctx, api, contract, "documents", &graph.Documents)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load documents %v", err)
+	}
+
+	err = loadAllRows(ctx, api, contract, "edges", &graph.Edges)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load edges %v", err)
+	}
+	return &graph, nil
+}
+
+// Graph returns the documents and edges of the archive
+func (a *GraphArchive) Graph() (*Graph, error) {
+
+	documents, err := a.Documents()
+	if err != nil {
+		return nil, err
+	}
+
+	edges, err := a.Edges()
+	if err != nil {
+		return nil, err
+	}
+	return &Graph{Documents: documents, Edges: edges}, nil
+}
+
+// loadGraphDump reads the documents.json and edges.json table dumps in folder
+func loadGraphDump(folder string) (*Graph, error) {
+
+	var graph Graph
+	for file, rows := range map[string]interface{}{"documents.json": &graph.Documents, "edges.json": &graph.Edges} {
+		data, err := ioutil.ReadFile(filepath.Join(folder, file))
+		if err != nil {
+			return nil, fmt.Errorf("cannot read %v: %v", file, err)
+		}
+
+		err = json.Unmarshal(data, rows)
+		if err != nil {
+			return nil, fmt.Errorf("cannot unmarshal %v: %v", file, err)
+		}
+	}
+	return &graph, nil
+}
+
+// OpenGraph loads the graph of contract from an endpoint when from is an http(s) URL, from the
+// documents.json and edges.json dumps when from is a folder, and from a graph archive otherwise
+func OpenGraph(ctx context.Context, from string, contract eos.AccountName) (*Graph, error) {
+	if strings.HasPrefix(from, "http://") || strings.HasPrefix(from, "https://") {
+		return LoadGraph(ctx, eos.New(from), contract)
+	}
+
+	info, err := os.Stat(from)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open graph %v: %v", from, err)
+	}
+	if info.IsDir() {
+		return loadGraphDump(from)
+	}
+
+	archive, err := LoadGraphArchive(from)
+	if err != nil {
+		return nil, err
+	}
+	return archive.Graph()
+}
+
+// DocumentType returns the type of a document, or an empty string for untyped documents
+func DocumentType(document docgraph.Document) string {
+	documentType, err := getName(document, "type")
+	if err != nil {
+		return ""
+	}
+	return string(documentType)
+}
+
+// NodeLabel returns the node_label of a document, falling back to its type and then to its short hash
+func NodeLabel(document docgraph.Document) string {
+	label, err := document.GetContent("node_label")
+	if err == nil && label != nil {
+		if value := contentValueString(label); value != "" {
+			return value
+		}
+	}
+
+	if documentType := DocumentType(document); documentType != "" {
+		return documentType
+	}
+
+	hash := document.Hash.String()
+	if len(hash) > 8 {
+		return hash[:8]
+	}
+	return hash
+}
+
+// GraphFilter selects the part of a graph to keep; empty fields do not filter
+type GraphFilter struct {
+	// Types are the document types to keep
+	Types []string
+
+	// EdgeNames are the edge names to keep
+	EdgeNames []eos.Name
+
+	// Start is the hash of the document the traversal starts from
+	Start string
+
+	// Depth is the maximum number of edges followed from Start, 0 for no limit
+	Depth int
+}
+
+// Filter returns the documents and edges of the graph selected by filter. Edges are kept
+// when both of their documents are kept.
+func (g *Graph) Filter(filter GraphFilter) *Graph {
+
+	edgeNames := make(map[eos.Name]bool)
+	for _, edgeName := range filter.EdgeNames {
+		edgeNames[edgeName] = true
+	}
+
+	var edges []docgraph.Edge
+	for _, edge := range g.Edges {
+		if len(edgeNames) == 0 || edgeNames[edge.EdgeName] {
+			edges = append(edges, edge)
+		}
+	}
+
+	var reachable map[string]bool
+	if filter.Start != "" {
+		reachable = reachableFrom(edges, filter.Start, filter.Depth)
+	}
+
+	types := make(map[string]bool)
+	for _, documentType := range filter.Types {
+		types[documentType] = true
+	}
+
+	var filtered Graph
+	kept := make(map[string]bool)
+	for _, document := range g.Documents {
+		hash := document.Hash.String()
+		if reachable != nil && !reachable[hash] {
+			continue
+		}
+		if len(types) > 0 && !types[DocumentType(document)] {
+			continue
+		}
+		kept[hash] = true
+		filtered.Documents = append(filtered.Documents, document)
+	}
+
+	for _, edge := range edges {
+		if kept[edge.FromNode.String()] && kept[edge.ToNode.String()] {
+			filtered.Edges = append(filtered.Edges, edge)
+		}
+	}
+	return &filtered
+}
+
+// reachableFrom follows the outgoing edges from start, breadth first, up to depth edges away
+func reachableFrom(edges []docgraph.Edge, start string, depth int) map[string]bool {
+
+	outgoing := make(map[string][]string)
+	for _, edge := range edges {
+		from := edge.FromNode.String()
+		outgoing[from] = append(outgoing[from], edge.ToNode.String())
+	}
+
+	reachable := map[string]bool{start: true}
+	frontier := []string{start}
+	for level := 0; len(frontier) > 0 && (depth == 0 || level < depth); level++ {
+		var next []string
+		for _, hash := range frontier {
+			for _, to := range outgoing[hash] {
+				if !reachable[to] {
+					reachable[to] = true
+					next = append(next, to)
+				}
+			}
+		}
+		frontier = next
+	}
+	return reachable
+}
+
+// graphIndex looks up the documents of a graph by hash, type and creator, and their edges by direction and name
+type graphIndex struct {
+	graph      *Graph
+	documents  map[string]docgraph.Document
+	byType     map[string][]docgraph.Document
+	byCreator  map[eos.AccountName][]docgraph.Document
+	outgoing   map[string][]docgraph.Edge
+	incoming   map[string][]docgraph.Edge
+	byEdgeName map[eos.Name][]docgraph.Edge
+	edgeKeys   map[string]bool
+}
+
+func newGraphIndex() *graphIndex {
+	return &graphIndex{
+		graph:      &Graph{},
+		documents:  make(map[string]docgraph.Document),
+		byType:     make(map[string][]docgraph.Document),
+		byCreator:  make(map[eos.AccountName][]docgraph.Document),
+		outgoing:   make(map[string][]docgraph.Edge),
+		incoming:   make(map[string][]docgraph.Edge),
+		byEdgeName: make(map[eos.Name][]docgraph.Edge),
+		edgeKeys:   make(map[string]bool),
+	}
+}
+
+func (g *Graph) index() *graphIndex {
+	index := newGraphIndex()
+	for _, document := range g.Documents {
+		index.addDocument(document)
+	}
+	for _, edge := range g.Edges {
+		index.addEdge(edge)
+	}
+	return index
+}
+
+// addDocument indexes a document, ignoring documents already indexed
+func (i *graphIndex) addDocument(document docgraph.Document) bool {
+	hash := document.Hash.String()
+	if _, ok := i.documents[hash]; ok {
+		return false
+	}
+
+	i.graph.Documents = append(i.graph.Documents, document)
+	i.documents[hash] = document
+
+	documentType := DocumentType(document)
+	i.byType[documentType] = append(i.byType[documentType], document)
+	i.byCreator[document.Creator] = append(i.byCreator[document.Creator], document)
+	return true
+}
+
+// addEdge indexes an edge, ignoring edges already indexed
+func (i *graphIndex) addEdge(edge docgraph.Edge) bool {
+	from, to := edge.FromNode.String(), edge.ToNode.String()
+	key := edgeKey(from, string(edge.EdgeName), to)
+	if i.edgeKeys[key] {
+		return false
+	}
+
+	i.graph.Edges = append(i.graph.Edges, edge)
+	i.edgeKeys[key] = true
+	i.outgoing[from] = append(i.outgoing[from], edge)
+	i.incoming[to] = append(i.incoming[to], edge)
+	i.byEdgeName[edge.EdgeName] = append(i.byEdgeName[edge.EdgeName], edge)
+	return true
+}
+
+// edgesFrom returns the edges named edgeName leaving hash
+func (i *graphIndex) edgesFrom(hash string, edgeName eos.Name) []docgraph.Edge {
+	var edges []docgraph.Edge
+	for _, edge := range i.outgoing[hash] {
+		if edge.EdgeName == edgeName {
+			edges = append(edges, edge)
+		}
+	}
+	return edges
+}
+
+// edgesTo returns the edges named edgeName reaching hash
+func (i *graphIndex) edgesTo(hash string, edgeName eos.Name) []docgraph.Edge {
+	var edges []docgraph.Edge
+	for _, edge := range i.incoming[hash] {
+		if edge.EdgeName == edgeName {
+			edges = append(edges, edge)
+		}
+	}
+	return edges
+}
+
+// ofType returns the documents of a type, in graph order
+func (i *graphIndex) ofType(documentType string) []docgraph.Document {
+	return i.byType[documentType]
+}
@@ -0,0 +1,406 @@
+package dao
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+	"github.com/spf13/viper"
+)
+
+// CacheOptions configures how a GraphCache loads and refreshes the graph
+type CacheOptions struct {
+	// Staleness is how old the cache may get before a read refreshes it; 0 refreshes on every read
+	Staleness time.Duration
+
+	// ReloadAfter is how old the last full load may get before a read reloads the graph, dropping
+	// erased documents and edges that an incremental refresh cannot see, such as the proposal edges
+	// removed on close and replaced votes; 0 reloads the whole graph whenever it is stale
+	ReloadAfter time.Duration
+
+	// Concurrency is the number of key ranges of each table loaded in parallel
+	Concurrency int
+
+	// DocumentsCreatedIndex and EdgesCreatedIndex are the index positions of the created date
+	// secondary indexes of the documents and edges tables, used by the incremental refresh
+	DocumentsCreatedIndex string
+	EdgesCreatedIndex     string
+}
+
+// DefaultCacheOptions reads the cacheStaleness, cacheReloadAfter and cacheConcurrency settings,
+// defaulting to a 30 second staleness, a full reload every 5 minutes and 4 concurrent ranges
+func DefaultCacheOptions() CacheOptions {
+	options := CacheOptions{
+		Staleness:             30 * time.Second,
+		ReloadAfter:           5 * time.Minute,
+		Concurrency:           4,
+		DocumentsCreatedIndex: "4",
+		EdgesCreatedIndex:     "5",
+	}
+
+	if viper.IsSet("cacheStaleness") {
+		options.Staleness = viper.GetDuration("cacheStaleness")
+	}
+	if viper.IsSet("cacheReloadAfter") {
+		options.ReloadAfter = viper.GetDuration("cacheReloadAfter")
+	}
+	if viper.IsSet("cacheConcurrency") {
+		options.Concurrency = viper.GetInt("cacheConcurrency")
+	}
+	return options
+}
+
+// GraphCache holds the documents and edges of a contract in memory, indexed by hash, type, creator,
+// edge name and direction. Reads refresh the cache incrementally, with the rows created since the
+// newest row loaded, once it is older than the configured staleness, and reload it entirely once the
+// last load is older than ReloadAfter, so erased rows are served at most that long. A GraphCache is a
+// QuerySource, passed to the functions of this package that take one.
+type GraphCache struct {
+	api      *eos.API
+	contract eos.AccountName
+	options  CacheOptions
+
+	// loading serializes loads and refreshes; mutex guards the index
+	loading     sync.Mutex
+	mutex       sync.RWMutex
+	index       *graphIndex
+	lastCreated eos.TimePoint
+	loadedAt    time.Time
+	refreshedAt time.Time
+}
+
+// NewGraphCache returns an empty cache of the graph of contract, loaded on first read
+func NewGraphCache(api *eos.API, contract eos.AccountName, options CacheOptions) *GraphCache {
+	if options.Concurrency < 1 {
+		options.Concurrency = 1
+	}
+	return &GraphCache{api: api, contract: contract, options: options}
+}
+
+// tableKeyRange returns the lowest and highest primary keys of a table, and false when it is empty
+func tableKeyRange(ctx context.Context, api *eos.API, contract eos.AccountName, table string) (uint64, uint64, bool, error) {
+
+	var bounds [2]uint64
+	for i, reverse := range []bool{false, true} {
+		var request eos.GetTableRowsRequest
+		request.Code = string(contract)
+		request.Scope = string(contract)
+		request.Table = table
+		request.Limit = 1
+		request.Reverse = reverse
+		request.JSON = true
+		response, err := api.GetTableRows(ctx, request)
+		if err != nil {
+			return 0, 0, false, fmt.Errorf("get table rows %v", err)
+		}
+
+		var rows []tableRowID
+		err = response.JSONToStructs(&rows)
+		if err != nil {
+			return 0, 0, false, fmt.Errorf("json to structs %v", err)
+		}
+		if len(rows) == 0 {
+			return 0, 0, false, nil
+		}
+		bounds[i] = rows[0].ID
+	}
+	return bounds[0], bounds[1], true, nil
+}
+
+// KeyRanges splits the primary keys from lowest to highest, both included, in at most count contiguous ranges
+func KeyRanges(lowest, highest uint64, count int) [][2]uint64 {
+	if count < 1 {
+		count = 1
+	}
+
+	width := (highest-lowest)/uint64(count) + 1
+	var ranges [][2]uint64
+	for lower := lowest; ; lower += width {
+		if width > highest-lower {
+			return append(ranges, [2]uint64{lower, highest})
+		}
+		ranges = append(ranges, [2]uint64{lower, lower + width - 1})
+	}
+}
+
+// loadTable reads every row of a table, splitting its primary keys in ranges read concurrently
+func (c *GraphCache) loadTable(ctx context.Context, table string) ([]json.RawMessage, error) {
+
+	lowest, highest, ok, err := tableKeyRange(ctx, c.api, c.contract, table)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	ranges := KeyRanges(lowest, highest, c.options.Concurrency)
+	results := make([][]json.RawMessage, len(ranges))
+	errs := make([]error, len(ranges))
+
+	var wg sync.WaitGroup
+	for i, keyRange := range ranges {
+		wg.Add(1)
+		go func(i int, lower, upper uint64) {
+			defer wg.Done()
+			results[i], errs[i] = getRowRange(ctx, c.api, c.contract, table, lower, upper)
+		}(i, keyRange[0], keyRange[1])
+	}
+	wg.Wait()
+
+	var rows []json.RawMessage
+	for i := range results {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		rows = append(rows, results[i]...)
+	}
+	return rows, nil
+}
+
+// getRowsCreatedSince reads the rows of a table created at or after since, through its created date index
+func getRowsCreatedSince(ctx context.Context, api *eos.API, contract eos.AccountName, table, index string, since eos.TimePoint) ([]json.RawMessage, error) {
+	return getIndexedRows(ctx, api, contract, IndexQuery{
+		Index: TableIndex{Table: table, Position: index, KeyType: "i64"},
+		Lower: TimeKey(ToTime(since)),
+		Limit: 1000,
+	})
+}
+
+func decodeGraphRows(documentRows, edgeRows []json.RawMessage) ([]docgraph.Document, []docgraph.Edge, error) {
+
+	documents := make([]docgraph.Document, len(documentRows))
+	for i, row := range documentRows {
+		if err := json.Unmarshal(row, &documents[i]); err != nil {
+			return nil, nil, fmt.Errorf("cannot unmarshal document %v", err)
+		}
+	}
+
+	edges := make([]docgraph.Edge, len(edgeRows))
+	for i, row := range edgeRows {
+		if err := json.Unmarshal(row, &edges[i]); err != nil {
+			return nil, nil, fmt.Errorf("cannot unmarshal edge %v", err)
+		}
+	}
+	return documents, edges, nil
+}
+
+func newestCreated(documents []docgraph.Document, edges []docgraph.Edge, newest eos.TimePoint) eos.TimePoint {
+	for _, document := range documents {
+		if document.CreatedDate > newest {
+			newest = document.CreatedDate
+		}
+	}
+	for _, edge := range edges {
+		if edge.CreatedDate > newest {
+			newest = edge.CreatedDate
+		}
+	}
+	return newest
+}
+
+// Load reads the whole graph, replacing the cached one
+func (c *GraphCache) Load(ctx context.Context) error {
+	c.loading.Lock()
+	defer c.loading.Unlock()
+
+	documentRows, err := c.loadTable(ctx, "documents")
+	if err != nil {
+		return fmt.Errorf("cannot load documents %v", err)
+	}
+
+	edgeRows, err := c.loadTable(ctx, "edges")
+	if err != nil {
+		return fmt.Errorf("cannot load edges %v", err)
+	}
+
+	documents, edges, err := decodeGraphRows(documentRows, edgeRows)
+	if err != nil {
+		return err
+	}
+
+	index := (&Graph{Documents: documents, Edges: edges}).index()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.index = index
+	c.lastCreated = newestCreated(documents, edges, 0)
+	c.loadedAt = time.Now()
+	c.refreshedAt = c.loadedAt
+	return nil
+}
+
+// Refresh adds the documents and edges created since the newest one in the cache
+func (c *GraphCache) Refresh(ctx context.Context) error {
+	c.mutex.RLock()
+	loaded, since := c.index != nil, c.lastCreated
+	c.mutex.RUnlock()
+
+	if !loaded {
+		return c.Load(ctx)
+	}
+
+	c.loading.Lock()
+	defer c.loading.Unlock()
+
+	documentRows, err := getRowsCreatedSince(ctx, c.api, c.contract, "documents", c.options.DocumentsCreatedIndex, since)
+	if err != nil {
+		return fmt.Errorf("cannot refresh documents %v", err)
+	}
+
+	edgeRows, err := getRowsCreatedSince(ctx, c.api, c.contract, "edges", c.options.EdgesCreatedIndex, since)
+	if err != nil {
+		return fmt.Errorf("cannot refresh edges %v", err)
+	}
+
+	documents, edges, err := decodeGraphRows(documentRows, edgeRows)
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, document := range documents {
+		c.index.addDocument(document)
+	}
+	for _, edge := range edges {
+		c.index.addEdge(edge)
+	}
+	c.lastCreated = newestCreated(documents, edges, c.lastCreated)
+	c.refreshedAt = time.Now()
+	return nil
+}
+
+// fresh reloads the cache when the last load is older than ReloadAfter, and otherwise refreshes it
+// when it is older than the configured staleness
+func (c *GraphCache) fresh(ctx context.Context) error {
+	c.mutex.RLock()
+	loaded, loadedAt, refreshedAt := c.index != nil, c.loadedAt, c.refreshedAt
+	c.mutex.RUnlock()
+
+	expired := c.options.ReloadAfter > 0 && time.Since(loadedAt) > c.options.ReloadAfter
+	stale := time.Since(refreshedAt) >= c.options.Staleness
+
+	switch {
+	case !loaded, expired, stale && c.options.ReloadAfter == 0:
+		return c.Load(ctx)
+	case stale:
+		return c.Refresh(ctx)
+	}
+	return nil
+}
+
+// Graph returns a copy of the cached documents and edges
+func (c *GraphCache) Graph(ctx context.Context) (*Graph, error) {
+	if err := c.fresh(ctx); err != nil {
+		return nil, err
+	}
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return &Graph{
+		Documents: append([]docgraph.Document{}, c.index.graph.Documents...),
+		Edges:     append([]docgraph.Edge{}, c.index.graph.Edges...),
+	}, nil
+}
+
+// Root returns the root document of the DAO
+func (c *GraphCache) Root(ctx context.Context) (docgraph.Document, error) {
+	root, err := c.Document(ctx, RootHash(c.contract).String())
+	if err != nil {
+		return docgraph.Document{}, err
+	}
+	return root, validateRoot(root, c.contract)
+}
+
+// Document returns a document by hash, reading it from the node and caching it when it is newer than the cache
+func (c *GraphCache) Document(ctx context.Context, hash string) (docgraph.Document, error) {
+	if err := c.fresh(ctx); err != nil {
+		return docgraph.Document{}, err
+	}
+
+	c.mutex.RLock()
+	document, ok := c.index.documents[hash]
+	c.mutex.RUnlock()
+	if ok {
+		return document, nil
+	}
+
+	document, err := docgraph.LoadDocument(ctx, c.api, c.contract, hash)
+	if err != nil {
+		return docgraph.Document{}, err
+	}
+
+	c.mutex.Lock()
+	c.index.addDocument(document)
+	c.mutex.Unlock()
+	return document, nil
+}
+
+// Documents returns every cached document
+func (c *GraphCache) Documents(ctx context.Context) ([]docgraph.Document, error) {
+	graph, err := c.Graph(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return graph.Documents, nil
+}
+
+// DocumentsOfType returns the cached documents of a type
+func (c *GraphCache) DocumentsOfType(ctx context.Context, documentType string) ([]docgraph.Document, error) {
+	if err := c.fresh(ctx); err != nil {
+		return nil, err
+	}
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return append([]docgraph.Document{}, c.index.byType[documentType]...), nil
+}
+
+// DocumentsByCreator returns the cached documents created by an account
+func (c *GraphCache) DocumentsByCreator(ctx context.Context, creator eos.AccountName) ([]docgraph.Document, error) {
+	if err := c.fresh(ctx); err != nil {
+		return nil, err
+	}
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return append([]docgraph.Document{}, c.index.byCreator[creator]...), nil
+}
+
+// EdgesNamed returns the cached edges with a name
+func (c *GraphCache) EdgesNamed(ctx context.Context, edgeName eos.Name) ([]docgraph.Edge, error) {
+	if err := c.fresh(ctx); err != nil {
+		return nil, err
+	}
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return append([]docgraph.Edge{}, c.index.byEdgeName[edgeName]...), nil
+}
+
+// EdgesFrom returns the cached edges named edgeName leaving hash, or all of them when edgeName is empty
+func (c *GraphCache) EdgesFrom(ctx context.Context, hash string, edgeName eos.Name) ([]docgraph.Edge, error) {
+	if err := c.fresh(ctx); err != nil {
+		return nil, err
+	}
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.index.EdgesFrom(ctx, hash, edgeName)
+}
+
+// EdgesTo returns the cached edges named edgeName reaching hash, or all of them when edgeName is empty
+func (c *GraphCache) EdgesTo(ctx context.Context, hash string, edgeName eos.Name) ([]docgraph.Edge, error) {
+	if err := c.fresh(ctx); err != nil {
+		return nil, err
+	}
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.index.EdgesTo(ctx, hash, edgeName)
+}
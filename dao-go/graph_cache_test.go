@@ -0,0 +1,16 @@
+package dao_test
+
+import (
+	"testing"
+
+	"github.com/hypha-dao/dao-contracts/dao-go"
+	"gotest.tools/assert"
+)
+
+func TestKeyRanges(t *testing.T) {
+
+	assert.DeepEqual(t, dao.KeyRanges(0, 9, 4), [][2]uint64{{0, 2}, {3, 5}, {6, 8}, {9, 9}})
+	assert.DeepEqual(t, dao.KeyRanges(5, 5, 4), [][2]uint64{{5, 5}})
+	assert.DeepEqual(t, dao.KeyRanges(10, 13, 0), [][2]uint64{{10, 13}})
+	assert.DeepEqual(t, dao.KeyRanges(0, ^uint64(0), 2), [][2]uint64{{0, 1<<63 - 1}, {1 << 63, ^uint64(0)}})
+}
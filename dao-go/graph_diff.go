@@ -0,0 +1,303 @@
+package dao
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/hypha-dao/document-graph/docgraph"
+)
+
+const (
+	// DiffAdded marks a document or edge only present in the new graph
+	DiffAdded = "added"
+
+	// DiffRemoved marks a document or edge only present in the old graph
+	DiffRemoved = "removed"
+
+	// DiffChanged marks a document matched by logical identity whose content changed
+	DiffChanged = "changed"
+)
+
+// ContentDiff is a content item whose value differs between two versions of a document
+type ContentDiff struct {
+	Item string `json:"item"`
+	Old  string `json:"old"`
+	New  string `json:"new"`
+}
+
+// DocumentChange is a document added, removed or changed between two graphs
+type DocumentChange struct {
+	Kind     string        `json:"kind"`
+	Identity string        `json:"identity"`
+	Type     string        `json:"type,omitempty"`
+	Label    string        `json:"label"`
+	OldHash  string        `json:"old_hash,omitempty"`
+	NewHash  string        `json:"new_hash,omitempty"`
+	Diffs    []ContentDiff `json:"diffs,omitempty"`
+}
+
+// EdgeChange is an edge added or removed between two graphs, with its ends named by logical identity
+type EdgeChange struct {
+	Kind     string `json:"kind"`
+	From     string `json:"from"`
+	EdgeName string `json:"edge_name"`
+	To       string `json:"to"`
+}
+
+// GraphDiff lists the changes from an old graph to a new one
+type GraphDiff struct {
+	UnchangedDocuments int              `json:"unchanged_documents"`
+	UnchangedEdges     int              `json:"unchanged_edges"`
+	Documents          []DocumentChange `json:"documents"`
+	Edges              []EdgeChange     `json:"edges"`
+}
+
+// LogicalIdentity names a document independently of its hash, so edited documents can be matched:
+// members by name, periods by label, proposals by ballot_id, and the root and settings by type.
+// Documents without a logical identity return an empty string.
+func LogicalIdentity(document docgraph.Document) string {
+	documentType := DocumentType(document)
+
+	if ballotID, err := getName(document, "ballot_id"); err == nil {
+		return "ballot/" + string(ballotID)
+	}
+
+	switch documentType {
+	case "member":
+		if member, err := getName(document, "member"); err == nil {
+			return "member/" + string(member)
+		}
+	case "period":
+		if label, err := document.GetContent("label"); err == nil && label != nil {
+			return "period/" + contentValueString(label)
+		}
+	case "dho", "settings":
+		return documentType
+	}
+	return ""
+}
+
+// flattenContent maps each content item of a document, named group/label, to its value
+func flattenContent(document docgraph.Document) map[string]string {
+	content := make(map[string]string)
+	for index, group := range document.ContentGroups {
+		groupLabel := strconv.Itoa(index)
+		for _, item := range group {
+			if item.Label == "content_group_label" && item.Value != nil {
+				groupLabel = contentValueString(item.Value)
+			}
+		}
+
+		for _, item := range group {
+			if item.Label == "content_group_label" {
+				continue
+			}
+			content[groupLabel+"/"+item.Label] = contentValueString(item.Value)
+		}
+	}
+	return content
+}
+
+func diffContent(before, after docgraph.Document) []ContentDiff {
+	oldContent, newContent := flattenContent(before), flattenContent(after)
+
+	var items []string
+	for item := range oldContent {
+		items = append(items, item)
+	}
+	for item := range newContent {
+		if _, ok := oldContent[item]; !ok {
+			items = append(items, item)
+		}
+	}
+	sort.Strings(items)
+
+	var diffs []ContentDiff
+	for _, item := range items {
+		oldValue, inOld := oldContent[item]
+		newValue, inNew := newContent[item]
+		if !inOld {
+			oldValue = absentValue
+		}
+		if !inNew {
+			newValue = absentValue
+		}
+		if oldValue != newValue {
+			diffs = append(diffs, ContentDiff{Item: item, Old: oldValue, New: newValue})
+		}
+	}
+	return diffs
+}
+
+// documentIdentity returns the logical identity of a document, or its hash when it has none
+func documentIdentity(document docgraph.Document) string {
+	if identity := LogicalIdentity(document); identity != "" {
+		return identity
+	}
+	return document.Hash.String()
+}
+
+// DiffGraphs compares two graphs. Documents with the same hash are unchanged; the remaining ones
+// are matched by logical identity and reported as changed, the others as added or removed. Edges
+// are compared by the identities of their ends, so edges of edited documents are not reported.
+func DiffGraphs(before, after *Graph) GraphDiff {
+
+	diff := GraphDiff{Documents: []DocumentChange{}, Edges: []EdgeChange{}}
+
+	newHashes := make(map[string]bool)
+	for _, document := range after.Documents {
+		newHashes[document.Hash.String()] = true
+	}
+
+	oldHashes := make(map[string]bool)
+	oldByIdentity := make(map[string][]docgraph.Document)
+	var oldIdentities []string
+	for _, document := range before.Documents {
+		hash := document.Hash.String()
+		oldHashes[hash] = true
+		if newHashes[hash] {
+			diff.UnchangedDocuments++
+			continue
+		}
+
+		identity := documentIdentity(document)
+		if _, ok := oldByIdentity[identity]; !ok {
+			oldIdentities = append(oldIdentities, identity)
+		}
+		oldByIdentity[identity] = append(oldByIdentity[identity], document)
+	}
+
+	for _, document := range after.Documents {
+		hash := document.Hash.String()
+		if oldHashes[hash] {
+			continue
+		}
+
+		identity := documentIdentity(document)
+		change := DocumentChange{
+			Kind:     DiffAdded,
+			Identity: identity,
+			Type:     DocumentType(document),
+			Label:    NodeLabel(document),
+			NewHash:  hash,
+		}
+
+		if matches := oldByIdentity[identity]; len(matches) > 0 {
+			oldDocument := matches[0]
+			oldByIdentity[identity] = matches[1:]
+
+			change.Kind = DiffChanged
+			change.OldHash = oldDocument.Hash.String()
+			change.Diffs = diffContent(oldDocument, document)
+		}
+		diff.Documents = append(diff.Documents, change)
+	}
+
+	for _, identity := range oldIdentities {
+		for _, document := range oldByIdentity[identity] {
+			diff.Documents = append(diff.Documents, DocumentChange{
+				Kind:     DiffRemoved,
+				Identity: identity,
+				Type:     DocumentType(document),
+				Label:    NodeLabel(document),
+				OldHash:  document.Hash.String(),
+			})
+		}
+	}
+
+	oldEdges := edgeIdentities(before)
+	newEdges := edgeIdentities(after)
+	for _, edge := range newEdges.order {
+		if oldEdges.set[edge] {
+			diff.UnchangedEdges++
+			continue
+		}
+		change := newEdges.changes[edge]
+		change.Kind = DiffAdded
+		diff.Edges = append(diff.Edges, change)
+	}
+	for _, edge := range oldEdges.order {
+		if !newEdges.set[edge] {
+			change := oldEdges.changes[edge]
+			change.Kind = DiffRemoved
+			diff.Edges = append(diff.Edges, change)
+		}
+	}
+	return diff
+}
+
+type edgeSet struct {
+	order   []string
+	set     map[string]bool
+	changes map[string]EdgeChange
+}
+
+// edgeIdentities names the edges of a graph by the identities of their ends, in graph order
+func edgeIdentities(g *Graph) edgeSet {
+	identities := make(map[string]string)
+	for _, document := range g.Documents {
+		identities[document.Hash.String()] = documentIdentity(document)
+	}
+
+	identity := func(hash string) string {
+		if id, ok := identities[hash]; ok {
+			return id
+		}
+		return hash
+	}
+
+	edges := edgeSet{set: make(map[string]bool), changes: make(map[string]EdgeChange)}
+	for _, edge := range g.Edges {
+		change := EdgeChange{
+			From:     identity(edge.FromNode.String()),
+			EdgeName: string(edge.EdgeName),
+			To:       identity(edge.ToNode.String()),
+		}
+
+		key := edgeKey(change.From, change.EdgeName, change.To)
+		if !edges.set[key] {
+			edges.set[key] = true
+			edges.order = append(edges.order, key)
+			edges.changes[key] = change
+		}
+	}
+	return edges
+}
+
+// Print writes the diff in a human readable format
+func (d *GraphDiff) Print(w io.Writer) {
+	fmt.Fprintf(w, "\nGraph diff: %d documents and %d edges unchanged\n", d.UnchangedDocuments, d.UnchangedEdges)
+
+	counts := make(map[string]int)
+	for _, change := range d.Documents {
+		counts[change.Kind]++
+	}
+	fmt.Fprintf(w, "documents   added: %6d  removed: %6d  changed: %6d\n", counts[DiffAdded], counts[DiffRemoved], counts[DiffChanged])
+
+	counts = make(map[string]int)
+	for _, change := range d.Edges {
+		counts[change.Kind]++
+	}
+	fmt.Fprintf(w, "edges       added: %6d  removed: %6d\n", counts[DiffAdded], counts[DiffRemoved])
+
+	symbols := map[string]string{DiffAdded: "+", DiffRemoved: "-", DiffChanged: "~"}
+	for _, change := range d.Documents {
+		fmt.Fprintf(w, "\n%v %v %v (%v)", symbols[change.Kind], change.Identity, change.Label, change.Type)
+		if change.OldHash != "" && change.NewHash != "" {
+			fmt.Fprintf(w, "\n    %v -> %v", change.OldHash, change.NewHash)
+		}
+		fmt.Fprintln(w)
+		for _, diff := range change.Diffs {
+			fmt.Fprintf(w, "    %v:\n      - %v\n      + %v\n", diff.Item, diff.Old, diff.New)
+		}
+	}
+
+	if len(d.Edges) > 0 {
+		fmt.Fprintln(w)
+	}
+	for _, change := range d.Edges {
+		fmt.Fprintf(w, "%v %v -%v-> %v\n", symbols[change.Kind], change.From, change.EdgeName, change.To)
+	}
+}
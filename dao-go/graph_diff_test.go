@@ -0,0 +1,71 @@
+package dao_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/dao-contracts/dao-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+	"gotest.tools/assert"
+)
+
+func withDetails(document docgraph.Document, label string, typeID uint32, impl interface{}) docgraph.Document {
+	details := docgraph.ContentGroup{
+		{Label: "content_group_label", Value: &docgraph.FlexValue{BaseVariant: eos.BaseVariant{TypeID: 1, Impl: "details"}}},
+		{Label: label, Value: &docgraph.FlexValue{BaseVariant: eos.BaseVariant{TypeID: typeID, Impl: impl}}},
+	}
+	document.ContentGroups = append([]docgraph.ContentGroup{details}, document.ContentGroups...)
+	return document
+}
+
+func TestGraphDiff(t *testing.T) {
+
+	root := testDocument(t, "1", "dho", "Hypha DHO Root")
+	member := withDetails(testDocument(t, "2", "member", "johnnyhypha1"), "member", 0, eos.Name("johnnyhypha1"))
+	q1 := withDetails(testDocument(t, "3", "period", "Q1"), "label", 1, "Q1")
+	q1Edited := withDetails(testDocument(t, "4", "period", "Q1 2021"), "label", 1, "Q1")
+	badge := testDocument(t, "5", "badge", "Healer")
+	role := testDocument(t, "6", "role", "Basketweaver")
+
+	old := &dao.Graph{
+		Documents: []docgraph.Document{root, member, q1, badge},
+		Edges: []docgraph.Edge{
+			testEdge(root, member, "member"),
+			testEdge(root, q1, "start"),
+			testEdge(root, badge, "badge"),
+		},
+	}
+
+	updated := &dao.Graph{
+		Documents: []docgraph.Document{root, member, q1Edited, role},
+		Edges: []docgraph.Edge{
+			testEdge(root, member, "member"),
+			testEdge(root, q1Edited, "start"),
+			testEdge(root, role, "role"),
+		},
+	}
+
+	diff := dao.DiffGraphs(old, updated)
+	assert.Equal(t, diff.UnchangedDocuments, 2)
+	assert.Equal(t, diff.UnchangedEdges, 2)
+
+	assert.Equal(t, len(diff.Documents), 3)
+	assert.Equal(t, diff.Documents[0].Kind, dao.DiffChanged)
+	assert.Equal(t, diff.Documents[0].Identity, "period/Q1")
+	assert.DeepEqual(t, diff.Documents[0].Diffs, []dao.ContentDiff{{Item: "1/node_label", Old: "Q1", New: "Q1 2021"}})
+	assert.Equal(t, diff.Documents[1].Kind, dao.DiffAdded)
+	assert.Equal(t, diff.Documents[1].Label, "Basketweaver")
+	assert.Equal(t, diff.Documents[2].Kind, dao.DiffRemoved)
+	assert.Equal(t, diff.Documents[2].Label, "Healer")
+
+	assert.Equal(t, len(diff.Edges), 2)
+	assert.DeepEqual(t, diff.Edges[0], dao.EdgeChange{Kind: dao.DiffAdded, From: "dho", EdgeName: "role", To: role.Hash.String()})
+	assert.DeepEqual(t, diff.Edges[1], dao.EdgeChange{Kind: dao.DiffRemoved, From: "dho", EdgeName: "badge", To: badge.Hash.String()})
+
+	var out bytes.Buffer
+	diff.Print(&out)
+	assert.Assert(t, strings.Contains(out.String(), "~ period/Q1 Q1 2021 (period)"))
+	assert.Assert(t, strings.Contains(out.String(), "- dho -badge-> "+badge.Hash.String()))
+}
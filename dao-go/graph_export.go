@@ -0,0 +1,195 @@
+package dao
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hypha-dao/document-graph/docgraph"
+)
+
+// ExportGraph writes the graph to w in format, one of dot, graphml or cypher
+func ExportGraph(w io.Writer, g *Graph, format string) error {
+	switch format {
+	case "dot":
+		return WriteDOT(w, g)
+	case "graphml":
+		return WriteGraphML(w, g)
+	case "cypher":
+		return WriteCypher(w, g)
+	}
+	return fmt.Errorf("unknown graph format %v, expected dot, graphml or cypher", format)
+}
+
+func dotQuote(value string) string {
+	return strconv.Quote(value)
+}
+
+// WriteDOT writes the graph in Graphviz DOT, with documents labelled by their node label and type
+func WriteDOT(w io.Writer, g *Graph) error {
+	out := bufio.NewWriter(w)
+
+	fmt.Fprintln(out, "digraph dao {")
+	fmt.Fprintln(out, "  node [shape=box, style=rounded];")
+	for _, document := range g.Documents {
+		label := NodeLabel(document)
+		if documentType := DocumentType(document); documentType != "" && documentType != label {
+			label += "\n" + documentType
+		}
+		fmt.Fprintf(out, "  %v [label=%v];\n", dotQuote(document.Hash.String()), dotQuote(label))
+	}
+
+	for _, edge := range g.Edges {
+		fmt.Fprintf(out, "  %v -> %v [label=%v];\n",
+			dotQuote(edge.FromNode.String()), dotQuote(edge.ToNode.String()), dotQuote(string(edge.EdgeName)))
+	}
+	fmt.Fprintln(out, "}")
+
+	return out.Flush()
+}
+
+func xmlEscape(value string) string {
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(value))
+	return escaped.String()
+}
+
+// WriteGraphML writes the graph in GraphML, with the node label, type, creator and created date of documents as node data
+func WriteGraphML(w io.Writer, g *Graph) error {
+	out := bufio.NewWriter(w)
+
+	fmt.Fprintln(out, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintln(out, `<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`)
+	fmt.Fprintln(out, `  <key id="label" for="node" attr.name="label" attr.type="string"/>`)
+	fmt.Fprintln(out, `  <key id="type" for="node" attr.name="type" attr.type="string"/>`)
+	fmt.Fprintln(out, `  <key id="creator" for="node" attr.name="creator" attr.type="string"/>`)
+	fmt.Fprintln(out, `  <key id="created_date" for="all" attr.name="created_date" attr.type="string"/>`)
+	fmt.Fprintln(out, `  <key id="edge_name" for="edge" attr.name="edge_name" attr.type="string"/>`)
+	fmt.Fprintln(out, `  <graph id="dao" edgedefault="directed">`)
+
+	for _, document := range g.Documents {
+		fmt.Fprintf(out, "    <node id=\"%v\">\n", document.Hash.String())
+		fmt.Fprintf(out, "      <data key=\"label\">%v</data>\n", xmlEscape(NodeLabel(document)))
+		fmt.Fprintf(out, "      <data key=\"type\">%v</data>\n", xmlEscape(DocumentType(document)))
+		fmt.Fprintf(out, "      <data key=\"creator\">%v</data>\n", xmlEscape(string(document.Creator)))
+		fmt.Fprintf(out, "      <data key=\"created_date\">%v</data>\n", formatTime(document.CreatedDate))
+		fmt.Fprintln(out, "    </node>")
+	}
+
+	for index, edge := range g.Edges {
+		fmt.Fprintf(out, "    <edge id=\"e%d\" source=\"%v\" target=\"%v\">\n", index, edge.FromNode.String(), edge.ToNode.String())
+		fmt.Fprintf(out, "      <data key=\"edge_name\">%v</data>\n", xmlEscape(string(edge.EdgeName)))
+		fmt.Fprintf(out, "      <data key=\"created_date\">%v</data>\n", formatTime(edge.CreatedDate))
+		fmt.Fprintln(out, "    </edge>")
+	}
+
+	fmt.Fprintln(out, "  </graph>")
+	fmt.Fprintln(out, "</graphml>")
+
+	return out.Flush()
+}
+
+func cypherString(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// cypherLabel returns the Neo4j label of a document, its type in backticks, or Document for untyped documents
+func cypherLabel(document docgraph.Document) string {
+	documentType := DocumentType(document)
+	if documentType == "" {
+		return ":Document"
+	}
+	return ":Document:`" + documentType + "`"
+}
+
+// WriteCypher writes the graph as Cypher statements creating one node per document and one relationship
+// per edge, named after the edge in upper case
+func WriteCypher(w io.Writer, g *Graph) error {
+	out := bufio.NewWriter(w)
+
+	fmt.Fprintln(out, "CREATE CONSTRAINT ON (d:Document) ASSERT d.hash IS UNIQUE;")
+	for _, document := range g.Documents {
+		fmt.Fprintf(out, "CREATE (%v {hash: %v, label: %v, type: %v, creator: %v, created_date: %v});\n",
+			cypherLabel(document),
+			cypherString(document.Hash.String()),
+			cypherString(NodeLabel(document)),
+			cypherString(DocumentType(document)),
+			cypherString(string(document.Creator)),
+			cypherString(formatTime(document.CreatedDate)))
+	}
+
+	for _, edge := range g.Edges {
+		fmt.Fprintf(out, "MATCH (a:Document {hash: %v}), (b:Document {hash: %v}) CREATE (a)-[:`%v` {created_date: %v}]->(b);\n",
+			cypherString(edge.FromNode.String()),
+			cypherString(edge.ToNode.String()),
+			strings.ToUpper(string(edge.EdgeName)),
+			cypherString(formatTime(edge.CreatedDate)))
+	}
+
+	return out.Flush()
+}
+
+// WriteNeo4jCSV writes the documents and edges as nodes and relationships files in the neo4j-admin import format
+func WriteNeo4jCSV(nodes, relationships io.Writer, g *Graph) error {
+
+	nodesOut := csv.NewWriter(nodes)
+	nodesOut.Write([]string{"hash:ID", "label", "type", "creator", "created_date", ":LABEL"})
+	for _, document := range g.Documents {
+		labels := "Document"
+		if documentType := DocumentType(document); documentType != "" {
+			labels += ";" + documentType
+		}
+		nodesOut.Write([]string{
+			document.Hash.String(),
+			NodeLabel(document),
+			DocumentType(document),
+			string(document.Creator),
+			formatTime(document.CreatedDate),
+			labels,
+		})
+	}
+	nodesOut.Flush()
+	if err := nodesOut.Error(); err != nil {
+		return fmt.Errorf("cannot write nodes %v", err)
+	}
+
+	relationshipsOut := csv.NewWriter(relationships)
+	relationshipsOut.Write([]string{":START_ID", ":END_ID", ":TYPE", "created_date"})
+	for _, edge := range g.Edges {
+		relationshipsOut.Write([]string{
+			edge.FromNode.String(),
+			edge.ToNode.String(),
+			strings.ToUpper(string(edge.EdgeName)),
+			formatTime(edge.CreatedDate),
+		})
+	}
+	relationshipsOut.Flush()
+	if err := relationshipsOut.Error(); err != nil {
+		return fmt.Errorf("cannot write relationships %v", err)
+	}
+	return nil
+}
+
+// ExportNeo4jCSV writes nodes.csv and relationships.csv for neo4j-admin import to folder
+func ExportNeo4jCSV(folder string, g *Graph) error {
+
+	nodes, err := os.Create(filepath.Join(folder, "nodes.csv"))
+	if err != nil {
+		return fmt.Errorf("cannot create nodes file %v", err)
+	}
+	defer nodes.Close()
+
+	relationships, err := os.Create(filepath.Join(folder, "relationships.csv"))
+	if err != nil {
+		return fmt.Errorf("cannot create relationships file %v", err)
+	}
+	defer relationships.Close()
+
+	return WriteNeo4jCSV(nodes, relationships, g)
+}
@@ -0,0 +1,99 @@
+package dao_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/dao-contracts/dao-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+	"gotest.tools/assert"
+)
+
+func testDocument(t *testing.T, hash, documentType, nodeLabel string) docgraph.Document {
+	checksum, err := hex.DecodeString(strings.Repeat(hash, 64))
+	assert.NilError(t, err)
+
+	return docgraph.Document{
+		Hash:    eos.Checksum256(checksum),
+		Creator: eos.AN("dao.hypha"),
+		ContentGroups: []docgraph.ContentGroup{{
+			{Label: "type", Value: &docgraph.FlexValue{BaseVariant: eos.BaseVariant{TypeID: 0, Impl: eos.Name(documentType)}}},
+			{Label: "node_label", Value: &docgraph.FlexValue{BaseVariant: eos.BaseVariant{TypeID: 1, Impl: nodeLabel}}},
+		}},
+	}
+}
+
+func testEdge(from, to docgraph.Document, edgeName string) docgraph.Edge {
+	return docgraph.Edge{FromNode: from.Hash, ToNode: to.Hash, EdgeName: eos.Name(edgeName)}
+}
+
+func TestGraphExport(t *testing.T) {
+
+	root := testDocument(t, "1", "dho", "Hypha DHO Root")
+	member := testDocument(t, "2", "member", "johnnyhypha1")
+	assignment := testDocument(t, "3", "assignment", `Johnny's "assignment"`)
+	period := testDocument(t, "4", "period", "Q1")
+
+	graph := &dao.Graph{
+		Documents: []docgraph.Document{root, member, assignment, period},
+		Edges: []docgraph.Edge{
+			testEdge(root, member, "member"),
+			testEdge(member, assignment, "assigned"),
+			testEdge(assignment, period, "claimed"),
+			testEdge(root, period, "start"),
+		},
+	}
+
+	t.Run("Filter by depth from a start node", func(t *testing.T) {
+		filtered := graph.Filter(dao.GraphFilter{Start: root.Hash.String(), Depth: 1})
+		assert.Equal(t, len(filtered.Documents), 3)
+		assert.Equal(t, len(filtered.Edges), 2)
+	})
+
+	t.Run("Filter by edge name and type", func(t *testing.T) {
+		filtered := graph.Filter(dao.GraphFilter{
+			Start:     root.Hash.String(),
+			EdgeNames: []eos.Name{"member", "assigned", "claimed"},
+			Types:     []string{"member", "assignment"},
+		})
+		assert.Equal(t, len(filtered.Documents), 2)
+		assert.Equal(t, len(filtered.Edges), 1)
+		assert.Equal(t, filtered.Edges[0].EdgeName, eos.Name("assigned"))
+	})
+
+	t.Run("Write DOT", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NilError(t, dao.ExportGraph(&out, graph, "dot"))
+		assert.Assert(t, strings.Contains(out.String(), `[label="johnnyhypha1\nmember"]`))
+		assert.Assert(t, strings.Contains(out.String(), `[label="Johnny's \"assignment\"\nassignment"]`))
+		assert.Assert(t, strings.Contains(out.String(), `[label="claimed"]`))
+	})
+
+	t.Run("Write GraphML", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NilError(t, dao.ExportGraph(&out, graph, "graphml"))
+		assert.Assert(t, strings.Contains(out.String(), `<data key="label">Johnny&#39;s &#34;assignment&#34;</data>`))
+		assert.Equal(t, strings.Count(out.String(), "<edge "), 4)
+	})
+
+	t.Run("Write Cypher and CSV", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NilError(t, dao.ExportGraph(&out, graph, "cypher"))
+		assert.Assert(t, strings.Contains(out.String(), "CREATE (:Document:`period` {hash: '"+period.Hash.String()+"', label: 'Q1'"))
+		assert.Assert(t, strings.Contains(out.String(), `label: 'Johnny\'s "assignment"'`))
+		assert.Assert(t, strings.Contains(out.String(), "CREATE (a)-[:`ASSIGNED`"))
+
+		var nodes, relationships bytes.Buffer
+		assert.NilError(t, dao.WriteNeo4jCSV(&nodes, &relationships, graph))
+		assert.Equal(t, strings.Count(nodes.String(), "\n"), 5)
+		assert.Assert(t, strings.Contains(relationships.String(), root.Hash.String()+","+period.Hash.String()+",START,"))
+	})
+
+	_, err := dao.OpenGraph(context.Background(), "missing-graph.json", eos.AN("dao.hypha"))
+	assert.Assert(t, err != nil)
+	assert.Assert(t, dao.ExportGraph(&bytes.Buffer{}, graph, "svg") != nil)
+}
@@ -0,0 +1,524 @@
+package dao
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+)
+
+const graphArchiveVersion = 1
+
+// GraphArchive is a point in time copy of the documents and edges tables of a contract, saved as a JSON file.
+// The rows are kept as returned by the node so hashes and dates survive the round trip unchanged.
+type GraphArchive struct {
+	Version   int             `json:"version"`
+	Contract  eos.AccountName `json:"contract"`
+	CreatedAt time.Time       `json:"created_at"`
+	Tables    SnapshotTables  `json:"tables"`
+}
+
+type tableRowID struct {
+	ID uint64 `json:"id"`
+}
+
+// getAllRows pages through a table of contract, scoped to the contract, by primary key
+func getAllRows(ctx context.Context, api *eos.API, contract eos.AccountName, table string) ([]json.RawMessage, error) {
+	return getRowRange(ctx, api, contract, table, 0, math.MaxUint64)
+}
+
+// getRowRange pages through the rows of a table with a primary key from lower to upper, both included
+func getRowRange(ctx context.Context, api *eos.API, contract eos.AccountName, table string, lower, upper uint64) ([]json.RawMessage, error) {
+
+	var rows []json.RawMessage
+	more := true
+
+	for more {
+		var page []json.RawMessage
+		var request eos.GetTableRowsRequest
+		request.LowerBound = strconv.FormatUint(lower, 10)
+		request.UpperBound = strconv.FormatUint(upper, 10)
+		request.Code = string(contract)
+		request.Scope = string(contract)
+		request.Table = table
+		request.Limit = 500
+		request.JSON = true
+		response, err := api.GetTableRows(ctx, request)
+		if err != nil {
+			return nil, fmt.Errorf("get table rows %v", err)
+		}
+
+		err = response.JSONToStructs(&page)
+		if err != nil {
+			return nil, fmt.Errorf("json to structs %v", err)
+		}
+
+		var rowID tableRowID
+		for _, row := range page {
+			err = json.Unmarshal(row, &rowID)
+			if err != nil {
+				return nil, fmt.Errorf("cannot read %v row id %v", table, err)
+			}
+			rows = append(rows, row)
+		}
+		more = response.More && len(page) > 0 && rowID.ID < upper
+		lower = rowID.ID + 1
+	}
+	return rows, nil
+}
+
+// loadAllRows reads every row of a table of contract into rows, a pointer to a slice of the row type
+func loadAllRows(ctx context.Context, api *eos.API, contract eos.AccountName, table string, rows interface{}) error {
+
+	raw, err := getAllRows(ctx, api, contract, table)
+	if err != nil {
+		return err
+	}
+	if raw == nil {
+		raw = []json.RawMessage{}
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return fmt.Errorf("cannot marshal %v rows %v", table, err)
+	}
+	err = json.Unmarshal(data, rows)
+	if err != nil {
+		return fmt.Errorf("cannot read %v rows %v", table, err)
+	}
+	return nil
+}
+
+// SnapshotGraph saves every document and edge of contract to a graph archive at path
+func SnapshotGraph(ctx context.Context, api *eos.API, contract eos.AccountName, path string) error {
+
+	archive := GraphArchive{
+		Version:   graphArchiveVersion,
+		Contract:  contract,
+		CreatedAt: time.Now().UTC(),
+		Tables:    make(SnapshotTables),
+	}
+
+	for _, table := range []string{"documents", "edges"} {
+		rows, err := getAllRows(ctx, api, contract, table)
+		if err != nil {
+			return fmt.Errorf("cannot load %v %v", table, err)
+		}
+		if rows == nil {
+			rows = []json.RawMessage{}
+		}
+		if err = archive.Tables.put(table, len(rows), rows); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.MarshalIndent(archive, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot marshal graph archive %v", err)
+	}
+
+	err = ioutil.WriteFile(path, data, 0644)
+	if err != nil {
+		return fmt.Errorf("cannot write graph archive %v: %v", path, err)
+	}
+
+	fmt.Printf("\nGraph of %v saved to %v: %d documents, %d edges\n", contract, path,
+		archive.Tables["documents"].Count, archive.Tables["edges"].Count)
+	return nil
+}
+
+// LoadGraphArchive reads a graph archive and verifies the checksum of each table
+func LoadGraphArchive(path string) (*GraphArchive, error) {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read graph archive %v: %v", path, err)
+	}
+
+	var archive GraphArchive
+	err = json.Unmarshal(data, &archive)
+	if err != nil {
+		return nil, fmt.Errorf("cannot unmarshal graph archive %v: %v", path, err)
+	}
+
+	if archive.Version != graphArchiveVersion {
+		return nil, fmt.Errorf("unsupported graph archive version %v, expected %v", archive.Version, graphArchiveVersion)
+	}
+
+	err = archive.Tables.verify()
+	if err != nil {
+		return nil, fmt.Errorf("graph archive %v is corrupt: %v", path, err)
+	}
+	return &archive, nil
+}
+
+// Documents returns the documents of the archive
+func (a *GraphArchive) Documents() ([]docgraph.Document, error) {
+	var documents []docgraph.Document
+	err := a.Tables.get("documents", &documents)
+	return documents, err
+}
+
+// Edges returns the edges of the archive
+func (a *GraphArchive) Edges() ([]docgraph.Edge, error) {
+	var edges []docgraph.Edge
+	err := a.Tables.get("edges", &edges)
+	return edges, err
+}
+
+// GraphRestore counts, by document type and edge name, the archived documents and edges that RestoreGraph
+// left out because no action of the contract recreates them
+type GraphRestore struct {
+	SkippedDocuments map[string]int `json:"skipped_documents"`
+	SkippedEdges     map[string]int `json:"skipped_edges"`
+}
+
+// Print writes the documents and edges left out of the restore
+func (r *GraphRestore) Print(w io.Writer) {
+	for _, skipped := range []struct {
+		kind   string
+		counts map[string]int
+	}{{"documents", r.SkippedDocuments}, {"edges", r.SkippedEdges}} {
+		var names []string
+		for name := range skipped.counts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			fmt.Fprintf(w, "not restored: %6d %v %v\n", skipped.counts[name], name, skipped.kind)
+		}
+	}
+}
+
+type restoreNotes struct {
+	Notes string `json:"notes"`
+}
+
+// restoredEdge is an archived edge that the restore recreates, between the hashes on the target contract
+type restoredEdge struct {
+	from, to eos.Checksum256
+	name     eos.Name
+}
+
+func (e restoredEdge) key() string {
+	return e.from.String() + "/" + string(e.name) + "/" + e.to.String()
+}
+
+type restorePlan struct {
+	contract eos.AccountName
+	root     eos.Checksum256
+	settings *docgraph.Document
+	members  []LegacyMember
+	periods  []addPeriod
+
+	// documents and edges expected on the target contract once restored
+	documents []eos.Checksum256
+	edges     []restoredEdge
+}
+
+// planRestore selects the archived documents and edges that createroot, setsetting, addmember, migratemem and
+// addperiod recreate on contract, and counts the others
+func planRestore(archive *GraphArchive, contract eos.AccountName) (*restorePlan, *GraphRestore, error) {
+
+	documents, err := archive.Documents()
+	if err != nil {
+		return nil, nil, err
+	}
+	edges, err := archive.Edges()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byHash := make(map[string]docgraph.Document)
+	for _, document := range documents {
+		byHash[document.Hash.String()] = document
+	}
+	edgesFrom := make(map[string][]docgraph.Edge)
+	for _, edge := range edges {
+		edgesFrom[edge.FromNode.String()] = append(edgesFrom[edge.FromNode.String()], edge)
+	}
+	targets := func(from string, name eos.Name) []docgraph.Document {
+		var found []docgraph.Document
+		for _, edge := range edgesFrom[from] {
+			if document, ok := byHash[edge.ToNode.String()]; ok && edge.EdgeName == name {
+				found = append(found, document)
+			}
+		}
+		return found
+	}
+
+	plan := restorePlan{contract: contract, root: RootHash(contract)}
+	archivedRoot := RootHash(archive.Contract).String()
+
+	// restored maps the archived hashes of the restored documents to their hashes on contract
+	restored := make(map[string]eos.Checksum256)
+	restoredEdges := make(map[string]bool)
+	link := func(from, to docgraph.Document, name eos.Name) {
+		edge := restoredEdge{from: restored[from.Hash.String()], to: restored[to.Hash.String()], name: name}
+		plan.edges = append(plan.edges, edge)
+		restoredEdges[from.Hash.String()+"/"+string(name)+"/"+to.Hash.String()] = true
+	}
+
+	root, found := byHash[archivedRoot]
+	if found {
+		restored[archivedRoot] = plan.root
+		plan.documents = append(plan.documents, plan.root)
+
+		// the settings document is recreated by createroot and gets the time of the restore in its updated_date
+		for _, settings := range targets(archivedRoot, "settings") {
+			settings := settings
+			plan.settings = &settings
+			restored[settings.Hash.String()] = nil
+			restoredEdges[archivedRoot+"/settings/"+settings.Hash.String()] = true
+		}
+
+		for _, member := range targets(archivedRoot, "member") {
+			name, err := getName(member, "member")
+			if err != nil {
+				return nil, nil, err
+			}
+			plan.members = append(plan.members, LegacyMember{MemberName: name})
+			restored[member.Hash.String()] = member.Hash
+			plan.documents = append(plan.documents, member.Hash)
+			link(root, member, "member")
+			if len(targets(member.Hash.String(), "memberof")) > 0 {
+				link(member, root, "memberof")
+			}
+		}
+
+		// periods are added after their predecessor, from the start edges of the root along the next edges
+		type periodStep struct{ predecessor, period docgraph.Document }
+		var queue []periodStep
+		for _, period := range targets(archivedRoot, "start") {
+			queue = append(queue, periodStep{root, period})
+		}
+		for len(queue) > 0 {
+			step := queue[0]
+			queue = queue[1:]
+			if _, done := restored[step.period.Hash.String()]; done {
+				continue
+			}
+
+			startTime, err := getTimePoint(step.period, "start_time")
+			if err != nil {
+				return nil, nil, err
+			}
+			label, err := step.period.GetContent("label")
+			if err != nil {
+				return nil, nil, fmt.Errorf("period %v has no label %v", step.period.Hash.String(), err)
+			}
+			plan.periods = append(plan.periods, addPeriod{
+				Predecessor: restored[step.predecessor.Hash.String()],
+				StartTime:   startTime,
+				Label:       contentValueString(label),
+			})
+			restored[step.period.Hash.String()] = step.period.Hash
+			plan.documents = append(plan.documents, step.period.Hash)
+
+			edgeName := eos.Name("next")
+			if bytes.Equal(step.predecessor.Hash, root.Hash) {
+				edgeName = "start"
+			}
+			link(step.predecessor, step.period, edgeName)
+
+			for _, next := range targets(step.period.Hash.String(), "next") {
+				queue = append(queue, periodStep{step.period, next})
+			}
+		}
+	}
+
+	skipped := GraphRestore{SkippedDocuments: make(map[string]int), SkippedEdges: make(map[string]int)}
+	for _, document := range documents {
+		if _, ok := restored[document.Hash.String()]; !ok {
+			skipped.SkippedDocuments[DocumentType(document)]++
+		}
+	}
+	for _, edge := range edges {
+		if !restoredEdges[edge.FromNode.String()+"/"+string(edge.EdgeName)+"/"+edge.ToNode.String()] {
+			skipped.SkippedEdges[string(edge.EdgeName)]++
+		}
+	}
+	return &plan, &skipped, nil
+}
+
+// RestoreGraph recreates on contract the part of the graph of archive that the existing actions of the
+// contract create: the root with createroot, the settings with setsetting, the members with addmember
+// and migratemem, and the periods with addperiod, all with the authority of contract. Members and periods
+// keep their archived hashes, and so does the root when contract is the archived contract. Proposals and
+// the documents they create, votes and payments have no action that recreates them with their archived
+// hash, and are only counted in the result. Documents already on contract are skipped.
+func RestoreGraph(ctx context.Context, api *eos.API, contract eos.AccountName, archive *GraphArchive, executor *Executor) (*GraphRestore, error) {
+
+	plan, skipped, err := planRestore(archive, contract)
+	if err != nil {
+		return nil, err
+	}
+
+	missing := func(hash eos.Checksum256) func(ctx context.Context) bool {
+		return func(ctx context.Context) bool {
+			_, err := docgraph.LoadDocument(ctx, api, contract, hash.String())
+			return err != nil
+		}
+	}
+	action := func(name string, data interface{}) *eos.Action {
+		return &eos.Action{
+			Account: contract,
+			Name:    eos.ActN(name),
+			Authorization: []eos.PermissionLevel{
+				{Actor: contract, Permission: eos.PN("active")},
+			},
+			ActionData: eos.NewActionData(data),
+		}
+	}
+
+	err = executor.Run(ctx, api, "restore root", false, []Task{{
+		Step:    "restoreroot",
+		Key:     string(contract),
+		Label:   string(contract),
+		Actions: []*eos.Action{action("createroot", restoreNotes{Notes: "graph restore"})},
+		Pending: missing(plan.root),
+	}})
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := plan.settingsTasks(ctx, api)
+	if err != nil {
+		return nil, err
+	}
+	err = executor.Run(ctx, api, "restore settings", false, tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks = nil
+	for index, member := range plan.members {
+		tasks = append(tasks, Task{
+			Step:    "restoremem",
+			Key:     string(member.MemberName),
+			Label:   string(member.MemberName) + ", " + strconv.Itoa(index) + " / " + strconv.Itoa(len(plan.members)),
+			Actions: []*eos.Action{action("addmember", member), action("migratemem", member)},
+			Pending: missing(MemberHash(eos.AccountName(member.MemberName))),
+		})
+	}
+	err = executor.Run(ctx, api, "restore members", false, tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks = nil
+	for index, period := range plan.periods {
+		tasks = append(tasks, Task{
+			Step:    "restoreper",
+			Key:     PeriodHash(period.StartTime, period.Label).String(),
+			Label:   period.Label + ", " + strconv.Itoa(index) + " / " + strconv.Itoa(len(plan.periods)),
+			Actions: []*eos.Action{action("addperiod", period)},
+			Pending: missing(PeriodHash(period.StartTime, period.Label)),
+		})
+	}
+	err = executor.Run(ctx, api, "restore periods", true, tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	return skipped, plan.verify(ctx, api)
+}
+
+// settingsTasks returns a task setting the archived settings that differ on contract, if any
+func (p *restorePlan) settingsTasks(ctx context.Context, api *eos.API) ([]Task, error) {
+
+	if p.settings == nil {
+		return nil, nil
+	}
+	current, err := getSettings(ctx, api, p.contract)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load settings to restore %v", err)
+	}
+
+	var actions []*eos.Action
+	for _, group := range p.settings.ContentGroups {
+		if groupLabel(group) != "settings" {
+			continue
+		}
+		for _, item := range group {
+			switch item.Label {
+			case "content_group_label", "root_node", "updated_date":
+				continue
+			}
+			value, err := current.GetContent(item.Label)
+			if err == nil && contentValueString(value) == contentValueString(item.Value) {
+				continue
+			}
+
+			action, err := setSettingAction(ctx, api, p.contract, item.Label, item.Value)
+			if err != nil {
+				return nil, err
+			}
+			actions = append(actions, action)
+		}
+	}
+
+	if len(actions) == 0 {
+		return nil, nil
+	}
+	return []Task{{
+		Label:   "settings",
+		Actions: actions,
+	}}, nil
+}
+
+// verify checks that every document and edge of the plan is on the contract
+func (p *restorePlan) verify(ctx context.Context, api *eos.API) error {
+
+	var restored []docgraph.Document
+	err := loadAllRows(ctx, api, p.contract, "documents", &restored)
+	if err != nil {
+		return fmt.Errorf("cannot load restored documents %v", err)
+	}
+
+	hashes := make(map[string]bool)
+	for _, document := range restored {
+		hashes[document.Hash.String()] = true
+	}
+
+	var missing int
+	for _, hash := range p.documents {
+		if !hashes[hash.String()] {
+			fmt.Println("missing document after restore: " + hash.String())
+			missing++
+		}
+	}
+
+	var restoredEdges []docgraph.Edge
+	err = loadAllRows(ctx, api, p.contract, "edges", &restoredEdges)
+	if err != nil {
+		return fmt.Errorf("cannot load restored edges %v", err)
+	}
+
+	edgeKeys := make(map[string]bool)
+	for _, edge := range restoredEdges {
+		edgeKeys[restoredEdge{from: edge.FromNode, to: edge.ToNode, name: edge.EdgeName}.key()] = true
+	}
+
+	for _, edge := range p.edges {
+		if !edgeKeys[edge.key()] {
+			fmt.Println("missing edge after restore: " + edge.key())
+			missing++
+		}
+	}
+
+	if missing > 0 {
+		return fmt.Errorf("restore is incomplete: %d documents or edges missing", missing)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package dao_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/dao-contracts/dao-go"
+	"github.com/hypha-dao/dao-contracts/dao-go/daotest"
+	"gotest.tools/assert"
+)
+
+func TestGraphArchive(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "graph")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	edges := []map[string]interface{}{
+		{"id": 1, "from_node": strings.Repeat("a", 64), "to_node": strings.Repeat("b", 64), "edge_name": "member"},
+		{"id": 2, "from_node": strings.Repeat("b", 64), "to_node": strings.Repeat("a", 64), "edge_name": "memberof"},
+	}
+
+	archive := dao.GraphArchive{
+		Version:  1,
+		Contract: eos.AN("dao.hypha"),
+		Tables: dao.SnapshotTables{
+			"documents": snapshotTable(t, []interface{}{}, 0),
+			"edges":     snapshotTable(t, edges, len(edges)),
+		},
+	}
+
+	data, err := json.MarshalIndent(archive, "", "  ")
+	assert.NilError(t, err)
+
+	path := filepath.Join(dir, "graph.json")
+	assert.NilError(t, ioutil.WriteFile(path, data, 0644))
+
+	t.Run("Read the archived edges", func(t *testing.T) {
+		loaded, err := dao.LoadGraphArchive(path)
+		assert.NilError(t, err)
+
+		loadedEdges, err := loaded.Edges()
+		assert.NilError(t, err)
+		assert.Equal(t, len(loadedEdges), 2)
+		assert.Equal(t, loadedEdges[1].EdgeName, eos.Name("memberof"))
+		assert.Equal(t, loadedEdges[1].ToNode.String(), strings.Repeat("a", 64))
+	})
+
+	t.Run("Reject an unknown version", func(t *testing.T) {
+		archive.Version = 2
+		data, err := json.Marshal(archive)
+		assert.NilError(t, err)
+		assert.NilError(t, ioutil.WriteFile(path, data, 0644))
+
+		_, err = dao.LoadGraphArchive(path)
+		assert.Assert(t, err != nil)
+		assert.Assert(t, strings.Contains(err.Error(), "unsupported graph archive version"))
+	})
+}
+
+func TestRestoreGraph(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "graph")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	options := daotest.DefaultOptions()
+	options.NumPeriods = 3
+	options.Members = options.Members[:1]
+	env, fake, err := daotest.SetupFake(options)
+	assert.NilError(t, err)
+	defer fake.Close()
+
+	path := filepath.Join(dir, "graph.json")
+	assert.NilError(t, dao.SnapshotGraph(env.Ctx, env.API, env.DAO, path))
+	archive, err := dao.LoadGraphArchive(path)
+	assert.NilError(t, err)
+
+	target, err := daotest.NewFakeChain(options.Accounts, time.Now())
+	assert.NilError(t, err)
+	defer target.Close()
+
+	t.Run("Restore through the actions of the contract", func(t *testing.T) {
+		executor := dao.NewExecutor(dao.ExecutorOptions{Concurrency: 1}, nil)
+		restore, err := dao.RestoreGraph(env.Ctx, target.API(), env.DAO, archive, executor)
+		assert.NilError(t, err)
+
+		// the receipts of the enrollments have no action recreating them
+		assert.Assert(t, restore.SkippedDocuments["payment"] > 0)
+		assert.Assert(t, restore.SkippedEdges["payment"] > 0)
+		assert.Equal(t, restore.SkippedDocuments["member"], 0)
+		assert.Equal(t, restore.SkippedDocuments["period"], 0)
+
+		hashes := make(map[string]bool)
+		for _, document := range target.Graph().Documents {
+			hashes[document.Hash.String()] = true
+		}
+		for _, document := range fake.Graph().Documents {
+			switch dao.DocumentType(document) {
+			case "dho", "member", "period":
+				assert.Assert(t, hashes[document.Hash.String()], dao.NodeLabel(document))
+			}
+		}
+
+		settings, err := dao.LoadSettings(env.Ctx, env.API, env.DAO)
+		assert.NilError(t, err)
+		restored, err := dao.LoadSettings(env.Ctx, target.API(), env.DAO)
+		assert.NilError(t, err)
+		assert.Equal(t, len(dao.DiffSettings(restored, settings)), 0)
+	})
+
+	t.Run("Restore again", func(t *testing.T) {
+		documents := len(target.Graph().Documents)
+		executor := dao.NewExecutor(dao.ExecutorOptions{Concurrency: 1}, nil)
+		_, err := dao.RestoreGraph(env.Ctx, target.API(), env.DAO, archive, executor)
+		assert.NilError(t, err)
+		assert.Equal(t, len(target.Graph().Documents), documents)
+	})
+}
@@ -0,0 +1,329 @@
+package dao
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+)
+
+const (
+	// SeverityError marks a violation that breaks contract behaviour, such as claims or voting
+	SeverityError = "error"
+
+	// SeverityWarning marks a violation that leaves the graph inconsistent without breaking the contract
+	SeverityWarning = "warning"
+)
+
+// Violation is a set of documents breaking an invariant
+type Violation struct {
+	Invariant string   `json:"invariant"`
+	Severity  string   `json:"severity"`
+	Hashes    []string `json:"hashes"`
+	Message   string   `json:"message"`
+}
+
+// Invariant is a named rule the DAO document graph must satisfy
+type Invariant struct {
+	Name        string
+	Severity    string
+	Description string
+	check       func(invariant Invariant, index *graphIndex) []Violation
+}
+
+func (inv Invariant) violation(message string, hashes ...string) Violation {
+	return Violation{Invariant: inv.Name, Severity: inv.Severity, Hashes: hashes, Message: message}
+}
+
+// Invariants returns the catalog of invariants evaluated by Check
+func Invariants() []Invariant {
+	return []Invariant{
+		{
+			Name:        "assignment-assigned",
+			Severity:    SeverityError,
+			Description: "a passed assignment is linked from its assignee with an assigned edge",
+			check:       checkAssignmentAssigned,
+		},
+		{
+			Name:        "period-single-next",
+			Severity:    SeverityError,
+			Description: "a period has at most one next edge",
+			check:       checkPeriodSingleNext,
+		},
+		{
+			Name:        "closed-proposal-detached",
+			Severity:    SeverityError,
+			Description: "a passed or failed proposal is no longer linked from the root with a proposal edge",
+			check:       checkClosedProposalDetached,
+		},
+		{
+			Name:        "single-settings",
+			Severity:    SeverityError,
+			Description: "the root has at most one settings edge",
+			check:       checkSingleSettings,
+		},
+		{
+			Name:        "votetally-voteon",
+			Severity:    SeverityWarning,
+			Description: "a proposal whose vote tally counts votes has at least one voteon edge",
+			check:       checkVoteTallyVoteOn,
+		},
+		{
+			Name:        "payment-claimed",
+			Severity:    SeverityError,
+			Description: "an assignment payment comes from an assignment or period with a claimed edge, unless a payout also links to it",
+			check:       checkPaymentClaimed,
+		},
+	}
+}
+
+// IntegrityReport lists the invariants evaluated against a graph and their violations
+type IntegrityReport struct {
+	CheckedAt  time.Time   `json:"checked_at"`
+	Documents  int         `json:"documents"`
+	Edges      int         `json:"edges"`
+	Invariants []string    `json:"invariants"`
+	Violations []Violation `json:"violations"`
+}
+
+// Errors returns the number of violations with error severity
+func (r *IntegrityReport) Errors() int {
+	var errors int
+	for _, violation := range r.Violations {
+		if violation.Severity == SeverityError {
+			errors++
+		}
+	}
+	return errors
+}
+
+// Check evaluates the named invariants against the graph, or the whole catalog when no name is given
+func Check(graph *Graph, names ...string) (IntegrityReport, error) {
+
+	selected := Invariants()
+	if len(names) > 0 {
+		catalog := make(map[string]Invariant)
+		for _, invariant := range selected {
+			catalog[invariant.Name] = invariant
+		}
+
+		selected = nil
+		for _, name := range names {
+			invariant, ok := catalog[name]
+			if !ok {
+				return IntegrityReport{}, fmt.Errorf("unknown invariant %v", name)
+			}
+			selected = append(selected, invariant)
+		}
+	}
+
+	report := IntegrityReport{
+		CheckedAt:  time.Now().UTC(),
+		Documents:  len(graph.Documents),
+		Edges:      len(graph.Edges),
+		Violations: []Violation{},
+	}
+
+	index := graph.index()
+	for _, invariant := range selected {
+		report.Invariants = append(report.Invariants, invariant.Name)
+		report.Violations = append(report.Violations, invariant.check(invariant, index)...)
+	}
+	return report, nil
+}
+
+// Print writes the report in a human readable format
+func (r *IntegrityReport) Print(w io.Writer) {
+	fmt.Fprintf(w, "\nIntegrity check of %d documents and %d edges\n", r.Documents, r.Edges)
+
+	counts := make(map[string]int)
+	for _, violation := range r.Violations {
+		counts[violation.Invariant]++
+	}
+	for _, name := range r.Invariants {
+		fmt.Fprintf(w, "%-26v violations: %6d\n", name, counts[name])
+	}
+
+	if len(r.Violations) == 0 {
+		fmt.Fprintln(w, "\nNo violations found")
+		return
+	}
+
+	fmt.Fprintln(w, "\nViolations: "+strconv.Itoa(len(r.Violations)))
+	for _, violation := range r.Violations {
+		fmt.Fprintf(w, "\n%v %v: %v\n", violation.Severity, violation.Invariant, violation.Message)
+		for _, hash := range violation.Hashes {
+			fmt.Fprintln(w, "    "+hash)
+		}
+	}
+}
+
+// edgeTargets returns the hashes the edges point to
+func edgeTargets(edges []docgraph.Edge) []string {
+	var hashes []string
+	for _, edge := range edges {
+		hashes = append(hashes, edge.ToNode.String())
+	}
+	return hashes
+}
+
+func checkAssignmentAssigned(invariant Invariant, index *graphIndex) []Violation {
+	var violations []Violation
+	for _, assignment := range index.ofType("assignment") {
+		hash := assignment.Hash.String()
+		assignees := index.edgesFrom(hash, eos.Name("assignee"))
+		passed := len(index.edgesTo(hash, eos.Name("passedprops"))) > 0
+		if len(assignees) == 0 && !passed {
+			continue
+		}
+
+		if len(assignees) == 0 {
+			violations = append(violations, invariant.violation("passed assignment has no assignee edge", hash))
+			continue
+		}
+
+		for _, assignee := range assignees {
+			member := assignee.ToNode.String()
+			found := false
+			for _, assigned := range index.edgesTo(hash, eos.Name("assigned")) {
+				found = found || assigned.FromNode.String() == member
+			}
+			if !found {
+				violations = append(violations, invariant.violation("assignee has no assigned edge to the assignment", hash, member))
+			}
+		}
+	}
+	return violations
+}
+
+func checkPeriodSingleNext(invariant Invariant, index *graphIndex) []Violation {
+	var violations []Violation
+	for _, period := range index.ofType("period") {
+		hash := period.Hash.String()
+		next := index.edgesFrom(hash, eos.Name("next"))
+		if len(next) > 1 {
+			violations = append(violations, invariant.violation(
+				"period has "+strconv.Itoa(len(next))+" next edges", append([]string{hash}, edgeTargets(next)...)...))
+		}
+	}
+	return violations
+}
+
+func checkClosedProposalDetached(invariant Invariant, index *graphIndex) []Violation {
+	var violations []Violation
+	for _, document := range index.graph.Documents {
+		hash := document.Hash.String()
+		closed := len(index.edgesTo(hash, eos.Name("passedprops"))) + len(index.edgesTo(hash, eos.Name("failedprops")))
+		if closed == 0 {
+			continue
+		}
+
+		if open := index.edgesTo(hash, eos.Name("proposal")); len(open) > 0 {
+			violations = append(violations, invariant.violation(
+				"closed "+DocumentType(document)+" proposal is still under proposal", hash))
+		}
+		if closed > 1 {
+			violations = append(violations, invariant.violation(
+				"proposal is linked "+strconv.Itoa(closed)+" times as passed or failed", hash))
+		}
+	}
+	return violations
+}
+
+func checkSingleSettings(invariant Invariant, index *graphIndex) []Violation {
+	var violations []Violation
+	for _, document := range index.graph.Documents {
+		hash := document.Hash.String()
+		settings := index.edgesFrom(hash, eos.Name("settings"))
+		if len(settings) > 1 {
+			violations = append(violations, invariant.violation(
+				"document has "+strconv.Itoa(len(settings))+" settings edges", append([]string{hash}, edgeTargets(settings)...)...))
+		}
+	}
+	return violations
+}
+
+// tallyHasVotes reports whether any option of a vote tally document has vote power
+func tallyHasVotes(tally docgraph.Document) bool {
+	for _, group := range tally.ContentGroups {
+		for _, item := range group {
+			if item.Label != "vote_power" || item.Value == nil {
+				continue
+			}
+
+			switch power := item.Value.Impl.(type) {
+			case eos.Asset:
+				if power.Amount > 0 {
+					return true
+				}
+			case *eos.Asset:
+				if power.Amount > 0 {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func checkVoteTallyVoteOn(invariant Invariant, index *graphIndex) []Violation {
+	var violations []Violation
+	for _, edge := range index.graph.Edges {
+		if edge.EdgeName != eos.Name("votetally") {
+			continue
+		}
+
+		proposal, tally := edge.FromNode.String(), edge.ToNode.String()
+		tallyDocument, ok := index.documents[tally]
+		if !ok {
+			violations = append(violations, invariant.violation("vote tally document is missing", proposal, tally))
+			continue
+		}
+
+		if tallyHasVotes(tallyDocument) && len(index.edgesTo(proposal, eos.Name("voteon"))) == 0 {
+			violations = append(violations, invariant.violation("vote tally counts votes but the proposal has no voteon edge", proposal, tally))
+		}
+	}
+	return violations
+}
+
+func checkPaymentClaimed(invariant Invariant, index *graphIndex) []Violation {
+	var violations []Violation
+	for _, edge := range index.graph.Edges {
+		if edge.EdgeName != eos.Name("payment") {
+			continue
+		}
+
+		from := edge.FromNode.String()
+		source, ok := index.documents[from]
+		if !ok {
+			continue
+		}
+
+		switch DocumentType(source) {
+		case "assignment":
+			if len(index.edgesFrom(from, eos.Name("claimed"))) == 0 {
+				violations = append(violations, invariant.violation("assignment paid without a claimed period", from, edge.ToNode.String()))
+			}
+		case "period":
+			// a migrated payout pays from the period of its date without a claim, and from the payout
+			if len(index.edgesTo(from, eos.Name("claimed"))) == 0 && !paidByPayout(index, edge.ToNode.String()) {
+				violations = append(violations, invariant.violation("payment from a period that no assignment claimed", from, edge.ToNode.String()))
+			}
+		}
+	}
+	return violations
+}
+
+// paidByPayout reports whether a payout document links to the receipt with a payment edge
+func paidByPayout(index *graphIndex, receipt string) bool {
+	for _, edge := range index.edgesTo(receipt, eos.Name("payment")) {
+		if source, ok := index.documents[edge.FromNode.String()]; ok && DocumentType(source) == "payout" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package dao_test
+
+import (
+	"testing"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/dao-contracts/dao-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+	"gotest.tools/assert"
+)
+
+func TestIntegrityCheck(t *testing.T) {
+
+	root := testDocument(t, "1", "dho", "Hypha DHO Root")
+	member := testDocument(t, "2", "member", "johnnyhypha1")
+	assignment := testDocument(t, "3", "assignment", "Underwater Basketweaver")
+	q1 := testDocument(t, "4", "period", "Q1")
+	q2 := testDocument(t, "5", "period", "Q2")
+	q3 := testDocument(t, "6", "period", "Q3")
+	receipt := testDocument(t, "7", "payment", "100.00 HUSD to johnnyhypha1")
+	settings := testDocument(t, "8", "settings", "settings")
+	settings2 := testDocument(t, "9", "settings", "settings")
+	payout := testDocument(t, "b", "payout", "Migrated payout")
+	payoutReceipt := testDocument(t, "c", "payment", "10.00 HUSD to johnnyhypha1")
+
+	tally := testDocument(t, "a", "", "")
+	tally.ContentGroups = []docgraph.ContentGroup{{
+		{Label: "content_group_label", Value: &docgraph.FlexValue{BaseVariant: eos.BaseVariant{TypeID: 1, Impl: "pass"}}},
+		{Label: "vote_power", Value: &docgraph.FlexValue{BaseVariant: eos.BaseVariant{TypeID: 2, Impl: eos.Asset{Amount: 100, Symbol: eos.Symbol{Precision: 2, Symbol: "HVOICE"}}}}},
+	}}
+
+	healthy := &dao.Graph{
+		Documents: []docgraph.Document{root, member, assignment, q1, q2, q3, receipt, settings, tally, payout, payoutReceipt},
+		Edges: []docgraph.Edge{
+			testEdge(root, settings, "settings"),
+			testEdge(root, assignment, "passedprops"),
+			testEdge(member, assignment, "assigned"),
+			testEdge(assignment, member, "assignee"),
+			testEdge(q1, q2, "next"),
+			testEdge(q2, q3, "next"),
+			testEdge(assignment, q1, "claimed"),
+			testEdge(assignment, receipt, "payment"),
+			testEdge(assignment, tally, "votetally"),
+			testEdge(member, assignment, "voteon"),
+
+			// a migrated payout pays from the period of its date, which no assignment claimed
+			testEdge(q3, payoutReceipt, "payment"),
+			testEdge(payout, payoutReceipt, "payment"),
+			testEdge(member, payoutReceipt, "paid"),
+		},
+	}
+
+	t.Run("Healthy graph", func(t *testing.T) {
+		report, err := dao.Check(healthy)
+		assert.NilError(t, err)
+		assert.Equal(t, len(report.Invariants), len(dao.Invariants()))
+		assert.Equal(t, len(report.Violations), 0)
+	})
+
+	t.Run("Broken graph", func(t *testing.T) {
+		broken := &dao.Graph{
+			Documents: append(healthy.Documents, settings2),
+			Edges: []docgraph.Edge{
+				testEdge(root, settings, "settings"),
+				testEdge(root, settings2, "settings"),
+				testEdge(root, assignment, "passedprops"),
+				testEdge(root, assignment, "proposal"),
+				testEdge(assignment, member, "assignee"),
+				testEdge(q1, q2, "next"),
+				testEdge(q1, q3, "next"),
+				testEdge(assignment, receipt, "payment"),
+				testEdge(assignment, tally, "votetally"),
+			},
+		}
+
+		report, err := dao.Check(broken)
+		assert.NilError(t, err)
+
+		violated := make(map[string]int)
+		for _, violation := range report.Violations {
+			violated[violation.Invariant]++
+		}
+		for _, invariant := range dao.Invariants() {
+			assert.Equal(t, violated[invariant.Name], 1, invariant.Name)
+		}
+		assert.Equal(t, report.Errors(), len(report.Violations)-1)
+	})
+
+	t.Run("Select invariants by name", func(t *testing.T) {
+		report, err := dao.Check(healthy, "period-single-next")
+		assert.NilError(t, err)
+		assert.DeepEqual(t, report.Invariants, []string{"period-single-next"})
+
+		_, err = dao.Check(healthy, "no-such-invariant")
+		assert.Assert(t, err != nil)
+	})
+}
@@ -0,0 +1,274 @@
+package dao
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// JournalSucceeded marks a record that was migrated
+	JournalSucceeded = "succeeded"
+
+	// JournalFailed marks a record whose transaction failed; it is retried on the next run
+	JournalFailed = "failed"
+
+	// JournalCompleted marks a phase whose records all succeeded; its step is the phase
+	JournalCompleted = "completed"
+)
+
+// JournalEntry is the outcome of migrating a single legacy record
+type JournalEntry struct {
+	Step   string    `json:"step"`
+	Key    string    `json:"key"`
+	Status string    `json:"status"`
+	TrxID  string    `json:"trx_id,omitempty"`
+	Error  string    `json:"error,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// Journal is an append-only JSON lines file recording the outcome of each migrated record
+// and the phases completed. Reopening the journal restores the latest outcome of every record,
+// so a migration can resume where it stopped and retry only the records that failed.
+type Journal struct {
+	mutex   sync.Mutex
+	file    *os.File
+	entries map[string]JournalEntry
+	phases  map[string]bool
+}
+
+func journalKey(step, key string) string {
+	return step + "/" + key
+}
+
+// OpenJournal opens or creates the journal at path and loads the entries already in it
+func OpenJournal(path string) (*Journal, error) {
+
+	journal := Journal{entries: make(map[string]JournalEntry), phases: make(map[string]bool)}
+
+	partial := false
+	existing, err := os.Open(path)
+	if err == nil {
+		content, err := ioutil.ReadAll(existing)
+		if err != nil {
+			existing.Close()
+			return nil, fmt.Errorf("cannot read journal %v: %v", path, err)
+		}
+		partial = len(content) > 0 && content[len(content)-1] != '\n'
+
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			var entry JournalEntry
+			// a crash can leave a partial last line; it is safe to ignore since
+			// that record is treated as not yet migrated
+			if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+				continue
+			}
+			if entry.Status == JournalCompleted {
+				journal.phases[entry.Step] = true
+			} else {
+				journal.entries[journalKey(entry.Step, entry.Key)] = entry
+			}
+		}
+		existing.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("cannot read journal %v: %v", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("cannot open journal %v: %v", path, err)
+	}
+
+	journal.file, err = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open journal %v: %v", path, err)
+	}
+
+	// terminate a partial line so the next entry starts on its own line
+	if partial {
+		_, err = journal.file.Write([]byte{'\n'})
+		if err != nil {
+			journal.file.Close()
+			return nil, fmt.Errorf("cannot write journal %v: %v", path, err)
+		}
+	}
+	return &journal, nil
+}
+
+// Succeeded returns true if the record was already migrated by this or a previous run.
+// A nil journal has no entries.
+func (j *Journal) Succeeded(step, key string) bool {
+	if j == nil {
+		return false
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	entry, found := j.entries[journalKey(step, key)]
+	return found && entry.Status == JournalSucceeded
+}
+
+// Record appends the outcome of a record to the journal and syncs it to disk.
+// Recording on a nil journal does nothing.
+func (j *Journal) Record(step, key, trxID string, trxErr error) error {
+	if j == nil {
+		return nil
+	}
+
+	entry := JournalEntry{
+		Step:   step,
+		Key:    key,
+		Status: JournalSucceeded,
+		TrxID:  trxID,
+		Time:   time.Now().UTC(),
+	}
+
+	if trxErr != nil {
+		entry.Status = JournalFailed
+		entry.TrxID = ""
+		entry.Error = trxErr.Error()
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	err := j.write(entry)
+	if err != nil {
+		return err
+	}
+
+	j.entries[journalKey(step, key)] = entry
+	return nil
+}
+
+// Complete records that every record of phase succeeded. Completing on a nil journal does nothing.
+func (j *Journal) Complete(phase string) error {
+	if j == nil {
+		return nil
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	err := j.write(JournalEntry{Step: phase, Status: JournalCompleted, Time: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+
+	j.phases[phase] = true
+	return nil
+}
+
+// Completed returns the phases completed by this or a previous run, in order of name.
+// A nil journal has none.
+func (j *Journal) Completed() []string {
+	if j == nil {
+		return nil
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	var phases []string
+	for phase := range j.phases {
+		phases = append(phases, phase)
+	}
+	sort.Strings(phases)
+	return phases
+}
+
+// write appends entry to the file and syncs it to disk; the caller holds the mutex
+func (j *Journal) write(entry JournalEntry) error {
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("cannot marshal journal entry: %v", err)
+	}
+
+	_, err = j.file.Write(append(line, '\n'))
+	if err != nil {
+		return fmt.Errorf("cannot write journal entry: %v", err)
+	}
+
+	err = j.file.Sync()
+	if err != nil {
+		return fmt.Errorf("cannot sync journal: %v", err)
+	}
+	return nil
+}
+
+// Failures returns the latest entry of every record that has not succeeded, ordered by step and key
+func (j *Journal) Failures() []JournalEntry {
+	if j == nil {
+		return nil
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	var failures []JournalEntry
+	for _, entry := range j.entries {
+		if entry.Status != JournalSucceeded {
+			failures = append(failures, entry)
+		}
+	}
+
+	sort.Slice(failures, func(a, b int) bool {
+		return journalKey(failures[a].Step, failures[a].Key) < journalKey(failures[b].Step, failures[b].Key)
+	})
+	return failures
+}
+
+// PrintSummary writes the number of succeeded and failed records per step, followed by each failure
+func (j *Journal) PrintSummary(w io.Writer) {
+	if j == nil {
+		return
+	}
+
+	j.mutex.Lock()
+	succeeded := make(map[string]int)
+	failed := make(map[string]int)
+	var steps []string
+	for _, entry := range j.entries {
+		if succeeded[entry.Step]+failed[entry.Step] == 0 {
+			steps = append(steps, entry.Step)
+		}
+		if entry.Status == JournalSucceeded {
+			succeeded[entry.Step]++
+		} else {
+			failed[entry.Step]++
+		}
+	}
+	j.mutex.Unlock()
+
+	sort.Strings(steps)
+
+	fmt.Fprintln(w, "\nMigration summary")
+	for _, step := range steps {
+		fmt.Fprintf(w, "%-16v succeeded: %6d    failed: %6d\n", step, succeeded[step], failed[step])
+	}
+
+	failures := j.Failures()
+	if len(failures) > 0 {
+		fmt.Fprintln(w, "\nFailed records")
+		for _, entry := range failures {
+			fmt.Fprintf(w, "%v %v: %v\n", entry.Step, entry.Key, entry.Error)
+		}
+	}
+}
+
+// Close closes the journal file
+func (j *Journal) Close() error {
+	if j == nil {
+		return nil
+	}
+	return j.file.Close()
+}
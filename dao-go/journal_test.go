@@ -0,0 +1,71 @@
+package dao_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hypha-dao/dao-contracts/dao-go"
+	"gotest.tools/assert"
+)
+
+func TestJournalResume(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "journal")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "migration.journal")
+
+	journal, err := dao.OpenJournal(path)
+	assert.NilError(t, err)
+
+	assert.NilError(t, journal.Record("migratemem", "mem1.hypha", "trx1", nil))
+	assert.NilError(t, journal.Record("migratemem", "mem2.hypha", "", errors.New("assertion failure")))
+	assert.NilError(t, journal.Close())
+
+	// a crash while writing leaves a partial line behind
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	assert.NilError(t, err)
+	_, err = file.WriteString(`{"step":"migratemem","key":"mem3`)
+	assert.NilError(t, err)
+	assert.NilError(t, file.Close())
+
+	journal, err = dao.OpenJournal(path)
+	assert.NilError(t, err)
+
+	assert.Assert(t, journal.Succeeded("migratemem", "mem1.hypha"))
+	assert.Assert(t, !journal.Succeeded("migratemem", "mem2.hypha"))
+	assert.Assert(t, !journal.Succeeded("migratemem", "mem3.hypha"))
+	assert.Equal(t, len(journal.Failures()), 1)
+
+	// retrying the failed record clears it from the failures
+	assert.NilError(t, journal.Record("migratemem", "mem2.hypha", "trx2", nil))
+	assert.Assert(t, journal.Succeeded("migratemem", "mem2.hypha"))
+	assert.Equal(t, len(journal.Failures()), 0)
+	assert.NilError(t, journal.Close())
+
+	journal, err = dao.OpenJournal(path)
+	assert.NilError(t, err)
+	assert.Assert(t, journal.Succeeded("migratemem", "mem2.hypha"))
+}
+
+func TestJournalNil(t *testing.T) {
+
+	// the executor runs without a journal when none is configured
+	var journal *dao.Journal
+
+	assert.Assert(t, !journal.Succeeded("migratemem", "mem1.hypha"))
+	assert.NilError(t, journal.Record("migratemem", "mem1.hypha", "trx1", nil))
+	assert.NilError(t, journal.Complete("migratemem"))
+	assert.Equal(t, len(journal.Completed()), 0)
+	assert.Equal(t, len(journal.Failures()), 0)
+
+	var summary bytes.Buffer
+	journal.PrintSummary(&summary)
+	assert.Equal(t, summary.Len(), 0)
+	assert.NilError(t, journal.Close())
+}
@@ -0,0 +1,56 @@
+package dao
+
+import (
+	"context"
+	"strings"
+
+	"github.com/eoscanada/eos-go"
+)
+
+// LegacyTables reads the legacy tables that are copied and migrated into the document graph
+type LegacyTables interface {
+	Members(ctx context.Context) ([]LegacyMember, error)
+	Periods(ctx context.Context) ([]Period, error)
+	Objects(ctx context.Context, scope eos.Name) ([]Object, error)
+	AssPayouts(ctx context.Context) ([]LegacyAssPayout, error)
+	Applicants(ctx context.Context) ([]LegacyApplicant, error)
+}
+
+type endpointTables struct {
+	api      *eos.API
+	contract eos.AccountName
+}
+
+// NewEndpointTables reads the legacy tables of contract live from the node behind api
+func NewEndpointTables(api *eos.API, contract eos.AccountName) LegacyTables {
+	return &endpointTables{api: api, contract: contract}
+}
+
+func (e *endpointTables) Members(ctx context.Context) ([]LegacyMember, error) {
+	return getLegacyMembers(ctx, e.api, e.contract)
+}
+
+func (e *endpointTables) Periods(ctx context.Context) ([]Period, error) {
+	return getLegacyPeriods(ctx, e.api, e.contract)
+}
+
+func (e *endpointTables) Objects(ctx context.Context, scope eos.Name) ([]Object, error) {
+	return getLegacyObjects(ctx, e.api, e.contract, scope)
+}
+
+func (e *endpointTables) AssPayouts(ctx context.Context) ([]LegacyAssPayout, error) {
+	return getAllAssPayouts(ctx, e.api, e.contract)
+}
+
+func (e *endpointTables) Applicants(ctx context.Context) ([]LegacyApplicant, error) {
+	return getLegacyApplicants(ctx, e.api, e.contract)
+}
+
+// OpenLegacySource returns the legacy tables of contract from an endpoint when from is an http(s) URL,
+// and from a snapshot file otherwise
+func OpenLegacySource(from string, contract eos.AccountName) (LegacyTables, error) {
+	if strings.HasPrefix(from, "http://") || strings.HasPrefix(from, "https://") {
+		return NewEndpointTables(eos.New(from), contract), nil
+	}
+	return LoadLegacySnapshot(from)
+}
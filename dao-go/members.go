@@ -0,0 +1,370 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+)
+
+const (
+	// StatusMember is the status of an account linked from the root with a member edge
+	StatusMember = "member"
+
+	// StatusApplicant is the status of an account linked from the root with an applicant edge
+	StatusApplicant = "applicant"
+)
+
+// MemberEntry is a member or applicant document, with when and by whom it was enrolled or applied
+type MemberEntry struct {
+	Account  eos.AccountName
+	Status   string
+	Document docgraph.Document
+	Since    time.Time
+	By       eos.AccountName
+}
+
+// memberEntries returns the documents linked from the root with edgeName, ordered by the creation of the edges
+func memberEntries(ctx context.Context, source QuerySource, edgeName eos.Name, status string) ([]MemberEntry, error) {
+
+	root, err := source.Root(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load root %v", err)
+	}
+
+	edges, err := source.EdgesFrom(ctx, root.Hash.String(), edgeName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %v edges %v", edgeName, err)
+	}
+
+	entries := make([]MemberEntry, 0, len(edges))
+	for _, edge := range edges {
+		document, err := source.Document(ctx, edge.ToNode.String())
+		if err != nil {
+			return entries, fmt.Errorf("cannot load %v %v: %v", status, edge.ToNode, err)
+		}
+
+		account, err := getName(document, "member")
+		if err != nil {
+			return entries, err
+		}
+
+		entries = append(entries, MemberEntry{
+			Account:  eos.AN(string(account)),
+			Status:   status,
+			Document: document,
+			Since:    ToTime(edge.CreatedDate),
+			By:       eos.AN(string(edge.Creator)),
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Since.Equal(entries[j].Since) {
+			return entries[i].Account < entries[j].Account
+		}
+		return entries[i].Since.Before(entries[j].Since)
+	})
+	return entries, nil
+}
+
+// Members returns the members of source in the order they were enrolled
+func Members(ctx context.Context, source QuerySource) ([]MemberEntry, error) {
+	return memberEntries(ctx, source, eos.Name("member"), StatusMember)
+}
+
+// Applicants returns the pending applicants of source in the order they applied
+func Applicants(ctx context.Context, source QuerySource) ([]MemberEntry, error) {
+	return memberEntries(ctx, source, eos.Name("applicant"), StatusApplicant)
+}
+
+// ListMembers returns the members of contract in the order they were enrolled
+func ListMembers(ctx context.Context, api *eos.API, contract eos.AccountName) ([]MemberEntry, error) {
+	return Members(ctx, NewEndpointSource(api, contract))
+}
+
+// ListApplicants returns the pending applicants of contract in the order they applied
+func ListApplicants(ctx context.Context, api *eos.API, contract eos.AccountName) ([]MemberEntry, error) {
+	return Applicants(ctx, NewEndpointSource(api, contract))
+}
+
+// PrintMembers writes members as a table to w
+func PrintMembers(w io.Writer, members []MemberEntry) {
+	fmt.Fprintf(w, "%-13v %-10v %-20v %-13v %v\n", "ACCOUNT", "STATUS", "SINCE", "BY", "HASH")
+	for _, member := range members {
+		fmt.Fprintf(w, "%-13v %-10v %-20v %-13v %v\n", member.Account, member.Status,
+			member.Since.Format("2006-01-02 15:04:05"), member.By, member.Document.Hash)
+	}
+}
+
+// MemberVote is a vote cast by a member on a proposal
+type MemberVote struct {
+	Proposal docgraph.Document
+	Vote     string
+	Power    eos.Asset
+	CastAt   time.Time
+}
+
+// MemberSummary is the profile of a member or applicant: what it holds, what it proposed and voted,
+// and what it was paid
+type MemberSummary struct {
+	MemberEntry
+	Assignments     []docgraph.Document
+	Badges          []docgraph.Document
+	Proposals       []docgraph.Document
+	Votes           []MemberVote
+	Payments        []docgraph.Document
+	PaymentTotals   []eos.Asset
+	VotingPower     eos.Asset
+	RegisteredVoter bool
+}
+
+// findMember returns the member or applicant entry of account, and false when it is neither
+func findMember(ctx context.Context, source QuerySource, account eos.AccountName) (MemberEntry, bool, error) {
+
+	hash := MemberHash(account).String()
+	root, err := source.Root(ctx)
+	if err != nil {
+		return MemberEntry{}, false, fmt.Errorf("cannot load root %v", err)
+	}
+
+	for _, status := range []string{StatusMember, StatusApplicant} {
+		edges, err := source.EdgesTo(ctx, hash, eos.Name(status))
+		if err != nil {
+			return MemberEntry{}, false, fmt.Errorf("cannot read %v edges of %v: %v", status, account, err)
+		}
+
+		for _, edge := range edges {
+			if edge.FromNode.String() != root.Hash.String() {
+				continue
+			}
+
+			document, err := source.Document(ctx, hash)
+			if err != nil {
+				return MemberEntry{}, false, fmt.Errorf("cannot load %v %v: %v", status, account, err)
+			}
+			return MemberEntry{
+				Account:  account,
+				Status:   status,
+				Document: document,
+				Since:    ToTime(edge.CreatedDate),
+				By:       eos.AN(string(edge.Creator)),
+			}, true, nil
+		}
+	}
+	return MemberEntry{}, false, nil
+}
+
+// targets loads the documents linked from hash with edgeName, once each, along with the first edge to each
+func targets(ctx context.Context, source QuerySource, hash string, edgeName eos.Name) ([]docgraph.Document, []docgraph.Edge, error) {
+
+	edges, err := source.EdgesFrom(ctx, hash, edgeName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot read %v edges of %v: %v", edgeName, hash, err)
+	}
+
+	var documents []docgraph.Document
+	var kept []docgraph.Edge
+	seen := make(map[string]bool)
+	for _, edge := range edges {
+		if seen[edge.ToNode.String()] {
+			continue
+		}
+		seen[edge.ToNode.String()] = true
+
+		document, err := source.Document(ctx, edge.ToNode.String())
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot load %v target %v: %v", edgeName, edge.ToNode, err)
+		}
+		documents = append(documents, document)
+		kept = append(kept, edge)
+	}
+	return documents, kept, nil
+}
+
+// assignmentActive returns whether the periods of assignment in calendar include at; assignments
+// that run past the end of the calendar are active once started
+func assignmentActive(assignment docgraph.Document, calendar []PeriodSpan, at time.Time) bool {
+
+	startPeriod, err := getChecksum(assignment, "start_period")
+	if err != nil {
+		return false
+	}
+	periodCount, err := getInt64(assignment, "period_count")
+	if err != nil {
+		return false
+	}
+
+	for i, period := range calendar {
+		if period.Document.Hash.String() != startPeriod.String() {
+			continue
+		}
+		if at.Before(period.StartTime) {
+			return false
+		}
+		last := i + int(periodCount) - 1
+		return last >= len(calendar)-1 || at.Before(calendar[last].EndTime)
+	}
+	return false
+}
+
+// ReadMemberProfile returns the profile of account in source, with the role assignments active at at;
+// the voting power is left empty as it is held by Telos Decide
+func ReadMemberProfile(ctx context.Context, source QuerySource, account eos.AccountName, at time.Time) (MemberSummary, error) {
+
+	entry, found, err := findMember(ctx, source, account)
+	if err != nil {
+		return MemberSummary{}, err
+	}
+	if !found {
+		return MemberSummary{}, fmt.Errorf("%v is neither a member nor an applicant", account)
+	}
+	summary := MemberSummary{MemberEntry: entry}
+	hash := entry.Document.Hash.String()
+
+	calendar, err := Calendar(ctx, source)
+	if err != nil {
+		return summary, err
+	}
+
+	assignments, _, err := targets(ctx, source, hash, eos.Name("assigned"))
+	if err != nil {
+		return summary, err
+	}
+	for _, assignment := range assignments {
+		if DocumentType(assignment) == "assignment" && assignmentActive(assignment, calendar, at) {
+			summary.Assignments = append(summary.Assignments, assignment)
+		}
+	}
+
+	if summary.Badges, _, err = targets(ctx, source, hash, eos.Name("holdsbadge")); err != nil {
+		return summary, err
+	}
+	if summary.Proposals, _, err = targets(ctx, source, hash, eos.Name("owns")); err != nil {
+		return summary, err
+	}
+
+	// vote documents are shared by the votes of a member with the same power and option
+	votes, _, err := targets(ctx, source, hash, eos.Name("vote"))
+	if err != nil {
+		return summary, err
+	}
+	for _, vote := range votes {
+		option, _ := vote.GetContent("vote")
+		power, _ := getAsset(vote, "vote_power")
+
+		proposals, edges, err := targets(ctx, source, vote.Hash.String(), eos.Name("voteon"))
+		if err != nil {
+			return summary, err
+		}
+		for i, proposal := range proposals {
+			memberVote := MemberVote{Proposal: proposal, Power: power, CastAt: ToTime(edges[i].CreatedDate)}
+			if option != nil {
+				memberVote.Vote = option.String()
+			}
+			summary.Votes = append(summary.Votes, memberVote)
+		}
+	}
+	sort.SliceStable(summary.Votes, func(i, j int) bool { return summary.Votes[i].CastAt.Before(summary.Votes[j].CastAt) })
+
+	// payments are linked with paid edges, and the genesis HVOICE of enrollment with a payment edge
+	totals := make(map[string]eos.Asset)
+	seen := make(map[string]bool)
+	for _, edgeName := range []eos.Name{"paid", "payment"} {
+		payments, _, err := targets(ctx, source, hash, edgeName)
+		if err != nil {
+			return summary, err
+		}
+		for _, payment := range payments {
+			if seen[payment.Hash.String()] || DocumentType(payment) != "payment" {
+				continue
+			}
+			seen[payment.Hash.String()] = true
+			summary.Payments = append(summary.Payments, payment)
+
+			amount, err := getAsset(payment, "amount")
+			if err != nil {
+				return summary, err
+			}
+			total, ok := totals[amount.Symbol.Symbol]
+			if !ok {
+				total = eos.Asset{Symbol: amount.Symbol}
+			}
+			total.Amount += amount.Amount
+			totals[amount.Symbol.Symbol] = total
+		}
+	}
+	for _, total := range totals {
+		summary.PaymentTotals = append(summary.PaymentTotals, total)
+	}
+	sort.Slice(summary.PaymentTotals, func(i, j int) bool {
+		return summary.PaymentTotals[i].Symbol.Symbol < summary.PaymentTotals[j].Symbol.Symbol
+	})
+	return summary, nil
+}
+
+// MemberProfile returns the profile of account in contract, with its active role assignments and its
+// HVOICE voting power in the Telos Decide contract of the settings
+func MemberProfile(ctx context.Context, api *eos.API, contract, account eos.AccountName) (MemberSummary, error) {
+
+	summary, err := ReadMemberProfile(ctx, NewEndpointSource(api, contract), account, time.Now())
+	if err != nil {
+		return summary, err
+	}
+
+	telosDecide, err := telosDecideContract(ctx, api, contract)
+	if err != nil {
+		return summary, err
+	}
+
+	summary.VotingPower, summary.RegisteredVoter, err = LoadVotingPower(ctx, api, telosDecide, account)
+	return summary, err
+}
+
+// telosDecideContract returns the Telos Decide contract of the settings of contract
+func telosDecideContract(ctx context.Context, api *eos.API, contract eos.AccountName) (eos.AccountName, error) {
+
+	settings, err := LoadSettings(ctx, api, contract)
+	if err != nil {
+		return "", err
+	}
+	if settings.TelosDecideContract == nil {
+		return "", fmt.Errorf("telos_decide_contract is not set")
+	}
+	return eos.AN(string(*settings.TelosDecideContract)), nil
+}
+
+// Print writes the profile to w
+func (s MemberSummary) Print(w io.Writer) {
+	fmt.Fprintf(w, "%v, %v since %v by %v\n", s.Account, s.Status, s.Since.Format("2006-01-02 15:04:05"), s.By)
+	fmt.Fprintf(w, "  document     %v\n", s.Document.Hash)
+	if s.RegisteredVoter {
+		fmt.Fprintf(w, "  voting power %v\n", s.VotingPower)
+	} else {
+		fmt.Fprintf(w, "  voting power not registered with Telos Decide\n")
+	}
+
+	fmt.Fprintf(w, "\nActive assignments: %v\n", len(s.Assignments))
+	for _, assignment := range s.Assignments {
+		fmt.Fprintf(w, "  %v %v\n", assignment.Hash, NodeLabel(assignment))
+	}
+	fmt.Fprintf(w, "\nBadges: %v\n", len(s.Badges))
+	for _, badge := range s.Badges {
+		fmt.Fprintf(w, "  %v %v\n", badge.Hash, NodeLabel(badge))
+	}
+	fmt.Fprintf(w, "\nProposals: %v\n", len(s.Proposals))
+	for _, proposal := range s.Proposals {
+		fmt.Fprintf(w, "  %v %-10v %v\n", proposal.Hash, DocumentType(proposal), NodeLabel(proposal))
+	}
+	fmt.Fprintf(w, "\nVotes: %v\n", len(s.Votes))
+	for _, vote := range s.Votes {
+		fmt.Fprintf(w, "  %v %-4v %v on %v\n", vote.CastAt.Format("2006-01-02 15:04:05"), vote.Vote, vote.Power, NodeLabel(vote.Proposal))
+	}
+	fmt.Fprintf(w, "\nPayments: %v\n", len(s.Payments))
+	for _, total := range s.PaymentTotals {
+		fmt.Fprintf(w, "  %v\n", total)
+	}
+}
@@ -0,0 +1,114 @@
+package dao_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/dao-contracts/dao-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+	"gotest.tools/assert"
+)
+
+func TestMembers(t *testing.T) {
+
+	start := time.Date(2021, 1, 4, 0, 0, 0, 0, time.UTC)
+	member := func(account string) docgraph.Document {
+		return docgraph.Document{Hash: dao.MemberHash(eos.AN(account)), ContentGroups: dao.MemberContent(eos.AN(account))}
+	}
+	edgeAt := func(from, to docgraph.Document, edgeName string, creator string, at time.Time) docgraph.Edge {
+		edge := testEdge(from, to, edgeName)
+		edge.Creator = eos.Name(creator)
+		edge.CreatedDate = dao.ToTimePoint(at)
+		return edge
+	}
+	usd := func(amount eos.Int64, symbol string) eos.Asset {
+		return eos.Asset{Amount: amount, Symbol: eos.Symbol{Precision: 2, Symbol: symbol}}
+	}
+	assignment := func(hexChar, label string, startPeriod docgraph.Document, periodCount int64) docgraph.Document {
+		document := withDetails(testDocument(t, hexChar, "assignment", label), "start_period", 7, startPeriod.Hash)
+		return withDetails(document, "period_count", 4, periodCount)
+	}
+	payment := func(hexChar string, amount eos.Asset) docgraph.Document {
+		return withDetails(testDocument(t, hexChar, "payment", amount.String()), "amount", 2, amount)
+	}
+
+	root := testDocument(t, "1", "dho", "Hypha DHO Root")
+	q1 := withDetails(testDocument(t, "2", "period", "Q1"), "start_time", 4, dao.ToTimePoint(start))
+	q2 := withDetails(testDocument(t, "3", "period", "Q2"), "start_time", 4, dao.ToTimePoint(start.AddDate(0, 0, 7)))
+	q3 := withDetails(testDocument(t, "4", "period", "Q3"), "start_time", 4, dao.ToTimePoint(start.AddDate(0, 0, 14)))
+
+	johnny, alice, bob := member("johnnyhypha1"), member("alice"), member("bob")
+	current := assignment("5", "Healer", q2, 1)
+	ended := assignment("6", "Basketweaver", q1, 1)
+	badge := testDocument(t, "7", "badge", "Enroller")
+	proposal := testDocument(t, "8", "role", "Underwater Basketweaver")
+	vote := withDetails(withDetails(testDocument(t, "9", "vote", "johnnyhypha1"), "vote_power", 2, usd(10000, "HVOICE")), "vote", 1, "pass")
+	genesis, salary, seeds := payment("a", usd(100, "HVOICE")), payment("b", usd(50000, "HVOICE")), payment("c", usd(1200, "SEEDS"))
+
+	graph := &dao.Graph{
+		Documents: []docgraph.Document{root, q1, q2, q3, johnny, alice, bob, current, ended, badge, proposal, vote, genesis, salary, seeds},
+		Edges: []docgraph.Edge{
+			testEdge(root, q1, "start"),
+			testEdge(q1, q2, "next"),
+			testEdge(q2, q3, "next"),
+			edgeAt(root, alice, "member", "johnnyhypha1", start.AddDate(0, 0, 2)),
+			edgeAt(root, johnny, "member", "dao.hypha", start),
+			edgeAt(root, bob, "applicant", "bob", start.AddDate(0, 0, 3)),
+			testEdge(johnny, current, "assigned"),
+			testEdge(johnny, ended, "assigned"),
+			testEdge(johnny, badge, "holdsbadge"),
+			testEdge(johnny, proposal, "owns"),
+			testEdge(johnny, vote, "vote"),
+			edgeAt(vote, proposal, "voteon", "johnnyhypha1", start.AddDate(0, 0, 8)),
+			testEdge(johnny, genesis, "payment"),
+			testEdge(johnny, salary, "paid"),
+			testEdge(johnny, seeds, "paid"),
+		},
+	}
+
+	t.Run("List", func(t *testing.T) {
+		members, err := dao.Members(context.Background(), graph.Source())
+		assert.NilError(t, err)
+		assert.Equal(t, len(members), 2)
+		assert.Equal(t, members[0].Account, eos.AN("johnnyhypha1"))
+		assert.Equal(t, members[1].Account, eos.AN("alice"))
+		assert.Equal(t, members[1].By, eos.AN("johnnyhypha1"))
+		assert.Equal(t, members[1].Since, start.AddDate(0, 0, 2))
+
+		applicants, err := dao.Applicants(context.Background(), graph.Source())
+		assert.NilError(t, err)
+		assert.Equal(t, len(applicants), 1)
+		assert.Equal(t, applicants[0].Account, eos.AN("bob"))
+		assert.Equal(t, applicants[0].Status, dao.StatusApplicant)
+	})
+
+	t.Run("Profile", func(t *testing.T) {
+		profile, err := dao.ReadMemberProfile(context.Background(), graph.Source(), "johnnyhypha1", start.AddDate(0, 0, 9))
+		assert.NilError(t, err)
+		assert.Equal(t, profile.Status, dao.StatusMember)
+		assert.Equal(t, profile.By, eos.AN("dao.hypha"))
+
+		assert.Equal(t, len(profile.Assignments), 1)
+		assert.Equal(t, profile.Assignments[0].Hash.String(), current.Hash.String())
+		assert.Equal(t, len(profile.Badges), 1)
+		assert.Equal(t, len(profile.Proposals), 1)
+
+		assert.Equal(t, len(profile.Votes), 1)
+		assert.Equal(t, profile.Votes[0].Vote, "pass")
+		assert.Equal(t, profile.Votes[0].Power, usd(10000, "HVOICE"))
+		assert.Equal(t, profile.Votes[0].Proposal.Hash.String(), proposal.Hash.String())
+
+		// payment totals are summed by symbol, including the genesis HVOICE of enrollment
+		assert.Equal(t, len(profile.Payments), 3)
+		assert.DeepEqual(t, profile.PaymentTotals, []eos.Asset{usd(50100, "HVOICE"), usd(1200, "SEEDS")})
+
+		applicant, err := dao.ReadMemberProfile(context.Background(), graph.Source(), "bob", start)
+		assert.NilError(t, err)
+		assert.Equal(t, applicant.Status, dao.StatusApplicant)
+
+		_, err = dao.ReadMemberProfile(context.Background(), graph.Source(), "carol", start)
+		assert.ErrorContains(t, err, "carol is neither a member nor an applicant")
+	})
+}
@@ -4,9 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
-	"time"
 
-	eostest "github.com/digital-scarcity/eos-go-test"
 	"github.com/eoscanada/eos-go"
 )
 
@@ -20,102 +18,94 @@ type migratePer struct {
 	ID uint64 `json:"id"`
 }
 
-// MigrateAssPayouts ...
-func MigrateAssPayouts(ctx context.Context, api *eos.API, contract eos.AccountName) {
+// MigrateAssPayouts migrates the legacy assignment payouts listed by source, skipping the ones the journal
+// records as already migrated
+func MigrateAssPayouts(ctx context.Context, api *eos.API, contract eos.AccountName, source LegacyTables, executor *Executor) error {
 
-	payoutsIn, err := getAllAssPayouts(ctx, api, contract)
+	payoutsIn, err := source.AssPayouts(ctx)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("cannot load assignment payouts %v", err)
 	}
 
-	fmt.Println("\nMigrating assignment payments : " + strconv.Itoa(len(payoutsIn)))
-	bar := DefaultProgressBar(len(payoutsIn))
-
+	var tasks []Task
 	for index, payoutIn := range payoutsIn {
-
-		actions := []*eos.Action{{
-			Account: contract,
-			Name:    eos.ActN("migasspay"),
-			Authorization: []eos.PermissionLevel{
-				{Actor: contract, Permission: eos.PN("active")},
-			},
-			ActionData: eos.NewActionData(migratePer{
-				ID: payoutIn.ID,
-			}),
-		}}
-
-		_, err := eostest.ExecTrx(ctx, api, actions)
-		if err != nil {
-			fmt.Println("\nFAILED to migrate assignment pay: ", payoutIn.PaymentDate.Format("2006 Jan 02"), ", ", strconv.Itoa(index)+" / "+strconv.Itoa(len(payoutsIn)))
-			fmt.Println(err)
-			fmt.Println()
-		}
-
-		bar.Add(1)
-		time.Sleep(defaultPause())
+		tasks = append(tasks, Task{
+			Step:  "migasspay",
+			Key:   strconv.Itoa(int(payoutIn.ID)),
+			Label: payoutIn.PaymentDate.Format("2006 Jan 02") + ", " + strconv.Itoa(index) + " / " + strconv.Itoa(len(payoutsIn)),
+			Actions: []*eos.Action{{
+				Account: contract,
+				Name:    eos.ActN("migasspay"),
+				Authorization: []eos.PermissionLevel{
+					{Actor: contract, Permission: eos.PN("active")},
+				},
+				ActionData: eos.NewActionData(migratePer{
+					ID: payoutIn.ID,
+				}),
+			}},
+		})
 	}
-}
 
-// MigratePeriods ...
-func MigratePeriods(ctx context.Context, api *eos.API, contract eos.AccountName) {
+	return executor.Run(ctx, api, "migasspay", false, tasks)
+}
 
-	periods := getLegacyPeriods(ctx, api, contract)
+// MigratePeriods migrates the legacy periods listed by source, skipping the ones the journal records as already migrated.
+// Periods are migrated one at a time since each one is linked to its predecessor.
+func MigratePeriods(ctx context.Context, api *eos.API, contract eos.AccountName, source LegacyTables, executor *Executor) error {
 
-	fmt.Println("\nMigrating periods: " + strconv.Itoa(len(periods)))
-	bar := DefaultProgressBar(len(periods))
+	periods, err := source.Periods(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot load periods %v", err)
+	}
 
+	var tasks []Task
 	for _, period := range periods {
-
-		actions := []*eos.Action{{
-			Account: contract,
-			Name:    eos.ActN("migrateper"),
-			Authorization: []eos.PermissionLevel{
-				{Actor: contract, Permission: eos.PN("active")},
-			},
-			ActionData: eos.NewActionData(migratePer{
-				ID: period.PeriodID,
-			}),
-		}}
-
-		_, err := eostest.ExecTrx(ctx, api, actions)
-		if err != nil {
-			fmt.Println("\nFAILED to migrate period: ", strconv.Itoa(int(period.PeriodID)))
-			fmt.Println(err)
-			fmt.Println()
-		}
-		bar.Add(1)
-		time.Sleep(defaultPause())
+		tasks = append(tasks, Task{
+			Step:  "migrateper",
+			Key:   strconv.Itoa(int(period.PeriodID)),
+			Label: strconv.Itoa(int(period.PeriodID)),
+			Actions: []*eos.Action{{
+				Account: contract,
+				Name:    eos.ActN("migrateper"),
+				Authorization: []eos.PermissionLevel{
+					{Actor: contract, Permission: eos.PN("active")},
+				},
+				ActionData: eos.NewActionData(migratePer{
+					ID: period.PeriodID,
+				}),
+			}},
+		})
 	}
-}
 
-// MigrateMembers ...
-func MigrateMembers(ctx context.Context, api *eos.API, contract eos.AccountName) {
+	return executor.Run(ctx, api, "migrateper", true, tasks)
+}
 
-	memberRecords := getLegacyMembers(ctx, api, contract)
+// MigrateMembers migrates the legacy members listed by source, skipping the ones the journal records as already migrated
+func MigrateMembers(ctx context.Context, api *eos.API, contract eos.AccountName, source LegacyTables, executor *Executor) error {
 
-	fmt.Println("\nMigrating members: " + strconv.Itoa(len(memberRecords)))
-	bar := DefaultProgressBar(len(memberRecords))
+	memberRecords, err := source.Members(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot load members %v", err)
+	}
 
+	var tasks []Task
 	for index, memberRecord := range memberRecords {
-		actions := []*eos.Action{{
-			Account: contract,
-			Name:    eos.ActN("migratemem"),
-			Authorization: []eos.PermissionLevel{
-				{Actor: contract, Permission: eos.PN("active")},
-			},
-			ActionData: eos.NewActionData(memberRecord),
-		}}
-
-		_, err := eostest.ExecTrx(ctx, api, actions)
-		if err != nil {
-			fmt.Println("\n\nFAILED to migrate a member: ", memberRecord.MemberName, ", ", strconv.Itoa(index)+" / "+strconv.Itoa(len(memberRecords)))
-			fmt.Println(err)
-			fmt.Println()
-		}
-
-		bar.Add(1)
-		time.Sleep(defaultPause())
+		tasks = append(tasks, Task{
+			Step:  "migratemem",
+			Key:   string(memberRecord.MemberName),
+			Label: string(memberRecord.MemberName) + ", " + strconv.Itoa(index) + " / " + strconv.Itoa(len(memberRecords)),
+			Actions: []*eos.Action{{
+				Account: contract,
+				Name:    eos.ActN("migratemem"),
+				Authorization: []eos.PermissionLevel{
+					{Actor: contract, Permission: eos.PN("active")},
+				},
+				ActionData: eos.NewActionData(memberRecord),
+			}},
+		})
 	}
+
+	return executor.Run(ctx, api, "migratemem", false, tasks)
 }
 
 type migrate struct {
@@ -123,36 +113,33 @@ type migrate struct {
 	ID    uint64   `json:"id"`
 }
 
-// MigrateObjects ...
-func MigrateObjects(ctx context.Context, api *eos.API, contract eos.AccountName, scope eos.Name) {
-
-	objects, _ := getLegacyObjects(ctx, api, contract, scope)
+// MigrateObjects migrates the legacy objects of a scope listed by source, skipping the ones the journal records as already migrated
+func MigrateObjects(ctx context.Context, api *eos.API, contract eos.AccountName, scope eos.Name, source LegacyTables, executor *Executor) error {
 
-	fmt.Println("\nMigrating " + string(scope) + " objects: " + strconv.Itoa(len(objects)))
-	bar := DefaultProgressBar(len(objects))
+	objects, err := source.Objects(ctx, scope)
+	if err != nil {
+		return fmt.Errorf("cannot load %v objects %v", scope, err)
+	}
 
+	var tasks []Task
 	for index, object := range objects {
-
-		actions := []*eos.Action{{
-			Account: contract,
-			Name:    eos.ActN("migrate"),
-			Authorization: []eos.PermissionLevel{
-				{Actor: contract, Permission: eos.PN("active")},
-			},
-			ActionData: eos.NewActionData(migrate{
-				Scope: scope,
-				ID:    object.ID,
-			}),
-		}}
-
-		_, err := eostest.ExecTrx(ctx, api, actions)
-		if err != nil {
-			fmt.Println("\n\nFailed to migrate : ", strconv.Itoa(int(object.ID)), ", ", strconv.Itoa(index)+" / "+strconv.Itoa(len(objects)))
-			fmt.Println(err)
-			fmt.Println()
-		}
-
-		bar.Add(1)
-		time.Sleep(defaultPause())
+		tasks = append(tasks, Task{
+			Step:  "migrate",
+			Key:   string(scope) + "/" + strconv.Itoa(int(object.ID)),
+			Label: strconv.Itoa(int(object.ID)) + ", " + strconv.Itoa(index) + " / " + strconv.Itoa(len(objects)),
+			Actions: []*eos.Action{{
+				Account: contract,
+				Name:    eos.ActN("migrate"),
+				Authorization: []eos.PermissionLevel{
+					{Actor: contract, Permission: eos.PN("active")},
+				},
+				ActionData: eos.NewActionData(migrate{
+					Scope: scope,
+					ID:    object.ID,
+				}),
+			}},
+		})
 	}
+
+	return executor.Run(ctx, api, "migrate "+string(scope), false, tasks)
 }
@@ -0,0 +1,516 @@
+package dao
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+)
+
+// PreviewDocument is a document the contract would create while migrating a legacy record
+type PreviewDocument struct {
+	Scope         eos.Name                `json:"scope"`
+	LegacyID      string                  `json:"legacy_id"`
+	ContentGroups []docgraph.ContentGroup `json:"content_groups"`
+
+	// Unresolved lists the referenced hashes that are not known yet; they are left as zero checksums
+	Unresolved []string `json:"unresolved,omitempty"`
+}
+
+// MigrationPreview holds the hashes of documents already on the graph, which migrated documents reference
+type MigrationPreview struct {
+	XRefs    map[eos.Name]map[uint64]eos.Checksum256
+	Members  map[eos.Name]eos.Checksum256
+	MemoHash eos.Checksum256
+	Periods  []Period
+}
+
+func newContentItem(label, typeName string, impl interface{}) docgraph.ContentItem {
+	return docgraph.ContentItem{
+		Label: label,
+		Value: &docgraph.FlexValue{
+			BaseVariant: eos.BaseVariant{
+				TypeID: docgraph.GetVariants().TypeID(typeName),
+				Impl:   impl,
+			}},
+	}
+}
+
+// insertOrReplace mirrors ContentWrapper::insertOrReplace: the value is replaced in place when the label exists
+func insertOrReplace(group *docgraph.ContentGroup, item docgraph.ContentItem) {
+	for index := range *group {
+		if (*group)[index].Label == item.Label {
+			(*group)[index] = item
+			return
+		}
+	}
+	*group = append(*group, item)
+}
+
+func removeContent(group *docgraph.ContentGroup, label string) {
+	for index := range *group {
+		if (*group)[index].Label == label {
+			*group = append((*group)[:index], (*group)[index+1:]...)
+			return
+		}
+	}
+}
+
+// legacyPeriodLabels returns the readable start time and node label that CopyPeriods stores for a period
+func legacyPeriodLabels(period Period) (string, string) {
+	return period.StartTime.Time.Format("2006 Jan 02 15:04:05 MST"),
+		"Starting " + period.StartTime.Time.Format("2006 Jan 02")
+}
+
+// LegacyContentGroups is a port of Migration::newContentGroups, converting a legacy object
+// into the system and details content groups of its migrated document
+func LegacyContentGroups(object Object) []docgraph.ContentGroup {
+
+	system := docgraph.ContentGroup{
+		newContentItem("content_group_label", "string", "system"),
+		newContentItem("legacy_object_scope", "name", object.Scope),
+		newContentItem("legacy_object_id", "int64", int64(object.ID)),
+		newContentItem("legacy_object_created_date", "time_point", object.CreatedDate),
+	}
+
+	details := docgraph.ContentGroup{
+		newContentItem("content_group_label", "string", "details"),
+	}
+
+	// the contract iterates std::maps, so every kind of value is processed in key order
+	names := append([]NameKV{}, object.Names...)
+	sort.SliceStable(names, func(a, b int) bool { return names[a].Key < names[b].Key })
+	for _, kv := range names {
+		item := newContentItem(kv.Key, "name", kv.Value)
+		switch kv.Key {
+		case "trx_action_contract", "trx_action_name", "prior_scope":
+			// skip
+		case "type", "ballot_id":
+			system = append(system, item)
+		default:
+			details = append(details, item)
+		}
+	}
+
+	assets := append([]AssetKV{}, object.Assets...)
+	sort.SliceStable(assets, func(a, b int) bool { return assets[a].Key < assets[b].Key })
+	for _, kv := range assets {
+		details = append(details, newContentItem(kv.Key, "asset", kv.Value))
+	}
+
+	strs := append([]StringKV{}, object.Strings...)
+	sort.SliceStable(strs, func(a, b int) bool { return strs[a].Key < strs[b].Key })
+	for _, kv := range strs {
+		if kv.Value == "" || kv.Value == "null" {
+			continue
+		}
+
+		item := newContentItem(kv.Key, "string", kv.Value)
+		switch kv.Key {
+		case "client_version", "contract_version":
+			system = append(system, item)
+		default:
+			// use the role's title as the node label
+			if kv.Key == "title" {
+				system = append(system, newContentItem("node_label", "string", kv.Value))
+			}
+			details = append(details, item)
+		}
+	}
+
+	ints := append([]IntKV{}, object.Ints...)
+	sort.SliceStable(ints, func(a, b int) bool { return ints[a].Key < ints[b].Key })
+	for _, kv := range ints {
+		switch kv.Key {
+		case "object_id", "prior_id", "original_object_id", "revisions":
+			// skip
+		default:
+			details = append(details, newContentItem(kv.Key, "int64", int64(kv.Value)))
+		}
+	}
+
+	timePoints := append([]TimePointKV{}, object.TimePoints...)
+	sort.SliceStable(timePoints, func(a, b int) bool { return timePoints[a].Key < timePoints[b].Key })
+	for _, kv := range timePoints {
+		details = append(details, newContentItem(kv.Key, "time_point", kv.Value))
+	}
+
+	return []docgraph.ContentGroup{system, details}
+}
+
+// memberContentGroups is a port of Member::defaultContent
+func memberContentGroups(member eos.Name) []docgraph.ContentGroup {
+	return []docgraph.ContentGroup{
+		{
+			newContentItem("content_group_label", "string", "details"),
+			newContentItem("member", "name", member),
+		},
+		{
+			newContentItem("content_group_label", "string", "system"),
+			newContentItem("type", "name", eos.Name("member")),
+			newContentItem("node_label", "string", string(member)),
+		},
+	}
+}
+
+// periodContentGroups mirrors Migration::migratePeriod, which passes the legacy phase as the period's label
+func periodContentGroups(period Period) []docgraph.ContentGroup {
+	readable, nodeLabel := legacyPeriodLabels(period)
+	return []docgraph.ContentGroup{
+		{
+			newContentItem("content_group_label", "string", "details"),
+			newContentItem("start_time", "time_point", ToTimePoint(period.StartTime.Time)),
+			newContentItem("label", "string", period.Phase),
+		},
+		{
+			newContentItem("content_group_label", "string", "system"),
+			newContentItem("type", "name", eos.Name("period")),
+			newContentItem("readable_start_time", "string", readable),
+			newContentItem("readable_start_date", "string", readable[:11]),
+			newContentItem("node_label", "string", nodeLabel),
+		},
+	}
+}
+
+// receiptContentGroups is a port of Payer::defaultReceipt
+func receiptContentGroups(recipient eos.Name, quantity eos.Asset, memo string) []docgraph.ContentGroup {
+	return []docgraph.ContentGroup{
+		{
+			newContentItem("content_group_label", "string", "details"),
+			newContentItem("recipient", "name", recipient),
+			newContentItem("amount", "asset", quantity),
+			newContentItem("memo", "string", memo),
+		},
+		{
+			newContentItem("content_group_label", "string", "system"),
+			newContentItem("type", "name", eos.Name("payment")),
+			newContentItem("node_label", "string", quantity.String()+" to "+string(recipient)),
+		},
+	}
+}
+
+// xref returns the hash of a migrated legacy record, recording it as unresolved when it is not known
+func (p *MigrationPreview) xref(doc *PreviewDocument, scope eos.Name, id uint64) eos.Checksum256 {
+	if hash, ok := p.XRefs[scope][id]; ok {
+		return hash
+	}
+	doc.Unresolved = append(doc.Unresolved, "xref "+string(scope)+" "+strconv.FormatUint(id, 10))
+	return make(eos.Checksum256, 32)
+}
+
+// accountHash mirrors Migration::getAccountHash, which falls back to a memo for accounts that are not members
+func (p *MigrationPreview) accountHash(doc *PreviewDocument, account eos.Name) eos.Checksum256 {
+	if hash, ok := p.Members[account]; ok {
+		return hash
+	}
+	if len(p.MemoHash) == 0 {
+		doc.Unresolved = append(doc.Unresolved, "member "+string(account))
+		return make(eos.Checksum256, 32)
+	}
+	return p.MemoHash
+}
+
+// period returns the legacy period with this ID
+func (p *MigrationPreview) period(id uint64) (Period, error) {
+	for _, period := range p.Periods {
+		if period.PeriodID == id {
+			return period, nil
+		}
+	}
+	return Period{}, fmt.Errorf("period %v not found", id)
+}
+
+// periodAsOf mirrors Period::asOf, returning the period whose end time is not before the moment
+func (p *MigrationPreview) periodAsOf(moment eos.TimePoint) (Period, error) {
+	periods := append([]Period{}, p.Periods...)
+	sort.Slice(periods, func(a, b int) bool { return periods[a].StartTime.Before(periods[b].StartTime.Time) })
+
+	if len(periods) == 0 || ToTimePoint(periods[0].StartTime.Time) >= moment {
+		return Period{}, fmt.Errorf("start_period is in the future. No period found.")
+	}
+
+	for index := 0; index < len(periods)-1; index++ {
+		if ToTimePoint(periods[index+1].StartTime.Time) >= moment {
+			return periods[index], nil
+		}
+	}
+	return Period{}, fmt.Errorf("End of calendar has been reached. Contact administrator to add more time periods.")
+}
+
+// previewObject mirrors Migration::migrateRole, migrateAssignment and migratePayout
+func (p *MigrationPreview) previewObject(scope eos.Name, object Object) ([]PreviewDocument, error) {
+
+	object.Scope = scope
+	doc := PreviewDocument{
+		Scope:         scope,
+		LegacyID:      strconv.FormatUint(object.ID, 10),
+		ContentGroups: LegacyContentGroups(object),
+	}
+	system := &doc.ContentGroups[0]
+	details := &doc.ContentGroups[1]
+
+	switch scope {
+	case eos.Name("role"):
+		return []PreviewDocument{doc}, nil
+
+	case eos.Name("assignment"):
+		roleID, _ := objectInt(object, "role_id")
+		startPeriodID, _ := objectInt(object, "start_period")
+		endPeriodID, _ := objectInt(object, "end_period")
+		assignee, ok := objectName(object, "assigned_account")
+		if !ok {
+			return nil, fmt.Errorf("assignment %v has no assigned_account", object.ID)
+		}
+
+		startPeriod, err := p.period(startPeriodID)
+		if err != nil {
+			return nil, fmt.Errorf("assignment %v: %v", object.ID, err)
+		}
+		_, startPeriodLabel := legacyPeriodLabels(startPeriod)
+
+		insertOrReplace(details, newContentItem("start_period", "checksum256", p.xref(&doc, eos.Name("period"), startPeriodID)))
+		insertOrReplace(details, newContentItem("period_count", "int64", int64(endPeriodID)-int64(startPeriodID)))
+		insertOrReplace(details, newContentItem("role", "checksum256", p.xref(&doc, eos.Name("role"), roleID)))
+		insertOrReplace(details, newContentItem("assignee", "name", assignee))
+		insertOrReplace(system, newContentItem("node_label", "string", string(assignee)+": "+startPeriodLabel))
+
+		removeContent(details, "assigned_account")
+		removeContent(details, "fk")
+		removeContent(details, "role_id")
+		return []PreviewDocument{doc}, nil
+
+	case eos.Name("payout"):
+		startPeriodID, _ := objectInt(object, "start_period")
+		endPeriodID, _ := objectInt(object, "end_period")
+		recipient, ok := objectName(object, "recipient")
+		if !ok {
+			return nil, fmt.Errorf("payout %v has no recipient", object.ID)
+		}
+
+		paymentPeriod, err := p.periodAsOf(object.CreatedDate)
+		if err != nil {
+			return nil, fmt.Errorf("payout %v: %v", object.ID, err)
+		}
+		readable, _ := legacyPeriodLabels(paymentPeriod)
+
+		insertOrReplace(details, newContentItem("start_period", "checksum256", p.xref(&doc, eos.Name("period"), startPeriodID)))
+		insertOrReplace(details, newContentItem("period_count", "int64", int64(endPeriodID)-int64(startPeriodID)))
+		insertOrReplace(details, newContentItem("payment_date", "time_point", object.CreatedDate))
+		insertOrReplace(details, newContentItem("recipient", "name", recipient))
+		insertOrReplace(system, newContentItem("node_label", "string", string(recipient)+": "+readable[:11]))
+
+		documents := []PreviewDocument{doc}
+
+		assets := append([]AssetKV{}, object.Assets...)
+		sort.SliceStable(assets, func(a, b int) bool { return assets[a].Key < assets[b].Key })
+		for _, kv := range assets {
+			// only receipts for symbols known to have been paid, matching the legacy logic
+			switch kv.Value.Symbol.Symbol {
+			case "HUSD", "HYPHA", "HVOICE", "SEEDS":
+			default:
+				continue
+			}
+			if kv.Value.Amount <= 0 {
+				continue
+			}
+
+			receipt := PreviewDocument{
+				Scope:         eos.Name("payment"),
+				LegacyID:      doc.LegacyID,
+				ContentGroups: receiptContentGroups(recipient, kv.Value, "created during migration: "+kv.Key),
+				Unresolved:    append([]string{}, doc.Unresolved...),
+			}
+			insertOrReplace(&receipt.ContentGroups[0], newContentItem("payment_date", "time_point", object.CreatedDate))
+			insertOrReplace(&receipt.ContentGroups[0], newContentItem("payout_hash", "checksum256", p.xref(&receipt, scope, object.ID)))
+			documents = append(documents, receipt)
+		}
+		return documents, nil
+	}
+	return nil, fmt.Errorf("unknown legacy object scope: %v", scope)
+}
+
+// previewAssPayout mirrors Migration::migrateAssPayout, which creates a receipt per paid asset
+func (p *MigrationPreview) previewAssPayout(payout LegacyAssPayout) []PreviewDocument {
+
+	var documents []PreviewDocument
+	var refs PreviewDocument
+	assignmentHash := p.xref(&refs, eos.Name("assignment"), payout.AssignmentID)
+	periodHash := p.xref(&refs, eos.Name("period"), payout.PeriodID)
+	recipientHash := p.accountHash(&refs, payout.Recipient)
+
+	for _, payment := range payout.Payments {
+		if payment.Amount <= 0 {
+			continue
+		}
+
+		receipt := PreviewDocument{
+			Scope:         eos.Name("asspay"),
+			LegacyID:      strconv.FormatUint(payout.ID, 10),
+			ContentGroups: receiptContentGroups(payout.Recipient, payment, "created during migration"),
+			Unresolved:    refs.Unresolved,
+		}
+		details := &receipt.ContentGroups[0]
+		insertOrReplace(details, newContentItem("payment_date", "time_point", ToTimePoint(payout.PaymentDate.Time)))
+		insertOrReplace(details, newContentItem("period_hash", "checksum256", periodHash))
+		insertOrReplace(details, newContentItem("recipient_hash", "checksum256", recipientHash))
+		insertOrReplace(details, newContentItem("assignment_hash", "checksum256", assignmentHash))
+		documents = append(documents, receipt)
+	}
+	return documents
+}
+
+// LoadMigrationPreview reads the xref tables and the member and memo documents already on contract,
+// and the legacy periods from source
+func LoadMigrationPreview(ctx context.Context, api *eos.API, contract eos.AccountName, source LegacyTables) (MigrationPreview, error) {
+
+	preview := MigrationPreview{
+		XRefs:   make(map[eos.Name]map[uint64]eos.Checksum256),
+		Members: make(map[eos.Name]eos.Checksum256),
+	}
+
+	for _, scope := range []eos.Name{"period", "role", "assignment", "payout"} {
+		xrefs, err := getXRefs(ctx, api, contract, scope)
+		if err != nil {
+			return preview, fmt.Errorf("cannot load %v xrefs %v", scope, err)
+		}
+
+		preview.XRefs[scope] = make(map[uint64]eos.Checksum256)
+		for id, hash := range xrefs {
+			preview.XRefs[scope][id] = hashFromString(hash)
+		}
+	}
+
+	graph, err := loadMigratedGraph(ctx, api, contract)
+	if err != nil {
+		return preview, err
+	}
+
+	for member, documents := range graph.byMember {
+		preview.Members[member] = documents[0].Hash
+	}
+
+	for _, document := range graph.documents {
+		docType, err := getName(document, "type")
+		if err == nil && docType == eos.Name("memo") {
+			preview.MemoHash = document.Hash
+			break
+		}
+	}
+
+	preview.Periods, err = source.Periods(ctx)
+	if err != nil {
+		return preview, fmt.Errorf("cannot load periods %v", err)
+	}
+	return preview, nil
+}
+
+// PreviewMigration converts the legacy members, periods, objects of each scope and assignment payouts
+// read from the source endpoint or snapshot file into the documents the contract would create, without sending any transaction
+func PreviewMigration(ctx context.Context, api *eos.API, contract eos.AccountName, scopes []eos.Name, from string) ([]PreviewDocument, error) {
+
+	source, err := OpenLegacySource(from, contract)
+	if err != nil {
+		return nil, err
+	}
+
+	preview, err := LoadMigrationPreview(ctx, api, contract, source)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := source.Members(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load members %v", err)
+	}
+
+	var documents []PreviewDocument
+	for _, member := range members {
+		documents = append(documents, PreviewDocument{
+			Scope:         eos.Name("member"),
+			LegacyID:      string(member.MemberName),
+			ContentGroups: memberContentGroups(member.MemberName),
+		})
+	}
+
+	for _, period := range preview.Periods {
+		documents = append(documents, PreviewDocument{
+			Scope:         eos.Name("period"),
+			LegacyID:      strconv.FormatUint(period.PeriodID, 10),
+			ContentGroups: periodContentGroups(period),
+		})
+	}
+
+	for _, scope := range scopes {
+		objects, err := source.Objects(ctx, scope)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load %v objects %v", scope, err)
+		}
+
+		for _, object := range objects {
+			objectDocuments, err := preview.previewObject(scope, object)
+			if err != nil {
+				return nil, err
+			}
+			documents = append(documents, objectDocuments...)
+		}
+	}
+
+	payouts, err := source.AssPayouts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load assignment payouts %v", err)
+	}
+
+	for _, payout := range payouts {
+		documents = append(documents, preview.previewAssPayout(payout)...)
+	}
+	return documents, nil
+}
+
+func hashFromString(hash string) eos.Checksum256 {
+	checksum, err := hex.DecodeString(hash)
+	if err != nil {
+		return make(eos.Checksum256, 32)
+	}
+	return eos.Checksum256(checksum)
+}
+
+// PrintPreview writes each previewed document with its content groups
+func PrintPreview(w io.Writer, documents []PreviewDocument) {
+	for _, document := range documents {
+		fmt.Fprintf(w, "\n%v %v\n", document.Scope, document.LegacyID)
+		for _, group := range document.ContentGroups {
+			for _, item := range group {
+				fmt.Fprintf(w, "    %-32v %v\n", item.Label, contentValueString(item.Value))
+			}
+			fmt.Fprintln(w)
+		}
+		for _, unresolved := range document.Unresolved {
+			fmt.Fprintln(w, "    unresolved: "+unresolved)
+		}
+	}
+}
+
+func contentValueString(value *docgraph.FlexValue) string {
+	if value == nil {
+		return ""
+	}
+
+	switch v := value.Impl.(type) {
+	case eos.Asset:
+		return v.String()
+	case *eos.Asset:
+		return v.String()
+	case eos.TimePoint:
+		return formatTime(v)
+	case eos.Checksum256:
+		return v.String()
+	case int64:
+		return strconv.FormatInt(v, 10)
+	}
+	return fmt.Sprintf("%v", value.Impl)
+}
@@ -0,0 +1,63 @@
+package dao_test
+
+import (
+	"testing"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/dao-contracts/dao-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+	"gotest.tools/assert"
+)
+
+func labels(group docgraph.ContentGroup) []string {
+	var result []string
+	for _, item := range group {
+		result = append(result, item.Label)
+	}
+	return result
+}
+
+func TestLegacyContentGroups(t *testing.T) {
+
+	object := dao.Object{
+		ID:    42,
+		Scope: eos.Name("role"),
+		Names: []dao.NameKV{
+			{Key: "type", Value: eos.Name("role")},
+			{Key: "trx_action_name", Value: eos.Name("propose")},
+			{Key: "owner", Value: eos.Name("johnnyhypha1")},
+			{Key: "ballot_id", Value: eos.Name("hypha1....1cf")},
+		},
+		Strings: []dao.StringKV{
+			{Key: "title", Value: "Underwater Basketweaver"},
+			{Key: "description", Value: "null"},
+			{Key: "client_version", Value: "1.0.0"},
+		},
+		Assets: []dao.AssetKV{
+			{Key: "annual_usd_salary", Value: eos.Asset{Amount: 15000000, Symbol: eos.Symbol{Precision: 2, Symbol: "USD"}}},
+		},
+		Ints: []dao.IntKV{
+			{Key: "revisions", Value: 1},
+			{Key: "fulltime_capacity_x100", Value: 200},
+		},
+		TimePoints: []dao.TimePointKV{
+			{Key: "date_proposed", Value: eos.TimePoint(1590000000000000)},
+		},
+		CreatedDate: eos.TimePoint(1590000000000000),
+	}
+
+	groups := dao.LegacyContentGroups(object)
+	assert.Equal(t, len(groups), 2)
+
+	// names and strings are processed in key order, skipped keys and empty strings are dropped
+	assert.DeepEqual(t, labels(groups[0]), []string{
+		"content_group_label", "legacy_object_scope", "legacy_object_id", "legacy_object_created_date",
+		"ballot_id", "type", "client_version", "node_label",
+	})
+	assert.DeepEqual(t, labels(groups[1]), []string{
+		"content_group_label", "owner", "annual_usd_salary", "title", "fulltime_capacity_x100", "date_proposed",
+	})
+
+	assert.Equal(t, groups[0][2].Value.Impl, int64(42))
+	assert.Equal(t, groups[0][7].Value.Impl, "Underwater Basketweaver")
+}
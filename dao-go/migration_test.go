@@ -10,6 +10,7 @@ import (
 
 	"github.com/eoscanada/eos-go"
 	"github.com/hypha-dao/dao-contracts/dao-go"
+	"github.com/hypha-dao/dao-contracts/dao-go/daotest"
 )
 
 func TestMigrate(t *testing.T) {
@@ -26,29 +27,36 @@ pause: 200ms
 
 	viper.ReadConfig(bytes.NewBuffer(yamlExample))
 
-	env = SetupEnvironmentWithFlags(t, false, false)
+	options := testOptions()
+	options.NumPeriods = 0
+	options.Whale = daotest.MemberOptions{}
+	options.Members = nil
+	env = setupEnvironment(t, options)
 
 	t.Log(env.String())
 	t.Log("\nDAO Environment Setup complete\n")
 
-	dao.CopyMembers(env.ctx, &env.api, env.DAO, "https://api.telos.kitchen")
-	dao.MigrateMembers(env.ctx, &env.api, env.DAO)
+	executor := dao.NewExecutor(dao.DefaultExecutorOptions(), nil)
+	source := dao.NewEndpointTables(env.API, env.DAO)
 
-	dao.CopyPeriods(env.ctx, &env.api, env.DAO, "https://api.telos.kitchen")
-	dao.MigratePeriods(env.ctx, &env.api, env.DAO)
+	dao.CopyMembers(env.Ctx, env.API, env.DAO, "https://api.telos.kitchen", executor)
+	dao.MigrateMembers(env.Ctx, env.API, env.DAO, source, executor)
+
+	dao.CopyPeriods(env.Ctx, env.API, env.DAO, "https://api.telos.kitchen", executor)
+	dao.MigratePeriods(env.Ctx, env.API, env.DAO, source, executor)
 
 	scopes := []eos.Name{"role", "assignment"}
 
 	for _, scope := range scopes {
-		dao.CopyObjects(env.ctx, &env.api, env.DAO, scope, "https://api.telos.kitchen")
-		dao.MigrateObjects(env.ctx, &env.api, env.DAO, scope)
+		dao.CopyObjects(env.Ctx, env.API, env.DAO, scope, "https://api.telos.kitchen", executor)
+		dao.MigrateObjects(env.Ctx, env.API, env.DAO, scope, source, executor)
 	}
 
-	dao.CopyObjects(env.ctx, &env.api, env.DAO, eos.Name("payout"), "https://api.telos.kitchen")
-	// dao.MigrateObjects(env.ctx, &env.api, env.DAO, eos.Name("payout"))
+	dao.CopyObjects(env.Ctx, env.API, env.DAO, eos.Name("payout"), "https://api.telos.kitchen", executor)
+	// dao.MigrateObjects(env.Ctx, env.API, env.DAO, eos.Name("payout"))
 
-	// dao.CopyAssPayouts(env.ctx, &env.api, env.DAO, "https://api.telos.kitchen")
-	// dao.MigrateAssPayouts(env.ctx, &env.api, env.DAO)
+	// dao.CopyAssPayouts(env.Ctx, env.API, env.DAO, "https://api.telos.kitchen")
+	// dao.MigrateAssPayouts(env.Ctx, env.API, env.DAO)
 }
 
 // func TestMigrateRoles(t *testing.T) {
@@ -84,7 +92,7 @@ pause: 200ms
 // 			copyObjects(t, env, test.scope, "https://api.telos.kitchen")
 // 			pause(t, env.ChainResponsePause, "", "Waiting...")
 
-// 			migrateMembers(env.ctx, &env.api, env.DAO, "https://api.telos.kitchen")
+// 			migrateMembers(env.Ctx, env.API, env.DAO, "https://api.telos.kitchen")
 
 // 			objects := getObjects(t, env, test.scope, "http://localhost:8888")
 
@@ -102,12 +110,12 @@ pause: 200ms
 // 						ID:    object.ID,
 // 					}),
 // 				}}
-// 				_, err := eostest.ExecTrx(env.ctx, &env.api, actions)
+// 				_, err := eostest.ExecTrx(env.Ctx, env.API, actions)
 // 				assert.NilError(t, err)
 
 // 				pause(t, env.ChainResponsePause, "", "Waiting...")
 
-// 				role, err := docgraph.GetLastDocumentOfEdge(env.ctx, &env.api, env.DAO, test.scope)
+// 				role, err := docgraph.GetLastDocumentOfEdge(env.Ctx, env.API, env.DAO, test.scope)
 // 				assert.NilError(t, err)
 
 // 				ballot, err := role.GetContent("ballot_id")
@@ -139,7 +147,7 @@ type notes struct {
 // 		}),
 // 	}}
 
-// 	trxID, err := eostest.ExecTrx(env.ctx, &env.api, actions)
+// 	trxID, err := eostest.ExecTrx(env.Ctx, env.API, actions)
 // 	assert.NilError(t, err)
 
 // 	t.Log("Reset successful: " + trxID)
@@ -178,12 +186,12 @@ type notes struct {
 // 				}),
 // 			}
 
-// 			_, err := eostest.ExecTrx(env.ctx, &env.api, []*eos.Action{&addPeriodAction})
+// 			_, err := eostest.ExecTrx(env.Ctx, env.API, []*eos.Action{&addPeriodAction})
 // 			assert.NilError(t, err)
 
 // 			pause(t, time.Second, "Build block...", "")
 
-// 			lastPeriod, err = docgraph.GetLastDocument(env.ctx, &env.api, env.DAO)
+// 			lastPeriod, err = docgraph.GetLastDocument(env.Ctx, env.API, env.DAO)
 // 			assert.NilError(t, err)
 
 // 			predecessor = lastPeriod.Hash
@@ -215,7 +223,7 @@ func TestReset(t *testing.T) {
 			}),
 		}}
 
-		trxID, err := eostest.ExecTrx(env.ctx, &env.api, actions)
+		trxID, err := eostest.ExecTrx(env.Ctx, env.API, actions)
 		assert.NilError(t, err)
 
 		t.Log("Reset successful: " + trxID)
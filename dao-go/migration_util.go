@@ -26,8 +26,8 @@ func defaultPeriodDuration() time.Duration {
 	}
 	return time.Second * 6
 }
-func getAssPayoutRange(ctx context.Context, api *eos.API, contract eos.AccountName, id, count int) ([]assPayoutIn, bool, error) {
-	var records []assPayoutIn
+func getAssPayoutRange(ctx context.Context, api *eos.API, contract eos.AccountName, id, count int) ([]LegacyAssPayout, bool, error) {
+	var records []LegacyAssPayout
 	var request eos.GetTableRowsRequest
 	request.LowerBound = strconv.Itoa(id)
 	request.Code = string(contract)
@@ -37,26 +37,26 @@ func getAssPayoutRange(ctx context.Context, api *eos.API, contract eos.AccountNa
 	request.JSON = true
 	response, err := api.GetTableRows(ctx, request)
 	if err != nil {
-		return []assPayoutIn{}, false, fmt.Errorf("get table rows %v", err)
+		return []LegacyAssPayout{}, false, fmt.Errorf("get table rows %v", err)
 	}
 
 	err = response.JSONToStructs(&records)
 	if err != nil {
-		return []assPayoutIn{}, false, fmt.Errorf("json to structs %v", err)
+		return []LegacyAssPayout{}, false, fmt.Errorf("json to structs %v", err)
 	}
 	return records, response.More, nil
 }
 
-func getAllAssPayouts(ctx context.Context, api *eos.API, contract eos.AccountName) ([]assPayoutIn, error) {
+func getAllAssPayouts(ctx context.Context, api *eos.API, contract eos.AccountName) ([]LegacyAssPayout, error) {
 
-	var allPayouts []assPayoutIn
+	var allPayouts []LegacyAssPayout
 
 	cursor := 0
 	batchSize := 100
 
 	batch, more, err := getAssPayoutRange(ctx, api, contract, cursor, batchSize)
 	if err != nil {
-		return []assPayoutIn{}, fmt.Errorf("json to structs %v", err)
+		return []LegacyAssPayout{}, fmt.Errorf("json to structs %v", err)
 	}
 	allPayouts = append(allPayouts, batch...)
 
@@ -64,7 +64,7 @@ func getAllAssPayouts(ctx context.Context, api *eos.API, contract eos.AccountNam
 		cursor += batchSize
 		batch, more, err = getAssPayoutRange(ctx, api, contract, cursor, batchSize)
 		if err != nil {
-			return []assPayoutIn{}, fmt.Errorf("json to structs %v", err)
+			return []LegacyAssPayout{}, fmt.Errorf("json to structs %v", err)
 		}
 		allPayouts = append(allPayouts, batch...)
 	}
@@ -72,7 +72,7 @@ func getAllAssPayouts(ctx context.Context, api *eos.API, contract eos.AccountNam
 	return allPayouts, nil
 }
 
-func getLegacyPeriods(ctx context.Context, api *eos.API, contract eos.AccountName) []Period {
+func getLegacyPeriods(ctx context.Context, api *eos.API, contract eos.AccountName) ([]Period, error) {
 
 	var periods []Period
 	var request eos.GetTableRowsRequest
@@ -81,9 +81,16 @@ func getLegacyPeriods(ctx context.Context, api *eos.API, contract eos.AccountNam
 	request.Table = "periods"
 	request.Limit = 1000
 	request.JSON = true
-	response, _ := api.GetTableRows(ctx, request)
-	response.JSONToStructs(&periods)
-	return periods
+	response, err := api.GetTableRows(ctx, request)
+	if err != nil {
+		return []Period{}, fmt.Errorf("get table rows %v", err)
+	}
+
+	err = response.JSONToStructs(&periods)
+	if err != nil {
+		return []Period{}, fmt.Errorf("json to structs %v", err)
+	}
+	return periods, nil
 }
 
 // DefaultProgressBar ...
@@ -166,21 +173,57 @@ func getLegacyObjects(ctx context.Context, api *eos.API, contract eos.AccountNam
 	return allObjects, nil
 }
 
-type memberRecord struct {
+// LegacyMember is a record of the legacy members table
+type LegacyMember struct {
 	MemberName eos.Name `json:"member"`
 }
 
-func getLegacyMembers(ctx context.Context, api *eos.API, contract eos.AccountName) []memberRecord {
-	var memberRecords []memberRecord
+func getLegacyMembers(ctx context.Context, api *eos.API, contract eos.AccountName) ([]LegacyMember, error) {
+	var memberRecords []LegacyMember
 	var request eos.GetTableRowsRequest
 	request.Code = string(contract)
 	request.Scope = string(contract)
 	request.Table = "members"
 	request.Limit = 500
 	request.JSON = true
-	response, _ := api.GetTableRows(ctx, request)
-	response.JSONToStructs(&memberRecords)
-	return memberRecords
+	response, err := api.GetTableRows(ctx, request)
+	if err != nil {
+		return []LegacyMember{}, fmt.Errorf("get table rows %v", err)
+	}
+
+	err = response.JSONToStructs(&memberRecords)
+	if err != nil {
+		return []LegacyMember{}, fmt.Errorf("json to structs %v", err)
+	}
+	return memberRecords, nil
+}
+
+// LegacyApplicant is a record of the legacy applicants table
+type LegacyApplicant struct {
+	Applicant   eos.Name      `json:"applicant"`
+	Content     string        `json:"content"`
+	CreatedDate eos.TimePoint `json:"created_date"`
+	UpdatedDate eos.TimePoint `json:"updated_date"`
+}
+
+func getLegacyApplicants(ctx context.Context, api *eos.API, contract eos.AccountName) ([]LegacyApplicant, error) {
+	var applicants []LegacyApplicant
+	var request eos.GetTableRowsRequest
+	request.Code = string(contract)
+	request.Scope = string(contract)
+	request.Table = "applicants"
+	request.Limit = 500
+	request.JSON = true
+	response, err := api.GetTableRows(ctx, request)
+	if err != nil {
+		return []LegacyApplicant{}, fmt.Errorf("get table rows %v", err)
+	}
+
+	err = response.JSONToStructs(&applicants)
+	if err != nil {
+		return []LegacyApplicant{}, fmt.Errorf("json to structs %v", err)
+	}
+	return applicants, nil
 }
 
 type eraseDoc struct {
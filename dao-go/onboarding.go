@@ -0,0 +1,314 @@
+package dao
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/eoscanada/eos-go"
+)
+
+// Onboarding steps, in the order they run
+const (
+	StepRegVoter = "regvoter"
+	StepApply    = "apply"
+	StepEnroll   = "enroll"
+	StepMint     = "mint"
+)
+
+// Onboarding outcomes of a row
+const (
+	OutcomeOnboarded = "onboarded"
+	OutcomeUnchanged = "unchanged"
+	OutcomePlanned   = "planned"
+	OutcomeFailed    = "failed"
+)
+
+// genesisVoice is the HVOICE the contract mints to an applicant it enrolls
+var genesisVoice = eos.Asset{Amount: 100, Symbol: eos.Symbol{Precision: 2, Symbol: "HVOICE"}}
+
+// OnboardingRow is an account to onboard, with the notes of its application and the HVOICE it
+// should hold once onboarded, including the genesis HVOICE of enrollment
+type OnboardingRow struct {
+	Account eos.AccountName
+	Notes   string
+	Hvoice  *eos.Asset
+}
+
+// ReadOnboardingCSV reads the rows of a CSV with the columns account, notes and hvoice; notes and
+// hvoice are optional, and a first row starting with account is a header
+func ReadOnboardingCSV(r io.Reader) ([]OnboardingRow, error) {
+
+	in := csv.NewReader(r)
+	in.FieldsPerRecord = -1
+	in.TrimLeadingSpace = true
+	records, err := in.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read onboarding CSV %v", err)
+	}
+
+	var rows []OnboardingRow
+	seen := make(map[eos.AccountName]int)
+	for i, record := range records {
+		line := i + 1
+		account := strings.TrimSpace(record[0])
+		if i == 0 && account == "account" {
+			continue
+		}
+		if account == "" {
+			return nil, fmt.Errorf("line %v has no account", line)
+		}
+		if previous, ok := seen[eos.AN(account)]; ok {
+			return nil, fmt.Errorf("line %v lists %v again, first listed on line %v", line, account, previous)
+		}
+		seen[eos.AN(account)] = line
+
+		row := OnboardingRow{Account: eos.AN(account)}
+		if len(record) > 1 {
+			row.Notes = strings.TrimSpace(record[1])
+		}
+		if len(record) > 2 && strings.TrimSpace(record[2]) != "" {
+			hvoice, err := eos.NewAssetFromString(strings.TrimSpace(record[2]))
+			if err != nil {
+				return nil, fmt.Errorf("line %v has an invalid hvoice %v", line, err)
+			}
+			if hvoice.Symbol.Symbol != genesisVoice.Symbol.Symbol || hvoice.Precision != genesisVoice.Precision {
+				return nil, fmt.Errorf("line %v has hvoice %v, expected an amount like %v", line, record[2], genesisVoice)
+			}
+			row.Hvoice = &hvoice
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// AccountState is what onboarding needs to know of an account: its status in the DAO, if any, whether
+// the signer holds its active key, and its registration and voting power in Telos Decide
+type AccountState struct {
+	Status      string
+	HoldsKey    bool
+	Registered  bool
+	VotingPower eos.Asset
+}
+
+// OnboardingPlan is the steps onboarding runs for an account, and the HVOICE it mints
+type OnboardingPlan struct {
+	Steps []string
+	Mint  eos.Asset
+}
+
+// PlanOnboarding returns the steps that make the account of row a member holding its HVOICE; an
+// onboarded account needs no steps, so running the plan of a row again changes nothing
+func PlanOnboarding(row OnboardingRow, state AccountState) (OnboardingPlan, error) {
+
+	var plan OnboardingPlan
+	power := eos.Asset{Symbol: genesisVoice.Symbol}
+	if state.Registered {
+		power.Amount = state.VotingPower.Amount
+	}
+
+	switch state.Status {
+	case "":
+		if !state.HoldsKey {
+			return plan, fmt.Errorf("%v has not applied and no key of %v is available to apply", row.Account, row.Account)
+		}
+		plan.Steps = append(plan.Steps, StepApply)
+		fallthrough
+	case StatusApplicant:
+		plan.Steps = append(plan.Steps, StepEnroll)
+		power.Amount += genesisVoice.Amount
+	}
+
+	if row.Hvoice != nil && row.Hvoice.Amount > power.Amount {
+		plan.Mint = eos.Asset{Amount: row.Hvoice.Amount - power.Amount, Symbol: row.Hvoice.Symbol}
+		plan.Steps = append(plan.Steps, StepMint)
+	}
+
+	// enrollment and minting need a voter registered with Telos Decide, which only the voter can register
+	if len(plan.Steps) > 0 && !state.Registered {
+		if !state.HoldsKey {
+			return plan, fmt.Errorf("%v is not registered with Telos Decide and no key of %v is available to register", row.Account, row.Account)
+		}
+		plan.Steps = append([]string{StepRegVoter}, plan.Steps...)
+	}
+	return plan, nil
+}
+
+// holdsKey returns whether the signer of api holds a key that satisfies the active permission of account
+func holdsKey(ctx context.Context, api *eos.API, account *eos.AccountResp) (bool, error) {
+
+	if api.Signer == nil {
+		return false, nil
+	}
+	available, err := api.Signer.AvailableKeys(ctx)
+	if err != nil {
+		return false, fmt.Errorf("cannot read the available keys %v", err)
+	}
+
+	keys := make(map[string]bool)
+	for _, key := range available {
+		keys[key.String()] = true
+	}
+
+	for _, permission := range account.Permissions {
+		if permission.PermName != "active" {
+			continue
+		}
+		for _, key := range permission.RequiredAuth.Keys {
+			if keys[key.PublicKey.String()] && uint32(key.Weight) >= permission.RequiredAuth.Threshold {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// LoadAccountState checks that account exists and reads its state in contract and telosDecide
+func LoadAccountState(ctx context.Context, api *eos.API, contract, telosDecide, account eos.AccountName) (AccountState, error) {
+
+	var state AccountState
+	resp, err := api.GetAccount(ctx, account)
+	if err != nil {
+		return state, fmt.Errorf("cannot find account %v: %v", account, err)
+	}
+
+	if state.HoldsKey, err = holdsKey(ctx, api, resp); err != nil {
+		return state, err
+	}
+
+	entry, _, err := findMember(ctx, NewEndpointSource(api, contract), account)
+	if err != nil {
+		return state, err
+	}
+	state.Status = entry.Status
+
+	state.VotingPower, state.Registered, err = LoadVotingPower(ctx, api, telosDecide, account)
+	return state, err
+}
+
+// OnboardOptions configures Onboard; the enroller and the HVOICE issuer default to the contract,
+// and Telos Decide to the contract of the settings
+type OnboardOptions struct {
+	Enroller    eos.AccountName
+	Issuer      eos.AccountName
+	TelosDecide eos.AccountName
+	DryRun      bool
+}
+
+// OnboardingOutcome is what onboarding did for a row: the status of the account before, the steps
+// planned or run, and the transactions of the steps that ran
+type OnboardingOutcome struct {
+	Account      eos.AccountName
+	Status       string
+	Steps        []string
+	Minted       eos.Asset
+	Outcome      string
+	Transactions []string
+	Error        string
+}
+
+// Onboard runs the onboarding steps of each row in turn; a failed row is reported in its outcome and
+// does not stop the others
+func Onboard(ctx context.Context, api *eos.API, contract eos.AccountName, options OnboardOptions, rows []OnboardingRow) ([]OnboardingOutcome, error) {
+
+	if options.Enroller == "" {
+		options.Enroller = contract
+	}
+	if options.Issuer == "" {
+		options.Issuer = contract
+	}
+	if options.TelosDecide == "" {
+		telosDecide, err := telosDecideContract(ctx, api, contract)
+		if err != nil {
+			return nil, err
+		}
+		options.TelosDecide = telosDecide
+	}
+
+	outcomes := make([]OnboardingOutcome, 0, len(rows))
+	for _, row := range rows {
+		outcome := OnboardingOutcome{Account: row.Account}
+		if err := onboard(ctx, api, contract, options, row, &outcome); err != nil {
+			outcome.Outcome = OutcomeFailed
+			outcome.Error = err.Error()
+		}
+		outcomes = append(outcomes, outcome)
+	}
+	return outcomes, nil
+}
+
+// onboard plans and runs the steps of row, recording them in outcome
+func onboard(ctx context.Context, api *eos.API, contract eos.AccountName, options OnboardOptions,
+	row OnboardingRow, outcome *OnboardingOutcome) error {
+
+	state, err := LoadAccountState(ctx, api, contract, options.TelosDecide, row.Account)
+	if err != nil {
+		return err
+	}
+	outcome.Status = state.Status
+
+	plan, err := PlanOnboarding(row, state)
+	if err != nil {
+		return err
+	}
+	outcome.Steps = plan.Steps
+	outcome.Minted = plan.Mint
+
+	switch {
+	case len(plan.Steps) == 0:
+		outcome.Outcome = OutcomeUnchanged
+		return nil
+	case options.DryRun:
+		outcome.Outcome = OutcomePlanned
+		return nil
+	}
+
+	for _, step := range plan.Steps {
+		var trxID string
+		switch step {
+		case StepRegVoter:
+			trxID, err = RegVoter(ctx, api, options.TelosDecide, row.Account)
+		case StepApply:
+			trxID, err = Apply(ctx, api, contract, row.Account, row.Notes)
+		case StepEnroll:
+			trxID, err = Enroll(ctx, api, contract, options.Enroller, row.Account)
+		case StepMint:
+			trxID, err = Mint(ctx, api, options.TelosDecide, options.Issuer, row.Account, plan.Mint)
+		}
+		if err != nil {
+			return fmt.Errorf("cannot %v %v: %v", step, row.Account, err)
+		}
+		outcome.Transactions = append(outcome.Transactions, trxID)
+	}
+	outcome.Outcome = OutcomeOnboarded
+	return nil
+}
+
+// WriteOnboardingCSV writes the outcomes of onboarding as a CSV, one row per account
+func WriteOnboardingCSV(w io.Writer, outcomes []OnboardingOutcome) error {
+
+	out := csv.NewWriter(w)
+	out.Write([]string{"account", "status", "steps", "minted", "outcome", "transactions", "error"})
+	for _, outcome := range outcomes {
+		minted := ""
+		if outcome.Minted.Amount > 0 {
+			minted = outcome.Minted.String()
+		}
+		out.Write([]string{
+			string(outcome.Account),
+			outcome.Status,
+			strings.Join(outcome.Steps, ";"),
+			minted,
+			outcome.Outcome,
+			strings.Join(outcome.Transactions, ";"),
+			outcome.Error,
+		})
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		return fmt.Errorf("cannot write onboarding outcomes %v", err)
+	}
+	return nil
+}
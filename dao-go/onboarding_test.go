@@ -0,0 +1,76 @@
+package dao_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/dao-contracts/dao-go"
+	"gotest.tools/assert"
+)
+
+func TestOnboarding(t *testing.T) {
+
+	hvoice := func(amount eos.Int64) eos.Asset {
+		return eos.Asset{Amount: amount, Symbol: eos.Symbol{Precision: 2, Symbol: "HVOICE"}}
+	}
+
+	t.Run("Read", func(t *testing.T) {
+		rows, err := dao.ReadOnboardingCSV(strings.NewReader("account,notes,hvoice\n" +
+			"johnnyhypha1,\"Healer, and weaver\",5.00 HVOICE\n" +
+			"alice\n" +
+			"bob, referred by alice,\n"))
+		assert.NilError(t, err)
+		assert.Equal(t, len(rows), 3)
+		assert.Equal(t, rows[0].Notes, "Healer, and weaver")
+		assert.Equal(t, *rows[0].Hvoice, hvoice(500))
+		assert.Assert(t, rows[1].Hvoice == nil)
+		assert.Equal(t, rows[2].Notes, "referred by alice")
+
+		_, err = dao.ReadOnboardingCSV(strings.NewReader("alice\nbob\nalice\n"))
+		assert.ErrorContains(t, err, "line 3 lists alice again, first listed on line 1")
+		_, err = dao.ReadOnboardingCSV(strings.NewReader("alice,,5 HVOICE\n"))
+		assert.ErrorContains(t, err, "line 1 has hvoice 5 HVOICE")
+	})
+
+	t.Run("Plan", func(t *testing.T) {
+		target := hvoice(500)
+		row := dao.OnboardingRow{Account: "johnnyhypha1", Hvoice: &target}
+
+		plan, err := dao.PlanOnboarding(row, dao.AccountState{HoldsKey: true})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, plan.Steps, []string{dao.StepRegVoter, dao.StepApply, dao.StepEnroll, dao.StepMint})
+		// enrollment mints the genesis 1.00 HVOICE
+		assert.Equal(t, plan.Mint, hvoice(400))
+
+		// an applicant is enrolled by the enroller, without its key
+		plan, err = dao.PlanOnboarding(row, dao.AccountState{Status: dao.StatusApplicant, Registered: true, VotingPower: hvoice(0)})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, plan.Steps, []string{dao.StepEnroll, dao.StepMint})
+
+		// running again once onboarded changes nothing
+		plan, err = dao.PlanOnboarding(row, dao.AccountState{Status: dao.StatusMember, Registered: true, VotingPower: hvoice(500)})
+		assert.NilError(t, err)
+		assert.Equal(t, len(plan.Steps), 0)
+
+		_, err = dao.PlanOnboarding(row, dao.AccountState{})
+		assert.ErrorContains(t, err, "johnnyhypha1 has not applied and no key of johnnyhypha1 is available to apply")
+		_, err = dao.PlanOnboarding(row, dao.AccountState{Status: dao.StatusApplicant})
+		assert.ErrorContains(t, err, "is not registered with Telos Decide")
+	})
+
+	t.Run("Write", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NilError(t, dao.WriteOnboardingCSV(&out, []dao.OnboardingOutcome{
+			{Account: "johnnyhypha1", Steps: []string{"apply", "enroll", "mint"}, Minted: hvoice(400),
+				Outcome: dao.OutcomeOnboarded, Transactions: []string{"a1", "b2", "c3"}},
+			{Account: "alice", Status: dao.StatusMember, Outcome: dao.OutcomeUnchanged},
+			{Account: "bob", Outcome: dao.OutcomeFailed, Error: "cannot find account bob: unknown key"},
+		}))
+		assert.Equal(t, out.String(), "account,status,steps,minted,outcome,transactions,error\n"+
+			"johnnyhypha1,,apply;enroll;mint,4.00 HVOICE,onboarded,a1;b2;c3,\n"+
+			"alice,member,,,unchanged,,\n"+
+			"bob,,,,failed,,cannot find account bob: unknown key\n")
+	})
+}
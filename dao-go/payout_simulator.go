@@ -0,0 +1,397 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+)
+
+// Compensation is a set of amounts owed for (part of) a period
+type Compensation struct {
+	Husd   eos.Asset
+	Hypha  eos.Asset
+	Hvoice eos.Asset
+	Seeds  eos.Asset
+}
+
+func zeroCompensation() Compensation {
+	return Compensation{
+		Husd:   eos.Asset{Amount: 0, Symbol: eos.Symbol{Precision: 2, Symbol: "HUSD"}},
+		Hypha:  eos.Asset{Amount: 0, Symbol: eos.Symbol{Precision: 2, Symbol: "HYPHA"}},
+		Hvoice: eos.Asset{Amount: 0, Symbol: eos.Symbol{Precision: 2, Symbol: "HVOICE"}},
+		Seeds:  eos.Asset{Amount: 0, Symbol: eos.Symbol{Precision: 4, Symbol: "SEEDS"}},
+	}
+}
+
+func (c Compensation) add(other Compensation) Compensation {
+	c.Husd.Amount += other.Husd.Amount
+	c.Hypha.Amount += other.Hypha.Amount
+	c.Hvoice.Amount += other.Hvoice.Amount
+	c.Seeds.Amount += other.Seeds.Amount
+	return c
+}
+
+// SegmentPayout is the part of a period's payout earned under a single time share
+type SegmentPayout struct {
+	TimeShare  TimeShareSegment
+	Start      time.Time
+	End        time.Time
+	Multiplier float32
+	Amounts    Compensation
+}
+
+// PeriodPayout is the simulated payout of a single period
+type PeriodPayout struct {
+	Period   PeriodSpan
+	Segments []SegmentPayout
+	Total    Compensation
+}
+
+// PayoutSimulator reproduces the pro-rating of claimnextper for an assignment, using
+// the same float32 arithmetic as the contract so the results match to the last unit.
+// Badge coefficients are not applied.
+type PayoutSimulator struct {
+	Husd   eos.Asset
+	Hypha  eos.Asset
+	Hvoice eos.Asset
+
+	// SeedsEscrow is the explicit seeds_escrow_salary_per_phase, if the assignment has one
+	SeedsEscrow *eos.Asset
+
+	// UsdSalary, TimeShare and Deferred are used to calculate the SEEDS amount from the
+	// SEEDS price at the end of each period when there is no explicit SEEDS salary
+	UsdSalary *eos.Asset
+	TimeShare int64
+	Deferred  int64
+
+	SeedsDeferralFactor int64
+	SeedsPrices         []SeedsPriceHistory
+}
+
+// NewPayoutSimulator creates a simulator from the salary content of an assignment
+func NewPayoutSimulator(assignment docgraph.Document, seedsDeferralFactor int64, seedsPrices []SeedsPriceHistory) (*PayoutSimulator, error) {
+
+	zero := zeroCompensation()
+	simulator := PayoutSimulator{
+		Husd:                zero.Husd,
+		Hypha:               zero.Hypha,
+		Hvoice:              zero.Hvoice,
+		SeedsDeferralFactor: seedsDeferralFactor,
+		SeedsPrices:         seedsPrices,
+	}
+
+	var err error
+	if _, contentErr := assignment.GetContent("husd_salary_per_phase"); contentErr == nil {
+		if simulator.Husd, err = getAsset(assignment, "husd_salary_per_phase"); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, contentErr := assignment.GetContent("hypha_salary_per_phase"); contentErr == nil {
+		if simulator.Hypha, err = getAsset(assignment, "hypha_salary_per_phase"); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, contentErr := assignment.GetContent("hvoice_salary_per_phase"); contentErr == nil {
+		if simulator.Hvoice, err = getAsset(assignment, "hvoice_salary_per_phase"); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, contentErr := assignment.GetContent("seeds_escrow_salary_per_phase"); contentErr == nil {
+		seedsEscrow, err := getAsset(assignment, "seeds_escrow_salary_per_phase")
+		if err != nil {
+			return nil, err
+		}
+		simulator.SeedsEscrow = &seedsEscrow
+	} else if _, contentErr := assignment.GetContent("usd_salary_value_per_phase"); contentErr == nil {
+		usdSalary, err := getAsset(assignment, "usd_salary_value_per_phase")
+		if err != nil {
+			return nil, err
+		}
+		simulator.UsdSalary = &usdSalary
+
+		if simulator.TimeShare, err = getInt64(assignment, "time_share_x100"); err != nil {
+			return nil, err
+		}
+
+		if simulator.Deferred, err = getInt64(assignment, "deferred_perc_x100"); err != nil {
+			return nil, err
+		}
+	}
+
+	return &simulator, nil
+}
+
+// ProjectAdjustment returns a copy of the time share history with a new time share starting
+// at startDate, as adjustcmtmnt would add it, so its effect can be simulated before submitting it
+func ProjectAdjustment(history []TimeShareSegment, timeShare int64, startDate time.Time) []TimeShareSegment {
+	projected := make([]TimeShareSegment, len(history), len(history)+1)
+	copy(projected, history)
+
+	var endDate time.Time
+	if len(projected) > 0 {
+		endDate = projected[len(projected)-1].EndDate
+		projected[len(projected)-1].EndDate = startDate
+	}
+
+	return append(projected, TimeShareSegment{
+		TimeShare: timeShare,
+		StartDate: startDate,
+		EndDate:   endDate,
+	})
+}
+
+// Simulate calculates the payout of each period given the time share history of the assignment.
+// The periods are simulated as consecutive claims: like claimnextper, each one is pro-rated from
+// the current time share onwards, and the last time share used becomes the current one for the
+// next period. Without a segment marked as current the simulation starts at the initial one.
+func (s *PayoutSimulator) Simulate(periods []PeriodSpan, history []TimeShareSegment) []PeriodPayout {
+	current := 0
+	for i, segment := range history {
+		if segment.Current {
+			current = i
+		}
+	}
+
+	payouts := make([]PeriodPayout, len(periods))
+	for i, period := range periods {
+		payouts[i], current = s.SimulatePeriod(period, history, current)
+	}
+	return payouts
+}
+
+// SimulatePeriod calculates the payout of a single period, walking the time share history from
+// the current segment the same way claimnextper walks the 'nextimeshare' chain. It returns the
+// payout and the index of the last segment used, which the contract makes the current time share.
+func (s *PayoutSimulator) SimulatePeriod(period PeriodSpan, history []TimeShareSegment, current int) (PeriodPayout, int) {
+
+	payout := PeriodPayout{
+		Period: period,
+		Total:  zeroCompensation(),
+	}
+
+	if current < 0 || current >= len(history) {
+		return payout, current
+	}
+
+	initTimeShare := history[0].TimeShare
+	periodStartSec := period.StartTime.Unix()
+	periodEndSec := period.EndTime.Unix()
+	fullPeriodSec := periodEndSec - periodStartSec
+	seeds := s.seedsSalary(period)
+	lastUsed := current
+
+	for i := current; i < len(history); i++ {
+
+		segment := history[i]
+		startDateSec := segment.StartDate.Unix()
+
+		// the time share does not belong to the period being claimed
+		if periodEndSec-startDateSec <= 0 {
+			break
+		}
+		lastUsed = i
+
+		// the time share may have been set on previous periods
+		baseDateSec := startDateSec
+		if periodStartSec > baseDateSec {
+			baseDateSec = periodStartSec
+		}
+
+		// like the contract, a time share superseded before the period started is not skipped:
+		// its remaining time is negative
+		endDateSec := periodEndSec
+		if i+1 < len(history) && history[i+1].StartDate.Unix() < periodEndSec {
+			endDateSec = history[i+1].StartDate.Unix()
+		}
+
+		relativeDuration := float32(endDateSec-baseDateSec) / float32(fullPeriodSec)
+		relativeCommitment := float32(segment.TimeShare) / float32(initTimeShare)
+		multiplier := relativeDuration * relativeCommitment
+
+		amounts := Compensation{
+			Husd:   adjustAsset(s.Husd, multiplier),
+			Hypha:  adjustAsset(s.Hypha, multiplier),
+			Hvoice: adjustAsset(s.Hvoice, multiplier),
+			Seeds:  adjustAsset(seeds, multiplier),
+		}
+
+		payout.Segments = append(payout.Segments, SegmentPayout{
+			TimeShare:  segment,
+			Start:      time.Unix(baseDateSec, 0).UTC(),
+			End:        time.Unix(endDateSec, 0).UTC(),
+			Multiplier: multiplier,
+			Amounts:    amounts,
+		})
+		payout.Total = payout.Total.add(amounts)
+	}
+	return payout, lastUsed
+}
+
+// seedsSalary is the full period SEEDS amount before pro-rating, priced at the end of the period
+func (s *PayoutSimulator) seedsSalary(period PeriodSpan) eos.Asset {
+	if s.SeedsEscrow != nil {
+		return *s.SeedsEscrow
+	}
+
+	zero := zeroCompensation().Seeds
+	if s.UsdSalary == nil || len(s.SeedsPrices) == 0 {
+		return zero
+	}
+
+	timeShare := float32(s.TimeShare) / float32(100)
+	deferred := float32(s.Deferred) / float32(100)
+
+	adjustedUsd := adjustAsset(adjustAsset(*s.UsdSalary, deferred), timeShare)
+	seedsDeferralCoeff := float32(s.SeedsDeferralFactor) / float32(100)
+	seedsPrice := s.seedsPriceUsd(period.EndTime)
+
+	zero.Amount = eos.Int64(float32(adjustedUsd.Amount) * float32(100) * seedsDeferralCoeff)
+	return adjustAsset(zero, float32(1)/seedsPrice)
+}
+
+// seedsPriceUsd walks the price history backwards to the last price at or before the moment
+func (s *PayoutSimulator) seedsPriceUsd(moment time.Time) float32 {
+	i := len(s.SeedsPrices) - 1
+	for s.SeedsPrices[i].ID > s.SeedsPrices[0].ID && moment.Unix() < ToTime(s.SeedsPrices[i].Date).Unix() {
+		i--
+	}
+
+	seedsUsd := s.SeedsPrices[i].SeedsUSD
+	seedsUsdFloat := float32(seedsUsd.Amount) / float32(math.Pow(10, float64(seedsUsd.Precision)))
+	return float32(1) / seedsUsdFloat
+}
+
+func adjustAsset(original eos.Asset, adjustment float32) eos.Asset {
+	return eos.Asset{Amount: eos.Int64(float32(original.Amount) * adjustment), Symbol: original.Symbol}
+}
+
+// SimulatePayouts loads an assignment's unclaimed periods, time share history, SEEDS deferral factor
+// and the SEEDS price history of exchange from the chain and simulates the payout of every period
+// still to be claimed.
+// The contract reads prices from tlosto.seeds regardless of settings, so exchange is the SEEDS
+// exchange of the network profile.
+func SimulatePayouts(ctx context.Context, api *eos.API, contract, exchange eos.AccountName, assignment docgraph.Document) ([]PeriodPayout, error) {
+
+	simulator, history, periods, err := loadPayoutSimulation(ctx, api, contract, exchange, assignment)
+	if err != nil {
+		return []PeriodPayout{}, err
+	}
+	return simulator.Simulate(periods, history), nil
+}
+
+// SimulateAdjustment simulates the payouts of an assignment as if the adjustment was already made
+func SimulateAdjustment(ctx context.Context, api *eos.API, contract, exchange eos.AccountName, assignment docgraph.Document,
+	newTimeShare int64, startDate time.Time) ([]PeriodPayout, error) {
+
+	simulator, history, periods, err := loadPayoutSimulation(ctx, api, contract, exchange, assignment)
+	if err != nil {
+		return []PeriodPayout{}, err
+	}
+	return simulator.Simulate(periods, ProjectAdjustment(history, newTimeShare, startDate)), nil
+}
+
+func loadPayoutSimulation(ctx context.Context, api *eos.API, contract, exchange eos.AccountName, assignment docgraph.Document) (*PayoutSimulator, []TimeShareSegment, []PeriodSpan, error) {
+
+	history, err := TimeShareHistory(ctx, api, contract, assignment)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	periods, err := unclaimedPeriods(ctx, api, contract, assignment)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	settings, err := getSettings(ctx, api, contract)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("cannot retrieve settings document %v", err)
+	}
+
+	seedsDeferralFactor, err := getInt64(settings, "seeds_deferral_factor_x100")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	seedsPrices, err := getSeedsPriceHistory(ctx, api, exchange)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	simulator, err := NewPayoutSimulator(assignment, seedsDeferralFactor, seedsPrices)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return simulator, history, periods, nil
+}
+
+// unclaimedPeriods returns the periods of an assignment without a 'claimed' edge; the current
+// time share already reflects the claimed ones, so simulating them again would not match the contract
+func unclaimedPeriods(ctx context.Context, api *eos.API, contract eos.AccountName, assignment docgraph.Document) ([]PeriodSpan, error) {
+
+	periods, err := AssignmentPeriods(ctx, api, contract, assignment)
+	if err != nil {
+		return []PeriodSpan{}, err
+	}
+
+	edges, err := docgraph.GetEdgesFromDocumentWithEdge(ctx, api, contract, assignment, eos.Name("claimed"))
+	if err != nil {
+		return []PeriodSpan{}, fmt.Errorf("cannot retrieve claimed edges from %v: %v", assignment.Hash.String(), err)
+	}
+
+	claimed := make(map[string]bool)
+	for _, edge := range edges {
+		claimed[edge.ToNode.String()] = true
+	}
+
+	var unclaimed []PeriodSpan
+	for _, period := range periods {
+		if !claimed[period.Document.Hash.String()] {
+			unclaimed = append(unclaimed, period)
+		}
+	}
+	return unclaimed, nil
+}
+
+func getSeedsPriceHistory(ctx context.Context, api *eos.API, exchange eos.AccountName) ([]SeedsPriceHistory, error) {
+
+	var allPrices []SeedsPriceHistory
+	more := true
+	lowerBound := 0
+
+	for more {
+		var prices []SeedsPriceHistory
+		var request eos.GetTableRowsRequest
+		request.LowerBound = strconv.Itoa(lowerBound)
+		request.Code = string(exchange)
+		request.Scope = string(exchange)
+		request.Table = "pricehistory"
+		request.Limit = 1000
+		request.JSON = true
+		response, err := api.GetTableRows(ctx, request)
+		if err != nil {
+			return []SeedsPriceHistory{}, fmt.Errorf("get table rows %v", err)
+		}
+
+		err = response.JSONToStructs(&prices)
+		if err != nil {
+			return []SeedsPriceHistory{}, fmt.Errorf("json to structs %v", err)
+		}
+
+		if len(prices) == 0 {
+			break
+		}
+
+		allPrices = append(allPrices, prices...)
+		lowerBound = int(prices[len(prices)-1].ID) + 1
+		more = response.More
+	}
+	return allPrices, nil
+}
@@ -0,0 +1,108 @@
+package dao_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/dao-contracts/dao-go"
+	"gotest.tools/assert"
+)
+
+func TestPayoutSimulator(t *testing.T) {
+
+	simulator := dao.PayoutSimulator{
+		Husd:   eos.Asset{Amount: 10000, Symbol: eos.Symbol{Precision: 2, Symbol: "HUSD"}},
+		Hypha:  eos.Asset{Amount: 2500, Symbol: eos.Symbol{Precision: 2, Symbol: "HYPHA"}},
+		Hvoice: eos.Asset{Amount: 20000, Symbol: eos.Symbol{Precision: 2, Symbol: "HVOICE"}},
+	}
+
+	start := time.Unix(1600000000, 0).UTC()
+	periods := []dao.PeriodSpan{
+		{StartTime: start, EndTime: start.Add(30 * time.Second)},
+		{StartTime: start.Add(30 * time.Second), EndTime: start.Add(60 * time.Second)},
+	}
+
+	t.Run("Approved during the first period", func(t *testing.T) {
+		history := []dao.TimeShareSegment{
+			{TimeShare: 100, StartDate: start.Add(20 * time.Second)},
+		}
+
+		payouts := simulator.Simulate(periods, history)
+		assert.Equal(t, len(payouts), 2)
+
+		// only the last third of the first period is paid
+		assert.Equal(t, len(payouts[0].Segments), 1)
+		assert.Equal(t, payouts[0].Total.Husd.Amount, eos.Int64(3333))
+		assert.Equal(t, payouts[0].Total.Hypha.Amount, eos.Int64(833))
+		assert.Equal(t, payouts[0].Total.Hvoice.Amount, eos.Int64(6666))
+
+		assert.Equal(t, payouts[1].Total.Husd.Amount, eos.Int64(10000))
+		assert.Equal(t, payouts[1].Total.Hypha.Amount, eos.Int64(2500))
+		assert.Equal(t, payouts[1].Total.Hvoice.Amount, eos.Int64(20000))
+	})
+
+	t.Run("Three time shares within a period", func(t *testing.T) {
+		history := []dao.TimeShareSegment{
+			{TimeShare: 100, StartDate: start.Add(-time.Hour)},
+			{TimeShare: 50, StartDate: start.Add(40 * time.Second)},
+			{TimeShare: 100, StartDate: start.Add(50 * time.Second)},
+		}
+
+		payouts := simulator.Simulate(periods, history)
+
+		// the first period is paid in full, the adjustments belong to the second one
+		assert.Equal(t, len(payouts[0].Segments), 1)
+		assert.Equal(t, payouts[0].Total.Husd.Amount, eos.Int64(10000))
+
+		// 1/3 at 100%, 1/3 at 50% and 1/3 at 100%
+		assert.Equal(t, len(payouts[1].Segments), 3)
+		assert.Equal(t, payouts[1].Segments[0].Amounts.Husd.Amount, eos.Int64(3333))
+		assert.Equal(t, payouts[1].Segments[1].Amounts.Husd.Amount, eos.Int64(1666))
+		assert.Equal(t, payouts[1].Segments[2].Amounts.Husd.Amount, eos.Int64(3333))
+		assert.Equal(t, payouts[1].Total.Husd.Amount, eos.Int64(8332))
+	})
+
+	t.Run("Consecutive claims start at the current time share", func(t *testing.T) {
+		history := []dao.TimeShareSegment{
+			{TimeShare: 100, StartDate: start.Add(-time.Hour)},
+			{TimeShare: 50, StartDate: start.Add(10 * time.Second)},
+			{TimeShare: 100, StartDate: start.Add(20 * time.Second)},
+		}
+
+		// claiming the first period moves the current time share to the last one
+		payouts := simulator.Simulate(periods, history)
+		assert.Equal(t, len(payouts[0].Segments), 3)
+		assert.Equal(t, payouts[0].Total.Husd.Amount, eos.Int64(8332))
+		assert.Equal(t, len(payouts[1].Segments), 1)
+		assert.Equal(t, payouts[1].Total.Husd.Amount, eos.Int64(10000))
+
+		// as in the contract, time shares superseded before the period are not skipped
+		payout, current := simulator.SimulatePeriod(periods[1], history, 0)
+		assert.Equal(t, current, 2)
+		assert.Equal(t, len(payout.Segments), 3)
+		assert.Equal(t, payout.Segments[0].Amounts.Husd.Amount, eos.Int64(-6666))
+		assert.Equal(t, payout.Segments[1].Amounts.Husd.Amount, eos.Int64(-1666))
+		assert.Equal(t, payout.Total.Husd.Amount, eos.Int64(1668))
+
+		history[2].Current = true
+		payouts = simulator.Simulate(periods[1:], history)
+		assert.Equal(t, len(payouts[0].Segments), 1)
+		assert.Equal(t, payouts[0].Total.Husd.Amount, eos.Int64(10000))
+	})
+
+	t.Run("Projected adjustment", func(t *testing.T) {
+		history := []dao.TimeShareSegment{
+			{TimeShare: 100, StartDate: start.Add(-time.Hour), EndDate: start.Add(60 * time.Second)},
+		}
+
+		projected := dao.ProjectAdjustment(history, 50, start.Add(45*time.Second))
+		assert.Equal(t, len(history), 1)
+		assert.Equal(t, len(projected), 2)
+		assert.Equal(t, projected[0].EndDate, start.Add(45*time.Second))
+		assert.Equal(t, projected[1].EndDate, start.Add(60*time.Second))
+
+		payouts := simulator.Simulate(periods, projected)
+		assert.Equal(t, payouts[1].Total.Husd.Amount, eos.Int64(7500))
+	})
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/eoscanada/eos-go"
 	"github.com/hypha-dao/dao-contracts/dao-go"
+	"github.com/hypha-dao/dao-contracts/dao-go/daotest"
 	"github.com/hypha-dao/document-graph/docgraph"
 	"gotest.tools/assert"
 )
@@ -27,7 +28,7 @@ func TestPayoutProposal(t *testing.T) {
 		tests := []struct {
 			name                string
 			title               string
-			recipient           Member
+			recipient           daotest.Member
 			usdAmount           string
 			deferred            int64
 			payout              string
@@ -79,13 +80,8 @@ func TestPayoutProposal(t *testing.T) {
 			t.Log("\n\nStarting test: ", test.name)
 
 			proposalAmount, _ := eos.NewAssetFromString(test.usdAmount)
-			trxID, err := dao.ProposePayout(env.ctx, &env.api, env.DAO, proposer.Member,
+			_, payout, err := dao.ProposePayout(env.Ctx, env.API, env.DAO, proposer.Member,
 				test.recipient.Member, proposalAmount, test.deferred, test.payout)
-			t.Log("Payout proposed: ", trxID)
-			assert.NilError(t, err)
-
-			// retrieve the document we just created
-			payout, err := docgraph.GetLastDocumentOfEdge(env.ctx, &env.api, env.DAO, eos.Name("proposal"))
 			assert.NilError(t, err)
 			assert.Equal(t, payout.Creator, proposer.Member)
 
@@ -105,7 +101,7 @@ func TestPayoutProposal(t *testing.T) {
 			voteToPassTD(t, env, payout)
 
 			t.Log("Member: ", closer.Member, " is closing payout proposal	: ", payout.Hash.String())
-			_, err = dao.CloseProposal(env.ctx, &env.api, env.DAO, closer.Member, payout.Hash)
+			_, err = dao.CloseProposal(env.Ctx, env.API, env.DAO, closer.Member, payout.Hash)
 			assert.NilError(t, err)
 
 			// verify that the edges are created correctly
@@ -120,7 +116,7 @@ func TestPayoutProposal(t *testing.T) {
 			checkEdge(t, env, env.Root, payout, eos.Name("passedprops"))
 
 			// there should also be an edge from the payout to a payment
-			payment, err := docgraph.GetLastDocumentOfEdge(env.ctx, &env.api, env.DAO, eos.Name("payment"))
+			payment, err := docgraph.GetLastDocumentOfEdge(env.Ctx, env.API, env.DAO, eos.Name("payment"))
 			assert.NilError(t, err)
 
 			checkEdge(t, env, payout, payment, eos.Name("payment"))
@@ -130,7 +126,7 @@ func TestPayoutProposal(t *testing.T) {
 			expectedHyphaAsset, _ := eos.NewAssetFromString(test.expectedHypha)
 			// expectedSeedsEscrowAsset, _ := eos.NewAssetFromString(test.expectedSeedsEscrow)
 
-			balance := GetBalance(t, env, test.recipient.Member)
+			balance := env.Balance(test.recipient.Member)
 			assert.Equal(t, balance.Husd.Amount, expectedHusdAsset.Amount)
 			assert.Equal(t, balance.Hvoice.Amount, expectedHvoiceAsset.Amount)
 			assert.Equal(t, balance.Hypha.Amount, expectedHyphaAsset.Amount)
@@ -158,7 +154,7 @@ func TestPayoutHistoricalPeriod(t *testing.T) {
 		tests := []struct {
 			name                string
 			title               string
-			recipient           Member
+			recipient           daotest.Member
 			usdAmount           string
 			deferred            int64
 			payout              string
@@ -186,13 +182,9 @@ func TestPayoutHistoricalPeriod(t *testing.T) {
 			t.Log("\n\nStarting test: ", test.name)
 
 			proposalAmount, _ := eos.NewAssetFromString(test.usdAmount)
-			_, err := dao.ProposePayoutWithPeriod(env.ctx, &env.api, env.DAO, proposer.Member,
+			_, payout, err := dao.ProposePayoutWithPeriod(env.Ctx, env.API, env.DAO, proposer.Member,
 				test.recipient.Member, env.Periods[0].Hash, proposalAmount, test.deferred, test.payout)
 			assert.NilError(t, err)
-
-			// retrieve the document we just created
-			payout, err := docgraph.GetLastDocumentOfEdge(env.ctx, &env.api, env.DAO, eos.Name("proposal"))
-			assert.NilError(t, err)
 			assert.Equal(t, payout.Creator, proposer.Member)
 
 			fv, err := payout.GetContent("title")
@@ -211,7 +203,7 @@ func TestPayoutHistoricalPeriod(t *testing.T) {
 			voteToPassTD(t, env, payout)
 
 			t.Log("Member: ", closer.Member, " is closing payout proposal	: ", payout.Hash.String())
-			_, err = dao.CloseProposal(env.ctx, &env.api, env.DAO, closer.Member, payout.Hash)
+			_, err = dao.CloseProposal(env.Ctx, env.API, env.DAO, closer.Member, payout.Hash)
 			assert.NilError(t, err)
 
 			// verify that the edges are created correctly
@@ -226,7 +218,7 @@ func TestPayoutHistoricalPeriod(t *testing.T) {
 			checkEdge(t, env, env.Root, payout, eos.Name("passedprops"))
 
 			// there should also be an edge from the payout to a payment
-			payment, err := docgraph.GetLastDocumentOfEdge(env.ctx, &env.api, env.DAO, eos.Name("payment"))
+			payment, err := docgraph.GetLastDocumentOfEdge(env.Ctx, env.API, env.DAO, eos.Name("payment"))
 			assert.NilError(t, err)
 
 			checkEdge(t, env, payout, payment, eos.Name("payment"))
@@ -236,7 +228,7 @@ func TestPayoutHistoricalPeriod(t *testing.T) {
 			expectedHyphaAsset, _ := eos.NewAssetFromString(test.expectedHypha)
 			// expectedSeedsEscrowAsset, _ := eos.NewAssetFromString(test.expectedSeedsEscrow)
 
-			balance := GetBalance(t, env, test.recipient.Member)
+			balance := env.Balance(test.recipient.Member)
 			assert.Equal(t, balance.Husd.Amount, expectedHusdAsset.Amount)
 			assert.Equal(t, balance.Hvoice.Amount, expectedHvoiceAsset.Amount)
 			assert.Equal(t, balance.Hypha.Amount, expectedHyphaAsset.Amount)
@@ -263,7 +255,7 @@ func TestCustomPayout(t *testing.T) {
 		tests := []struct {
 			name                string
 			title               string
-			recipient           Member
+			recipient           daotest.Member
 			payout              string
 			expectedHusd        string
 			expectedHvoice      string
@@ -299,18 +291,12 @@ func TestCustomPayout(t *testing.T) {
 					}},
 			})
 
-			trxID, err := dao.Propose(env.ctx, &env.api, env.DAO, proposer.Member, dao.Proposal{
+			_, payout, err := dao.Propose(env.Ctx, env.API, env.DAO, proposer.Member, dao.Proposal{
 				Proposer:      proposer.Member,
 				ProposalType:  eos.Name("payout"),
 				ContentGroups: payoutDoc.ContentGroups,
 			})
 			assert.NilError(t, err)
-
-			t.Log("Payout proposed: ", trxID)
-
-			// retrieve the document we just created
-			payout, err := docgraph.GetLastDocumentOfEdge(env.ctx, &env.api, env.DAO, eos.Name("proposal"))
-			assert.NilError(t, err)
 			assert.Equal(t, payout.Creator, proposer.Member)
 
 			fv, err := payout.GetContent("title")
@@ -329,7 +315,7 @@ func TestCustomPayout(t *testing.T) {
 			voteToPassTD(t, env, payout)
 
 			t.Log("Member: ", closer.Member, " is closing payout proposal	: ", payout.Hash.String())
-			_, err = dao.CloseProposal(env.ctx, &env.api, env.DAO, closer.Member, payout.Hash)
+			_, err = dao.CloseProposal(env.Ctx, env.API, env.DAO, closer.Member, payout.Hash)
 			assert.NilError(t, err)
 
 			// verify that the edges are created correctly
@@ -344,7 +330,7 @@ func TestCustomPayout(t *testing.T) {
 			checkEdge(t, env, env.Root, payout, eos.Name("passedprops"))
 
 			// there should also be an edge from the payout to a payment
-			payment, err := docgraph.GetLastDocumentOfEdge(env.ctx, &env.api, env.DAO, eos.Name("payment"))
+			payment, err := docgraph.GetLastDocumentOfEdge(env.Ctx, env.API, env.DAO, eos.Name("payment"))
 			assert.NilError(t, err)
 
 			checkEdge(t, env, payout, payment, eos.Name("payment"))
@@ -354,7 +340,7 @@ func TestCustomPayout(t *testing.T) {
 			expectedHyphaAsset, _ := eos.NewAssetFromString(test.expectedHypha)
 			// expectedSeedsEscrowAsset, _ := eos.NewAssetFromString(test.expectedSeedsEscrow)
 
-			balance := GetBalance(t, env, test.recipient.Member)
+			balance := env.Balance(test.recipient.Member)
 			assert.Equal(t, balance.Husd.Amount, expectedHusdAsset.Amount)
 			assert.Equal(t, balance.Hvoice.Amount, expectedHvoiceAsset.Amount)
 			assert.Equal(t, balance.Hypha.Amount, expectedHyphaAsset.Amount)
@@ -381,7 +367,7 @@ func TestUnknownAssetPayout(t *testing.T) {
 		tests := []struct {
 			name      string
 			title     string
-			recipient Member
+			recipient daotest.Member
 			payout    string
 		}{
 			{
@@ -409,18 +395,12 @@ func TestUnknownAssetPayout(t *testing.T) {
 					}},
 			})
 
-			trxID, err := dao.Propose(env.ctx, &env.api, env.DAO, proposer.Member, dao.Proposal{
+			_, payout, err := dao.Propose(env.Ctx, env.API, env.DAO, proposer.Member, dao.Proposal{
 				Proposer:      proposer.Member,
 				ProposalType:  eos.Name("payout"),
 				ContentGroups: payoutDoc.ContentGroups,
 			})
 			assert.NilError(t, err)
-
-			t.Log("Payout proposed: ", trxID)
-
-			// retrieve the document we just created
-			payout, err := docgraph.GetLastDocumentOfEdge(env.ctx, &env.api, env.DAO, eos.Name("proposal"))
-			assert.NilError(t, err)
 			assert.Equal(t, payout.Creator, proposer.Member)
 
 			fv, err := payout.GetContent("title")
@@ -439,7 +419,7 @@ func TestUnknownAssetPayout(t *testing.T) {
 			voteToPassTD(t, env, payout)
 
 			t.Log("Member: ", closer.Member, " is closing payout proposal	: ", payout.Hash.String())
-			_, err = dao.CloseProposal(env.ctx, &env.api, env.DAO, closer.Member, payout.Hash)
+			_, err = dao.CloseProposal(env.Ctx, env.API, env.DAO, closer.Member, payout.Hash)
 			assert.ErrorContains(t, err, "Unknown")
 		}
 	})
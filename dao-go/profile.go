@@ -0,0 +1,263 @@
+package dao
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+// Profile is a network the DAO runs on: the endpoints of its nodes, its chain, the account of the DAO,
+// the Telos Decide and SEEDS exchange contracts read by the commands, and the permission of the DAO
+// that msig proposals request. The token, escrow and publisher contracts are read from the settings.
+type Profile struct {
+	Name          string             `yaml:"-"`
+	Endpoints     []string           `yaml:"endpoints"`
+	ChainID       string             `yaml:"chain_id"`
+	Contract      eos.AccountName    `yaml:"contract"`
+	TelosDecide   eos.AccountName    `yaml:"telos_decide"`
+	SeedsExchange eos.AccountName    `yaml:"seeds_exchange"`
+	Permission    eos.PermissionName `yaml:"permission"`
+
+	// MaxBlockAge is how far the head block of a healthy endpoint may lag behind, not checked if zero
+	MaxBlockAge time.Duration `yaml:"max_block_age"`
+	// Timeout bounds the health check of each endpoint, defaultHealthTimeout if zero
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+const defaultHealthTimeout = 5 * time.Second
+
+// Profiles returns the built in profiles: a local node, the Telos testnet and the Telos mainnet
+func Profiles() map[string]Profile {
+	return map[string]Profile{
+		"local": {
+			Name:          "local",
+			Endpoints:     []string{"http://localhost:8888"},
+			Contract:      "dao.hypha",
+			TelosDecide:   "telos.decide",
+			SeedsExchange: "tlosto.seeds",
+			Permission:    "active",
+		},
+		"testnet": {
+			Name:          "testnet",
+			Endpoints:     []string{"https://testnet.telos.caleos.io", "https://test.telos.kitchen"},
+			ChainID:       "1eaa0824707c8c16bd25145493bf062aecddfeb56c736f6ba6397f3195f33c9f",
+			Contract:      "dao.hypha",
+			TelosDecide:   "trailservice",
+			SeedsExchange: "tlosto.seeds",
+			Permission:    "active",
+			MaxBlockAge:   2 * time.Minute,
+		},
+		"mainnet": {
+			Name:          "mainnet",
+			Endpoints:     []string{"https://api.telos.kitchen", "https://telos.caleos.io"},
+			ChainID:       "4667b205c6838ef70ff7988f6e8257e8be0e1284a2f59699054a018f743b1d11",
+			Contract:      "dao.hypha",
+			TelosDecide:   "trailservice",
+			SeedsExchange: "tlosto.seeds",
+			Permission:    "active",
+			MaxBlockAge:   2 * time.Minute,
+		},
+	}
+}
+
+// LoadProfiles returns the built in profiles with the profiles of the config file at path added;
+// a profile of the file named after a built in one only replaces the fields it sets
+//
+//	profiles:
+//	  testnet:
+//	    endpoints: [http://localhost:8888]
+//	  custom:
+//	    endpoints: [https://node.example.com]
+//	    contract: mydao.hypha
+func LoadProfiles(path string) (map[string]Profile, error) {
+
+	profiles := Profiles()
+	if path == "" {
+		return profiles, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read profiles %v", err)
+	}
+
+	var config struct {
+		Profiles map[string]interface{} `yaml:"profiles"`
+	}
+	err = yaml.Unmarshal(data, &config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse profiles of %v: %v", path, err)
+	}
+
+	for name, fields := range config.Profiles {
+		data, err := yaml.Marshal(fields)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read profile %v: %v", name, err)
+		}
+
+		profile := profiles[name]
+		err = yaml.UnmarshalStrict(data, &profile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse profile %v: %v", name, err)
+		}
+		profile.Name = name
+		if profile.Permission == "" {
+			profile.Permission = "active"
+		}
+		profiles[name] = profile
+	}
+	return profiles, nil
+}
+
+// LoadProfile returns the profile called name, built in or from the config file at path
+func LoadProfile(path, name string) (Profile, error) {
+
+	profiles, err := LoadProfiles(path)
+	if err != nil {
+		return Profile{}, err
+	}
+
+	profile, ok := profiles[name]
+	if !ok {
+		var names []string
+		for name := range profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return Profile{}, fmt.Errorf("unknown profile %v, expected one of %v", name, strings.Join(names, ", "))
+	}
+
+	if len(profile.Endpoints) == 0 {
+		return profile, fmt.Errorf("profile %v has no endpoints", name)
+	}
+	return profile, nil
+}
+
+// Use makes the contract of the profile the configured default, leaving a contract set in the configuration in place
+func (p Profile) Use() {
+	viper.SetDefault("contract", string(p.Contract))
+}
+
+// EndpointHealth is the state of an endpoint found by a health check
+type EndpointHealth struct {
+	Endpoint      string
+	ChainID       string
+	HeadBlockNum  uint32
+	HeadBlockTime time.Time
+	Latency       time.Duration
+	Err           error
+}
+
+// Healthy returns whether the endpoint passed the health check
+func (h EndpointHealth) Healthy() bool {
+	return h.Err == nil
+}
+
+type chainInfo struct {
+	ChainID       string `json:"chain_id"`
+	HeadBlockNum  uint32 `json:"head_block_num"`
+	HeadBlockTime string `json:"head_block_time"`
+}
+
+// CheckEndpoint reads the chain info of endpoint, which is healthy if it answers within the timeout
+// of the profile, is on the chain of the profile and its head block is recent enough
+func (p Profile) CheckEndpoint(ctx context.Context, endpoint string) EndpointHealth {
+
+	health := EndpointHealth{Endpoint: endpoint}
+	timeout := p.Timeout
+	if timeout == 0 {
+		timeout = defaultHealthTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/v1/chain/get_info", bytes.NewReader([]byte("{}")))
+	if err != nil {
+		health.Err = fmt.Errorf("cannot create get info request %v", err)
+		return health
+	}
+
+	started := time.Now()
+	response, err := http.DefaultClient.Do(request)
+	health.Latency = time.Since(started)
+	if err != nil {
+		health.Err = fmt.Errorf("get info %v", err)
+		return health
+	}
+	defer response.Body.Close()
+
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		health.Err = fmt.Errorf("cannot read get info response %v", err)
+		return health
+	}
+	if response.StatusCode != http.StatusOK {
+		health.Err = fmt.Errorf("get info: status %v: %s", response.StatusCode, data)
+		return health
+	}
+
+	var info chainInfo
+	err = json.Unmarshal(data, &info)
+	if err != nil {
+		health.Err = fmt.Errorf("cannot unmarshal get info response %v", err)
+		return health
+	}
+	health.ChainID = info.ChainID
+	health.HeadBlockNum = info.HeadBlockNum
+
+	health.HeadBlockTime, err = parseBlockTime(info.HeadBlockTime)
+	if err != nil {
+		health.Err = fmt.Errorf("cannot parse head block time %v", err)
+		return health
+	}
+
+	if p.ChainID != "" && info.ChainID != p.ChainID {
+		health.Err = fmt.Errorf("endpoint is on chain %v, expected %v", info.ChainID, p.ChainID)
+	} else if age := time.Since(health.HeadBlockTime); p.MaxBlockAge > 0 && age > p.MaxBlockAge {
+		health.Err = fmt.Errorf("head block %v is %v old", info.HeadBlockNum, age.Round(time.Second))
+	}
+	return health
+}
+
+// CheckEndpoints checks every endpoint of the profile, in order
+func (p Profile) CheckEndpoints(ctx context.Context) []EndpointHealth {
+	var checks []EndpointHealth
+	for _, endpoint := range p.Endpoints {
+		checks = append(checks, p.CheckEndpoint(ctx, endpoint))
+	}
+	return checks
+}
+
+// Endpoint returns the first healthy endpoint of the profile, falling back to the next endpoints in order
+func (p Profile) Endpoint(ctx context.Context) (string, error) {
+
+	var problems []string
+	for _, endpoint := range p.Endpoints {
+		health := p.CheckEndpoint(ctx, endpoint)
+		if health.Healthy() {
+			return endpoint, nil
+		}
+		problems = append(problems, endpoint+": "+health.Err.Error())
+	}
+	return "", fmt.Errorf("no healthy endpoint in profile %v: %v", p.Name, strings.Join(problems, "; "))
+}
+
+// Connect returns an API on the first healthy endpoint of the profile
+func (p Profile) Connect(ctx context.Context) (*eos.API, error) {
+	endpoint, err := p.Endpoint(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return eos.New(endpoint), nil
+}
@@ -0,0 +1,95 @@
+package dao_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hypha-dao/dao-contracts/dao-go"
+	"gotest.tools/assert"
+)
+
+func TestProfiles(t *testing.T) {
+
+	chainID := "1eaa0824707c8c16bd25145493bf062aecddfeb56c736f6ba6397f3195f33c9f"
+	node := func(chainID string, headBlock time.Time) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, r.URL.Path, "/v1/chain/get_info")
+			fmt.Fprintf(w, `{"chain_id":"%v","head_block_num":42,"head_block_time":"%v"}`,
+				chainID, headBlock.UTC().Format("2006-01-02T15:04:05.000"))
+		}))
+	}
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	otherChain := node("4667b205c6838ef70ff7988f6e8257e8be0e1284a2f59699054a018f743b1d11", time.Now())
+	defer otherChain.Close()
+	stale := node(chainID, time.Now().Add(-time.Hour))
+	defer stale.Close()
+	healthy := node(chainID, time.Now())
+	defer healthy.Close()
+
+	config := filepath.Join(t.TempDir(), "dao.yaml")
+	err := ioutil.WriteFile(config, []byte(fmt.Sprintf(`
+host: https://ignored.example.com
+profiles:
+  testnet:
+    endpoints: [%v, %v, %v, %v]
+  custom:
+    endpoints: [%v]
+    contract: mydao.hypha
+    max_block_age: 1m
+`, down.URL, otherChain.URL, stale.URL, healthy.URL, stale.URL)), 0644)
+	assert.NilError(t, err)
+
+	t.Run("Load", func(t *testing.T) {
+		profiles, err := dao.LoadProfiles(config)
+		assert.NilError(t, err)
+		assert.Equal(t, len(profiles), 4)
+
+		// an overridden built in profile keeps the fields the file does not set
+		testnet := profiles["testnet"]
+		assert.Equal(t, len(testnet.Endpoints), 4)
+		assert.Equal(t, testnet.ChainID, chainID)
+		assert.Equal(t, string(testnet.TelosDecide), "trailservice")
+		assert.Equal(t, testnet.MaxBlockAge, 2*time.Minute)
+
+		custom := profiles["custom"]
+		assert.Equal(t, custom.Name, "custom")
+		assert.Equal(t, string(custom.Contract), "mydao.hypha")
+		assert.Equal(t, custom.MaxBlockAge, time.Minute)
+		assert.Equal(t, string(custom.Permission), "active")
+
+		_, err = dao.LoadProfile(config, "staging")
+		assert.ErrorContains(t, err, "unknown profile staging, expected one of custom, local, mainnet, testnet")
+	})
+
+	t.Run("Fallback", func(t *testing.T) {
+		testnet, err := dao.LoadProfile(config, "testnet")
+		assert.NilError(t, err)
+
+		checks := testnet.CheckEndpoints(context.Background())
+		assert.Equal(t, len(checks), 4)
+		assert.ErrorContains(t, checks[0].Err, "status 503")
+		assert.ErrorContains(t, checks[1].Err, "expected "+chainID)
+		assert.ErrorContains(t, checks[2].Err, "old")
+		assert.Assert(t, checks[3].Healthy())
+		assert.Equal(t, checks[3].HeadBlockNum, uint32(42))
+
+		endpoint, err := testnet.Endpoint(context.Background())
+		assert.NilError(t, err)
+		assert.Equal(t, endpoint, healthy.URL)
+
+		custom, err := dao.LoadProfile(config, "custom")
+		assert.NilError(t, err)
+		_, err = custom.Endpoint(context.Background())
+		assert.ErrorContains(t, err, "no healthy endpoint in profile custom")
+	})
+}
@@ -26,16 +26,12 @@ func TestProposalDocumentVote(t *testing.T) {
 	})
 
 	t.Run("Test Native voting for proposals", func(t *testing.T) {
-		_, err := dao.ProposeRole(env.ctx, &env.api, env.DAO, proposer.Member, role1)
-		assert.NilError(t, err)
-
-		// retrieve the document we just created
-		role, err := docgraph.GetLastDocumentOfEdge(env.ctx, &env.api, env.DAO, eos.Name("proposal"))
+		_, role, err := dao.ProposeRole(env.Ctx, env.API, env.DAO, proposer.Member, role1)
 		assert.NilError(t, err)
 		assert.Equal(t, role.Creator, proposer.Member)
 
 		// Tally must exist
-		voteTally, err := docgraph.GetLastDocumentOfEdge(env.ctx, &env.api, env.DAO, eos.Name("votetally"))
+		voteTally, err := docgraph.GetLastDocumentOfEdge(env.Ctx, env.API, env.DAO, eos.Name("votetally"))
 		assert.NilError(t, err)
 
 		// verify that the edges are created correctly
@@ -54,7 +50,7 @@ func TestProposalDocumentVote(t *testing.T) {
 
 		// whale votes "pass"
 		t.Log("whale votes pass")
-		_, err = dao.ProposalVote(env.ctx, &env.api, env.DAO, env.Whale.Member, "pass", role.Hash)
+		_, err = dao.ProposalVote(env.Ctx, env.API, env.DAO, env.Whale.Member, "pass", role.Hash)
 		assert.NilError(t, err)
 		voteDocument := checkLastVote(t, env, role, env.Whale)
 		AssertVote(t, voteDocument, "whale", "100.00 HVOICE", "pass")
@@ -65,7 +61,7 @@ func TestProposalDocumentVote(t *testing.T) {
 
 		// whale changes his mind and votes "fail"
 		t.Log("whale votes fail")
-		_, err = dao.ProposalVote(env.ctx, &env.api, env.DAO, env.Whale.Member, "fail", role.Hash)
+		_, err = dao.ProposalVote(env.Ctx, env.API, env.DAO, env.Whale.Member, "fail", role.Hash)
 		assert.NilError(t, err)
 		voteDocument = checkLastVote(t, env, role, env.Whale)
 		AssertVote(t, voteDocument, "whale", "100.00 HVOICE", "fail")
@@ -76,7 +72,7 @@ func TestProposalDocumentVote(t *testing.T) {
 
 		// whale decides to vote again for "fail". Just in case ;-)
 		t.Log("whale votes fail (again)")
-		_, err = dao.ProposalVote(env.ctx, &env.api, env.DAO, env.Whale.Member, "fail", role.Hash)
+		_, err = dao.ProposalVote(env.Ctx, env.API, env.DAO, env.Whale.Member, "fail", role.Hash)
 		assert.NilError(t, err)
 		voteDocument = checkLastVote(t, env, role, env.Whale)
 		AssertVote(t, voteDocument, "whale", "100.00 HVOICE", "fail")
@@ -86,7 +82,7 @@ func TestProposalDocumentVote(t *testing.T) {
 		AssertTally(t, voteTally, "0.00 HVOICE", "100.00 HVOICE")
 
 		// Member1 decides to vote pass
-		_, err = dao.ProposalVote(env.ctx, &env.api, env.DAO, env.Members[0].Member, "pass", role.Hash)
+		_, err = dao.ProposalVote(env.Ctx, env.API, env.DAO, env.Members[0].Member, "pass", role.Hash)
 		assert.NilError(t, err)
 		voteDocument = checkLastVote(t, env, role, env.Members[0])
 		AssertVote(t, voteDocument, "member1", "1.00 HVOICE", "pass")
@@ -96,7 +92,7 @@ func TestProposalDocumentVote(t *testing.T) {
 		AssertTally(t, voteTally, "1.00 HVOICE", "100.00 HVOICE")
 
 		// Member2 decides to vote fail
-		_, err = dao.ProposalVote(env.ctx, &env.api, env.DAO, env.Members[1].Member, "fail", role.Hash)
+		_, err = dao.ProposalVote(env.Ctx, env.API, env.DAO, env.Members[1].Member, "fail", role.Hash)
 		assert.NilError(t, err)
 		voteDocument = checkLastVote(t, env, role, env.Members[1])
 		AssertVote(t, voteDocument, "member2", "1.00 HVOICE", "fail")
@@ -106,7 +102,7 @@ func TestProposalDocumentVote(t *testing.T) {
 		AssertTally(t, voteTally, "1.00 HVOICE", "101.00 HVOICE")
 
 		// Member1 decides to vote pass (again)
-		_, err = dao.ProposalVote(env.ctx, &env.api, env.DAO, env.Members[0].Member, "pass", role.Hash)
+		_, err = dao.ProposalVote(env.Ctx, env.API, env.DAO, env.Members[0].Member, "pass", role.Hash)
 		assert.NilError(t, err)
 		voteDocument = checkLastVote(t, env, role, env.Members[0])
 		AssertVote(t, voteDocument, "member1", "1.00 HVOICE", "pass")
@@ -116,25 +112,25 @@ func TestProposalDocumentVote(t *testing.T) {
 		AssertTally(t, voteTally, "1.00 HVOICE", "101.00 HVOICE")
 
 		t.Log("Member: ", closer.Member, " is closing role proposal	: ", role.Hash.String())
-		_, err = dao.CloseProposal(env.ctx, &env.api, env.DAO, closer.Member, role.Hash)
+		_, err = dao.CloseProposal(env.Ctx, env.API, env.DAO, closer.Member, role.Hash)
 		assert.NilError(t, err)
 
 		// Member1 decides to vote pass
-		_, err = dao.ProposalVote(env.ctx, &env.api, env.DAO, env.Members[0].Member, "pass", role.Hash)
+		_, err = dao.ProposalVote(env.Ctx, env.API, env.DAO, env.Members[0].Member, "pass", role.Hash)
 		// but can't, proposal is closed
 		assert.ErrorContains(t, err, "Only allowed to vote active proposals")
 	})
 }
 
 func AssertDifferentLastTally(t *testing.T, tally docgraph.Document) docgraph.Document {
-	lastTally, err := docgraph.GetLastDocumentOfEdge(env.ctx, &env.api, env.DAO, eos.Name("votetally"))
+	lastTally, err := docgraph.GetLastDocumentOfEdge(env.Ctx, env.API, env.DAO, eos.Name("votetally"))
 	assert.NilError(t, err)
 	assert.Assert(t, tally.Hash.String() != lastTally.Hash.String())
 	return lastTally
 }
 
 func AssertSameLastTally(t *testing.T, tally docgraph.Document) docgraph.Document {
-	lastTally, err := docgraph.GetLastDocumentOfEdge(env.ctx, &env.api, env.DAO, eos.Name("votetally"))
+	lastTally, err := docgraph.GetLastDocumentOfEdge(env.Ctx, env.API, env.DAO, eos.Name("votetally"))
 	assert.NilError(t, err)
 	assert.Assert(t, tally.Hash.String() == lastTally.Hash.String())
 	return lastTally
@@ -36,30 +36,22 @@ type Proposal struct {
 	ContentGroups []docgraph.ContentGroup `json:"content_groups"`
 }
 
-// Propose ...
+// Propose proposes proposal and returns the transaction ID and the proposal document it created. When the
+// transaction succeeded the ID is returned even with an error, which is then a *ResultError.
 func Propose(ctx context.Context, api *eos.API,
-	contract, proposer eos.AccountName, proposal Proposal) (string, error) {
-	action := eos.ActN("propose")
-	actions := []*eos.Action{{
-		Account: contract,
-		Name:    action,
-		Authorization: []eos.PermissionLevel{
-			{Actor: proposer, Permission: eos.PN("active")},
-		},
-		ActionData: eos.NewActionData(proposal)}}
-
-	return eostest.ExecTrx(ctx, api, actions)
+	contract, proposer eos.AccountName, proposal Proposal) (string, docgraph.Document, error) {
+	return submitProposal(ctx, api, contract, proposal, []*eos.Action{proposeAction(contract, proposer, proposal)})
 }
 
 // ProposePayout ...
 func ProposePayout(ctx context.Context, api *eos.API,
 	contract, proposer, recipient eos.AccountName,
-	usdAmount eos.Asset, deferred int64, payout string) (string, error) {
+	usdAmount eos.Asset, deferred int64, payout string) (string, docgraph.Document, error) {
 
 	var payoutDoc docgraph.Document
 	err := json.Unmarshal([]byte(payout), &payoutDoc)
 	if err != nil {
-		return "error", fmt.Errorf("ProposePayout unmarshal : %v", err)
+		return "", docgraph.Document{}, fmt.Errorf("ProposePayout unmarshal : %v", err)
 	}
 
 	// inject the assignee in the first content group of the document
@@ -102,12 +94,12 @@ func ProposePayout(ctx context.Context, api *eos.API,
 // ProposePayoutWithPeriod creates a proposal for an new payout/contribution
 func ProposePayoutWithPeriod(ctx context.Context, api *eos.API,
 	contract, proposer, recipient eos.AccountName, endPeriod eos.Checksum256,
-	usdAmount eos.Asset, deferred int64, payout string) (string, error) {
+	usdAmount eos.Asset, deferred int64, payout string) (string, docgraph.Document, error) {
 
 	var payoutDoc docgraph.Document
 	err := json.Unmarshal([]byte(payout), &payoutDoc)
 	if err != nil {
-		return "error", fmt.Errorf("ProposePayout unmarshal : %v", err)
+		return "", docgraph.Document{}, fmt.Errorf("ProposePayout unmarshal : %v", err)
 	}
 
 	// inject the assignee in the first content group of the document
@@ -159,12 +151,12 @@ func ProposePayoutWithPeriod(ctx context.Context, api *eos.API,
 
 // ProposeEdit creates an edit proposal
 func ProposeEdit(ctx context.Context, api *eos.API,
-	contract, proposer eos.AccountName, original docgraph.Document, edit string) (string, error) {
+	contract, proposer eos.AccountName, original docgraph.Document, edit string) (string, docgraph.Document, error) {
 
 	var editDoc docgraph.Document
 	err := json.Unmarshal([]byte(edit), &editDoc)
 	if err != nil {
-		return "error", fmt.Errorf("ProposeEdit unmarshal : %v", err)
+		return "", docgraph.Document{}, fmt.Errorf("ProposeEdit unmarshal : %v", err)
 	}
 
 	// inject the assignee in the first content group of the document
@@ -186,12 +178,12 @@ func ProposeEdit(ctx context.Context, api *eos.API,
 
 // ProposeRole creates a proposal for an new assignment
 func ProposeRole(ctx context.Context, api *eos.API,
-	contract, proposer eos.AccountName, role string) (string, error) {
+	contract, proposer eos.AccountName, role string) (string, docgraph.Document, error) {
 
 	var roleDoc docgraph.Document
 	err := json.Unmarshal([]byte(role), &roleDoc)
 	if err != nil {
-		return "error", fmt.Errorf("ProposeRole unmarshal : %v", err)
+		return "", docgraph.Document{}, fmt.Errorf("ProposeRole unmarshal : %v", err)
 	}
 
 	return Propose(ctx, api, contract, proposer, Proposal{
@@ -204,12 +196,12 @@ func ProposeRole(ctx context.Context, api *eos.API,
 // ProposeAssignment creates a proposal for an new assignment
 func ProposeAssignment(ctx context.Context, api *eos.API,
 	contract, proposer, assignee eos.AccountName,
-	roleHash, startPeriod eos.Checksum256, assignment string) (string, error) {
+	roleHash, startPeriod eos.Checksum256, assignment string) (string, docgraph.Document, error) {
 
 	var assignmentDoc docgraph.Document
 	err := json.Unmarshal([]byte(assignment), &assignmentDoc)
 	if err != nil {
-		return "error", fmt.Errorf("ProposeAssignment unmarshal : %v", err)
+		return "", docgraph.Document{}, fmt.Errorf("ProposeAssignment unmarshal : %v", err)
 	}
 
 	// inject the role hash in the first content group of the document
@@ -250,14 +242,14 @@ func ProposeAssignment(ctx context.Context, api *eos.API,
 }
 
 // ProposeBadge proposes the badge to the specified DAO contract
-func ProposeBadge(ctx context.Context, api *eos.API, contract, proposer eos.AccountName, content string) (string, error) {
+func ProposeBadge(ctx context.Context, api *eos.API, contract, proposer eos.AccountName, content string) (string, docgraph.Document, error) {
 
 	action := eos.ActN("propose")
 
 	var dump map[string]interface{}
 	err := json.Unmarshal([]byte(content), &dump)
 	if err != nil {
-		return "error", fmt.Errorf("ProposeBadge : %v", err)
+		return "", docgraph.Document{}, fmt.Errorf("ProposeBadge : %v", err)
 	}
 
 	dump["proposer"] = proposer
@@ -274,18 +266,23 @@ func ProposeBadge(ctx context.Context, api *eos.API, contract, proposer eos.Acco
 		ActionData: eos.NewActionDataFromHexData([]byte(actionBinary)),
 	}}
 
-	return eostest.ExecTrx(ctx, api, actions)
+	var proposal Proposal
+	if err = json.Unmarshal([]byte(content), &proposal); err != nil {
+		return "", docgraph.Document{}, fmt.Errorf("ProposeBadge : %v", err)
+	}
+	proposal.ProposalType = eos.Name("badge")
+	return submitProposal(ctx, api, contract, proposal, actions)
 }
 
 // ProposeBadgeAssignment proposes the badge assignment to the specified DAO contract
 func ProposeBadgeAssignment(ctx context.Context, api *eos.API,
 	contract, proposer, assignee eos.AccountName,
-	badgeHash, startPeriod eos.Checksum256, assignment string) (string, error) {
+	badgeHash, startPeriod eos.Checksum256, assignment string) (string, docgraph.Document, error) {
 
 	var badgeAssignmentDoc docgraph.Document
 	err := json.Unmarshal([]byte(assignment), &badgeAssignmentDoc)
 	if err != nil {
-		return "error", fmt.Errorf("ProposeBadgeAssignment unmarshal : %v", err)
+		return "", docgraph.Document{}, fmt.Errorf("ProposeBadgeAssignment unmarshal : %v", err)
 	}
 
 	action := eos.ActN("propose")
@@ -310,19 +307,20 @@ func ProposeBadgeAssignment(ctx context.Context, api *eos.API,
 			}},
 	})
 
+	proposal := Proposal{
+		Proposer:      proposer,
+		ProposalType:  eos.Name("assignbadge"),
+		ContentGroups: badgeAssignmentDoc.ContentGroups,
+	}
 	actions := []*eos.Action{{
 		Account: contract,
 		Name:    action,
 		Authorization: []eos.PermissionLevel{
 			{Actor: proposer, Permission: eos.PN("active")},
 		},
-		ActionData: eos.NewActionData(Proposal{
-			Proposer:      proposer,
-			ProposalType:  eos.Name("assignbadge"),
-			ContentGroups: badgeAssignmentDoc.ContentGroups,
-		})}}
+		ActionData: eos.NewActionData(proposal)}}
 
-	return eostest.ExecTrx(ctx, api, actions)
+	return submitProposal(ctx, api, contract, proposal, actions)
 }
 
 // TelosDecideVote ...
@@ -0,0 +1,536 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+)
+
+// QuerySource is a document graph a query runs against
+type QuerySource interface {
+	Root(ctx context.Context) (docgraph.Document, error)
+	Document(ctx context.Context, hash string) (docgraph.Document, error)
+	Documents(ctx context.Context) ([]docgraph.Document, error)
+
+	// EdgesFrom and EdgesTo return the edges named edgeName leaving or reaching hash, or all of them when edgeName is empty
+	EdgesFrom(ctx context.Context, hash string, edgeName eos.Name) ([]docgraph.Edge, error)
+	EdgesTo(ctx context.Context, hash string, edgeName eos.Name) ([]docgraph.Edge, error)
+}
+
+type endpointSource struct {
+	api      *eos.API
+	contract eos.AccountName
+}
+
+// NewEndpointSource runs queries live against the graph of contract, one request per document and edge lookup
+func NewEndpointSource(api *eos.API, contract eos.AccountName) QuerySource {
+	return &endpointSource{api: api, contract: contract}
+}
+
+func (e *endpointSource) Root(ctx context.Context) (docgraph.Document, error) {
+	return LoadRoot(ctx, e.api, e.contract)
+}
+
+func (e *endpointSource) Document(ctx context.Context, hash string) (docgraph.Document, error) {
+	return docgraph.LoadDocument(ctx, e.api, e.contract, hash)
+}
+
+func (e *endpointSource) Documents(ctx context.Context) ([]docgraph.Document, error) {
+	return docgraph.GetAllDocuments(ctx, e.api, e.contract)
+}
+
+func (e *endpointSource) EdgesFrom(ctx context.Context, hash string, edgeName eos.Name) ([]docgraph.Edge, error) {
+	document := docgraph.Document{Hash: hashFromString(hash)}
+	if edgeName == "" {
+		return docgraph.GetEdgesFromDocument(ctx, e.api, e.contract, document)
+	}
+	return docgraph.GetEdgesFromDocumentWithEdge(ctx, e.api, e.contract, document, edgeName)
+}
+
+func (e *endpointSource) EdgesTo(ctx context.Context, hash string, edgeName eos.Name) ([]docgraph.Edge, error) {
+	document := docgraph.Document{Hash: hashFromString(hash)}
+	if edgeName == "" {
+		return docgraph.GetEdgesToDocument(ctx, e.api, e.contract, document)
+	}
+	return docgraph.GetEdgesToDocumentWithEdge(ctx, e.api, e.contract, document, edgeName)
+}
+
+// Source returns the graph as a query source
+func (g *Graph) Source() QuerySource {
+	return g.index()
+}
+
+func (i *graphIndex) Root(ctx context.Context) (docgraph.Document, error) {
+	roots := i.ofType("dho")
+	if len(roots) == 0 {
+		return docgraph.Document{}, fmt.Errorf("graph has no root document")
+	}
+
+	// prefer the root a contract created over other dho documents, such as those of partial archives
+	for _, root := range roots {
+		if isRoot(root) {
+			return root, nil
+		}
+	}
+	return roots[0], nil
+}
+
+func (i *graphIndex) Document(ctx context.Context, hash string) (docgraph.Document, error) {
+	document, ok := i.documents[hash]
+	if !ok {
+		return docgraph.Document{}, fmt.Errorf("document not found %v", hash)
+	}
+	return document, nil
+}
+
+func (i *graphIndex) Documents(ctx context.Context) ([]docgraph.Document, error) {
+	return i.graph.Documents, nil
+}
+
+func (i *graphIndex) EdgesFrom(ctx context.Context, hash string, edgeName eos.Name) ([]docgraph.Edge, error) {
+	if edgeName == "" {
+		return i.outgoing[hash], nil
+	}
+	return i.edgesFrom(hash, edgeName), nil
+}
+
+func (i *graphIndex) EdgesTo(ctx context.Context, hash string, edgeName eos.Name) ([]docgraph.Edge, error) {
+	if edgeName == "" {
+		return i.incoming[hash], nil
+	}
+	return i.edgesTo(hash, edgeName), nil
+}
+
+// queryFilter compares a field of a document to a value
+type queryFilter struct {
+	field    string
+	operator string
+	value    string
+}
+
+// nodePattern matches documents by type, or any document for *, with optional filters
+type nodePattern struct {
+	name    string
+	filters []queryFilter
+}
+
+// queryStep follows the edges named edgeName, or any edge for *, then matches the documents reached
+type queryStep struct {
+	edgeName eos.Name
+	incoming bool
+	node     nodePattern
+}
+
+// Query is a parsed traversal such as
+//
+//	root -member-> * -assigned-> assignment[type=assignment] -claimed-> period return hash, node_label limit 10
+//
+// A query starts at root, at a document hash, at every document of a type, or at every document for *.
+// Each step follows outgoing edges with -name-> or incoming edges with <-name-, and the documents reached
+// are matched by type and by filters on fields with =, !=, <, <=, >, >= or ~ (contains). Fields are content
+// labels or hash, creator and created_date. The documents reached by the last step are returned, projected
+// on the fields listed after return.
+type Query struct {
+	start  nodePattern
+	steps  []queryStep
+	fields []string
+	limit  int
+}
+
+var queryOperators = []string{"!=", "<=", ">=", "=", "<", ">", "~"}
+
+type queryParser struct {
+	text     string
+	position int
+}
+
+func (p *queryParser) skipSpaces() {
+	for p.position < len(p.text) && unicode.IsSpace(rune(p.text[p.position])) {
+		p.position++
+	}
+}
+
+func (p *queryParser) done() bool {
+	p.skipSpaces()
+	return p.position >= len(p.text)
+}
+
+func (p *queryParser) peek(prefix string) bool {
+	p.skipSpaces()
+	return strings.HasPrefix(p.text[p.position:], prefix)
+}
+
+func (p *queryParser) expect(prefix string) error {
+	if !p.peek(prefix) {
+		return fmt.Errorf("expected %q at position %d of query", prefix, p.position)
+	}
+	p.position += len(prefix)
+	return nil
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '.' || c == '/' || c == '*' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func (p *queryParser) word() (string, error) {
+	p.skipSpaces()
+	start := p.position
+	for p.position < len(p.text) && isWordChar(p.text[p.position]) {
+		p.position++
+	}
+	if start == p.position {
+		return "", fmt.Errorf("expected a name at position %d of query", start)
+	}
+	return p.text[start:p.position], nil
+}
+
+// value reads a filter value, either double quoted or up to the next comma or bracket
+func (p *queryParser) value() (string, error) {
+	p.skipSpaces()
+	if p.peek(`"`) {
+		end := strings.Index(p.text[p.position+1:], `"`)
+		if end < 0 {
+			return "", fmt.Errorf("unterminated string at position %d of query", p.position)
+		}
+		value := p.text[p.position+1 : p.position+1+end]
+		p.position += end + 2
+		return value, nil
+	}
+
+	start := p.position
+	for p.position < len(p.text) && p.text[p.position] != ',' && p.text[p.position] != ']' {
+		p.position++
+	}
+	return strings.TrimSpace(p.text[start:p.position]), nil
+}
+
+func (p *queryParser) node() (nodePattern, error) {
+	name, err := p.word()
+	if err != nil {
+		return nodePattern{}, err
+	}
+
+	pattern := nodePattern{name: name}
+	if p.position >= len(p.text) || p.text[p.position] != '[' {
+		return pattern, nil
+	}
+	p.position++
+
+	for {
+		field, err := p.word()
+		if err != nil {
+			return nodePattern{}, err
+		}
+
+		filter := queryFilter{field: field}
+		for _, operator := range queryOperators {
+			if p.peek(operator) {
+				filter.operator = operator
+				p.position += len(operator)
+				break
+			}
+		}
+		if filter.operator == "" {
+			return nodePattern{}, fmt.Errorf("expected an operator after %v at position %d of query", field, p.position)
+		}
+
+		filter.value, err = p.value()
+		if err != nil {
+			return nodePattern{}, err
+		}
+		pattern.filters = append(pattern.filters, filter)
+
+		if p.peek("]") {
+			p.position++
+			return pattern, nil
+		}
+		if err = p.expect(","); err != nil {
+			return nodePattern{}, err
+		}
+	}
+}
+
+func (p *queryParser) step() (queryStep, error) {
+	var step queryStep
+	closing := "->"
+	if p.peek("<-") {
+		step.incoming = true
+		closing = "-"
+		p.position += 2
+	} else if err := p.expect("-"); err != nil {
+		return step, err
+	}
+
+	edgeName, err := p.word()
+	if err != nil {
+		return step, err
+	}
+	if edgeName != "*" {
+		step.edgeName = eos.Name(edgeName)
+	}
+
+	if err = p.expect(closing); err != nil {
+		return step, err
+	}
+
+	step.node, err = p.node()
+	return step, err
+}
+
+// ParseQuery parses a traversal query
+func ParseQuery(text string) (*Query, error) {
+
+	parser := queryParser{text: text}
+	start, err := parser.node()
+	if err != nil {
+		return nil, err
+	}
+
+	query := Query{start: start}
+	for !parser.done() && (parser.peek("-") || parser.peek("<-")) {
+		step, err := parser.step()
+		if err != nil {
+			return nil, err
+		}
+		query.steps = append(query.steps, step)
+	}
+
+	for !parser.done() {
+		keyword, err := parser.word()
+		if err != nil {
+			return nil, err
+		}
+
+		switch keyword {
+		case "return":
+			for {
+				field, err := parser.word()
+				if err != nil {
+					return nil, err
+				}
+				query.fields = append(query.fields, field)
+				if !parser.peek(",") {
+					break
+				}
+				parser.position++
+			}
+		case "limit":
+			count, err := parser.word()
+			if err != nil {
+				return nil, err
+			}
+			query.limit, err = strconv.Atoi(count)
+			if err != nil || query.limit < 1 {
+				return nil, fmt.Errorf("invalid limit %v", count)
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %v in query, expected a step, return or limit", keyword)
+		}
+	}
+	return &query, nil
+}
+
+// QueryField returns the value of a field of a document: hash, creator, created_date or a content label
+func QueryField(document docgraph.Document, field string) (string, bool) {
+	switch field {
+	case "hash":
+		return document.Hash.String(), true
+	case "creator":
+		return string(document.Creator), true
+	case "created_date":
+		return formatTime(document.CreatedDate), true
+	}
+
+	value, err := document.GetContent(field)
+	if err != nil || value == nil {
+		return "", false
+	}
+	return contentValueString(value), true
+}
+
+// leadingNumber parses the number at the start of a value, such as the amount of an asset
+func leadingNumber(value string) (float64, bool) {
+	number, err := strconv.ParseFloat(strings.Fields(value + " ")[0], 64)
+	return number, err == nil
+}
+
+func (f queryFilter) matches(document docgraph.Document) bool {
+	value, ok := QueryField(document, f.field)
+	if !ok {
+		return f.operator == "!="
+	}
+
+	switch f.operator {
+	case "=":
+		return value == f.value
+	case "!=":
+		return value != f.value
+	case "~":
+		return strings.Contains(value, f.value)
+	}
+
+	comparison := strings.Compare(value, f.value)
+	if left, ok := leadingNumber(value); ok {
+		if right, ok := leadingNumber(f.value); ok {
+			comparison = 0
+			if left < right {
+				comparison = -1
+			} else if left > right {
+				comparison = 1
+			}
+		}
+	}
+
+	switch f.operator {
+	case "<":
+		return comparison < 0
+	case "<=":
+		return comparison <= 0
+	case ">":
+		return comparison > 0
+	}
+	return comparison >= 0
+}
+
+func (n nodePattern) matches(document docgraph.Document) bool {
+	switch {
+	case n.name == "*" || n.name == "root":
+	case len(n.name) == 64:
+		if document.Hash.String() != n.name {
+			return false
+		}
+	case DocumentType(document) != n.name:
+		return false
+	}
+	for _, filter := range n.filters {
+		if !filter.matches(document) {
+			return false
+		}
+	}
+	return true
+}
+
+func (q *Query) startDocuments(ctx context.Context, source QuerySource) ([]docgraph.Document, error) {
+	switch {
+	case q.start.name == "root":
+		root, err := source.Root(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load root %v", err)
+		}
+		return []docgraph.Document{root}, nil
+	case len(q.start.name) == 64:
+		document, err := source.Document(ctx, q.start.name)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load start document %v", err)
+		}
+		return []docgraph.Document{document}, nil
+	}
+	return source.Documents(ctx)
+}
+
+// QueryResult holds the documents a query reached, projected on its fields
+type QueryResult struct {
+	Fields    []string            `json:"fields"`
+	Rows      [][]string          `json:"rows"`
+	Documents []docgraph.Document `json:"-"`
+}
+
+// Run evaluates the query against source
+func (q *Query) Run(ctx context.Context, source QuerySource) (QueryResult, error) {
+
+	candidates, err := q.startDocuments(ctx, source)
+	if err != nil {
+		return QueryResult{}, err
+	}
+
+	var current []docgraph.Document
+	for _, document := range candidates {
+		if q.start.matches(document) {
+			current = append(current, document)
+		}
+	}
+
+	for _, step := range q.steps {
+		var next []docgraph.Document
+		seen := make(map[string]bool)
+		for _, document := range current {
+			var edges []docgraph.Edge
+			if step.incoming {
+				edges, err = source.EdgesTo(ctx, document.Hash.String(), step.edgeName)
+			} else {
+				edges, err = source.EdgesFrom(ctx, document.Hash.String(), step.edgeName)
+			}
+			if err != nil {
+				return QueryResult{}, fmt.Errorf("cannot retrieve %v edges of %v: %v", step.edgeName, document.Hash.String(), err)
+			}
+
+			for _, edge := range edges {
+				hash := edge.ToNode.String()
+				if step.incoming {
+					hash = edge.FromNode.String()
+				}
+				if seen[hash] {
+					continue
+				}
+				seen[hash] = true
+
+				reached, err := source.Document(ctx, hash)
+				if err != nil {
+					return QueryResult{}, fmt.Errorf("cannot load %v: %v", hash, err)
+				}
+				if step.node.matches(reached) {
+					next = append(next, reached)
+				}
+			}
+		}
+		current = next
+	}
+
+	if q.limit > 0 && len(current) > q.limit {
+		current = current[:q.limit]
+	}
+
+	result := QueryResult{Fields: q.fields, Rows: [][]string{}, Documents: current}
+	if len(result.Fields) == 0 {
+		result.Fields = []string{"hash", "type", "node_label"}
+	}
+	for _, document := range current {
+		var row []string
+		for _, field := range result.Fields {
+			value, ok := QueryField(document, field)
+			if !ok {
+				value = absentValue
+			}
+			row = append(row, value)
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	return result, nil
+}
+
+// RunQuery parses and evaluates a query against source
+func RunQuery(ctx context.Context, source QuerySource, text string) (QueryResult, error) {
+	query, err := ParseQuery(text)
+	if err != nil {
+		return QueryResult{}, err
+	}
+	return query.Run(ctx, source)
+}
+
+// Print writes the result as a table
+func (r *QueryResult) Print(w io.Writer) {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, strings.Join(r.Fields, "\t"))
+	for _, row := range r.Rows {
+		fmt.Fprintln(table, strings.Join(row, "\t"))
+	}
+	table.Flush()
+	fmt.Fprintf(w, "%d documents\n", len(r.Rows))
+}
@@ -0,0 +1,75 @@
+package dao_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hypha-dao/dao-contracts/dao-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+	"gotest.tools/assert"
+)
+
+func TestQuery(t *testing.T) {
+
+	root := testDocument(t, "1", "dho", "Hypha DHO Root")
+	johnny := testDocument(t, "2", "member", "johnnyhypha1")
+	alice := testDocument(t, "3", "member", "alice")
+	weaver := withDetails(testDocument(t, "4", "assignment", "Basketweaver"), "title", 1, "Underwater Basketweaver")
+	healer := withDetails(testDocument(t, "5", "assignment", "Healer"), "title", 1, "Healer")
+	q1 := withDetails(testDocument(t, "6", "period", "Q1"), "label", 1, "Q1")
+	q2 := withDetails(testDocument(t, "7", "period", "Q2"), "label", 1, "Q2")
+
+	graph := &dao.Graph{
+		Documents: []docgraph.Document{root, johnny, alice, weaver, healer, q1, q2},
+		Edges: []docgraph.Edge{
+			testEdge(root, johnny, "member"),
+			testEdge(root, alice, "member"),
+			testEdge(johnny, weaver, "assigned"),
+			testEdge(alice, healer, "assigned"),
+			testEdge(weaver, q1, "claimed"),
+			testEdge(weaver, q2, "claimed"),
+			testEdge(healer, q1, "claimed"),
+			testEdge(root, q1, "start"),
+			testEdge(q1, q2, "next"),
+		},
+	}
+
+	run := func(t *testing.T, text string) dao.QueryResult {
+		result, err := dao.RunQuery(context.Background(), graph.Source(), text)
+		assert.NilError(t, err)
+		return result
+	}
+
+	t.Run("Follow outgoing edges", func(t *testing.T) {
+		result := run(t, "root -member-> * -assigned-> assignment[type=assignment] -claimed-> period return label")
+		assert.DeepEqual(t, result.Rows, [][]string{{"Q1"}, {"Q2"}})
+	})
+
+	t.Run("Filter on content", func(t *testing.T) {
+		result := run(t, `root -member-> * -assigned-> *[title~"Basket"] return node_label, title`)
+		assert.DeepEqual(t, result.Rows, [][]string{{"Basketweaver", "Underwater Basketweaver"}})
+
+		result = run(t, "period[label>Q1]")
+		assert.Equal(t, len(result.Documents), 1)
+		assert.Equal(t, result.Documents[0].Hash.String(), q2.Hash.String())
+	})
+
+	t.Run("Follow incoming edges with a limit", func(t *testing.T) {
+		result := run(t, q1.Hash.String()+" <-claimed- assignment <-assigned- member return node_label limit 1")
+		assert.DeepEqual(t, result.Rows, [][]string{{"johnnyhypha1"}})
+		assert.DeepEqual(t, result.Fields, []string{"node_label"})
+	})
+
+	t.Run("Any edge and default projection", func(t *testing.T) {
+		result := run(t, "root -*-> period")
+		assert.DeepEqual(t, result.Fields, []string{"hash", "type", "node_label"})
+		assert.DeepEqual(t, result.Rows, [][]string{{q1.Hash.String(), "period", "Q1"}})
+	})
+
+	t.Run("Reject invalid queries", func(t *testing.T) {
+		for _, text := range []string{"root -member>", "root -member-> *[title]", "root limit 0", "root order by", `*[title="open`} {
+			_, err := dao.ParseQuery(text)
+			assert.Assert(t, err != nil, text)
+		}
+	})
+}
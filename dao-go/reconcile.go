@@ -0,0 +1,573 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+)
+
+const (
+	// ReconcileMissing marks a legacy record without a migrated document
+	ReconcileMissing = "missing"
+
+	// ReconcileDuplicate marks a legacy record that was migrated into more than one document
+	ReconcileDuplicate = "duplicate"
+
+	// ReconcileMismatch marks a migrated document whose content differs from the legacy record
+	ReconcileMismatch = "mismatch"
+
+	absentValue = "<absent>"
+)
+
+// FieldDiff is a field whose migrated value differs from the legacy value
+type FieldDiff struct {
+	Field  string `json:"field"`
+	Legacy string `json:"legacy"`
+	Graph  string `json:"graph"`
+}
+
+// Discrepancy is a legacy record that did not migrate cleanly
+type Discrepancy struct {
+	Kind     string      `json:"kind"`
+	Scope    eos.Name    `json:"scope"`
+	LegacyID string      `json:"legacy_id"`
+	Hashes   []string    `json:"hashes,omitempty"`
+	Note     string      `json:"note,omitempty"`
+	Diffs    []FieldDiff `json:"diffs,omitempty"`
+}
+
+func (d *Discrepancy) compare(field, legacy, graph string) {
+	if legacy != graph {
+		d.Diffs = append(d.Diffs, FieldDiff{Field: field, Legacy: legacy, Graph: graph})
+	}
+}
+
+// ReconciliationReport lists the legacy records checked per scope and every discrepancy found
+type ReconciliationReport struct {
+	Checked       map[eos.Name]int `json:"checked"`
+	Discrepancies []Discrepancy    `json:"discrepancies"`
+}
+
+func (r *ReconciliationReport) add(d Discrepancy) {
+	r.Checked[d.Scope]++
+	if d.Kind == ReconcileMismatch && len(d.Diffs) == 0 {
+		return
+	}
+	r.Discrepancies = append(r.Discrepancies, d)
+}
+
+// Print writes the report in a human readable format
+func (r *ReconciliationReport) Print(w io.Writer) {
+	fmt.Fprintln(w, "\nReconciliation report")
+	for _, scope := range []eos.Name{"member", "period", "role", "assignment", "payout", "asspay"} {
+		if count, ok := r.Checked[scope]; ok {
+			fmt.Fprintf(w, "%-12v checked: %6d\n", scope, count)
+		}
+	}
+
+	if len(r.Discrepancies) == 0 {
+		fmt.Fprintln(w, "\nNo discrepancies found")
+		return
+	}
+
+	fmt.Fprintln(w, "\nDiscrepancies: "+strconv.Itoa(len(r.Discrepancies)))
+	for _, d := range r.Discrepancies {
+		fmt.Fprintf(w, "\n%v %v %v", d.Kind, d.Scope, d.LegacyID)
+		for _, hash := range d.Hashes {
+			fmt.Fprintf(w, " %v", hash)
+		}
+		fmt.Fprintln(w)
+		if d.Note != "" {
+			fmt.Fprintln(w, "    "+d.Note)
+		}
+		for _, diff := range d.Diffs {
+			fmt.Fprintf(w, "    %v:\n      - %v\n      + %v\n", diff.Field, diff.Legacy, diff.Graph)
+		}
+	}
+}
+
+type xRef struct {
+	ID   uint64          `json:"id"`
+	Hash eos.Checksum256 `json:"hash"`
+}
+
+// getXRefs reads the migration cross reference table of a scope, mapping legacy IDs to document hashes
+func getXRefs(ctx context.Context, api *eos.API, contract eos.AccountName, scope eos.Name) (map[uint64]string, error) {
+
+	xrefs := make(map[uint64]string)
+	lowerBound := uint64(0)
+	more := true
+
+	for more {
+		var records []xRef
+		var request eos.GetTableRowsRequest
+		request.LowerBound = strconv.FormatUint(lowerBound, 10)
+		request.Code = string(contract)
+		request.Scope = string(scope)
+		request.Table = "xref"
+		request.Limit = 100
+		request.JSON = true
+		response, err := api.GetTableRows(ctx, request)
+		if err != nil {
+			return nil, fmt.Errorf("get table rows %v", err)
+		}
+
+		err = response.JSONToStructs(&records)
+		if err != nil {
+			return nil, fmt.Errorf("json to structs %v", err)
+		}
+
+		for _, record := range records {
+			xrefs[record.ID] = record.Hash.String()
+			lowerBound = record.ID + 1
+		}
+		more = response.More && len(records) > 0
+	}
+	return xrefs, nil
+}
+
+// migratedGraph indexes the documents and edges of the migrated graph by their content keys
+type migratedGraph struct {
+	documents map[string]docgraph.Document
+	byLegacy  map[string][]docgraph.Document
+	byMember  map[eos.Name][]docgraph.Document
+	byStart   map[eos.TimePoint][]docgraph.Document
+	receipts  map[string][]docgraph.Document
+	edges     map[string]bool
+	root      string
+}
+
+func legacyKey(scope eos.Name, id uint64) string {
+	return string(scope) + "/" + strconv.FormatUint(id, 10)
+}
+
+func edgeKey(from, edgeName, to string) string {
+	return from + "/" + edgeName + "/" + to
+}
+
+func loadMigratedGraph(ctx context.Context, api *eos.API, contract eos.AccountName) (migratedGraph, error) {
+
+	graph := migratedGraph{
+		documents: make(map[string]docgraph.Document),
+		byLegacy:  make(map[string][]docgraph.Document),
+		byMember:  make(map[eos.Name][]docgraph.Document),
+		byStart:   make(map[eos.TimePoint][]docgraph.Document),
+		receipts:  make(map[string][]docgraph.Document),
+		edges:     make(map[string]bool),
+	}
+
+	loaded, err := LoadGraph(ctx, api, contract)
+	if err != nil {
+		return graph, err
+	}
+
+	for _, document := range loaded.Documents {
+		graph.documents[document.Hash.String()] = document
+
+		docType, err := getName(document, "type")
+		if err != nil {
+			continue
+		}
+
+		switch docType {
+		case eos.Name("dho"):
+			graph.root = document.Hash.String()
+		case eos.Name("member"):
+			member, err := getName(document, "member")
+			if err == nil {
+				graph.byMember[member] = append(graph.byMember[member], document)
+			}
+		case eos.Name("period"):
+			startTime, err := getTimePoint(document, "start_time")
+			if err == nil {
+				graph.byStart[startTime] = append(graph.byStart[startTime], document)
+			}
+		case eos.Name("payment"):
+			assignment, err := getChecksum(document, "assignment_hash")
+			if err != nil {
+				continue
+			}
+			period, err := getChecksum(document, "period_hash")
+			if err == nil {
+				key := assignment.String() + "/" + period.String()
+				graph.receipts[key] = append(graph.receipts[key], document)
+			}
+		}
+
+		scope, err := getName(document, "legacy_object_scope")
+		if err != nil {
+			continue
+		}
+		id, err := getInt64(document, "legacy_object_id")
+		if err == nil {
+			key := legacyKey(scope, uint64(id))
+			graph.byLegacy[key] = append(graph.byLegacy[key], document)
+		}
+	}
+
+	for _, edge := range loaded.Edges {
+		graph.edges[edgeKey(edge.FromNode.String(), string(edge.EdgeName), edge.ToNode.String())] = true
+	}
+	return graph, nil
+}
+
+func (g *migratedGraph) hasEdge(from, edgeName, to string) bool {
+	return g.edges[edgeKey(from, edgeName, to)]
+}
+
+func hashes(documents []docgraph.Document) []string {
+	var result []string
+	for _, document := range documents {
+		result = append(result, document.Hash.String())
+	}
+	return result
+}
+
+// resolve finds the document migrated from a legacy record, using the xref table when it has
+// the record and the candidates matched by content otherwise
+func (g *migratedGraph) resolve(d *Discrepancy, xrefs map[uint64]string, id uint64, candidates []docgraph.Document) (docgraph.Document, bool) {
+
+	if len(candidates) > 1 {
+		d.Kind = ReconcileDuplicate
+		d.Hashes = hashes(candidates)
+		return docgraph.Document{}, false
+	}
+
+	if hash, ok := xrefs[id]; ok {
+		document, found := g.documents[hash]
+		if !found {
+			d.Kind = ReconcileMissing
+			d.Hashes = []string{hash}
+			d.Note = "xref points to a document that does not exist"
+			return docgraph.Document{}, false
+		}
+		return document, true
+	}
+
+	if len(candidates) == 0 {
+		d.Kind = ReconcileMissing
+		return docgraph.Document{}, false
+	}
+	return candidates[0], true
+}
+
+// resolveHash returns the hash of the document migrated from a legacy record, or an empty string
+func (g *migratedGraph) resolveHash(xrefs map[uint64]string, scope eos.Name, id uint64) string {
+	if hash, ok := xrefs[id]; ok {
+		return hash
+	}
+
+	candidates := g.byLegacy[legacyKey(scope, id)]
+	if len(candidates) == 1 {
+		return candidates[0].Hash.String()
+	}
+	return ""
+}
+
+func formatTime(tp eos.TimePoint) string {
+	return ToTime(tp).Format(time.RFC3339)
+}
+
+func graphName(document docgraph.Document, label string) string {
+	value, err := getName(document, label)
+	if err != nil {
+		return absentValue
+	}
+	return string(value)
+}
+
+func graphAsset(document docgraph.Document, label string) string {
+	value, err := getAsset(document, label)
+	if err != nil {
+		return absentValue
+	}
+	return value.String()
+}
+
+func graphTime(document docgraph.Document, label string) string {
+	value, err := getTimePoint(document, label)
+	if err != nil {
+		return absentValue
+	}
+	return formatTime(value)
+}
+
+func graphInt(document docgraph.Document, label string) string {
+	value, err := getInt64(document, label)
+	if err != nil {
+		return absentValue
+	}
+	return strconv.FormatInt(value, 10)
+}
+
+func graphChecksum(document docgraph.Document, label string) string {
+	value, err := getChecksum(document, label)
+	if err != nil {
+		return absentValue
+	}
+	return value.String()
+}
+
+func edgeState(exists bool) string {
+	if exists {
+		return "present"
+	}
+	return absentValue
+}
+
+func objectName(object Object, key string) (eos.Name, bool) {
+	for _, kv := range object.Names {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return eos.Name(""), false
+}
+
+func objectInt(object Object, key string) (uint64, bool) {
+	for _, kv := range object.Ints {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return 0, false
+}
+
+// Reconcile compares the legacy members, periods, objects of each scope and assignment payouts
+// read from the source endpoint or snapshot file against the document graph migrated on contract.
+// Legacy records are matched to documents through the xref table or, when it has no entry,
+// through content keys (member name, period start time, legacy object scope and id).
+func Reconcile(ctx context.Context, api *eos.API, contract eos.AccountName, scopes []eos.Name, from string) (ReconciliationReport, error) {
+
+	report := ReconciliationReport{Checked: make(map[eos.Name]int)}
+	source, err := OpenLegacySource(from, contract)
+	if err != nil {
+		return report, err
+	}
+
+	graph, err := loadMigratedGraph(ctx, api, contract)
+	if err != nil {
+		return report, err
+	}
+
+	members, err := source.Members(ctx)
+	if err != nil {
+		return report, fmt.Errorf("cannot load members %v", err)
+	}
+
+	fmt.Println("\nReconciling members")
+	for _, member := range members {
+		reconcileMember(&report, &graph, member.MemberName)
+	}
+
+	periodXRefs, err := getXRefs(ctx, api, contract, eos.Name("period"))
+	if err != nil {
+		return report, fmt.Errorf("cannot load period xrefs %v", err)
+	}
+
+	periods, err := source.Periods(ctx)
+	if err != nil {
+		return report, fmt.Errorf("cannot load periods %v", err)
+	}
+
+	fmt.Println("Reconciling periods")
+	for _, period := range periods {
+		reconcilePeriod(&report, &graph, periodXRefs, period)
+	}
+
+	objectXRefs := make(map[eos.Name]map[uint64]string)
+	for _, scope := range scopes {
+		objectXRefs[scope], err = getXRefs(ctx, api, contract, scope)
+		if err != nil {
+			return report, fmt.Errorf("cannot load %v xrefs %v", scope, err)
+		}
+
+		objects, err := source.Objects(ctx, scope)
+		if err != nil {
+			return report, fmt.Errorf("cannot load %v objects %v", scope, err)
+		}
+
+		fmt.Println("Reconciling " + string(scope) + " objects")
+		for _, object := range objects {
+			reconcileObject(&report, &graph, objectXRefs[scope], periodXRefs, scope, object)
+		}
+	}
+
+	assignmentXRefs, ok := objectXRefs[eos.Name("assignment")]
+	if !ok {
+		assignmentXRefs, err = getXRefs(ctx, api, contract, eos.Name("assignment"))
+		if err != nil {
+			return report, fmt.Errorf("cannot load assignment xrefs %v", err)
+		}
+	}
+
+	payouts, err := source.AssPayouts(ctx)
+	if err != nil {
+		return report, fmt.Errorf("cannot load assignment payouts %v", err)
+	}
+
+	fmt.Println("Reconciling assignment payouts")
+	for _, payout := range payouts {
+		reconcileAssPayout(&report, &graph, assignmentXRefs, periodXRefs, payout)
+	}
+
+	return report, nil
+}
+
+func reconcileMember(report *ReconciliationReport, graph *migratedGraph, member eos.Name) {
+
+	d := Discrepancy{Kind: ReconcileMismatch, Scope: eos.Name("member"), LegacyID: string(member)}
+	candidates := graph.byMember[member]
+
+	switch len(candidates) {
+	case 0:
+		d.Kind = ReconcileMissing
+	case 1:
+		hash := candidates[0].Hash.String()
+		d.compare("root -member-> member", "present", edgeState(graph.hasEdge(graph.root, "member", hash)))
+		d.compare("member -memberof-> root", "present", edgeState(graph.hasEdge(hash, "memberof", graph.root)))
+	default:
+		d.Kind = ReconcileDuplicate
+		d.Hashes = hashes(candidates)
+	}
+	report.add(d)
+}
+
+func reconcilePeriod(report *ReconciliationReport, graph *migratedGraph, xrefs map[uint64]string, period Period) {
+
+	d := Discrepancy{Kind: ReconcileMismatch, Scope: eos.Name("period"), LegacyID: strconv.FormatUint(period.PeriodID, 10)}
+	startTime := ToTimePoint(period.StartTime.Time)
+
+	document, ok := graph.resolve(&d, xrefs, period.PeriodID, graph.byStart[startTime])
+	if !ok {
+		report.add(d)
+		return
+	}
+
+	hash := document.Hash.String()
+	d.compare("start_time", formatTime(startTime), graphTime(document, "start_time"))
+
+	if period.PeriodID == 0 {
+		d.compare("root -start-> period", "present", edgeState(graph.hasEdge(graph.root, "start", hash)))
+	} else if predecessor, ok := xrefs[period.PeriodID-1]; ok {
+		d.compare("previous -next-> period", "present", edgeState(graph.hasEdge(predecessor, "next", hash)))
+	}
+	report.add(d)
+}
+
+func reconcileObject(report *ReconciliationReport, graph *migratedGraph, xrefs, periodXRefs map[uint64]string, scope eos.Name, object Object) {
+
+	d := Discrepancy{Kind: ReconcileMismatch, Scope: scope, LegacyID: strconv.FormatUint(object.ID, 10)}
+
+	document, ok := graph.resolve(&d, xrefs, object.ID, graph.byLegacy[legacyKey(scope, object.ID)])
+	if !ok {
+		report.add(d)
+		return
+	}
+
+	// salary and other amounts
+	for _, kv := range object.Assets {
+		d.compare(kv.Key, kv.Value.String(), graphAsset(document, kv.Key))
+	}
+
+	// dates
+	for _, kv := range object.TimePoints {
+		d.compare(kv.Key, formatTime(kv.Value), graphTime(document, kv.Key))
+	}
+	d.compare("legacy_object_created_date", formatTime(object.CreatedDate), graphTime(document, "legacy_object_created_date"))
+
+	if ballot, ok := objectName(object, "ballot_id"); ok {
+		d.compare("ballot_id", string(ballot), graphName(document, "ballot_id"))
+	}
+
+	switch scope {
+	case eos.Name("assignment"):
+		if assignee, ok := objectName(object, "assigned_account"); ok {
+			d.compare("assignee", string(assignee), graphName(document, "assignee"))
+		}
+
+		startPeriod, hasStart := objectInt(object, "start_period")
+		endPeriod, hasEnd := objectInt(object, "end_period")
+		if hasStart && hasEnd {
+			d.compare("period_count", strconv.FormatInt(int64(endPeriod)-int64(startPeriod), 10), graphInt(document, "period_count"))
+		}
+		if periodHash, ok := periodXRefs[startPeriod]; hasStart && ok {
+			d.compare("start_period", periodHash, graphChecksum(document, "start_period"))
+		}
+
+	case eos.Name("payout"):
+		if recipient, ok := objectName(object, "recipient"); ok {
+			d.compare("recipient", string(recipient), graphName(document, "recipient"))
+		}
+		d.compare("payment_date", formatTime(object.CreatedDate), graphTime(document, "payment_date"))
+	}
+	report.add(d)
+}
+
+func reconcileAssPayout(report *ReconciliationReport, graph *migratedGraph, assignmentXRefs, periodXRefs map[uint64]string, payout LegacyAssPayout) {
+
+	d := Discrepancy{Kind: ReconcileMismatch, Scope: eos.Name("asspay"), LegacyID: strconv.FormatUint(payout.ID, 10)}
+
+	assignmentHash := graph.resolveHash(assignmentXRefs, eos.Name("assignment"), payout.AssignmentID)
+	periodHash, ok := periodXRefs[payout.PeriodID]
+	if assignmentHash == "" || !ok {
+		d.Kind = ReconcileMissing
+		d.Note = fmt.Sprintf("cannot resolve assignment %v or period %v", payout.AssignmentID, payout.PeriodID)
+		report.add(d)
+		return
+	}
+
+	receipts := graph.receipts[assignmentHash+"/"+periodHash]
+	claimed := graph.hasEdge(assignmentHash, "claimed", periodHash)
+
+	if !claimed && len(receipts) == 0 {
+		d.Kind = ReconcileMissing
+		report.add(d)
+		return
+	}
+
+	d.compare("assignment -claimed-> period", "present", edgeState(claimed))
+
+	var expected []eos.Asset
+	for _, payment := range payout.Payments {
+		if payment.Amount > 0 {
+			expected = append(expected, payment)
+		}
+	}
+
+	if len(receipts) > len(expected) {
+		d.Kind = ReconcileDuplicate
+		d.Hashes = hashes(receipts)
+		report.add(d)
+		return
+	}
+
+	paymentDate := formatTime(ToTimePoint(payout.PaymentDate.Time))
+	for _, payment := range expected {
+		symbol := payment.Symbol.Symbol
+
+		var receipt *docgraph.Document
+		for index := range receipts {
+			amount, err := getAsset(receipts[index], "amount")
+			if err == nil && amount.Symbol.Symbol == symbol {
+				receipt = &receipts[index]
+				break
+			}
+		}
+
+		if receipt == nil {
+			d.compare(symbol+" receipt", payment.String(), absentValue)
+			continue
+		}
+
+		d.compare(symbol+" amount", payment.String(), graphAsset(*receipt, "amount"))
+		d.compare(symbol+" recipient", string(payout.Recipient), graphName(*receipt, "recipient"))
+		d.compare(symbol+" payment_date", paymentDate, graphTime(*receipt, "payment_date"))
+	}
+	report.add(d)
+}
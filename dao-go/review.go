@@ -0,0 +1,424 @@
+package dao
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	eostest "github.com/digital-scarcity/eos-go-test"
+	"github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/msig"
+)
+
+// Review decisions of reviewers, and the submissions of the enrollment of an approved applicant
+const (
+	ReviewApprove  = "approve"
+	ReviewReject   = "reject"
+	ReviewEnrolled = "enrolled"
+	ReviewProposed = "proposed"
+)
+
+// Statuses of an application under review
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+)
+
+// Application is a pending applicant with the notes of its apply action
+type Application struct {
+	MemberEntry
+	Notes string
+	TrxID string
+}
+
+type historyActionsJSON struct {
+	Actions []struct {
+		BlockTime   string `json:"block_time"`
+		ActionTrace struct {
+			Receiver eos.AccountName `json:"receiver"`
+			TrxID    string          `json:"trx_id"`
+			Action   struct {
+				Account eos.AccountName `json:"account"`
+				Name    eos.ActionName  `json:"name"`
+				Data    struct {
+					Content string `json:"content"`
+				} `json:"data"`
+			} `json:"act"`
+		} `json:"action_trace"`
+	} `json:"actions"`
+}
+
+// ApplicationNotes returns the notes and transaction of the last apply action of applicant to contract,
+// read from the history of the node
+func ApplicationNotes(ctx context.Context, api *eos.API, contract, applicant eos.AccountName) (string, string, error) {
+
+	var history historyActionsJSON
+	request := map[string]interface{}{"account_name": applicant, "pos": -1, "offset": -100}
+	if err := postChain(ctx, api, "/v1/history/get_actions", request, &history); err != nil {
+		return "", "", fmt.Errorf("cannot read the actions of %v: %v", applicant, err)
+	}
+
+	for i := len(history.Actions) - 1; i >= 0; i-- {
+		trace := history.Actions[i].ActionTrace
+		if trace.Receiver == contract && trace.Action.Account == contract && trace.Action.Name == eos.ActN("apply") {
+			return trace.Action.Data.Content, trace.TrxID, nil
+		}
+	}
+	return "", "", fmt.Errorf("no apply action of %v to %v in the last 100 actions", applicant, contract)
+}
+
+// ListApplications returns the pending applicants of contract with the notes of their application;
+// the notes are left empty when the node serves no history of the applicant
+func ListApplications(ctx context.Context, api *eos.API, contract eos.AccountName) ([]Application, error) {
+
+	applicants, err := ListApplicants(ctx, api, contract)
+	if err != nil {
+		return nil, err
+	}
+
+	applications := make([]Application, 0, len(applicants))
+	for _, applicant := range applicants {
+		application := Application{MemberEntry: applicant}
+		application.Notes, application.TrxID, _ = ApplicationNotes(ctx, api, contract, applicant.Account)
+		applications = append(applications, application)
+	}
+	return applications, nil
+}
+
+// ReviewPolicy is who reviews applicants, how many approvals enroll one, and the account enrolling
+type ReviewPolicy struct {
+	Reviewers []eos.AccountName
+	Threshold int
+	Enroller  eos.AccountName
+}
+
+// Validate checks that the threshold can be reached by the reviewers
+func (p ReviewPolicy) Validate() error {
+
+	if len(p.Reviewers) == 0 {
+		return fmt.Errorf("review policy has no reviewers")
+	}
+	seen := make(map[eos.AccountName]bool)
+	for _, reviewer := range p.Reviewers {
+		if seen[reviewer] {
+			return fmt.Errorf("reviewer %v is listed twice", reviewer)
+		}
+		seen[reviewer] = true
+	}
+	if p.Threshold < 1 || p.Threshold > len(p.Reviewers) {
+		return fmt.Errorf("review threshold %v must be between 1 and the %v reviewers", p.Threshold, len(p.Reviewers))
+	}
+	if p.Enroller == "" {
+		return fmt.Errorf("review policy has no enroller")
+	}
+	return nil
+}
+
+func (p ReviewPolicy) isReviewer(account eos.AccountName) bool {
+	for _, reviewer := range p.Reviewers {
+		if reviewer == account {
+			return true
+		}
+	}
+	return false
+}
+
+// ReviewEntry is a decision of a reviewer on an applicant, or the submission of its enrollment
+type ReviewEntry struct {
+	Applicant eos.AccountName `json:"applicant"`
+	Reviewer  eos.AccountName `json:"reviewer"`
+	Decision  string          `json:"decision"`
+	Reason    string          `json:"reason,omitempty"`
+	TrxID     string          `json:"trx_id,omitempty"`
+	Time      time.Time       `json:"time"`
+}
+
+// ReviewTally is the state of the review of an applicant under a policy
+type ReviewTally struct {
+	Applicant  eos.AccountName
+	Approvals  []eos.AccountName
+	Rejections []eos.AccountName
+	Status     string
+	Submitted  *ReviewEntry
+}
+
+// Tally counts the latest decision of each reviewer of the policy in trail, the entries of an applicant
+// in the order they were recorded; an application is rejected once the threshold cannot be reached
+func (p ReviewPolicy) Tally(applicant eos.AccountName, trail []ReviewEntry) ReviewTally {
+
+	tally := ReviewTally{Applicant: applicant, Status: ReviewPending}
+	decisions := make(map[eos.AccountName]string)
+	for i, entry := range trail {
+		switch entry.Decision {
+		case ReviewApprove, ReviewReject:
+			if p.isReviewer(entry.Reviewer) {
+				decisions[entry.Reviewer] = entry.Decision
+			}
+		case ReviewEnrolled, ReviewProposed:
+			tally.Submitted = &trail[i]
+		}
+	}
+
+	for _, reviewer := range p.Reviewers {
+		switch decisions[reviewer] {
+		case ReviewApprove:
+			tally.Approvals = append(tally.Approvals, reviewer)
+		case ReviewReject:
+			tally.Rejections = append(tally.Rejections, reviewer)
+		}
+	}
+
+	if len(tally.Approvals) >= p.Threshold {
+		tally.Status = ReviewApproved
+	} else if len(tally.Rejections) > len(p.Reviewers)-p.Threshold {
+		tally.Status = ReviewRejected
+	}
+	return tally
+}
+
+// ReviewStore is an append-only JSON lines file of review decisions and enrollments; it is the
+// audit trail of who approved whom, and reopening it restores the state of every review
+type ReviewStore struct {
+	mutex   sync.Mutex
+	file    *os.File
+	entries []ReviewEntry
+}
+
+// OpenReviewStore opens or creates the review store at path and loads the entries already in it
+func OpenReviewStore(path string) (*ReviewStore, error) {
+
+	var store ReviewStore
+	content, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("cannot read review store %v: %v", path, err)
+	}
+
+	partial := len(content) > 0 && content[len(content)-1] != '\n'
+	lines := bytes.Split(bytes.TrimSuffix(content, []byte{'\n'}), []byte{'\n'})
+	for i, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var entry ReviewEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			// a crash can leave a partial last line, a decision that was not recorded
+			if partial && i == len(lines)-1 {
+				continue
+			}
+			return nil, fmt.Errorf("cannot read line %v of review store %v: %v", i+1, path, err)
+		}
+		store.entries = append(store.entries, entry)
+	}
+
+	store.file, err = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open review store %v: %v", path, err)
+	}
+
+	// terminate a partial line so the next entry starts on its own line
+	if partial {
+		if _, err = store.file.Write([]byte{'\n'}); err != nil {
+			store.file.Close()
+			return nil, fmt.Errorf("cannot write review store %v: %v", path, err)
+		}
+	}
+	return &store, nil
+}
+
+// record appends entry to the store and syncs it to disk
+func (s *ReviewStore) record(entry ReviewEntry) (ReviewEntry, error) {
+
+	entry.Time = time.Now().UTC()
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return entry, fmt.Errorf("cannot marshal review entry: %v", err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, err = s.file.Write(append(line, '\n')); err != nil {
+		return entry, fmt.Errorf("cannot write review entry: %v", err)
+	}
+	if err = s.file.Sync(); err != nil {
+		return entry, fmt.Errorf("cannot sync review store: %v", err)
+	}
+	s.entries = append(s.entries, entry)
+	return entry, nil
+}
+
+// Trail returns the entries of applicant in the order they were recorded, or every entry if applicant is empty
+func (s *ReviewStore) Trail(applicant eos.AccountName) []ReviewEntry {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var trail []ReviewEntry
+	for _, entry := range s.entries {
+		if applicant == "" || entry.Applicant == applicant {
+			trail = append(trail, entry)
+		}
+	}
+	return trail
+}
+
+// Decide records the approve or reject decision of reviewer on applicant and returns the new tally;
+// a reviewer can change its decision until the enrollment is submitted
+func (s *ReviewStore) Decide(policy ReviewPolicy, applicant, reviewer eos.AccountName, decision, reason string) (ReviewTally, error) {
+
+	if decision != ReviewApprove && decision != ReviewReject {
+		return ReviewTally{}, fmt.Errorf("unknown decision %v, expected %v or %v", decision, ReviewApprove, ReviewReject)
+	}
+	if !policy.isReviewer(reviewer) {
+		return ReviewTally{}, fmt.Errorf("%v is not a reviewer, expected one of %v", reviewer, policy.Reviewers)
+	}
+
+	tally := policy.Tally(applicant, s.Trail(applicant))
+	if tally.Submitted != nil {
+		return tally, fmt.Errorf("the enrollment of %v was already %v in transaction %v", applicant, tally.Submitted.Decision, tally.Submitted.TrxID)
+	}
+
+	_, err := s.record(ReviewEntry{Applicant: applicant, Reviewer: reviewer, Decision: decision, Reason: reason})
+	if err != nil {
+		return tally, err
+	}
+	return policy.Tally(applicant, s.Trail(applicant)), nil
+}
+
+// PrintTrail writes entries to w, one decision per line
+func PrintTrail(w io.Writer, entries []ReviewEntry) {
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%v %-13v %-8v by %-13v", entry.Time.Format("2006-01-02 15:04:05"), entry.Applicant, entry.Decision, entry.Reviewer)
+		if entry.Reason != "" {
+			fmt.Fprintf(w, " %v", entry.Reason)
+		}
+		if entry.TrxID != "" {
+			fmt.Fprintf(w, " in %v", entry.TrxID)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// Close closes the review store file
+func (s *ReviewStore) Close() error {
+	return s.file.Close()
+}
+
+// reviewProposalExpiration is how long an enroll msig proposal can collect approvals
+const reviewProposalExpiration = 7 * 24 * time.Hour
+
+// enrollProposalName returns the name of the msig proposal enrolling applicant, enroll followed by
+// six characters of the hash of its member document
+func enrollProposalName(applicant eos.AccountName) eos.Name {
+	const charset = "abcdefghijklmnopqrstuvwxyz12345"
+	name := []byte("enroll")
+	for _, b := range MemberHash(applicant)[:6] {
+		name = append(name, charset[int(b)%len(charset)])
+	}
+	return eos.Name(name)
+}
+
+// proposeEnroll proposes the enroll action of enroller as an eosio.msig proposal by proposer, requesting
+// the approval of the accounts of the active authority of enroller
+func proposeEnroll(ctx context.Context, api *eos.API, contract, proposer, applicant eos.AccountName,
+	enroller *eos.AccountResp) (string, error) {
+
+	var requested []eos.PermissionLevel
+	for _, permission := range enroller.Permissions {
+		if permission.PermName == "active" {
+			for _, account := range permission.RequiredAuth.Accounts {
+				requested = append(requested, account.Permission)
+			}
+		}
+	}
+	if len(requested) == 0 {
+		return "", fmt.Errorf("no key of %v is available and its active authority has no accounts to approve an msig", enroller.AccountName)
+	}
+
+	var txOpts eos.TxOptions
+	if err := txOpts.FillFromChain(ctx, api); err != nil {
+		return "", fmt.Errorf("cannot fill transaction options %v", err)
+	}
+
+	transaction := eos.NewTransaction([]*eos.Action{enrollAction(contract, enroller.AccountName, applicant)}, &txOpts)
+	transaction.SetExpiration(reviewProposalExpiration)
+
+	propose := msig.NewPropose(proposer, enrollProposalName(applicant), requested, transaction)
+	trxID, err := eostest.ExecTrx(ctx, api, []*eos.Action{propose})
+	if err != nil {
+		return "", fmt.Errorf("cannot propose the enrollment of %v: %v", applicant, err)
+	}
+	return trxID, nil
+}
+
+// EnrollApproved submits the enrollment of the pending applicants the policy approves, and records it in
+// the store: enroll is sent when the signer holds a key of the enroller, otherwise it is proposed as an msig
+// by proposer; applicants whose enrollment was already submitted are skipped
+func EnrollApproved(ctx context.Context, api *eos.API, contract eos.AccountName, store *ReviewStore,
+	policy ReviewPolicy, proposer eos.AccountName) ([]ReviewEntry, error) {
+
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	applicants, err := ListApplicants(ctx, api, contract)
+	if err != nil {
+		return nil, err
+	}
+
+	enroller, err := api.GetAccount(ctx, policy.Enroller)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find enroller %v: %v", policy.Enroller, err)
+	}
+	direct, err := holdsKey(ctx, api, enroller)
+	if err != nil {
+		return nil, err
+	}
+	if !direct && proposer == "" {
+		return nil, fmt.Errorf("no key of enroller %v is available, and no proposer is set to propose an msig", policy.Enroller)
+	}
+
+	var submitted []ReviewEntry
+	for _, applicant := range applicants {
+		tally := policy.Tally(applicant.Account, store.Trail(applicant.Account))
+		if tally.Status != ReviewApproved || tally.Submitted != nil {
+			continue
+		}
+
+		entry := ReviewEntry{Applicant: applicant.Account, Decision: ReviewEnrolled, Reviewer: policy.Enroller}
+		if direct {
+			var result TransactionResult
+			_, result, err = EnrollApplicant(ctx, api, contract, policy.Enroller, applicant.Account)
+			entry.TrxID = result.ID
+			// only the transaction is recorded, the member document it created is not needed
+			if errors.Is(err, ErrNoDeltas) {
+				err = nil
+			}
+		} else {
+			entry.Decision, entry.Reviewer = ReviewProposed, proposer
+			entry.Reason = "msig " + string(enrollProposalName(applicant.Account))
+			entry.TrxID, err = proposeEnroll(ctx, api, contract, proposer, applicant.Account, enroller)
+		}
+		// a transaction that went through is recorded even if reading what it created failed
+		if entry.TrxID == "" {
+			return submitted, err
+		}
+
+		entry, recordErr := store.record(entry)
+		if recordErr != nil {
+			return submitted, recordErr
+		}
+		submitted = append(submitted, entry)
+		if err != nil {
+			return submitted, err
+		}
+	}
+	return submitted, nil
+}
@@ -0,0 +1,112 @@
+package dao_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/dao-contracts/dao-go"
+	"gotest.tools/assert"
+)
+
+func TestReview(t *testing.T) {
+
+	policy := dao.ReviewPolicy{Reviewers: []eos.AccountName{"alice", "bob", "carol"}, Threshold: 2, Enroller: "dao.hypha"}
+
+	t.Run("Policy", func(t *testing.T) {
+		assert.NilError(t, policy.Validate())
+
+		invalid := policy
+		invalid.Threshold = 4
+		assert.ErrorContains(t, invalid.Validate(), "review threshold 4 must be between 1 and the 3 reviewers")
+		invalid.Reviewers = []eos.AccountName{"alice", "alice"}
+		assert.ErrorContains(t, invalid.Validate(), "reviewer alice is listed twice")
+	})
+
+	t.Run("Store", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "review")
+		assert.NilError(t, err)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "reviews.jsonl")
+
+		store, err := dao.OpenReviewStore(path)
+		assert.NilError(t, err)
+
+		tally, err := store.Decide(policy, "johnnyhypha1", "alice", dao.ReviewApprove, "worked with johnny")
+		assert.NilError(t, err)
+		assert.Equal(t, tally.Status, dao.ReviewPending)
+
+		// a reviewer changing its decision counts once
+		_, err = store.Decide(policy, "johnnyhypha1", "bob", dao.ReviewReject, "")
+		assert.NilError(t, err)
+		tally, err = store.Decide(policy, "johnnyhypha1", "bob", dao.ReviewApprove, "met in the call")
+		assert.NilError(t, err)
+		assert.Equal(t, tally.Status, dao.ReviewApproved)
+		assert.DeepEqual(t, tally.Approvals, []eos.AccountName{"alice", "bob"})
+		assert.Equal(t, len(tally.Rejections), 0)
+
+		// two rejections of three reviewers leave the threshold of two out of reach
+		_, err = store.Decide(policy, "spammer", "alice", dao.ReviewReject, "")
+		assert.NilError(t, err)
+		tally, err = store.Decide(policy, "spammer", "carol", dao.ReviewReject, "")
+		assert.NilError(t, err)
+		assert.Equal(t, tally.Status, dao.ReviewRejected)
+
+		_, err = store.Decide(policy, "johnnyhypha1", "mallory", dao.ReviewApprove, "")
+		assert.ErrorContains(t, err, "mallory is not a reviewer")
+		_, err = store.Decide(policy, "johnnyhypha1", "carol", "abstain", "")
+		assert.ErrorContains(t, err, "unknown decision abstain")
+		assert.NilError(t, store.Close())
+
+		// a crash while writing leaves a partial line behind
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+		assert.NilError(t, err)
+		_, err = file.WriteString(`{"applicant":"johnnyhypha1","reviewer":"car`)
+		assert.NilError(t, err)
+		assert.NilError(t, file.Close())
+
+		store, err = dao.OpenReviewStore(path)
+		assert.NilError(t, err)
+		defer store.Close()
+
+		trail := store.Trail("johnnyhypha1")
+		assert.Equal(t, len(trail), 3)
+		assert.Equal(t, trail[0].Reason, "worked with johnny")
+		assert.Equal(t, len(store.Trail("")), 5)
+		assert.Equal(t, policy.Tally("johnnyhypha1", trail).Status, dao.ReviewApproved)
+
+		// a submitted enrollment closes the review
+		trail = append(trail, dao.ReviewEntry{Applicant: "johnnyhypha1", Reviewer: "dao.hypha", Decision: dao.ReviewEnrolled, TrxID: "abc123"})
+		tally = policy.Tally("johnnyhypha1", trail)
+		assert.Equal(t, tally.Submitted.TrxID, "abc123")
+	})
+
+	t.Run("Notes", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, r.URL.Path, "/v1/history/get_actions")
+			action := func(receiver, account, name, content, trxID string) string {
+				return fmt.Sprintf(`{"block_time":"2020-10-16T14:13:58.500","action_trace":{"receiver":"%v","trx_id":"%v",
+					"act":{"account":"%v","name":"%v","data":{"applicant":"johnnyhypha1","content":"%v"}}}}`, receiver, trxID, account, name, content)
+			}
+			fmt.Fprintf(w, `{"actions":[%v,%v,%v]}`,
+				action("dao.hypha", "dao.hypha", "apply", "first try", "trx1"),
+				action("dao.hypha", "dao.hypha", "apply", "Healer and basket weaver", "trx2"),
+				action("dao1.hypha", "dao1.hypha", "apply", "another DAO", "trx3"))
+		}))
+		defer server.Close()
+
+		notes, trxID, err := dao.ApplicationNotes(context.Background(), eos.New(server.URL), "dao.hypha", "johnnyhypha1")
+		assert.NilError(t, err)
+		assert.Equal(t, notes, "Healer and basket weaver")
+		assert.Equal(t, trxID, "trx2")
+
+		_, _, err = dao.ApplicationNotes(context.Background(), eos.New(server.URL), "dao2.hypha", "johnnyhypha1")
+		assert.ErrorContains(t, err, "no apply action of johnnyhypha1 to dao2.hypha")
+	})
+}
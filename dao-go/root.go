@@ -0,0 +1,75 @@
+package dao
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+)
+
+// RootContent returns the content groups of the root document of contract, as the contract's getRootContent creates them
+func RootContent(contract eos.AccountName) []docgraph.ContentGroup {
+	return []docgraph.ContentGroup{
+		{
+			newContentItem("content_group_label", "string", "details"),
+			newContentItem("root_node", "name", eos.Name(contract)),
+		},
+		{
+			newContentItem("content_group_label", "string", "system"),
+			newContentItem("type", "name", eos.Name("dho")),
+			newContentItem("node_label", "string", "Hypha DHO Root"),
+		},
+	}
+}
+
+// rootFormat is the rendering the document graph hashes for the content of RootContent, with the
+// contract as the root_node
+const rootFormat = "[[{content_group_label=[string,details]},{root_node=[name,%v]}]," +
+	"[{content_group_label=[string,system]},{type=[name,dho]},{node_label=[string,Hypha DHO Root]}]]"
+
+// RootHash returns the hash of the root document of contract, computed from its content like the contract's getRoot
+func RootHash(contract eos.AccountName) eos.Checksum256 {
+	hash := sha256.Sum256([]byte(fmt.Sprintf(rootFormat, contract)))
+	return eos.Checksum256(hash[:])
+}
+
+// LoadRoot loads the root document of contract and checks that it is the root the contract creates
+func LoadRoot(ctx context.Context, api *eos.API, contract eos.AccountName) (docgraph.Document, error) {
+
+	hash := RootHash(contract)
+	root, err := docgraph.LoadDocument(ctx, api, contract, hash.String())
+	if err != nil {
+		return docgraph.Document{}, fmt.Errorf("cannot load root %v of %v, created with createroot: %v", hash, contract, err)
+	}
+
+	if err = validateRoot(root, contract); err != nil {
+		return docgraph.Document{}, err
+	}
+	return root, nil
+}
+
+// validateRoot checks that root has the hash and the root_node and type of the root document of contract
+func validateRoot(root docgraph.Document, contract eos.AccountName) error {
+
+	if !bytes.Equal(root.Hash, RootHash(contract)) {
+		return fmt.Errorf("document %v is not the root of %v", root.Hash, contract)
+	}
+
+	rootNode, err := getName(root, "root_node")
+	if err != nil || rootNode != eos.Name(contract) {
+		return fmt.Errorf("invalid root %v: its root_node is not %v", root.Hash, contract)
+	}
+	if docType, err := getName(root, "type"); err != nil || docType != eos.Name("dho") {
+		return fmt.Errorf("invalid root %v: it is not a dho document", root.Hash)
+	}
+	return nil
+}
+
+// isRoot returns whether document is the root of the contract named in its root_node
+func isRoot(document docgraph.Document) bool {
+	contract, err := getName(document, "root_node")
+	return err == nil && validateRoot(document, eos.AN(string(contract))) == nil
+}
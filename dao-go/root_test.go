@@ -0,0 +1,27 @@
+package dao_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hypha-dao/dao-contracts/dao-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+	"gotest.tools/assert"
+)
+
+func TestRoot(t *testing.T) {
+
+	// roots created by createroot on the testnet
+	assert.Equal(t, dao.RootHash("dao.hypha").String(), "52a7ff82bd6f53b31285e97d6806d886eefb650e79754784e9d923d3df347c91")
+	assert.Equal(t, dao.RootHash("dao1.hypha").String(), "0f374e7a9d8ab17f172f8c478744cdd4016497e15229616f2ffd04d8002ef64a")
+
+	// a graph archive may hold other dho documents; its root is the one matching the hash of its content
+	root := docgraph.Document{Hash: dao.RootHash("dao1.hypha"), ContentGroups: dao.RootContent("dao1.hypha")}
+	other := testDocument(t, "1", "dho", "Hypha DHO Root")
+	forged := docgraph.Document{Hash: dao.RootHash("dao.hypha"), ContentGroups: dao.RootContent("dao1.hypha")}
+
+	graph := &dao.Graph{Documents: []docgraph.Document{other, forged, root}}
+	found, err := graph.Source().Root(context.Background())
+	assert.NilError(t, err)
+	assert.Equal(t, found.Hash.String(), root.Hash.String())
+}
@@ -0,0 +1,495 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	eostest "github.com/digital-scarcity/eos-go-test"
+	"github.com/eoscanada/eos-go"
+	"github.com/eoscanada/eos-go/msig"
+	"github.com/hypha-dao/document-graph/docgraph"
+	"gopkg.in/yaml.v2"
+)
+
+// Settings are the typed values of the DAO settings document, read from the chain or from a YAML
+// file keyed by setting name. A nil field is a setting that is not set.
+type Settings struct {
+	Paused                  *int64    `yaml:"paused,omitempty"`
+	VotingDurationSec       *int64    `yaml:"voting_duration_sec,omitempty"`
+	SeedsDeferralFactorX100 *int64    `yaml:"seeds_deferral_factor_x100,omitempty"`
+	HyphaDeferralFactorX100 *int64    `yaml:"hypha_deferral_factor_x100,omitempty"`
+	HyphaTokenContract      *eos.Name `yaml:"hypha_token_contract,omitempty"`
+	HvoiceTokenContract     *eos.Name `yaml:"hvoice_token_contract,omitempty"`
+	HusdTokenContract       *eos.Name `yaml:"husd_token_contract,omitempty"`
+	SeedsTokenContract      *eos.Name `yaml:"seeds_token_contract,omitempty"`
+	SeedsEscrowContract     *eos.Name `yaml:"seeds_escrow_contract,omitempty"`
+	TelosDecideContract     *eos.Name `yaml:"telos_decide_contract,omitempty"`
+	PublisherContract       *eos.Name `yaml:"publisher_contract,omitempty"`
+	TreasuryContract        *eos.Name `yaml:"treasury_contract,omitempty"`
+	LastBallotID            *eos.Name `yaml:"last_ballot_id,omitempty"`
+	ClientVersion           *string   `yaml:"client_version,omitempty"`
+	ContractVersion         *string   `yaml:"contract_version,omitempty"`
+}
+
+// requiredSettings are read by the contract with getSettingOrFail
+var requiredSettings = []string{
+	"voting_duration_sec",
+	"seeds_deferral_factor_x100",
+	"hypha_deferral_factor_x100",
+	"hypha_token_contract",
+	"husd_token_contract",
+	"seeds_token_contract",
+	"seeds_escrow_contract",
+	"telos_decide_contract",
+	"treasury_contract",
+}
+
+// settingField is a field of Settings with the setting key from its yaml tag
+type settingField struct {
+	key   string
+	value reflect.Value
+}
+
+func (s *Settings) fields() []settingField {
+	value := reflect.ValueOf(s).Elem()
+	fields := make([]settingField, value.NumField())
+	for i := range fields {
+		tag := value.Type().Field(i).Tag.Get("yaml")
+		fields[i] = settingField{key: strings.Split(tag, ",")[0], value: value.Field(i)}
+	}
+	return fields
+}
+
+func (f settingField) isSet() bool {
+	return !f.value.IsNil()
+}
+
+// typeName is the flex value type of the setting
+func (f settingField) typeName() string {
+	switch f.value.Type().Elem().Kind() {
+	case reflect.Int64:
+		return "int64"
+	case reflect.String:
+		if f.value.Type().Elem() == reflect.TypeOf(eos.Name("")) {
+			return "name"
+		}
+	}
+	return "string"
+}
+
+// String is the setting value as written in the settings file, empty if not set
+func (f settingField) String() string {
+	if !f.isSet() {
+		return ""
+	}
+	if f.typeName() == "int64" {
+		return strconv.FormatInt(f.value.Elem().Int(), 10)
+	}
+	return f.value.Elem().String()
+}
+
+// flexValue is the setting value as stored in the settings document
+func (f settingField) flexValue() *docgraph.FlexValue {
+	var impl interface{}
+	switch f.typeName() {
+	case "int64":
+		impl = f.value.Elem().Int()
+	case "name":
+		impl = eos.Name(f.value.Elem().String())
+	default:
+		impl = f.value.Elem().String()
+	}
+	return &docgraph.FlexValue{
+		BaseVariant: eos.BaseVariant{
+			TypeID: docgraph.GetVariants().TypeID(f.typeName()),
+			Impl:   impl,
+		},
+	}
+}
+
+// set stores a value read from the settings document, failing if its type does not match the setting
+func (f settingField) set(value *docgraph.FlexValue) error {
+	switch impl := value.Impl.(type) {
+	case int64:
+		if f.typeName() == "int64" {
+			f.value.Set(reflect.ValueOf(&impl))
+			return nil
+		}
+	case eos.Name:
+		if f.typeName() == "name" {
+			f.value.Set(reflect.ValueOf(&impl))
+			return nil
+		}
+	case string:
+		if f.typeName() == "string" {
+			f.value.Set(reflect.ValueOf(&impl))
+			return nil
+		}
+	}
+	return fmt.Errorf("setting %v holds %T %v, expected %v", f.key, value.Impl, value.Impl, f.typeName())
+}
+
+func (s *Settings) field(key string) (settingField, error) {
+	for _, field := range s.fields() {
+		if field.key == key {
+			return field, nil
+		}
+	}
+	return settingField{}, fmt.Errorf("unknown setting %v", key)
+}
+
+// Get returns the value of the setting key as written in the settings file, and whether it is set
+func (s *Settings) Get(key string) (string, bool, error) {
+	field, err := s.field(key)
+	if err != nil {
+		return "", false, err
+	}
+	return field.String(), field.isSet(), nil
+}
+
+// Set parses value as the type of the setting key and sets it
+func (s *Settings) Set(key, value string) error {
+	field, err := s.field(key)
+	if err != nil {
+		return err
+	}
+
+	switch field.typeName() {
+	case "int64":
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("setting %v must be an int64: %v", key, err)
+		}
+		field.value.Set(reflect.ValueOf(&parsed))
+	case "name":
+		name := eos.Name(value)
+		field.value.Set(reflect.ValueOf(&name))
+	default:
+		field.value.Set(reflect.ValueOf(&value))
+	}
+	return nil
+}
+
+// Remove unsets the setting key
+func (s *Settings) Remove(key string) error {
+	field, err := s.field(key)
+	if err != nil {
+		return err
+	}
+	field.value.Set(reflect.Zero(field.value.Type()))
+	return nil
+}
+
+// Print writes the settings that are set, one key: value line each
+func (s *Settings) Print(w io.Writer) {
+	for _, field := range s.fields() {
+		if field.isSet() {
+			fmt.Fprintf(w, "%v: %v\n", field.key, field.String())
+		}
+	}
+}
+
+// SettingsFromDocument reads the typed settings from the settings content group of the settings
+// document; items that are not settings, such as root_node and updated_date, are ignored
+func SettingsFromDocument(document docgraph.Document) (Settings, error) {
+
+	var settings Settings
+	fields := make(map[string]settingField)
+	for _, field := range settings.fields() {
+		fields[field.key] = field
+	}
+
+	for _, group := range document.ContentGroups {
+		if groupLabel(group) != "settings" {
+			continue
+		}
+		for _, item := range group {
+			field, known := fields[item.Label]
+			if !known || item.Value == nil {
+				continue
+			}
+			if err := field.set(item.Value); err != nil {
+				return settings, err
+			}
+		}
+		return settings, nil
+	}
+	return settings, fmt.Errorf("settings document %v has no settings content group", document.Hash.String())
+}
+
+func groupLabel(group docgraph.ContentGroup) string {
+	for _, item := range group {
+		if item.Label == "content_group_label" && item.Value != nil {
+			return item.Value.String()
+		}
+	}
+	return ""
+}
+
+// LoadSettings reads the settings document at the end of the root's settings edge
+func LoadSettings(ctx context.Context, api *eos.API, contract eos.AccountName) (Settings, error) {
+
+	document, err := getSettings(ctx, api, contract)
+	if err != nil {
+		return Settings{}, err
+	}
+	return SettingsFromDocument(document)
+}
+
+// ReadSettingsFile reads settings from a YAML file, rejecting unknown keys
+func ReadSettingsFile(path string) (Settings, error) {
+
+	var settings Settings
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return settings, fmt.Errorf("cannot read settings file %v: %v", path, err)
+	}
+
+	err = yaml.UnmarshalStrict(data, &settings)
+	if err != nil {
+		return settings, fmt.Errorf("cannot unmarshal settings file %v: %v", path, err)
+	}
+	return settings, nil
+}
+
+// WriteSettingsFile saves settings as a YAML file, such as to start a settings file from the chain
+func WriteSettingsFile(path string, settings Settings) error {
+
+	data, err := yaml.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("cannot marshal settings %v", err)
+	}
+
+	err = ioutil.WriteFile(path, data, 0644)
+	if err != nil {
+		return fmt.Errorf("cannot write settings file %v: %v", path, err)
+	}
+	return nil
+}
+
+// isName checks the characters and length of an EOSIO name
+func isName(name string) bool {
+	if len(name) == 0 || len(name) > 13 {
+		return false
+	}
+	for i, c := range name {
+		valid := c == '.' || (c >= '1' && c <= '5') || (c >= 'a' && c <= 'z')
+		if i == 12 {
+			valid = c == '.' || (c >= '1' && c <= '5') || (c >= 'a' && c <= 'j')
+		}
+		if !valid {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks that the settings read by the contract are set and that every value has a valid
+// format and range, listing all the problems found
+func (s Settings) Validate() error {
+
+	fields := make(map[string]settingField)
+	var problems []string
+	for _, field := range s.fields() {
+		fields[field.key] = field
+		if field.isSet() && field.typeName() == "name" && !isName(field.String()) {
+			problems = append(problems, field.key+" is not a valid name: "+field.String())
+		}
+	}
+
+	for _, key := range requiredSettings {
+		if !fields[key].isSet() {
+			problems = append(problems, key+" is required")
+		}
+	}
+
+	if s.Paused != nil && *s.Paused != 0 && *s.Paused != 1 {
+		problems = append(problems, "paused must be 0 or 1")
+	}
+	if s.VotingDurationSec != nil && *s.VotingDurationSec <= 0 {
+		problems = append(problems, "voting_duration_sec must be positive")
+	}
+	for key, factor := range map[string]*int64{
+		"seeds_deferral_factor_x100": s.SeedsDeferralFactorX100,
+		"hypha_deferral_factor_x100": s.HyphaDeferralFactorX100,
+	} {
+		if factor != nil && (*factor < 0 || *factor > 10000) {
+			problems = append(problems, key+" must be from 0 to 10000")
+		}
+	}
+	for key, version := range map[string]*string{
+		"client_version":   s.ClientVersion,
+		"contract_version": s.ContractVersion,
+	} {
+		if version != nil && strings.TrimSpace(*version) == "" {
+			problems = append(problems, key+" must not be empty")
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("invalid settings: %v", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// SettingChange is a setting to add, change or remove, using the kinds of a GraphDiff
+type SettingChange struct {
+	Kind string `json:"kind"`
+	Key  string `json:"key"`
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+
+	value *docgraph.FlexValue
+}
+
+// SettingsDiff is the list of changes from the current to the desired settings, in key order
+type SettingsDiff []SettingChange
+
+// DiffSettings lists the settings of desired that differ from current; settings set in current
+// and not in desired are removed
+func DiffSettings(current, desired Settings) SettingsDiff {
+
+	var diff SettingsDiff
+	currentFields := current.fields()
+	for i, field := range desired.fields() {
+		old := currentFields[i]
+		change := SettingChange{Key: field.key, Old: old.String(), New: field.String()}
+		switch {
+		case field.isSet() && !old.isSet():
+			change.Kind = DiffAdded
+		case !field.isSet() && old.isSet():
+			change.Kind = DiffRemoved
+		case field.isSet() && change.Old != change.New:
+			change.Kind = DiffChanged
+		default:
+			continue
+		}
+		if field.isSet() {
+			change.value = field.flexValue()
+		}
+		diff = append(diff, change)
+	}
+
+	sort.Slice(diff, func(i, j int) bool { return diff[i].Key < diff[j].Key })
+	return diff
+}
+
+// Print writes one line per setting change
+func (d SettingsDiff) Print(w io.Writer) {
+	if len(d) == 0 {
+		fmt.Fprintln(w, "settings are up to date")
+		return
+	}
+	for _, change := range d {
+		switch change.Kind {
+		case DiffAdded:
+			fmt.Fprintf(w, "+ %v: %v\n", change.Key, change.New)
+		case DiffRemoved:
+			fmt.Fprintf(w, "- %v: %v\n", change.Key, change.Old)
+		default:
+			fmt.Fprintf(w, "~ %v: %v -> %v\n", change.Key, change.Old, change.New)
+		}
+	}
+}
+
+// Actions returns a setsetting action for each added or changed setting and a remsetting action
+// for each removed one, authorized by the contract's active permission
+func (d SettingsDiff) Actions(ctx context.Context, api *eos.API, contract eos.AccountName) ([]*eos.Action, error) {
+
+	var actions []*eos.Action
+	for _, change := range d {
+		action := eos.ActN("setsetting")
+		actionData := map[string]interface{}{"key": change.Key}
+		if change.Kind == DiffRemoved {
+			action = eos.ActN("remsetting")
+		} else {
+			actionData["value"] = change.value
+		}
+
+		actionBinary, err := api.ABIJSONToBin(ctx, contract, eos.Name(action), actionData)
+		if err != nil {
+			return nil, fmt.Errorf("cannot pack action data %v: %v", change.Key, err)
+		}
+
+		actions = append(actions, &eos.Action{
+			Account: contract,
+			Name:    action,
+			Authorization: []eos.PermissionLevel{
+				{Actor: contract, Permission: eos.PN("active")},
+			},
+			ActionData: eos.NewActionDataFromHexData([]byte(actionBinary)),
+		})
+	}
+	return actions, nil
+}
+
+// ApplySettings validates desired and sends the changes from the settings on chain in one transaction,
+// signed by the contract; it returns the changes, and an empty transaction ID if there were none
+func ApplySettings(ctx context.Context, api *eos.API, contract eos.AccountName, desired Settings) (SettingsDiff, string, error) {
+
+	diff, actions, err := settingsActions(ctx, api, contract, desired)
+	if err != nil || len(actions) == 0 {
+		return diff, "", err
+	}
+
+	trxID, err := eostest.ExecTrx(ctx, api, actions)
+	if err != nil {
+		return diff, "", fmt.Errorf("cannot apply settings %v", err)
+	}
+	return diff, trxID, nil
+}
+
+// settingsProposalExpiration is how long a settings msig proposal can collect approvals
+const settingsProposalExpiration = 7 * 24 * time.Hour
+
+// ProposeSettings validates desired and proposes the changes from the settings on chain as an
+// eosio.msig proposal named proposalName by proposer, requesting the approval of requested
+func ProposeSettings(ctx context.Context, api *eos.API, contract, proposer eos.AccountName, proposalName eos.Name,
+	requested []eos.PermissionLevel, desired Settings) (SettingsDiff, string, error) {
+
+	diff, actions, err := settingsActions(ctx, api, contract, desired)
+	if err != nil || len(actions) == 0 {
+		return diff, "", err
+	}
+
+	var txOpts eos.TxOptions
+	err = txOpts.FillFromChain(ctx, api)
+	if err != nil {
+		return diff, "", fmt.Errorf("cannot fill transaction options %v", err)
+	}
+
+	transaction := eos.NewTransaction(actions, &txOpts)
+	transaction.SetExpiration(settingsProposalExpiration)
+
+	propose := msig.NewPropose(proposer, proposalName, requested, transaction)
+	trxID, err := eostest.ExecTrx(ctx, api, []*eos.Action{propose})
+	if err != nil {
+		return diff, "", fmt.Errorf("cannot propose settings %v", err)
+	}
+	return diff, trxID, nil
+}
+
+func settingsActions(ctx context.Context, api *eos.API, contract eos.AccountName, desired Settings) (SettingsDiff, []*eos.Action, error) {
+
+	err := desired.Validate()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	current, err := LoadSettings(ctx, api, contract)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot load settings %v", err)
+	}
+
+	diff := DiffSettings(current, desired)
+	actions, err := diff.Actions(ctx, api, contract)
+	return diff, actions, err
+}
@@ -0,0 +1,127 @@
+package dao_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/dao-contracts/dao-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+	"gotest.tools/assert"
+)
+
+func TestSettings(t *testing.T) {
+
+	item := func(label string, impl interface{}) docgraph.ContentItem {
+		return docgraph.ContentItem{Label: label, Value: &docgraph.FlexValue{BaseVariant: eos.BaseVariant{Impl: impl}}}
+	}
+
+	document := docgraph.Document{ContentGroups: []docgraph.ContentGroup{{
+		item("content_group_label", "settings"),
+		item("root_node", "52a7ff82bd6f53b31285e97d6806d886eefb650e79754784e9d923d3df347c91"),
+		item("paused", int64(0)),
+		item("voting_duration_sec", int64(3600)),
+		item("hypha_token_contract", eos.Name("token.hypha")),
+		item("publisher_contract", eos.Name("publsh.hypha")),
+		item("client_version", "0.2.0 pre-release"),
+	}}}
+
+	current, err := dao.SettingsFromDocument(document)
+	assert.NilError(t, err)
+	assert.Equal(t, *current.VotingDurationSec, int64(3600))
+	assert.Equal(t, *current.HyphaTokenContract, eos.Name("token.hypha"))
+	assert.Assert(t, current.TreasuryContract == nil)
+
+	folder, err := ioutil.TempDir("", "settings")
+	assert.NilError(t, err)
+	defer os.RemoveAll(folder)
+
+	path := filepath.Join(folder, "settings.yaml")
+	assert.NilError(t, ioutil.WriteFile(path, []byte(`
+paused: 1
+voting_duration_sec: 3600
+seeds_deferral_factor_x100: 100
+hypha_deferral_factor_x100: 25
+hypha_token_contract: token.hypha
+husd_token_contract: husd.hypha
+seeds_token_contract: token.seeds
+seeds_escrow_contract: escrow.seeds
+telos_decide_contract: trailservice
+treasury_contract: bank.hypha
+last_ballot_id: hypha1....1ce
+client_version: 0.2.0 pre-release
+`), 0644))
+
+	desired, err := dao.ReadSettingsFile(path)
+	assert.NilError(t, err)
+	assert.NilError(t, desired.Validate())
+
+	t.Run("Diff against the settings on chain", func(t *testing.T) {
+		diff := dao.DiffSettings(current, desired)
+		assert.Equal(t, len(diff), 10)
+
+		var out bytes.Buffer
+		diff.Print(&out)
+		assert.Equal(t, out.String(), `+ husd_token_contract: husd.hypha
++ hypha_deferral_factor_x100: 25
++ last_ballot_id: hypha1....1ce
+~ paused: 0 -> 1
+- publisher_contract: publsh.hypha
++ seeds_deferral_factor_x100: 100
++ seeds_escrow_contract: escrow.seeds
++ seeds_token_contract: token.seeds
++ telos_decide_contract: trailservice
++ treasury_contract: bank.hypha
+`)
+
+		out.Reset()
+		dao.DiffSettings(desired, desired).Print(&out)
+		assert.Equal(t, out.String(), "settings are up to date\n")
+	})
+
+	t.Run("Set and remove single settings", func(t *testing.T) {
+		updated := current
+		assert.NilError(t, updated.Set("voting_duration_sec", "7200"))
+		assert.NilError(t, updated.Set("treasury_contract", "bank.hypha"))
+		assert.NilError(t, updated.Remove("publisher_contract"))
+		assert.ErrorContains(t, updated.Set("paused", "no"), "must be an int64")
+		assert.ErrorContains(t, updated.Remove("pause"), "unknown setting pause")
+
+		value, set, err := updated.Get("treasury_contract")
+		assert.NilError(t, err)
+		assert.Assert(t, set)
+		assert.Equal(t, value, "bank.hypha")
+
+		var out bytes.Buffer
+		dao.DiffSettings(current, updated).Print(&out)
+		assert.Equal(t, out.String(), "- publisher_contract: publsh.hypha\n+ treasury_contract: bank.hypha\n~ voting_duration_sec: 3600 -> 7200\n")
+	})
+
+	t.Run("Round trip through a settings file", func(t *testing.T) {
+		copyPath := filepath.Join(folder, "copy.yaml")
+		assert.NilError(t, dao.WriteSettingsFile(copyPath, desired))
+		copied, err := dao.ReadSettingsFile(copyPath)
+		assert.NilError(t, err)
+		assert.Equal(t, len(dao.DiffSettings(desired, copied)), 0)
+	})
+
+	t.Run("Reject invalid settings", func(t *testing.T) {
+		assert.ErrorContains(t, current.Validate(), "treasury_contract is required")
+
+		invalid := desired
+		paused, name := int64(2), eos.Name("Bank.Hypha")
+		invalid.Paused, invalid.TreasuryContract = &paused, &name
+		assert.ErrorContains(t, invalid.Validate(), "paused must be 0 or 1; treasury_contract is not a valid name")
+
+		assert.NilError(t, ioutil.WriteFile(path, []byte("pause: 1\n"), 0644))
+		_, err := dao.ReadSettingsFile(path)
+		assert.ErrorContains(t, err, "field pause not found")
+
+		document.ContentGroups[0][2] = item("paused", eos.Name("no"))
+		_, err = dao.SettingsFromDocument(document)
+		assert.ErrorContains(t, err, "expected int64")
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"path/filepath"
 	"strconv"
 	"time"
 
@@ -12,10 +13,10 @@ import (
 	"github.com/eoscanada/eos-go"
 	"github.com/hypha-dao/dao-go"
 	"github.com/hypha-dao/document-graph/docgraph"
-	"github.com/spf13/viper"
 )
 
-type assPayoutIn struct {
+// LegacyAssPayout is a record of the legacy asspayouts table
+type LegacyAssPayout struct {
 	ID           uint64             `json:"ass_payment_id"`
 	AssignmentID uint64             `json:"assignment_id"`
 	Recipient    eos.Name           `json:"recipient"`
@@ -34,7 +35,7 @@ type assPayoutOut struct {
 }
 
 func payoutExists(ctx context.Context, api *eos.API, contract eos.AccountName, id int) bool {
-	var records []assPayoutIn
+	var records []LegacyAssPayout
 	var request eos.GetTableRowsRequest
 	request.Code = string(contract)
 	request.Scope = string(contract)
@@ -59,25 +60,25 @@ func payoutExists(ctx context.Context, api *eos.API, contract eos.AccountName, i
 	return false
 }
 
-// CopyAssPayouts ...
-func CopyAssPayouts(ctx context.Context, api *eos.API, contract eos.AccountName, from string) {
+// CopyAssPayouts copies the legacy records from an endpoint URL or a snapshot file into contract
+func CopyAssPayouts(ctx context.Context, api *eos.API, contract eos.AccountName, from string, executor *Executor) error {
 
-	sourceAPI := *eos.New(from)
-
-	payoutsIn, err := getAllAssPayouts(ctx, &sourceAPI, contract)
+	source, err := OpenLegacySource(from, contract)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	fmt.Println("\nCopying " + strconv.Itoa(len(payoutsIn)) + " assignment payout records from " + from)
-	bar := DefaultProgressBar(len(payoutsIn))
+	payoutsIn, err := source.AssPayouts(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot load assignment payouts %v", err)
+	}
 
+	var tasks []Task
 	for index, payoutIn := range payoutsIn {
-
-		paymentDate := eos.TimePoint(payoutIn.PaymentDate.UnixNano() / 1000)
-
-		if !payoutExists(ctx, api, contract, int(payoutIn.ID)) {
-			actions := []*eos.Action{{
+		id := int(payoutIn.ID)
+		tasks = append(tasks, Task{
+			Label: payoutIn.PaymentDate.Format("2006 Jan 02") + ", " + strconv.Itoa(index) + " / " + strconv.Itoa(len(payoutsIn)),
+			Actions: []*eos.Action{{
 				Account: contract,
 				Name:    eos.ActN("addasspayout"),
 				Authorization: []eos.PermissionLevel{
@@ -89,20 +90,16 @@ func CopyAssPayouts(ctx context.Context, api *eos.API, contract eos.AccountName,
 					Recipient:    payoutIn.Recipient,
 					PeriodID:     payoutIn.PeriodID,
 					Payments:     payoutIn.Payments,
-					PaymentDate:  paymentDate,
+					PaymentDate:  eos.TimePoint(payoutIn.PaymentDate.UnixNano() / 1000),
 				}),
-			}}
-
-			_, err := eostest.ExecTrx(ctx, api, actions)
-			if err != nil {
-				fmt.Println("\n\nFAILED to migrate assignment pay: ", payoutIn.PaymentDate.Format("2006 Jan 02"), ", ", strconv.Itoa(index)+" / "+strconv.Itoa(len(payoutsIn)))
-				fmt.Println(err)
-				fmt.Println()
-			}
-			time.Sleep(defaultPause())
-		}
-		bar.Add(1)
+			}},
+			Pending: func(ctx context.Context) bool {
+				return !payoutExists(ctx, api, contract, id)
+			},
+		})
 	}
+
+	return executor.Run(ctx, api, "addasspayout", false, tasks)
 }
 
 type addLegPer struct {
@@ -166,24 +163,30 @@ func periodExists(ctx context.Context, api *eos.API, contract eos.AccountName, i
 	return false
 }
 
-// CopyPeriods ...
-func CopyPeriods(ctx context.Context, api *eos.API, contract eos.AccountName, from string) {
+// CopyPeriods copies the legacy records from an endpoint URL or a snapshot file into contract.
+// Periods are copied one at a time since the contract assigns their IDs in order.
+func CopyPeriods(ctx context.Context, api *eos.API, contract eos.AccountName, from string, executor *Executor) error {
 
-	sourceAPI := *eos.New(from)
-	periods := getLegacyPeriods(ctx, &sourceAPI, contract)
-
-	fmt.Println("\nCopying " + strconv.Itoa(len(periods)) + " periods from " + from)
+	source, err := OpenLegacySource(from, contract)
+	if err != nil {
+		return err
+	}
 
-	bar := DefaultProgressBar(len(periods))
+	periods, err := source.Periods(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot load periods %v", err)
+	}
 
+	var tasks []Task
 	for _, period := range periods {
-
-		if !periodExists(ctx, api, contract, int(period.PeriodID)) {
-
-			startTime := eos.TimePoint(period.StartTime.UnixNano() / 1000)
-			endTime := eos.TimePoint(period.EndTime.UnixNano() / 1000)
-
-			actions := []*eos.Action{{
+		id := int(period.PeriodID)
+		startTime := eos.TimePoint(period.StartTime.UnixNano() / 1000)
+		endTime := eos.TimePoint(period.EndTime.UnixNano() / 1000)
+		readable, nodeLabel := legacyPeriodLabels(period)
+
+		tasks = append(tasks, Task{
+			Label: strconv.Itoa(id),
+			Actions: []*eos.Action{{
 				Account: contract,
 				Name:    eos.ActN("addlegper"),
 				Authorization: []eos.PermissionLevel{
@@ -194,62 +197,58 @@ func CopyPeriods(ctx context.Context, api *eos.API, contract eos.AccountName, fr
 					StartTime: startTime,
 					EndTime:   endTime,
 					Phase:     period.Phase,
-					Readable:  period.StartTime.Time.Format("2006 Jan 02 15:04:05 MST"),
-					NodeLabel: "Starting " + period.StartTime.Time.Format("2006 Jan 02"),
+					Readable:  readable,
+					NodeLabel: nodeLabel,
 				}),
-			}}
-
-			_, err := eostest.ExecTrx(ctx, api, actions)
-			if err != nil {
-				fmt.Println("\n\nFAILED to copy period: ", strconv.Itoa(int(period.PeriodID)))
-				fmt.Println(err)
-				fmt.Println()
-			}
-			time.Sleep(defaultPause())
-		}
-		bar.Add(1)
+			}},
+			Pending: func(ctx context.Context) bool {
+				return !periodExists(ctx, api, contract, id)
+			},
+		})
 	}
+
+	return executor.Run(ctx, api, "addlegper", true, tasks)
 }
 
-// CopyObjects ...
-func CopyObjects(ctx context.Context, api *eos.API, contract eos.AccountName, scope eos.Name, from string) {
+// CopyObjects copies the legacy records from an endpoint URL or a snapshot file into contract
+func CopyObjects(ctx context.Context, api *eos.API, contract eos.AccountName, scope eos.Name, from string, executor *Executor) error {
 
-	sourceAPI := *eos.New(from)
-	objects, _ := getLegacyObjects(ctx, &sourceAPI, contract, scope)
+	source, err := OpenLegacySource(from, contract)
+	if err != nil {
+		return err
+	}
 
-	fmt.Println("\nCopying " + strconv.Itoa(len(objects)) + " " + string(scope) + " objects from " + from)
-	bar := DefaultProgressBar(len(objects))
+	objects, err := source.Objects(ctx, scope)
+	if err != nil {
+		return fmt.Errorf("cannot load %v objects %v", scope, err)
+	}
 
+	var tasks []Task
 	for _, object := range objects {
+		id := int(object.ID)
+		object.Scope = eos.Name(scope)
 
-		if !exists(ctx, api, contract, eos.Name("objects"), scope, int(object.ID)) {
-
-			object.Scope = eos.Name(scope)
-
-			actions := []*eos.Action{{
+		tasks = append(tasks, Task{
+			Label: string(scope) + ", " + strconv.Itoa(id),
+			Actions: []*eos.Action{{
 				Account: contract,
 				Name:    eos.ActN("createobj"),
 				Authorization: []eos.PermissionLevel{
 					{Actor: contract, Permission: eos.PN("active")},
 				},
 				ActionData: eos.NewActionData(object),
-			}}
-
-			_, err := eostest.ExecTrx(ctx, api, actions)
-			if err != nil {
-				fmt.Println("\n\nFAILED to createobj object - scope: ", string(scope)+", ", strconv.Itoa(int(object.ID)))
-				fmt.Println(err)
-				fmt.Println()
-			} else {
-				time.Sleep(defaultPause())
-			}
-		}
-		bar.Add(1)
+			}},
+			Pending: func(ctx context.Context) bool {
+				return !exists(ctx, api, contract, eos.Name("objects"), scope, id)
+			},
+		})
 	}
+
+	return executor.Run(ctx, api, "createobj "+string(scope), false, tasks)
 }
 
 func memberExists(ctx context.Context, api *eos.API, contract eos.AccountName, member eos.Name) bool {
-	var records []memberRecord
+	var records []LegacyMember
 	var request eos.GetTableRowsRequest
 	request.Code = string(contract)
 	request.Scope = string(contract)
@@ -274,61 +273,61 @@ func memberExists(ctx context.Context, api *eos.API, contract eos.AccountName, m
 	return false
 }
 
-// CopyMembers ...
-func CopyMembers(ctx context.Context, api *eos.API, contract eos.AccountName, from string) {
+// CopyMembers copies the legacy records from an endpoint URL or a snapshot file into contract
+func CopyMembers(ctx context.Context, api *eos.API, contract eos.AccountName, from string, executor *Executor) error {
 
-	sourceAPI := *eos.New(from)
-	memberRecords := getLegacyMembers(ctx, &sourceAPI, contract)
+	source, err := OpenLegacySource(from, contract)
+	if err != nil {
+		return err
+	}
 
-	fmt.Println("\nCopying " + strconv.Itoa(len(memberRecords)) + " members from " + from)
-	bar := DefaultProgressBar(len(memberRecords))
+	memberRecords, err := source.Members(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot load members %v", err)
+	}
 
+	var tasks []Task
 	for _, memberRecord := range memberRecords {
+		member := memberRecord.MemberName
 
-		if !memberExists(ctx, api, contract, memberRecord.MemberName) {
-
-			actions := []*eos.Action{{
+		tasks = append(tasks, Task{
+			Label: string(member),
+			Actions: []*eos.Action{{
 				Account: contract,
 				Name:    eos.ActN("addmember"),
 				Authorization: []eos.PermissionLevel{
 					{Actor: contract, Permission: eos.PN("active")},
 				},
 				ActionData: eos.NewActionData(memberRecord),
-			}}
-
-			_, err := eostest.ExecTrx(ctx, api, actions)
-			if err != nil {
-				fmt.Println("\n\nFAILED to copy member: ", string(memberRecord.MemberName))
-				fmt.Println(err)
-				fmt.Println()
-			}
-			time.Sleep(defaultPause())
-		} else {
-			fmt.Println("\nMember already exists: " + string(memberRecord.MemberName))
-		}
-		bar.Add(1)
+			}},
+			Pending: func(ctx context.Context) bool {
+				return !memberExists(ctx, api, contract, member)
+			},
+		})
 	}
+
+	return executor.Run(ctx, api, "addmember", false, tasks)
 }
 
-// CreatePretend ...
-func CreatePretend(ctx context.Context, api *eos.API, contract, telosDecide, member eos.AccountName) (docgraph.Document, error) {
+// CreatePretend creates a role, an assignment to it, a payout, a badge and an assignment to the badge
+// proposed by member, from the role.json, assignment.json, payout.json, badge.json and
+// badge-assignment.json files of dir, and returns the role assignment
+func CreatePretend(ctx context.Context, api *eos.API, contract, telosDecide, member eos.AccountName, dir string) (docgraph.Document, error) {
 
-	roleFilename := "/Users/max/dev/hypha/daoctl/testing/role.json"
-	roleData, err := ioutil.ReadFile(roleFilename)
+	roleData, err := readPretendFile(dir, "role.json")
 	if err != nil {
-		return docgraph.Document{}, fmt.Errorf("Unable to read file: %v %v", roleFilename, err)
+		return docgraph.Document{}, err
 	}
 
 	role, err := CreateRole(ctx, api, contract, telosDecide, member, roleData)
 	if err != nil {
-		panic(err)
+		return docgraph.Document{}, fmt.Errorf("Unable to create role: %v", err)
 	}
 	fmt.Println("Created role document	: ", role.Hash.String())
 
-	assignmentData, err := ioutil.ReadFile("/Users/max/dev/hypha/daoctl/testing/assignment.json")
+	assignmentData, err := readPretendFile(dir, "assignment.json")
 	if err != nil {
-		return docgraph.Document{}, fmt.Errorf("Unable to read file: %v", err)
-
+		return docgraph.Document{}, err
 	}
 
 	roleAssignment, err := CreateAssignment(ctx, api, contract, telosDecide, member, eos.Name("role"), eos.Name("assignment"), assignmentData)
@@ -342,9 +341,9 @@ func CreatePretend(ctx context.Context, api *eos.API, contract, telosDecide, mem
 
 	_, err = claimNextPeriod(ctx, api, contract, member, roleAssignment)
 
-	payoutData, err := ioutil.ReadFile("/Users/max/dev/hypha/daoctl/testing/payout.json")
+	payoutData, err := readPretendFile(dir, "payout.json")
 	if err != nil {
-		return docgraph.Document{}, fmt.Errorf("Unable to read payout file: %v", err)
+		return docgraph.Document{}, err
 	}
 
 	payAmt, _ := eos.NewAssetFromString("1000.00 USD")
@@ -354,9 +353,9 @@ func CreatePretend(ctx context.Context, api *eos.API, contract, telosDecide, mem
 	}
 	fmt.Println("Created payout document	: ", payout.Hash.String())
 
-	badgeData, err := ioutil.ReadFile("/Users/max/dev/hypha/daoctl/testing/badge.json")
+	badgeData, err := readPretendFile(dir, "badge.json")
 	if err != nil {
-		return docgraph.Document{}, fmt.Errorf("Unable to read badge file: %v", err)
+		return docgraph.Document{}, err
 	}
 
 	badge, err := CreateBadge(ctx, api, contract, telosDecide, member, badgeData)
@@ -365,9 +364,9 @@ func CreatePretend(ctx context.Context, api *eos.API, contract, telosDecide, mem
 	}
 	fmt.Println("Created badge document	: ", badge.Hash.String())
 
-	badgeAssignmentData, err := ioutil.ReadFile("/Users/max/dev/hypha/daoctl/testing/badge-assignment.json")
+	badgeAssignmentData, err := readPretendFile(dir, "badge-assignment.json")
 	if err != nil {
-		return docgraph.Document{}, fmt.Errorf("Unable to read badge assignment file: %v", err)
+		return docgraph.Document{}, err
 	}
 
 	badgeAssignment, err := CreateAssignment(ctx, api, contract, telosDecide, member, eos.Name("badge"), eos.Name("assignbadge"), badgeAssignmentData)
@@ -378,41 +377,27 @@ func CreatePretend(ctx context.Context, api *eos.API, contract, telosDecide, mem
 	return roleAssignment, nil
 }
 
+func readPretendFile(dir, name string) ([]byte, error) {
+	filename := filepath.Join(dir, name)
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read file: %v %v", filename, err)
+	}
+	return data, nil
+}
+
 type claimNext struct {
 	AssignmentHash eos.Checksum256 `json:"assignment_hash"`
 }
 
 func claimNextPeriod(ctx context.Context, api *eos.API, contract, claimer eos.AccountName, assignment docgraph.Document) (string, error) {
 
-	actions := []*eos.Action{{
-		Account: contract,
-		Name:    eos.ActN("claimnextper"),
-		Authorization: []eos.PermissionLevel{
-			{Actor: claimer, Permission: eos.PN("active")},
-		},
-		// ActionData: eos.NewActionDataFromHexData([]byte(actionBinary)),
-		ActionData: eos.NewActionData(claimNext{
-			AssignmentHash: assignment.Hash,
-		}),
-	}}
-
-	trxID, err := eostest.ExecTrx(ctx, api, actions)
+	trxID, err := ClaimNextPeriod(ctx, api, contract, claimer, assignment.Hash)
 
 	if err != nil {
 		fmt.Println("Waiting for a period to lapse...")
 		time.Sleep(time.Second * 7)
-		actions := []*eos.Action{{
-			Account: contract,
-			Name:    eos.ActN("claimnextper"),
-			Authorization: []eos.PermissionLevel{
-				{Actor: claimer, Permission: eos.PN("active")},
-			},
-			ActionData: eos.NewActionData(claimNext{
-				AssignmentHash: assignment.Hash,
-			}),
-		}}
-
-		trxID, err = eostest.ExecTrx(ctx, api, actions)
+		trxID, err = ClaimNextPeriod(ctx, api, contract, claimer, assignment.Hash)
 	}
 
 	return trxID, err
@@ -458,7 +443,7 @@ func enrollMember(ctx context.Context, api *eos.API, contract, member eos.Accoun
 
 	pause(defaultPause(), "Building block...", "")
 
-	memberDoc, err := docgraph.GetLastDocumentOfEdge(ctx, api, contract, "member")
+	memberDoc, err := LoadHashed(ctx, api, contract, MemberHash(member))
 	if err != nil {
 		return docgraph.Document{}, fmt.Errorf("error enrolling %v", err)
 	}
@@ -468,59 +453,30 @@ func enrollMember(ctx context.Context, api *eos.API, contract, member eos.Accoun
 
 func getSettings(ctx context.Context, api *eos.API, contract eos.AccountName) (docgraph.Document, error) {
 
-	rootHash := string("52a7ff82bd6f53b31285e97d6806d886eefb650e79754784e9d923d3df347c91")
-	if len(viper.GetString("rootHash")) > 0 {
-		rootHash = viper.GetString("rootHash")
-	}
-
-	root, err := docgraph.LoadDocument(ctx, api, contract, rootHash)
+	result, err := RunQuery(ctx, NewEndpointSource(api, contract), "root -settings-> * limit 1")
 	if err != nil {
-		return docgraph.Document{}, fmt.Errorf("Root document not found, required for default period %v", err)
+		return docgraph.Document{}, fmt.Errorf("error retrieving settings %v", err)
 	}
 
-	edges, err := docgraph.GetEdgesFromDocumentWithEdge(ctx, api, contract, root, eos.Name("settings"))
-	if err != nil || len(edges) <= 0 {
-		return docgraph.Document{}, fmt.Errorf("error retrieving settings edge %v", err)
+	if len(result.Documents) == 0 {
+		return docgraph.Document{}, fmt.Errorf("root document has no settings edge")
 	}
-
-	return docgraph.LoadDocument(ctx, api, contract, edges[0].ToNode.String())
+	return result.Documents[0], nil
 }
 
 func getDefaultPeriod(api *eos.API, contract eos.AccountName) docgraph.Document {
 
-	ctx := context.Background()
-
-	root, err := docgraph.LoadDocument(ctx, api, contract, viper.GetString("rootHash"))
-	if err != nil {
-		panic("Root document not found, required for default period.")
-	}
-
-	edges, err := docgraph.GetEdgesFromDocumentWithEdge(ctx, api, contract, root, eos.Name("start"))
-	if err != nil || len(edges) <= 0 {
-		panic("Next document not found: " + edges[0].ToNode.String())
-	}
-
-	lastDocument, err := docgraph.LoadDocument(ctx, api, contract, edges[0].ToNode.String())
+	// the sixth period from the start
+	result, err := RunQuery(context.Background(), NewEndpointSource(api, contract),
+		"root -start-> * -next-> * -next-> * -next-> * -next-> * -next-> * limit 1")
 	if err != nil {
-		panic("Next document not found: " + edges[0].ToNode.String())
+		panic("Default period not found: " + err.Error())
 	}
 
-	index := 1
-	for index < 6 {
-
-		edges, err := docgraph.GetEdgesFromDocumentWithEdge(ctx, api, contract, lastDocument, eos.Name("next"))
-		if err != nil || len(edges) <= 0 {
-			panic("There are no next edges")
-		}
-
-		lastDocument, err = docgraph.LoadDocument(ctx, api, contract, edges[0].ToNode.String())
-		if err != nil {
-			panic("Next document not found: " + edges[0].ToNode.String())
-		}
-
-		index++
+	if len(result.Documents) == 0 {
+		panic("There are no next edges")
 	}
-	return lastDocument
+	return result.Documents[0]
 }
 
 type proposal struct {
@@ -3,6 +3,8 @@ package dao_test
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/eoscanada/eos-go"
@@ -20,7 +22,7 @@ func TestSetup(t *testing.T) {
 	t.Log("\nDAO Environment Setup complete\n")
 
 	// enroll the test members
-	// dao.EnrollMembers(env.ctx, &env.api, env.DAO)
+	// dao.EnrollMembers(env.Ctx, env.API, env.DAO)
 
 	mem2 := env.Members[2].Member
 	roleFilename := "/Users/max/dev/hypha/daoctl/testing/role.json"
@@ -30,7 +32,7 @@ func TestSetup(t *testing.T) {
 		return
 	}
 
-	role, err := dao.CreateRole(env.ctx, &env.api, env.DAO, env.TelosDecide, mem2, roleData)
+	role, err := dao.CreateRole(env.Ctx, env.API, env.DAO, env.TelosDecide, mem2, roleData)
 	if err != nil {
 		panic(err)
 	}
@@ -42,7 +44,7 @@ func TestSetup(t *testing.T) {
 		return
 	}
 
-	roleAssignment, err := dao.CreateAssignment(env.ctx, &env.api, env.DAO, env.TelosDecide, mem2, eos.Name("role"), eos.Name("assignment"), assignmentData)
+	roleAssignment, err := dao.CreateAssignment(env.Ctx, env.API, env.DAO, env.TelosDecide, mem2, eos.Name("role"), eos.Name("assignment"), assignmentData)
 	if err != nil {
 		panic(err)
 	}
@@ -58,7 +60,7 @@ func TestSetup(t *testing.T) {
 	}
 
 	payAmt, _ := eos.NewAssetFromString("1000.00 USD")
-	payout, err := dao.CreatePayout(env.ctx, &env.api, env.DAO, env.TelosDecide, mem2, mem2, payAmt, 50, payoutData)
+	payout, err := dao.CreatePayout(env.Ctx, env.API, env.DAO, env.TelosDecide, mem2, mem2, payAmt, 50, payoutData)
 	if err != nil {
 		panic(err)
 	}
@@ -69,7 +71,7 @@ func TestSetup(t *testing.T) {
 		panic(err)
 	}
 
-	badge, err := dao.CreateBadge(env.ctx, &env.api, env.DAO, env.TelosDecide, mem2, badgeData)
+	badge, err := dao.CreateBadge(env.Ctx, env.API, env.DAO, env.TelosDecide, mem2, badgeData)
 	if err != nil {
 		panic(err)
 	}
@@ -80,7 +82,7 @@ func TestSetup(t *testing.T) {
 		panic(err)
 	}
 
-	badgeAssignment, err := dao.CreateAssignment(env.ctx, &env.api, env.DAO, env.TelosDecide, mem2, eos.Name("badge"), eos.Name("assignbadge"), badgeAssignmentData)
+	badgeAssignment, err := dao.CreateAssignment(env.Ctx, env.API, env.DAO, env.TelosDecide, mem2, eos.Name("badge"), eos.Name("assignbadge"), badgeAssignmentData)
 	if err != nil {
 		panic(err)
 	}
@@ -103,10 +105,25 @@ func TestPretend(t *testing.T) {
 	t.Log("\nDAO Environment Setup complete\n")
 
 	// enroll the test members
-	//dao.EnrollMembers(env.ctx, &env.api, env.DAO)
+	//dao.EnrollMembers(env.Ctx, env.API, env.DAO)
+
+	dir, err := ioutil.TempDir("", "pretend")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"role.json":             role1,
+		"assignment.json":       assignment1,
+		"payout.json":           payout1,
+		"badge.json":            enroller_badge,
+		"badge-assignment.json": enroller_badge_assignment,
+	}
+	for name, content := range files {
+		assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
 
 	mem2 := env.Members[2].Member
-	roleAssignment, err := dao.CreatePretend(env.ctx, &env.api, env.DAO, env.TelosDecide, mem2)
+	roleAssignment, err := dao.CreatePretend(env.Ctx, env.API, env.DAO, env.TelosDecide, mem2, dir)
 	assert.NilError(t, err)
 
 	t.Log("Waiting for a period to lapse...")
@@ -152,7 +169,7 @@ func TestPretend(t *testing.T) {
 // 		// 	}),
 // 		// }}
 
-// 		// trxID, err := eostest.ExecTrx(env.ctx, &env.api, actions)
+// 		// trxID, err := eostest.ExecTrx(env.Ctx, env.API, actions)
 // 		// assert.NilError(t, err)
 
 // 		// t.Log("Reset successful: " + trxID)
@@ -0,0 +1,219 @@
+package dao
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/eoscanada/eos-go"
+)
+
+const legacySnapshotVersion = 1
+
+// SnapshotTable holds the rows of one legacy table, with their count and the sha256 of the compact JSON rows
+type SnapshotTable struct {
+	Count    int             `json:"count"`
+	Checksum string          `json:"checksum"`
+	Rows     json.RawMessage `json:"rows"`
+}
+
+// SnapshotTables maps table names to their checksummed rows
+type SnapshotTables map[string]SnapshotTable
+
+// LegacySnapshot is a point in time copy of the legacy tables of a contract, saved as a JSON file
+// so a migration can be rehearsed and reproduced without the source endpoint
+type LegacySnapshot struct {
+	Version   int             `json:"version"`
+	Contract  eos.AccountName `json:"contract"`
+	CreatedAt time.Time       `json:"created_at"`
+	Tables    SnapshotTables  `json:"tables"`
+}
+
+func objectsTable(scope eos.Name) string {
+	return "objects/" + string(scope)
+}
+
+func rowsChecksum(rows []byte) string {
+	hash := sha256.Sum256(rows)
+	return hex.EncodeToString(hash[:])
+}
+
+func (s SnapshotTables) put(table string, count int, rows interface{}) error {
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return fmt.Errorf("cannot marshal %v: %v", table, err)
+	}
+
+	s[table] = SnapshotTable{
+		Count:    count,
+		Checksum: rowsChecksum(data),
+		Rows:     data,
+	}
+	return nil
+}
+
+func (s SnapshotTables) get(table string, rows interface{}) error {
+	snapshotTable, ok := s[table]
+	if !ok {
+		return fmt.Errorf("snapshot has no %v table", table)
+	}
+
+	err := json.Unmarshal(snapshotTable.Rows, rows)
+	if err != nil {
+		return fmt.Errorf("cannot unmarshal %v: %v", table, err)
+	}
+	return nil
+}
+
+// verify checks the checksum of every table; the rows are compacted first since indenting the file reformats them
+func (s SnapshotTables) verify() error {
+	for table, snapshotTable := range s {
+		var compact bytes.Buffer
+		err := json.Compact(&compact, snapshotTable.Rows)
+		if err != nil {
+			return fmt.Errorf("cannot read %v rows: %v", table, err)
+		}
+
+		checksum := rowsChecksum(compact.Bytes())
+		if checksum != snapshotTable.Checksum {
+			return fmt.Errorf("checksum mismatch on %v: expected %v, computed %v", table, snapshotTable.Checksum, checksum)
+		}
+	}
+	return nil
+}
+
+// SnapshotLegacyTables saves the members, periods, objects of each scope, asspayouts and applicants
+// tables of contract to a snapshot file at path
+func SnapshotLegacyTables(ctx context.Context, api *eos.API, contract eos.AccountName, scopes []eos.Name, path string) error {
+
+	source := NewEndpointTables(api, contract)
+	snapshot := LegacySnapshot{
+		Version:   legacySnapshotVersion,
+		Contract:  contract,
+		CreatedAt: time.Now().UTC(),
+		Tables:    make(SnapshotTables),
+	}
+
+	members, err := source.Members(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot load members %v", err)
+	}
+	if err = snapshot.Tables.put("members", len(members), members); err != nil {
+		return err
+	}
+
+	periods, err := source.Periods(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot load periods %v", err)
+	}
+	if err = snapshot.Tables.put("periods", len(periods), periods); err != nil {
+		return err
+	}
+
+	for _, scope := range scopes {
+		objects, err := source.Objects(ctx, scope)
+		if err != nil {
+			return fmt.Errorf("cannot load %v objects %v", scope, err)
+		}
+		if err = snapshot.Tables.put(objectsTable(scope), len(objects), objects); err != nil {
+			return err
+		}
+	}
+
+	payouts, err := source.AssPayouts(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot load assignment payouts %v", err)
+	}
+	if err = snapshot.Tables.put("asspayouts", len(payouts), payouts); err != nil {
+		return err
+	}
+
+	applicants, err := source.Applicants(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot load applicants %v", err)
+	}
+	if err = snapshot.Tables.put("applicants", len(applicants), applicants); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot marshal snapshot %v", err)
+	}
+
+	err = ioutil.WriteFile(path, data, 0644)
+	if err != nil {
+		return fmt.Errorf("cannot write snapshot %v: %v", path, err)
+	}
+
+	fmt.Println("\nSnapshot of " + string(contract) + " saved to " + path)
+	for table, snapshotTable := range snapshot.Tables {
+		fmt.Printf("%-20v %6d rows\n", table, snapshotTable.Count)
+	}
+	return nil
+}
+
+// LoadLegacySnapshot reads a snapshot file and verifies the checksum of each table
+func LoadLegacySnapshot(path string) (*LegacySnapshot, error) {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read snapshot %v: %v", path, err)
+	}
+
+	var snapshot LegacySnapshot
+	err = json.Unmarshal(data, &snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("cannot unmarshal snapshot %v: %v", path, err)
+	}
+
+	if snapshot.Version != legacySnapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %v, expected %v", snapshot.Version, legacySnapshotVersion)
+	}
+
+	err = snapshot.Tables.verify()
+	if err != nil {
+		return nil, fmt.Errorf("snapshot %v is corrupt: %v", path, err)
+	}
+	return &snapshot, nil
+}
+
+// Members returns the snapshot of the members table
+func (s *LegacySnapshot) Members(ctx context.Context) ([]LegacyMember, error) {
+	var members []LegacyMember
+	err := s.Tables.get("members", &members)
+	return members, err
+}
+
+// Periods returns the snapshot of the periods table
+func (s *LegacySnapshot) Periods(ctx context.Context) ([]Period, error) {
+	var periods []Period
+	err := s.Tables.get("periods", &periods)
+	return periods, err
+}
+
+// Objects returns the snapshot of the objects table of a scope
+func (s *LegacySnapshot) Objects(ctx context.Context, scope eos.Name) ([]Object, error) {
+	var objects []Object
+	err := s.Tables.get(objectsTable(scope), &objects)
+	return objects, err
+}
+
+// AssPayouts returns the snapshot of the asspayouts table
+func (s *LegacySnapshot) AssPayouts(ctx context.Context) ([]LegacyAssPayout, error) {
+	var payouts []LegacyAssPayout
+	err := s.Tables.get("asspayouts", &payouts)
+	return payouts, err
+}
+
+// Applicants returns the snapshot of the applicants table
+func (s *LegacySnapshot) Applicants(ctx context.Context) ([]LegacyApplicant, error) {
+	var applicants []LegacyApplicant
+	err := s.Tables.get("applicants", &applicants)
+	return applicants, err
+}
@@ -0,0 +1,77 @@
+package dao_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/dao-contracts/dao-go"
+	"gotest.tools/assert"
+)
+
+func snapshotTable(t *testing.T, rows interface{}, count int) dao.SnapshotTable {
+	data, err := json.Marshal(rows)
+	assert.NilError(t, err)
+
+	checksum := sha256.Sum256(data)
+	return dao.SnapshotTable{Count: count, Checksum: hex.EncodeToString(checksum[:]), Rows: data}
+}
+
+func TestLegacySnapshot(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "snapshot")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	members := []dao.LegacyMember{{MemberName: eos.Name("mem1.hypha")}, {MemberName: eos.Name("mem2.hypha")}}
+	roles := []dao.Object{{ID: 7, Strings: []dao.StringKV{{Key: "title", Value: "Underwater Basketweaver"}}}}
+
+	snapshot := dao.LegacySnapshot{
+		Version:  1,
+		Contract: eos.AN("dao.hypha"),
+		Tables: dao.SnapshotTables{
+			"members":      snapshotTable(t, members, len(members)),
+			"objects/role": snapshotTable(t, roles, len(roles)),
+		},
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	assert.NilError(t, err)
+
+	path := filepath.Join(dir, "legacy-snapshot.json")
+	assert.NilError(t, ioutil.WriteFile(path, data, 0644))
+
+	t.Run("Read tables from the snapshot", func(t *testing.T) {
+		ctx := context.Background()
+		source, err := dao.OpenLegacySource(path, eos.AN("dao.hypha"))
+		assert.NilError(t, err)
+
+		loadedMembers, err := source.Members(ctx)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, loadedMembers, members)
+
+		loadedRoles, err := source.Objects(ctx, eos.Name("role"))
+		assert.NilError(t, err)
+		assert.Equal(t, len(loadedRoles), 1)
+		assert.Equal(t, loadedRoles[0].ID, uint64(7))
+
+		_, err = source.AssPayouts(ctx)
+		assert.Assert(t, err != nil)
+	})
+
+	t.Run("Detect a modified table", func(t *testing.T) {
+		tampered := strings.Replace(string(data), "mem2.hypha", "mem3.hypha", 1)
+		assert.NilError(t, ioutil.WriteFile(path, []byte(tampered), 0644))
+
+		_, err := dao.LoadLegacySnapshot(path)
+		assert.Assert(t, err != nil)
+		assert.Assert(t, strings.Contains(err.Error(), "checksum mismatch on members"))
+	})
+}
@@ -0,0 +1,294 @@
+package dao
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+)
+
+// TableIndex is a secondary index of a table, by index position and key type as used by get_table_rows
+type TableIndex struct {
+	Table    string
+	Position string
+	KeyType  string
+}
+
+// Secondary indexes of the documents and edges tables
+var (
+	DocumentsByHash    = TableIndex{Table: "documents", Position: "2", KeyType: "sha256"}
+	DocumentsByCreated = TableIndex{Table: "documents", Position: "4", KeyType: "i64"}
+	EdgesByFromNode    = TableIndex{Table: "edges", Position: "2", KeyType: "sha256"}
+	EdgesByToNode      = TableIndex{Table: "edges", Position: "3", KeyType: "sha256"}
+	EdgesByName        = TableIndex{Table: "edges", Position: "4", KeyType: "i64"}
+	EdgesByCreated     = TableIndex{Table: "edges", Position: "5", KeyType: "i64"}
+)
+
+const defaultIndexPageSize = 500
+
+// IndexQuery selects the rows of a table with a secondary key from Lower to Upper, both included;
+// an empty bound leaves that side open. Limit is the page size, defaulting to 500.
+type IndexQuery struct {
+	Index TableIndex
+	Lower string
+	Upper string
+	Limit uint32
+}
+
+// HashKey is the index key of a document hash
+func HashKey(hash eos.Checksum256) string {
+	return hash.String()
+}
+
+// NameKey is the i64 index key of a name, such as an edge name
+func NameKey(name eos.Name) (string, error) {
+	value, err := eos.StringToName(string(name))
+	if err != nil {
+		return "", fmt.Errorf("invalid name %v: %v", name, err)
+	}
+	return strconv.FormatUint(value, 10), nil
+}
+
+// TimeKey is the i64 index key of a created date, in seconds
+func TimeKey(t time.Time) string {
+	return strconv.FormatInt(t.Unix(), 10)
+}
+
+type tableRowsPage struct {
+	Rows    []json.RawMessage `json:"rows"`
+	More    bool              `json:"more"`
+	NextKey string            `json:"next_key"`
+}
+
+// getTableRowsPage reads one page of an index through a direct get_table_rows call, since the
+// next_key of the response is needed to continue from a secondary key
+func getTableRowsPage(ctx context.Context, api *eos.API, contract eos.AccountName, index TableIndex, lower, upper string, limit uint32) (tableRowsPage, error) {
+
+	var page tableRowsPage
+	body, err := json.Marshal(map[string]interface{}{
+		"code":           contract,
+		"scope":          contract,
+		"table":          index.Table,
+		"index_position": index.Position,
+		"key_type":       index.KeyType,
+		"lower_bound":    lower,
+		"upper_bound":    upper,
+		"limit":          limit,
+		"json":           true,
+	})
+	if err != nil {
+		return page, fmt.Errorf("cannot marshal get table rows request %v", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, api.BaseURL+"/v1/chain/get_table_rows", bytes.NewReader(body))
+	if err != nil {
+		return page, fmt.Errorf("cannot create get table rows request %v", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := api.HttpClient.Do(request)
+	if err != nil {
+		return page, fmt.Errorf("get table rows %v", err)
+	}
+	defer response.Body.Close()
+
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return page, fmt.Errorf("cannot read get table rows response %v", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return page, fmt.Errorf("get table rows on %v index %v: status %v: %s", index.Table, index.Position, response.StatusCode, data)
+	}
+
+	err = json.Unmarshal(data, &page)
+	if err != nil {
+		return page, fmt.Errorf("cannot unmarshal get table rows response %v", err)
+	}
+	return page, nil
+}
+
+// getIndexedRows reads every row selected by query, continuing each page from its next_key. Rows
+// sharing the key at a page boundary are read again on the next page and skipped by id; when a
+// single key holds more rows than a page, the page grows until it covers them.
+func getIndexedRows(ctx context.Context, api *eos.API, contract eos.AccountName, query IndexQuery) ([]json.RawMessage, error) {
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = defaultIndexPageSize
+	}
+
+	var rows []json.RawMessage
+	seen := make(map[uint64]bool)
+	lower := query.Lower
+
+	for {
+		page, err := getTableRowsPage(ctx, api, contract, query.Index, lower, query.Upper, limit)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range page.Rows {
+			var rowID tableRowID
+			err = json.Unmarshal(row, &rowID)
+			if err != nil {
+				return nil, fmt.Errorf("cannot read %v row id %v", query.Index.Table, err)
+			}
+			if !seen[rowID.ID] {
+				seen[rowID.ID] = true
+				rows = append(rows, row)
+			}
+		}
+
+		if !page.More || page.NextKey == "" {
+			return rows, nil
+		}
+		if page.NextKey == lower {
+			limit *= 2
+		}
+		lower = page.NextKey
+	}
+}
+
+// QueryEdges returns the edges selected by query, which must be on an index of the edges table
+func QueryEdges(ctx context.Context, api *eos.API, contract eos.AccountName, query IndexQuery) ([]docgraph.Edge, error) {
+
+	if query.Index.Table != "edges" {
+		return nil, fmt.Errorf("index %v is on table %v, not edges", query.Index.Position, query.Index.Table)
+	}
+
+	rows, err := getIndexedRows(ctx, api, contract, query)
+	if err != nil {
+		return nil, err
+	}
+
+	edges := make([]docgraph.Edge, len(rows))
+	for i, row := range rows {
+		if err := json.Unmarshal(row, &edges[i]); err != nil {
+			return nil, fmt.Errorf("cannot unmarshal edge %v", err)
+		}
+	}
+	return edges, nil
+}
+
+// QueryDocuments returns the documents selected by query, which must be on an index of the documents table
+func QueryDocuments(ctx context.Context, api *eos.API, contract eos.AccountName, query IndexQuery) ([]docgraph.Document, error) {
+
+	if query.Index.Table != "documents" {
+		return nil, fmt.Errorf("index %v is on table %v, not documents", query.Index.Position, query.Index.Table)
+	}
+
+	rows, err := getIndexedRows(ctx, api, contract, query)
+	if err != nil {
+		return nil, err
+	}
+
+	documents := make([]docgraph.Document, len(rows))
+	for i, row := range rows {
+		if err := json.Unmarshal(row, &documents[i]); err != nil {
+			return nil, fmt.Errorf("cannot unmarshal document %v", err)
+		}
+	}
+	return documents, nil
+}
+
+// DocumentsCreatedBetween returns the documents created from start to end, both included, to the second
+func DocumentsCreatedBetween(ctx context.Context, api *eos.API, contract eos.AccountName, start, end time.Time) ([]docgraph.Document, error) {
+
+	documents, err := QueryDocuments(ctx, api, contract, IndexQuery{Index: DocumentsByCreated, Lower: TimeKey(start), Upper: TimeKey(end)})
+	if err != nil {
+		return nil, fmt.Errorf("cannot read documents created from %v to %v: %v", start, end, err)
+	}
+	return documents, nil
+}
+
+func filterEdges(edges []docgraph.Edge, keep func(edge docgraph.Edge) bool) []docgraph.Edge {
+	var kept []docgraph.Edge
+	for _, edge := range edges {
+		if keep(edge) {
+			kept = append(kept, edge)
+		}
+	}
+	return kept
+}
+
+// OutgoingEdges returns the edges from document named edgeName, or all of them if edgeName is empty
+func OutgoingEdges(ctx context.Context, api *eos.API, contract eos.AccountName, document docgraph.Document, edgeName eos.Name) ([]docgraph.Edge, error) {
+
+	key := HashKey(document.Hash)
+	edges, err := QueryEdges(ctx, api, contract, IndexQuery{Index: EdgesByFromNode, Lower: key, Upper: key})
+	if err != nil {
+		return nil, fmt.Errorf("cannot read edges from %v: %v", key, err)
+	}
+	return filterEdges(edges, func(edge docgraph.Edge) bool {
+		return edgeName == "" || edge.EdgeName == edgeName
+	}), nil
+}
+
+// IncomingEdges returns the edges to document named edgeName, or all of them if edgeName is empty
+func IncomingEdges(ctx context.Context, api *eos.API, contract eos.AccountName, document docgraph.Document, edgeName eos.Name) ([]docgraph.Edge, error) {
+
+	key := HashKey(document.Hash)
+	edges, err := QueryEdges(ctx, api, contract, IndexQuery{Index: EdgesByToNode, Lower: key, Upper: key})
+	if err != nil {
+		return nil, fmt.Errorf("cannot read edges to %v: %v", key, err)
+	}
+	return filterEdges(edges, func(edge docgraph.Edge) bool {
+		return edgeName == "" || edge.EdgeName == edgeName
+	}), nil
+}
+
+// EdgesNamed returns every edge named edgeName, whatever its nodes
+func EdgesNamed(ctx context.Context, api *eos.API, contract eos.AccountName, edgeName eos.Name) ([]docgraph.Edge, error) {
+
+	key, err := NameKey(edgeName)
+	if err != nil {
+		return nil, err
+	}
+
+	edges, err := QueryEdges(ctx, api, contract, IndexQuery{Index: EdgesByName, Lower: key, Upper: key})
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %v edges: %v", edgeName, err)
+	}
+	return edges, nil
+}
+
+// EdgesCreatedBetween returns the edges created from start to end, both included, to the second
+func EdgesCreatedBetween(ctx context.Context, api *eos.API, contract eos.AccountName, start, end time.Time) ([]docgraph.Edge, error) {
+
+	edges, err := QueryEdges(ctx, api, contract, IndexQuery{Index: EdgesByCreated, Lower: TimeKey(start), Upper: TimeKey(end)})
+	if err != nil {
+		return nil, fmt.Errorf("cannot read edges created from %v to %v: %v", start, end, err)
+	}
+	return edges, nil
+}
+
+// ListByEdge returns the documents that document points to with edgeName, such as the approved badges
+// with ListByEdge(ctx, api, contract, root, "badge"). The edges are read through the edge name index and
+// kept when they start from document.
+func ListByEdge(ctx context.Context, api *eos.API, contract eos.AccountName, document docgraph.Document, edgeName eos.Name) ([]docgraph.Document, error) {
+
+	edges, err := EdgesNamed(ctx, api, contract, edgeName)
+	if err != nil {
+		return nil, err
+	}
+
+	var documents []docgraph.Document
+	for _, edge := range edges {
+		if edge.FromNode.String() != document.Hash.String() {
+			continue
+		}
+		target, err := docgraph.LoadDocument(ctx, api, contract, edge.ToNode.String())
+		if err != nil {
+			return nil, fmt.Errorf("cannot load %v document %v: %v", edgeName, edge.ToNode.String(), err)
+		}
+		documents = append(documents, target)
+	}
+	return documents, nil
+}
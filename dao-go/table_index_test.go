@@ -0,0 +1,81 @@
+package dao_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/dao-contracts/dao-go"
+	"gotest.tools/assert"
+)
+
+func TestIndexedEdgeQueries(t *testing.T) {
+
+	proposal := testDocument(t, "1", "proposal", "Healer")
+	root := testDocument(t, "2", "dho", "Hypha DHO Root")
+	member := testDocument(t, "3", "member", "johnnyhypha1")
+
+	type edgeRow struct {
+		ID       uint64          `json:"id"`
+		FromNode eos.Checksum256 `json:"from_node"`
+		ToNode   eos.Checksum256 `json:"to_node"`
+		EdgeName eos.Name        `json:"edge_name"`
+	}
+
+	// five edges share the proposal as to_node, more than the pages of two rows used below
+	var rows []edgeRow
+	for id := uint64(1); id <= 5; id++ {
+		rows = append(rows, edgeRow{ID: id, FromNode: member.Hash, ToNode: proposal.Hash, EdgeName: "owns"})
+	}
+	rows[0].FromNode, rows[0].EdgeName = root.Hash, "proposal"
+
+	var requests []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Path, "/v1/chain/get_table_rows")
+
+		var request map[string]interface{}
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&request))
+		requests = append(requests, request)
+
+		limit := int(request["limit"].(float64))
+		page := map[string]interface{}{"rows": rows, "more": false, "next_key": ""}
+		if limit < len(rows) {
+			page = map[string]interface{}{"rows": rows[:limit], "more": true, "next_key": proposal.Hash.String()}
+		}
+		assert.NilError(t, json.NewEncoder(w).Encode(page))
+	}))
+	defer server.Close()
+
+	api := eos.New(server.URL)
+	ctx := context.Background()
+
+	t.Run("Follow next_key until a shared key fits a page", func(t *testing.T) {
+		requests = nil
+		key := dao.HashKey(proposal.Hash)
+		edges, err := dao.QueryEdges(ctx, api, "dao.hypha", dao.IndexQuery{Index: dao.EdgesByToNode, Lower: key, Upper: key, Limit: 2})
+		assert.NilError(t, err)
+		assert.Equal(t, len(edges), 5)
+		assert.Equal(t, edges[0].EdgeName, eos.Name("proposal"))
+
+		assert.Equal(t, len(requests), 3)
+		assert.Equal(t, requests[0]["index_position"], "3")
+		assert.Equal(t, requests[0]["key_type"], "sha256")
+		assert.Equal(t, requests[0]["table"], "edges")
+		assert.Equal(t, requests[2]["limit"], float64(8))
+	})
+
+	t.Run("Incoming edges by name", func(t *testing.T) {
+		edges, err := dao.IncomingEdges(ctx, api, "dao.hypha", proposal, "proposal")
+		assert.NilError(t, err)
+		assert.Equal(t, len(edges), 1)
+		assert.Equal(t, edges[0].FromNode.String(), root.Hash.String())
+	})
+
+	t.Run("Reject indexes of other tables", func(t *testing.T) {
+		_, err := dao.QueryEdges(ctx, api, "dao.hypha", dao.IndexQuery{Index: dao.DocumentsByHash})
+		assert.ErrorContains(t, err, "not edges")
+	})
+}
@@ -0,0 +1,236 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	eostest "github.com/digital-scarcity/eos-go-test"
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+)
+
+// the adjustcmtmnt action reads the assignment hash from this label, spelling included
+const adjustAssignmentLabel = "assignemnt_id"
+
+type adjustCommitment struct {
+	Issuer     eos.AccountName         `json:"issuer"`
+	AdjustInfo []docgraph.ContentGroup `json:"adjust_info"`
+}
+
+// TimeShareSegment is a span of an assignment during which a single time_share_x100 applies
+type TimeShareSegment struct {
+	Hash      eos.Checksum256
+	TimeShare int64
+	StartDate time.Time
+
+	// EndDate is the start of the following segment, or the end of the assignment's last
+	// period for the final segment; it is zero if the calendar does not reach that far
+	EndDate time.Time
+
+	// Current is set on the segment the assignment's 'curtimeshare' edge points to, where
+	// claimnextper starts pro-rating the next claim
+	Current bool
+}
+
+// AdjustCommitment changes the time share of an assignment, effective at startDate.
+// A zero startDate lets the contract use the current time. The adjustment is signed by
+// the assignee, so the API's signer must hold the assignee's key.
+func AdjustCommitment(ctx context.Context, api *eos.API, contract eos.AccountName,
+	assignmentHash eos.Checksum256, newTimeShare int64, startDate eos.TimePoint) (string, error) {
+
+	assignment, err := LoadAssignment(ctx, api, contract, assignmentHash)
+	if err != nil {
+		return "error", err
+	}
+
+	assignee, err := getName(assignment, "assignee")
+	if err != nil {
+		return "error", err
+	}
+
+	err = validateAdjustment(ctx, api, contract, assignment, newTimeShare, startDate)
+	if err != nil {
+		return "error", err
+	}
+
+	adjustInfo := docgraph.ContentGroup{
+		{
+			Label: adjustAssignmentLabel,
+			Value: &docgraph.FlexValue{
+				BaseVariant: eos.BaseVariant{
+					TypeID: docgraph.GetVariants().TypeID("checksum256"),
+					Impl:   assignmentHash,
+				}},
+		},
+		{
+			Label: "new_time_share_x100",
+			Value: &docgraph.FlexValue{
+				BaseVariant: eos.BaseVariant{
+					TypeID: docgraph.GetVariants().TypeID("int64"),
+					Impl:   newTimeShare,
+				}},
+		},
+	}
+
+	if startDate != 0 {
+		adjustInfo = append(adjustInfo, docgraph.ContentItem{
+			Label: "start_date",
+			Value: &docgraph.FlexValue{
+				BaseVariant: eos.BaseVariant{
+					TypeID: docgraph.GetVariants().TypeID("time_point"),
+					Impl:   startDate,
+				}},
+		})
+	}
+
+	actions := []*eos.Action{{
+		Account: contract,
+		Name:    eos.ActN("adjustcmtmnt"),
+		Authorization: []eos.PermissionLevel{
+			{Actor: eos.AN(string(assignee)), Permission: eos.PN("active")},
+		},
+		ActionData: eos.NewActionData(adjustCommitment{
+			Issuer:     eos.AN(string(assignee)),
+			AdjustInfo: []docgraph.ContentGroup{adjustInfo},
+		}),
+	}}
+
+	return eostest.ExecTrx(ctx, api, actions)
+}
+
+// validateAdjustment applies the same checks as the adjustcmtmnt action, and also
+// requires the start date to fall within the periods of the assignment
+func validateAdjustment(ctx context.Context, api *eos.API, contract eos.AccountName,
+	assignment docgraph.Document, newTimeShare int64, startDate eos.TimePoint) error {
+
+	roleHash, err := getChecksum(assignment, "role")
+	if err != nil {
+		return err
+	}
+
+	role, err := docgraph.LoadDocument(ctx, api, contract, roleHash.String())
+	if err != nil {
+		return fmt.Errorf("cannot load role %v: %v", roleHash.String(), err)
+	}
+
+	minTimeShare, err := getInt64(role, "min_time_share_x100")
+	if err != nil {
+		return err
+	}
+
+	originalTimeShare, err := getInt64(assignment, "time_share_x100")
+	if err != nil {
+		return err
+	}
+
+	if newTimeShare < minTimeShare {
+		return fmt.Errorf("new_time_share_x100 must be greater than or equal to: %v You submitted: %v", minTimeShare, newTimeShare)
+	}
+
+	if newTimeShare > originalTimeShare {
+		return fmt.Errorf("new_time_share_x100 must be less than or equal to original time_share_x100: %v You submitted: %v", originalTimeShare, newTimeShare)
+	}
+
+	effectiveDate := time.Now().UTC()
+	if startDate != 0 {
+		effectiveDate = ToTime(startDate)
+
+		lastTimeShare, err := getEdgeTarget(ctx, api, contract, assignment, eos.Name("lastimeshare"))
+		if err != nil {
+			return err
+		}
+
+		lastStartDate, err := getTimePoint(lastTimeShare, "start_date")
+		if err != nil {
+			return err
+		}
+
+		if ToTime(lastStartDate).Unix() >= effectiveDate.Unix() {
+			return fmt.Errorf("new time share start date must be greater than the previous time share: %v", ToTime(lastStartDate))
+		}
+	}
+
+	periods, err := AssignmentPeriods(ctx, api, contract, assignment)
+	if err != nil {
+		return err
+	}
+
+	if len(periods) == 0 {
+		return fmt.Errorf("assignment %v does not cover any periods", assignment.Hash.String())
+	}
+
+	first, last := periods[0].StartTime, periods[len(periods)-1].EndTime
+	if effectiveDate.Before(first) || !effectiveDate.Before(last) {
+		return fmt.Errorf("start date %v is outside of the assignment's periods: %v to %v", effectiveDate, first, last)
+	}
+	return nil
+}
+
+// TimeShareHistory returns the time share segments of an assignment in order, following
+// the 'nextimeshare' edges from the initial time share and marking the current one
+func TimeShareHistory(ctx context.Context, api *eos.API, contract eos.AccountName, assignment docgraph.Document) ([]TimeShareSegment, error) {
+
+	current, err := getEdgeTarget(ctx, api, contract, assignment, eos.Name("curtimeshare"))
+	if err != nil {
+		return []TimeShareSegment{}, err
+	}
+
+	timeShare, err := getEdgeTarget(ctx, api, contract, assignment, eos.Name("initimeshare"))
+	if err != nil {
+		return []TimeShareSegment{}, err
+	}
+
+	var segments []TimeShareSegment
+	visited := make(map[string]bool)
+
+	for {
+		if visited[timeShare.Hash.String()] {
+			return segments, fmt.Errorf("time share chain of assignment %v loops at %v", assignment.Hash.String(), timeShare.Hash.String())
+		}
+		visited[timeShare.Hash.String()] = true
+
+		share, err := getInt64(timeShare, "time_share_x100")
+		if err != nil {
+			return segments, err
+		}
+
+		startDate, err := getTimePoint(timeShare, "start_date")
+		if err != nil {
+			return segments, err
+		}
+
+		if len(segments) > 0 {
+			segments[len(segments)-1].EndDate = ToTime(startDate)
+		}
+
+		segments = append(segments, TimeShareSegment{
+			Hash:      timeShare.Hash,
+			TimeShare: share,
+			StartDate: ToTime(startDate),
+			Current:   timeShare.Hash.String() == current.Hash.String(),
+		})
+
+		edges, err := docgraph.GetEdgesFromDocumentWithEdge(ctx, api, contract, timeShare, eos.Name("nextimeshare"))
+		if err != nil {
+			return segments, fmt.Errorf("cannot retrieve nextimeshare edge from %v: %v", timeShare.Hash.String(), err)
+		}
+
+		if len(edges) == 0 {
+			break
+		}
+
+		timeShare, err = docgraph.LoadDocument(ctx, api, contract, edges[0].ToNode.String())
+		if err != nil {
+			return segments, fmt.Errorf("cannot load time share %v: %v", edges[0].ToNode.String(), err)
+		}
+	}
+
+	// the final segment runs until the end of the assignment, when the calendar reaches it
+	periods, err := AssignmentPeriods(ctx, api, contract, assignment)
+	if err == nil && len(periods) > 0 {
+		segments[len(segments)-1].EndDate = periods[len(periods)-1].EndTime
+	}
+
+	return segments, nil
+}
@@ -0,0 +1,437 @@
+package dao
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+)
+
+// ActionTrace is an action run by a transaction, either one of its actions or an inline action they sent.
+// Ordinal numbers the actions of the transaction, and CreatorOrdinal is the ordinal of the action that
+// sent an inline action.
+type ActionTrace struct {
+	Ordinal        uint32
+	CreatorOrdinal uint32
+	Receiver       eos.AccountName
+	Account        eos.AccountName
+	Name           eos.ActionName
+	Authorization  []eos.PermissionLevel
+	Data           json.RawMessage
+	Console        string
+	Inline         bool
+}
+
+// TransactionResult is what a transaction did: its actions, with the inline actions, and the documents
+// and edges of the graph it created, and the documents it updated in place, such as the settings.
+// Deltas is false when the node reported no table deltas, and then what the transaction did to the
+// graph is not available: Documents, Edges and Updated are empty.
+type TransactionResult struct {
+	ID        string
+	BlockNum  uint32
+	BlockTime time.Time
+	Actions   []ActionTrace
+	Deltas    bool
+	Documents []docgraph.Document
+	Edges     []docgraph.Edge
+	Updated   []docgraph.Document
+}
+
+// ErrNoDeltas is returned when what a transaction created is needed and the node reported no table deltas
+var ErrNoDeltas = errors.New("the node reports no table deltas, what the transaction created is not available")
+
+// ResultError is returned when a transaction was pushed and succeeded but its result cannot be read.
+// The transaction must not be sent again: TrxID identifies it on chain.
+type ResultError struct {
+	TrxID string
+	Err   error
+}
+
+func (e *ResultError) Error() string {
+	return fmt.Sprintf("transaction %v succeeded but its result cannot be read: %v", e.TrxID, e.Err)
+}
+
+func (e *ResultError) Unwrap() error {
+	return e.Err
+}
+
+// Created returns the documents of documentType the transaction created
+func (r TransactionResult) Created(documentType string) []docgraph.Document {
+	var documents []docgraph.Document
+	for _, document := range r.Documents {
+		if DocumentType(document) == documentType {
+			documents = append(documents, document)
+		}
+	}
+	return documents
+}
+
+// CreatedEdges returns the edges named edgeName the transaction created
+func (r TransactionResult) CreatedEdges(edgeName eos.Name) []docgraph.Edge {
+	return filterEdges(r.Edges, func(edge docgraph.Edge) bool {
+		return edge.EdgeName == edgeName
+	})
+}
+
+// Print writes the transaction and what it created to w
+func (r TransactionResult) Print(w io.Writer) {
+	fmt.Fprintf(w, "Transaction: %v in block %v at %v\n", r.ID, r.BlockNum, r.BlockTime.UTC().Format(time.RFC3339Nano))
+	for _, action := range r.Actions {
+		indent := ""
+		if action.Inline {
+			indent = "  "
+		}
+		fmt.Fprintf(w, "  %vaction %v::%v\n", indent, action.Account, action.Name)
+	}
+	if !r.Deltas {
+		fmt.Fprintf(w, "  changes to the graph not available: %v\n", ErrNoDeltas)
+	}
+	for _, document := range r.Documents {
+		fmt.Fprintf(w, "  created %v %v %v\n", DocumentType(document), document.Hash, NodeLabel(document))
+	}
+	for _, document := range r.Updated {
+		fmt.Fprintf(w, "  updated %v %v\n", DocumentType(document), document.Hash)
+	}
+	for _, edge := range r.Edges {
+		fmt.Fprintf(w, "  edge %v -%v-> %v\n", edge.FromNode, edge.EdgeName, edge.ToNode)
+	}
+}
+
+type traceAction struct {
+	Account       eos.AccountName       `json:"account"`
+	Name          eos.ActionName        `json:"name"`
+	Authorization []eos.PermissionLevel `json:"authorization"`
+	Data          json.RawMessage       `json:"data"`
+}
+
+type actionTraceJSON struct {
+	ActionOrdinal        uint32            `json:"action_ordinal"`
+	CreatorActionOrdinal uint32            `json:"creator_action_ordinal"`
+	Receiver             eos.AccountName   `json:"receiver"`
+	Action               traceAction       `json:"act"`
+	Console              string            `json:"console"`
+	InlineTraces         []actionTraceJSON `json:"inline_traces"`
+	DBOps                []dbOpJSON        `json:"db_ops"`
+}
+
+// transactionTraceJSON is the trace of push_transaction's processed field, and of get_transaction
+// where the action traces are named traces
+type transactionTraceJSON struct {
+	ID           string            `json:"id"`
+	BlockNum     uint32            `json:"block_num"`
+	BlockTime    string            `json:"block_time"`
+	ActionTraces []actionTraceJSON `json:"action_traces"`
+	Traces       []actionTraceJSON `json:"traces"`
+}
+
+// parseBlockTime parses a block time of the chain API, in UTC without a time zone
+func parseBlockTime(value string) (time.Time, error) {
+	return time.Parse("2006-01-02T15:04:05.999", value)
+}
+
+// flattenTraces lists the actions of traces in execution order, skipping the notifications of other
+// receivers; nodes list inline actions either flat with their ordinals or nested in inline_traces,
+// which are numbered in order here with creator as their creator ordinal
+func flattenTraces(traces []actionTraceJSON, creator uint32, numbered *uint32) []ActionTrace {
+	var actions []ActionTrace
+	for _, trace := range traces {
+		ordinal, creatorOrdinal := trace.ActionOrdinal, trace.CreatorActionOrdinal
+		if ordinal == 0 {
+			*numbered++
+			ordinal, creatorOrdinal = *numbered, creator
+		}
+
+		if trace.Receiver == trace.Action.Account {
+			actions = append(actions, ActionTrace{
+				Ordinal:        ordinal,
+				CreatorOrdinal: creatorOrdinal,
+				Receiver:       trace.Receiver,
+				Account:        trace.Action.Account,
+				Name:           trace.Action.Name,
+				Authorization:  trace.Action.Authorization,
+				Data:           trace.Action.Data,
+				Console:        trace.Console,
+				Inline:         creatorOrdinal > 0,
+			})
+		}
+		actions = append(actions, flattenTraces(trace.InlineTraces, ordinal, numbered)...)
+	}
+	return actions
+}
+
+// postChain posts request to path of the node and unmarshals the response, reporting the error
+// messages of the node when it fails
+func postChain(ctx context.Context, api *eos.API, path string, request, response interface{}) error {
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("cannot marshal %v request %v", path, err)
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, api.BaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("cannot create %v request %v", path, err)
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+
+	httpResponse, err := api.HttpClient.Do(httpRequest)
+	if err != nil {
+		return fmt.Errorf("%v %v", path, err)
+	}
+	defer httpResponse.Body.Close()
+
+	data, err := ioutil.ReadAll(httpResponse.Body)
+	if err != nil {
+		return fmt.Errorf("cannot read %v response %v", path, err)
+	}
+
+	if httpResponse.StatusCode != http.StatusOK {
+		var failure struct {
+			Error struct {
+				What    string `json:"what"`
+				Details []struct {
+					Message string `json:"message"`
+				} `json:"details"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(data, &failure) != nil || failure.Error.What == "" {
+			return fmt.Errorf("%v: status %v: %s", path, httpResponse.StatusCode, data)
+		}
+
+		messages := []string{failure.Error.What}
+		for _, detail := range failure.Error.Details {
+			messages = append(messages, detail.Message)
+		}
+		return fmt.Errorf("%v: %v", path, strings.Join(messages, ": "))
+	}
+
+	if err = json.Unmarshal(data, response); err != nil {
+		return fmt.Errorf("cannot unmarshal %v response %v", path, err)
+	}
+	return nil
+}
+
+// Submit signs and pushes a transaction of actions, and reads from its action traces what it did to
+// the graph of contract. When the push fails, the result is empty; when the transaction succeeded but
+// its traces cannot be read, the result has the transaction ID and the error is a *ResultError.
+func Submit(ctx context.Context, api *eos.API, contract eos.AccountName, actions []*eos.Action) (TransactionResult, error) {
+
+	options := &eos.TxOptions{}
+	if err := options.FillFromChain(ctx, api); err != nil {
+		return TransactionResult{}, fmt.Errorf("cannot read chain state %v", err)
+	}
+
+	_, packed, err := api.SignTransaction(ctx, eos.NewTransaction(actions, options), options.ChainID, eos.CompressionNone)
+	if err != nil {
+		return TransactionResult{}, fmt.Errorf("cannot sign transaction %v", err)
+	}
+
+	var response struct {
+		TransactionID string               `json:"transaction_id"`
+		Processed     transactionTraceJSON `json:"processed"`
+	}
+	err = postChain(ctx, api, "/v1/chain/push_transaction", packed, &response)
+	if err != nil {
+		return TransactionResult{}, err
+	}
+
+	if response.Processed.ID == "" {
+		response.Processed.ID = response.TransactionID
+	}
+	result, err := readTransaction(contract, response.Processed)
+	if err != nil {
+		return result, &ResultError{TrxID: result.ID, Err: err}
+	}
+	return result, nil
+}
+
+// LoadTransaction reads what the transaction trxID did to the graph of contract, from the traces of a node
+// running the history plugin
+func LoadTransaction(ctx context.Context, api *eos.API, contract eos.AccountName, trxID string) (TransactionResult, error) {
+
+	var response transactionTraceJSON
+	err := postChain(ctx, api, "/v1/history/get_transaction", map[string]string{"id": trxID}, &response)
+	if err != nil {
+		return TransactionResult{}, err
+	}
+
+	if response.ID == "" {
+		response.ID = trxID
+	}
+	return readTransaction(contract, response)
+}
+
+// readTransaction reads the actions of trace, and what they did to the graph of contract from the table
+// deltas of the documents and edges tables; without table deltas the changes are not available
+func readTransaction(contract eos.AccountName, trace transactionTraceJSON) (TransactionResult, error) {
+
+	result := TransactionResult{ID: trace.ID, BlockNum: trace.BlockNum}
+	blockTime, err := parseBlockTime(trace.BlockTime)
+	if err != nil {
+		return result, fmt.Errorf("cannot parse block time of transaction %v: %v", trace.ID, err)
+	}
+	result.BlockTime = blockTime
+
+	traces := trace.ActionTraces
+	if len(traces) == 0 {
+		traces = trace.Traces
+	}
+	var numbered uint32
+	result.Actions = flattenTraces(traces, 0, &numbered)
+
+	// a trace without db_ops means the node does not report deltas, unlike an empty list
+	for _, action := range traces {
+		result.Deltas = result.Deltas || action.DBOps != nil
+	}
+	if !result.Deltas {
+		return result, nil
+	}
+	return result, readDeltas(&result, contract, tableDeltas(traces))
+}
+
+// createdDocument returns the only document of documentType that result created
+func createdDocument(result TransactionResult, documentType string) (docgraph.Document, error) {
+	if !result.Deltas {
+		return docgraph.Document{}, &ResultError{TrxID: result.ID, Err: ErrNoDeltas}
+	}
+	documents := result.Created(documentType)
+	if len(documents) != 1 {
+		return docgraph.Document{}, &ResultError{TrxID: result.ID,
+			Err: fmt.Errorf("created %v %v documents, expected one", len(documents), documentType)}
+	}
+	return documents[0], nil
+}
+
+func proposeAction(contract, proposer eos.AccountName, proposal Proposal) *eos.Action {
+	return &eos.Action{
+		Account: contract,
+		Name:    eos.ActN("propose"),
+		Authorization: []eos.PermissionLevel{
+			{Actor: proposer, Permission: eos.PN("active")},
+		},
+		ActionData: eos.NewActionData(proposal),
+	}
+}
+
+// submitProposal submits the actions of proposal and returns the transaction ID and the proposal document
+// it created. The ID is returned whenever the transaction succeeded, with a *ResultError if the document
+// cannot be read. Without table deltas the document is loaded by the hash PredictProposalContent gives
+// for the block of the transaction.
+func submitProposal(ctx context.Context, api *eos.API, contract eos.AccountName, proposal Proposal, actions []*eos.Action) (string, docgraph.Document, error) {
+
+	result, err := Submit(ctx, api, contract, actions)
+	if err != nil {
+		if result.ID != "" {
+			return result.ID, docgraph.Document{}, err
+		}
+		return "", docgraph.Document{}, fmt.Errorf("cannot propose %v", err)
+	}
+	if result.Deltas {
+		document, err := createdDocument(result, string(proposal.ProposalType))
+		return result.ID, document, err
+	}
+
+	document, err := loadPredictedProposal(ctx, api, contract, proposal, result.BlockTime)
+	if err != nil {
+		return result.ID, docgraph.Document{}, &ResultError{TrxID: result.ID, Err: fmt.Errorf("%v, %v", ErrNoDeltas, err)}
+	}
+	return result.ID, document, nil
+}
+
+// loadPredictedProposal loads the document proposing proposal in a block at blockTime
+func loadPredictedProposal(ctx context.Context, api *eos.API, contract eos.AccountName, proposal Proposal, blockTime time.Time) (docgraph.Document, error) {
+
+	if proposal.ContentGroups == nil {
+		return docgraph.Document{}, fmt.Errorf("the content of the proposal is unknown")
+	}
+	content, err := PredictProposalContent(ctx, api, contract, proposal.ProposalType, proposal.ContentGroups, blockTime)
+	if err != nil {
+		return docgraph.Document{}, fmt.Errorf("cannot predict the proposal %v", err)
+	}
+	hash, err := HashContent(content)
+	if err != nil {
+		return docgraph.Document{}, err
+	}
+	return LoadHashed(ctx, api, contract, hash)
+}
+
+func enrollAction(contract, enroller, applicant eos.AccountName) *eos.Action {
+	return &eos.Action{
+		Account: contract,
+		Name:    eos.ActN("enroll"),
+		Authorization: []eos.PermissionLevel{
+			{Actor: enroller, Permission: eos.PN("active")},
+		},
+		ActionData: eos.NewActionData(enrollParm{
+			Enroller:  enroller,
+			Applicant: applicant,
+			Content:   string("enroll in dao"),
+		}),
+	}
+}
+
+// EnrollApplicant enrolls applicant and returns the member document that the root now links to.
+// When the transaction succeeded, result has its ID even if an error is returned.
+func EnrollApplicant(ctx context.Context, api *eos.API, contract, enroller, applicant eos.AccountName) (docgraph.Document, TransactionResult, error) {
+
+	result, err := Submit(ctx, api, contract, []*eos.Action{enrollAction(contract, enroller, applicant)})
+	if err != nil {
+		if result.ID != "" {
+			return docgraph.Document{}, result, err
+		}
+		return docgraph.Document{}, result, fmt.Errorf("cannot enroll %v", err)
+	}
+	if !result.Deltas {
+		return docgraph.Document{}, result, &ResultError{TrxID: result.ID, Err: ErrNoDeltas}
+	}
+
+	for _, edge := range result.CreatedEdges(eos.Name("member")) {
+		if bytes.Equal(edge.ToNode, MemberHash(applicant)) {
+			member, err := LoadHashed(ctx, api, contract, edge.ToNode)
+			if err != nil {
+				return docgraph.Document{}, result, &ResultError{TrxID: result.ID, Err: err}
+			}
+			return member, result, nil
+		}
+	}
+	return docgraph.Document{}, result, &ResultError{TrxID: result.ID, Err: fmt.Errorf("created no member edge to %v", applicant)}
+}
+
+func claimNextAction(contract, claimer eos.AccountName, assignmentHash eos.Checksum256) *eos.Action {
+	return &eos.Action{
+		Account: contract,
+		Name:    eos.ActN("claimnextper"),
+		Authorization: []eos.PermissionLevel{
+			{Actor: claimer, Permission: eos.PN("active")},
+		},
+		ActionData: eos.NewActionData(claimNext{
+			AssignmentHash: assignmentHash,
+		}),
+	}
+}
+
+// ClaimNextPayments claims the next period of pay of an assignment and returns the payment documents it created.
+// When the transaction succeeded, result has its ID even if an error is returned.
+func ClaimNextPayments(ctx context.Context, api *eos.API, contract, claimer eos.AccountName, assignmentHash eos.Checksum256) ([]docgraph.Document, TransactionResult, error) {
+
+	result, err := Submit(ctx, api, contract, []*eos.Action{claimNextAction(contract, claimer, assignmentHash)})
+	if err != nil {
+		if result.ID != "" {
+			return nil, result, err
+		}
+		return nil, result, fmt.Errorf("cannot claim %v", err)
+	}
+	if !result.Deltas {
+		return nil, result, &ResultError{TrxID: result.ID, Err: ErrNoDeltas}
+	}
+	return result.Created("payment"), result, nil
+}
@@ -0,0 +1,86 @@
+package dao
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/document-graph/docgraph"
+)
+
+// dbOpJSON is a table delta of an action trace, as nodes running a table delta plugin report them in db_ops;
+// operation is INS, UPD or REM, and the rows are decoded with the ABI
+type dbOpJSON struct {
+	Operation string          `json:"operation"`
+	Code      eos.AccountName `json:"code"`
+	TableName string          `json:"table_name"`
+	OldJSON   json.RawMessage `json:"old_json"`
+	NewJSON   json.RawMessage `json:"new_json"`
+}
+
+// tableDeltas lists the table deltas of traces in execution order
+func tableDeltas(traces []actionTraceJSON) []dbOpJSON {
+	var ops []dbOpJSON
+	for _, trace := range traces {
+		ops = append(ops, trace.DBOps...)
+		ops = append(ops, tableDeltas(trace.InlineTraces)...)
+	}
+	return ops
+}
+
+// readDeltas reads the rows of the documents and edges tables of contract that ops inserted or updated.
+// A document inserted in place of an erased document of the same type, which is how the document graph
+// updates documents such as the settings, is updated rather than created.
+func readDeltas(result *TransactionResult, contract eos.AccountName, ops []dbOpJSON) error {
+
+	var inserted, erased []docgraph.Document
+	for _, op := range ops {
+		if op.Code != contract {
+			continue
+		}
+
+		row := op.NewJSON
+		if op.Operation == "REM" {
+			row = op.OldJSON
+		}
+
+		switch op.TableName {
+		case "edges":
+			if op.Operation != "INS" {
+				continue
+			}
+			var edge docgraph.Edge
+			if err := json.Unmarshal(row, &edge); err != nil {
+				return fmt.Errorf("cannot unmarshal edge delta %v", err)
+			}
+			result.Edges = append(result.Edges, edge)
+
+		case "documents":
+			var document docgraph.Document
+			if err := json.Unmarshal(row, &document); err != nil {
+				return fmt.Errorf("cannot unmarshal document delta %v", err)
+			}
+			switch op.Operation {
+			case "INS":
+				inserted = append(inserted, document)
+			case "UPD":
+				result.Updated = append(result.Updated, document)
+			case "REM":
+				erased = append(erased, document)
+			}
+		}
+	}
+
+	for _, document := range inserted {
+		replaces := false
+		for _, old := range erased {
+			replaces = replaces || DocumentType(old) == DocumentType(document)
+		}
+		if replaces {
+			result.Updated = append(result.Updated, document)
+		} else {
+			result.Documents = append(result.Documents, document)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package dao_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/eoscanada/eos-go"
+	"github.com/hypha-dao/dao-contracts/dao-go"
+	"gotest.tools/assert"
+)
+
+func TestTransactionTraces(t *testing.T) {
+
+	blockTime := "2020-10-16T14:13:58.500"
+	hash := func(digit string) string { return strings.Repeat(digit, 64) }
+	document := func(digit, documentType string) string {
+		return fmt.Sprintf(`{"id":%v,"hash":"%v","creator":"johnnyhypha1","content_groups":[[
+			{"label":"type","value":["name","%v"]},{"label":"node_label","value":["string","Healer"]}]],
+			"certificates":[],"created_date":"%v"}`, digit, hash(digit), documentType, blockTime)
+	}
+	edge := func(id int, creator, from, to, name string) string {
+		return fmt.Sprintf(`{"id":%v,"creator":"%v","from_node":"%v","to_node":"%v","edge_name":"%v","created_date":"%v"}`,
+			id, creator, from, to, name, blockTime)
+	}
+
+	// the node reports the table deltas of each action, so no table is read
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]interface{}
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&request))
+
+		if r.URL.Path != "/v1/history/get_transaction" {
+			t.Errorf("unexpected request %v", r.URL.Path)
+			return
+		}
+		if request["id"] != "abc123" {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"code":500,"error":{"what":"Unknown Transaction","details":[{"message":"Transaction is not found"}]}}`)
+			return
+		}
+		fmt.Fprintf(w, `{"id":"abc123","block_num":42,"block_time":"%v","traces":[
+			{"action_ordinal":1,"creator_action_ordinal":0,"receiver":"dao.hypha","act":{"account":"dao.hypha","name":"propose",
+				"authorization":[{"actor":"johnnyhypha1","permission":"active"}],"data":{"proposer":"johnnyhypha1"}},
+				"db_ops":[
+					{"operation":"INS","code":"dao.hypha","table_name":"documents","new_json":%v},
+					{"operation":"INS","code":"dao.hypha","table_name":"edges","new_json":%v},
+					{"operation":"REM","code":"dao.hypha","table_name":"edges","old_json":%v},
+					{"operation":"REM","code":"dao.hypha","table_name":"documents","old_json":%v},
+					{"operation":"INS","code":"dao.hypha","table_name":"documents","new_json":%v}]},
+			{"action_ordinal":2,"creator_action_ordinal":1,"receiver":"publsh.hypha","act":{"account":"publsh.hypha","name":"publish",
+				"authorization":[{"actor":"dao.hypha","permission":"active"}],"data":{}},
+				"db_ops":[{"operation":"INS","code":"publsh.hypha","table_name":"documents","new_json":%v}]},
+			{"action_ordinal":3,"creator_action_ordinal":2,"receiver":"johnnyhypha1","act":{"account":"publsh.hypha","name":"publish",
+				"authorization":[{"actor":"dao.hypha","permission":"active"}],"data":{}}}]}`, blockTime,
+			document("1", "role"), edge(1, "johnnyhypha1", hash("4"), hash("1"), "owns"), edge(2, "dao.hypha", hash("5"), hash("6"), "votetally"),
+			document("2", "settings"), document("3", "settings"), document("7", "role"))
+	}))
+	defer server.Close()
+
+	api := eos.New(server.URL)
+	result, err := dao.LoadTransaction(context.Background(), api, "dao.hypha", "abc123")
+	assert.NilError(t, err)
+
+	assert.Equal(t, result.BlockNum, uint32(42))
+	assert.Equal(t, len(result.Actions), 2)
+	assert.Equal(t, string(result.Actions[0].Name), "propose")
+	assert.Assert(t, !result.Actions[0].Inline)
+	assert.Equal(t, string(result.Actions[1].Account), "publsh.hypha")
+	assert.Assert(t, result.Actions[1].Inline)
+	assert.Equal(t, result.Actions[1].CreatorOrdinal, uint32(1))
+	assert.Assert(t, result.Deltas)
+
+	// the rows of the contract, with the settings replaced in place updated rather than created
+	assert.Equal(t, len(result.Documents), 1)
+	assert.Equal(t, result.Documents[0].Hash.String(), hash("1"))
+	assert.Equal(t, len(result.Created("role")), 1)
+	assert.Equal(t, len(result.Edges), 1)
+	assert.Equal(t, len(result.CreatedEdges("owns")), 1)
+	assert.Equal(t, len(result.Updated), 1)
+	assert.Equal(t, result.Updated[0].Hash.String(), hash("3"))
+
+	_, err = dao.LoadTransaction(context.Background(), api, "dao.hypha", "def456")
+	assert.ErrorContains(t, err, "/v1/history/get_transaction: Unknown Transaction: Transaction is not found")
+}
+
+func TestTransactionWithoutDeltas(t *testing.T) {
+
+	// stock nodeos reports no db_ops, and what the transaction created is then not read from the tables
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/history/get_transaction" {
+			t.Errorf("unexpected request %v", r.URL.Path)
+			return
+		}
+		fmt.Fprint(w, `{"id":"abc123","block_num":42,"block_time":"2020-10-16T14:13:58.500","traces":[
+			{"action_ordinal":1,"creator_action_ordinal":0,"receiver":"dao.hypha","act":{"account":"dao.hypha","name":"claimnextper",
+				"authorization":[{"actor":"alice","permission":"active"}],"data":{}}},
+			{"action_ordinal":2,"creator_action_ordinal":1,"receiver":"token.hypha","act":{"account":"token.hypha","name":"issue",
+				"authorization":[{"actor":"dao.hypha","permission":"active"}],"data":{}}}]}`)
+	}))
+	defer server.Close()
+
+	result, err := dao.LoadTransaction(context.Background(), eos.New(server.URL), "dao.hypha", "abc123")
+	assert.NilError(t, err)
+	assert.Equal(t, result.ID, "abc123")
+	assert.Equal(t, len(result.Actions), 2)
+	assert.Assert(t, !result.Deltas)
+	assert.Equal(t, len(result.Created("payment")), 0)
+	assert.Equal(t, len(result.Edges), 0)
+
+	var out strings.Builder
+	result.Print(&out)
+	assert.Assert(t, strings.Contains(out.String(), "not available"))
+
+	// a succeeded transaction keeps its ID in the error
+	err = &dao.ResultError{TrxID: result.ID, Err: dao.ErrNoDeltas}
+	assert.Assert(t, errors.Is(err, dao.ErrNoDeltas))
+	assert.ErrorContains(t, err, "transaction abc123 succeeded")
+}
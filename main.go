@@ -1,219 +1,256 @@
 package main
 
 import (
-	"bytes"
 	"context"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
 	"fmt"
-	"time"
+	"io/ioutil"
+	"os"
+	"strings"
 
 	eostest "github.com/digital-scarcity/eos-go-test"
 	"github.com/eoscanada/eos-go"
 	"github.com/hypha-dao/dao-contracts/dao-go"
-	"github.com/hypha-dao/document-graph/docgraph"
 	"github.com/spf13/viper"
 )
 
-// var mainnet = "https://api.telos.kitchen"
-// var testnet = "https://test.telos.kitchen"
-
-type notes struct {
-	Notes string `json:"notes"`
+// defaults of the configuration; the contract and host default to those of the profile
+var defaults = map[string]interface{}{
+	"profile":        "testnet",
+	"pause":          "1s",
+	"periodDuration": "300s",
+	"review.store":   "reviews.jsonl",
 }
 
-type eraseobjs struct {
-	Scope eos.Name `json:"scope"`
+// command is a top level command of the CLI; commands with subcommands read them from args[0]
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(ctx context.Context, args []string)
 }
 
-func reset4test(ctx context.Context, api *eos.API, contract eos.AccountName) {
-
-	fmt.Println("\nRunning contract reset action on: " + string(contract))
-	bar := dao.DefaultProgressBar(1)
-
-	actions := []*eos.Action{{
-		Account: contract,
-		Name:    eos.ActN("reset4test"),
-		Authorization: []eos.PermissionLevel{
-			{Actor: contract, Permission: eos.PN("active")},
-		},
-		ActionData: eos.NewActionData(notes{
-			Notes: "resetting for testing",
-		}),
-	}}
-	_, err := eostest.ExecTrx(ctx, api, actions)
-	if err != nil {
-		panic(err)
+func commands() []command {
+	return []command{
+		{"settings", "get|set|rm|apply", "read and change the settings document", runSettings},
+		{"periods", "list|add|current", "read and extend the calendar of periods", runPeriods},
+		{"propose", "role|assignment|payout|badge|assignbadge|attestation|edit", "propose a document read from a JSON file", runPropose},
+		{"vote", "<proposal hash>", "vote on a proposal", runVote},
+		{"close", "<proposal hash>", "close a proposal once voting has ended", runClose},
+		{"claim", "<assignment hash>", "claim the next period of pay of an assignment, or simulate its payouts", runClaim},
+		{"trace", "<transaction id>", "show the actions of a transaction and what it created", runTrace},
+		{"members", "list|show|apply|enroll|onboard", "list members and applicants, show a profile, apply, enroll and onboard", runMembers},
+		{"review", "list|approve|reject|enroll|trail", "review applicants and enroll them once approved", runReview},
+		{"migrate", "snapshot|copy|preview|run|reconcile", "copy and migrate the legacy tables into the document graph", runMigrate},
+		{"graph", "export|check|diff|snapshot|restore", "export, check, compare, save and restore the document graph", runGraph},
+		{"query", "<query>", "run a traversal query against the document graph", runQuery},
+		{"dev", "reset|erase|seed|pretend", "reset and populate a test environment", runDev},
+		{"profiles", "list|check", "list the network profiles and check the health of their endpoints", runProfiles},
 	}
-
-	bar.Add(1)
 }
 
-func eraseobjsByScope(ctx context.Context, api *eos.API, contract eos.AccountName, scope eos.Name) {
-	actions := []*eos.Action{{
-		Account: contract,
-		Name:    eos.ActN("eraseobjs"),
-		Authorization: []eos.PermissionLevel{
-			{Actor: contract, Permission: eos.PN("active")},
-		},
-		ActionData: eos.NewActionData(eraseobjs{
-			Scope: scope,
-		}),
-	}}
-	trxID, err := eostest.ExecTrx(ctx, api, actions)
-	if err != nil {
-		panic(err)
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: dao <command> [subcommand] [flags] [arguments]")
+	fmt.Fprintln(os.Stderr, "\nConfiguration is read from -config, or dao.yaml when present, then from DAO_ environment")
+	fmt.Fprintln(os.Stderr, "variables such as DAO_HOST, then from flags. The profile, testnet if not set, provides the")
+	fmt.Fprintln(os.Stderr, "host and contract when they are not configured. Private keys are read from the configuration")
+	fmt.Fprintln(os.Stderr, "or DAO_KEYS only, never from flags.\n\nCommands:")
+	for _, cmd := range commands() {
+		fmt.Fprintf(os.Stderr, "  %-9v %-58v %v\n", cmd.name, cmd.usage, cmd.summary)
 	}
-
-	fmt.Println("Completed the eraseobjs transaction: " + string(scope) + ": trxID: " + trxID)
-	fmt.Println()
 }
 
-type claimNext struct {
-	AssignmentHash eos.Checksum256 `json:"assignment_hash"`
+// newFlagSet returns the flags of a command, with the configuration flags every command accepts
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.String("config", "", "configuration file, dao.yaml when present if not set")
+	flags.String("profile", "", "network profile: local, testnet, mainnet or a profile of the configuration file")
+	flags.String("host", "", "API endpoint of the node, the first healthy endpoint of the profile if not set")
+	flags.String("contract", "", "DAO contract account")
+	flags.Bool("cache", false, "serve graph reads from an in-memory cache of the graph")
+	return flags
 }
 
-func claimNextPeriod(ctx context.Context, api *eos.API, contract, claimer eos.AccountName, assignment docgraph.Document) (string, error) {
-
-	actions := []*eos.Action{{
-		Account: contract,
-		Name:    eos.ActN("claimnextper"),
-		Authorization: []eos.PermissionLevel{
-			{Actor: claimer, Permission: eos.PN("active")},
-		},
-		// ActionData: eos.NewActionDataFromHexData([]byte(actionBinary)),
-		ActionData: eos.NewActionData(claimNext{
-			AssignmentHash: assignment.Hash,
-		}),
-	}}
+// loadConfig reads the configuration file, DAO_ environment variables and the flags set on
+// the command line, in increasing precedence
+func loadConfig(flags *flag.FlagSet) error {
 
-	trxID, err := eostest.ExecTrx(ctx, api, actions)
+	for key, value := range defaults {
+		viper.SetDefault(key, value)
+	}
 
-	if err != nil {
-		fmt.Println("Waiting for a period to lapse...")
-		time.Sleep(time.Second * 7)
-		actions := []*eos.Action{{
-			Account: contract,
-			Name:    eos.ActN("claimnextper"),
-			Authorization: []eos.PermissionLevel{
-				{Actor: claimer, Permission: eos.PN("active")},
-			},
-			ActionData: eos.NewActionData(claimNext{
-				AssignmentHash: assignment.Hash,
-			}),
-		}}
+	viper.SetEnvPrefix("dao")
+	viper.AutomaticEnv()
 
-		trxID, err = eostest.ExecTrx(ctx, api, actions)
+	path := flags.Lookup("config").Value.String()
+	if path == "" {
+		path = os.Getenv("DAO_CONFIG")
+	}
+	if path == "" {
+		if _, err := os.Stat("dao.yaml"); err == nil {
+			path = "dao.yaml"
+		}
+	}
+	if path != "" {
+		viper.SetConfigFile(path)
+		if err := viper.ReadInConfig(); err != nil {
+			return fmt.Errorf("cannot read config %v: %v", path, err)
+		}
 	}
 
-	return trxID, err
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name != "config" {
+			viper.Set(f.Name, f.Value.String())
+		}
+	})
+	return nil
 }
 
-func main() {
+// session is the API, contract and network profile a command works with, and the graph source its
+// reads go through: the graph cache with -cache, and live reads otherwise
+type session struct {
+	api      *eos.API
+	contract eos.AccountName
+	profile  dao.Profile
+	graph    dao.QuerySource
+	cache    *dao.GraphCache
+}
 
-	viper.SetConfigType("yaml")
+// connect parses args into flags, loads the configuration and returns a session signing with the keys
+// of the configuration or DAO_KEYS; keys are never read from flags, and only the local profile falls
+// back to the eosio development key
+func connect(ctx context.Context, flags *flag.FlagSet, args []string) *session {
 
-	var yamlExample = []byte(`
-contract: dao.hypha
-host: https://testnet.telos.caleos.io
-pause: 1s
-periodDuration: 300s
-rootHash: 52a7ff82bd6f53b31285e97d6806d886eefb650e79754784e9d923d3df347c91
-`)
+	flags.Parse(args)
+	if err := loadConfig(flags); err != nil {
+		fail(err)
+	}
 
-	// dao.hypha: 52a7ff82bd6f53b31285e97d6806d886eefb650e79754784e9d923d3df347c91
-	// dao1.hypha: 0f374e7a9d8ab17f172f8c478744cdd4016497e15229616f2ffd04d8002ef64a
-	viper.ReadConfig(bytes.NewBuffer(yamlExample))
+	profile, err := dao.LoadProfile(viper.ConfigFileUsed(), viper.GetString("profile"))
+	if err != nil {
+		fail(err)
+	}
+	profile.Use()
+
+	var keys []string
+	for _, value := range viper.GetStringSlice("keys") {
+		for _, key := range strings.Split(value, ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				keys = append(keys, key)
+			}
+		}
+	}
+	if len(keys) == 0 && profile.Name == "local" {
+		keys = []string{eostest.DefaultKey()}
+	}
 
-	ctx := context.Background()
 	keyBag := &eos.KeyBag{}
+	for _, key := range keys {
+		if err := keyBag.ImportPrivateKey(ctx, key); err != nil {
+			fail(fmt.Errorf("cannot import private key %v", err))
+		}
+	}
 
-	// api := eos.New(testnet)
-	contract := eos.AccountName(viper.GetString("contract"))
-	err := keyBag.ImportPrivateKey(ctx, "5KCZ9VBJMMiLaAY24Ro66mhx4vU1VcJELZVGrJbkUBATyqxyYmj")
-	err = keyBag.ImportPrivateKey(ctx, "5HwnoWBuuRmNdcqwBzd1LABFRKnTk2RY2kUMYKkZfF8tKodubtK")
-	err = keyBag.ImportPrivateKey(ctx, eostest.DefaultKey())
-
-	//new test key: 5KCZ9VBJMMiLaAY24Ro66mhx4vU1VcJELZVGrJbkUBATyqxyYmj
-	// old test key: 5HwnoWBuuRmNdcqwBzd1LABFRKnTk2RY2kUMYKkZfF8tKodubtK
-	// api := eos.New("https://test.telos.kitchen")
-	api := eos.New(viper.GetString("host"))
-
-	if err != nil {
-		panic(err)
+	host := viper.GetString("host")
+	if host == "" {
+		host, err = profile.Endpoint(ctx)
+		if err != nil {
+			fail(err)
+		}
 	}
-	api.SetSigner(keyBag)
 
-	// erase environment except for settings
-	// dao.EraseAllDocuments(ctx, api, contract)
-	// reset4test(ctx, api, contract)
-	// dao.EnrollMembers(ctx, api, contract)
+	api := eos.New(host)
+	api.SetSigner(keyBag)
+	contract := eos.AN(viper.GetString("contract"))
 
-	// duration := viper.GetDuration("periodDuration")
-	// fmt.Println("Adding "+strconv.Itoa(20)+" periods with duration 		 ", duration)
+	s := &session{api: api, contract: contract, profile: profile, graph: dao.NewEndpointSource(api, contract)}
+	if viper.GetBool("cache") {
+		s.cache = dao.NewGraphCache(api, contract, dao.DefaultCacheOptions())
+		s.graph = s.cache
+	}
+	return s
+}
 
-	// settings, err := docgraph.GetLastDocumentOfEdge(ctx, api, contract, eos.Name("settings"))
-	// if err != nil {
-	// 	panic(err)
-	// }
+// subcommand splits the subcommand from the arguments of a command
+func subcommand(name string, args []string, subcommands ...string) (string, []string) {
+	if len(args) > 0 {
+		for _, sub := range subcommands {
+			if args[0] == sub {
+				return sub, args[1:]
+			}
+		}
+	}
+	fmt.Fprintf(os.Stderr, "usage: dao %v %v [flags] [arguments]\n", name, strings.Join(subcommands, "|"))
+	os.Exit(2)
+	return "", nil
+}
 
-	// rootNode, err := settings.GetContent("root_node")
-	// if err != nil {
-	// 	panic(err)
-	// }
+// arguments checks the number of positional arguments left after the flags
+func arguments(flags *flag.FlagSet, count int, names string) []string {
+	if flags.NArg() != count {
+		fmt.Fprintf(os.Stderr, "usage: dao %v [flags] %v\n", flags.Name(), names)
+		flags.PrintDefaults()
+		os.Exit(2)
+	}
+	return flags.Args()
+}
 
-	// roleDocument, err := docgraph.LoadDocument(ctx, api, contract, rootNode.String())
-	// if err != nil {
-	// 	panic(err)
-	// }
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
 
-	// _, err = dao.AddPeriods(ctx, api, contract, roleDocument.Hash, 20, duration)
-	// if err != nil {
-	// 	panic(err)
-	// }
-	// time.Sleep(time.Second)
+func parseHash(value string) eos.Checksum256 {
+	hash, err := hex.DecodeString(value)
+	if err != nil || len(hash) != 32 {
+		fail(fmt.Errorf("invalid hash %v", value))
+	}
+	return eos.Checksum256(hash)
+}
 
-	roleAssignment, err := dao.CreatePretend(ctx, api, contract, eos.AN("trailservice"), eos.AN("mem2.hypha"))
+func readFile(path string) string {
+	data, err := ioutil.ReadFile(path)
 	if err != nil {
-		panic(err)
+		fail(err)
 	}
-	fmt.Println(roleAssignment)
-
-	// dao.CopyMembers(ctx, api, contract, "https://api.telos.kitchen")
-	// dao.MigrateMembers(ctx, api, contract)
+	return string(data)
+}
 
-	// dao.CopyPeriods(ctx, api, contract, "https://api.telos.kitchen")
-	// dao.MigratePeriods(ctx, api, contract)
+func writeJSON(path string, v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		fail(err)
+	}
 
-	// scopes := []eos.Name{"assignment", "payout"}
+	err = ioutil.WriteFile(path, data, 0644)
+	if err != nil {
+		fail(err)
+	}
+}
 
-	// for _, scope := range scopes {
-	// 	dao.CopyObjects(ctx, api, contract, scope, "https://api.telos.kitchen")
-	// 	dao.MigrateObjects(ctx, api, contract, scope)
-	// }
+func printTransaction(trxID string, err error) {
+	if err != nil {
+		fail(err)
+	}
+	fmt.Println("Transaction: " + trxID)
+}
 
-	// dao.CopyObjects(ctx, api, contract, eos.Name("payout"), "https://api.telos.kitchen")
-	// dao.MigrateObjects(ctx, api, contract, eos.Name("payout"))
+func main() {
 
-	// dao.CopyAssPayouts(ctx, api, contract, "https://api.telos.kitchen")
-	// dao.MigrateAssPayouts(ctx, api, contract)
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
 
-	// **********************************************************************
-	// *******************
-	// test data and use cases
+	for _, cmd := range commands() {
+		if cmd.name == os.Args[1] {
+			cmd.run(context.Background(), os.Args[2:])
+			return
+		}
+	}
 
+	usage()
+	os.Exit(2)
 }
-
-// eosc -u https://testnet.telos.caleos.io --vault-file ../eosc-testnet-vault.json tx create dao.hypha setsetting '{"key":"paused", "value":["int64","0"]}' -p dao.hypha
-// eosc -u https://testnet.telos.caleos.io --vault-file ../eosc-testnet-vault.json tx create dao.hypha setsetting '{"key":"seeds_token_contract", "value":["name","token.seeds"]}' -p dao.hypha
-// eosc -u https://testnet.telos.caleos.io --vault-file ../eosc-testnet-vault.json tx create dao.hypha setsetting '{"key":"voting_duration_sec", "value":["int64",3600]}' -p dao.hypha
-// eosc -u https://testnet.telos.caleos.io --vault-file ../eosc-testnet-vault.json tx create dao.hypha setsetting '{"key":"seeds_deferral_factor_x100", "value":["int64","100"]}' -p dao.hypha
-// eosc -u https://testnet.telos.caleos.io --vault-file ../eosc-testnet-vault.json tx create dao.hypha setsetting '{"key":"telos_decide_contract", "value":["name","trailservice"]}' -p dao.hypha
-// eosc -u https://testnet.telos.caleos.io --vault-file ../eosc-testnet-vault.json tx create dao.hypha setsetting '{"key":"husd_token_contract", "value":["name","husd.hypha"]}' -p dao.hypha
-// eosc -u https://testnet.telos.caleos.io --vault-file ../eosc-testnet-vault.json tx create dao.hypha setsetting '{"key":"hypha_token_contract", "value":["name","token.hypha"]}' -p dao.hypha
-// eosc -u https://testnet.telos.caleos.io --vault-file ../eosc-testnet-vault.json tx create dao.hypha setsetting '{"key":"seeds_escrow_contract", "value":["name","escrow.seeds"]}' -p dao.hypha
-// eosc -u https://testnet.telos.caleos.io --vault-file ../eosc-testnet-vault.json tx create dao.hypha setsetting '{"key":"publisher_contract", "value":["name","publsh.hypha"]}' -p dao.hypha
-// eosc -u https://testnet.telos.caleos.io --vault-file ../eosc-testnet-vault.json tx create dao.hypha setsetting '{"key":"treasury_contract", "value":["name","bank.hypha"]}' -p dao.hypha
-// eosc -u https://testnet.telos.caleos.io --vault-file ../eosc-testnet-vault.json tx create dao.hypha setsetting '{"key":"last_ballot_id", "value":["name","hypha1....1ce"]}' -p dao.hypha
-// eosc -u https://testnet.telos.caleos.io --vault-file ../eosc-testnet-vault.json tx create dao.hypha setsetting '{"key":"hypha_deferral_factor_x100", "value":["int64","25"]}' -p dao.hypha
-// eosc -u https://testnet.telos.caleos.io --vault-file ../eosc-testnet-vault.json tx create dao.hypha setsetting '{"key":"seeds_deferral_factor_x100", "value":["int64","100"]}' -p dao.hypha
-// eosc -u https://testnet.telos.caleos.io --vault-file ../eosc-testnet-vault.json tx create dao.hypha setsetting '{"key":"client_version", "value":["string","0.2.0 pre-release"]}' -p dao.hypha
-// eosc -u https://testnet.telos.caleos.io --vault-file ../eosc-testnet-vault.json tx create dao.hypha setsetting '{"key":"contract_version", "value":["string","0.2.0 pre-release"]}' -p dao.hypha